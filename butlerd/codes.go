@@ -14,10 +14,16 @@ var codeMessages = map[Code]string{
 
 	CodeUnsupportedHost: "This title is hosted on an incompatible third-party website",
 
+	CodeUnsupportedDownload: "This title's download link doesn't lead to a file that can be downloaded",
+
+	CodeDownloadVerificationFailed: "The downloaded file is corrupted, and re-downloading it didn't help",
+
 	CodeNoLaunchCandidates: "Nothing that can be launched was found.",
 
 	CodeLaunchTargetNotFound: "The requested launch target was not found.",
 
+	CodeLaunchProfileNotFound: "The requested launch profile was not found.",
+
 	CodeJavaRuntimeNeeded: "Java Runtime Environment is required to launch this title.",
 
 	CodeNetworkDisconnected: "There is no Internet connection",
@@ -28,6 +34,8 @@ var codeMessages = map[Code]string{
 
 	CodeCantRemoveLocationBecauseOfActiveDownloads: "An install location could not be removed because it has active downloads",
 
+	CodeInsufficientDiskSpace: "There isn't enough disk space in the install location",
+
 	CodeSandboxNotAvailable: "The selected sandbox is not available on this system.",
 
 	CodeNoSuchProfile: "The requested profile does not exist.",
@@ -0,0 +1,23 @@
+package butlerd
+
+import (
+	"github.com/itchio/butler/peercache"
+)
+
+// Config holds daemon-wide settings, set from command-line flags
+// when butlerd starts.
+type Config struct {
+	// Local directory or http(s) URL of a prereqs mirror, as created
+	// by `butler prereqs-mirror`. When set, prereqs are fetched from
+	// there instead of itch.io.
+	PrereqsMirror string
+
+	// When set, fetch requests answer from the cache even if it's stale,
+	// refresh it in the background, and send Fetch.Refreshed once
+	// fresher data is available.
+	OfflineFirst bool
+
+	// When set, install sources with known hashes are asked to LAN peers
+	// before itch.io, and kept to be served to them once verified.
+	PeerCache *peercache.PeerCache
+}
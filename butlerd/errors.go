@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/itchio/butler/butlerd/jsonrpc2"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/headway/united"
 	"github.com/itchio/savior"
 )
 
@@ -54,6 +56,103 @@ func (re *RpcError) Error() string {
 	return fmt.Sprintf("RPC error %d: %s", re.Code, re.Message)
 }
 
+// InsufficientDiskSpaceError is returned when something doesn't fit in
+// an install location. Its code is CodeInsufficientDiskSpace.
+type InsufficientDiskSpaceError struct {
+	InstallLocationID string
+	Shortage          *models.SpaceShortage
+}
+
+var _ Error = (*InsufficientDiskSpaceError)(nil)
+
+func (e *InsufficientDiskSpaceError) RpcErrorCode() int64 {
+	return CodeInsufficientDiskSpace.RpcErrorCode()
+}
+
+func (e *InsufficientDiskSpaceError) RpcErrorMessage() string {
+	return CodeInsufficientDiskSpace.RpcErrorMessage()
+}
+
+func (e *InsufficientDiskSpaceError) RpcErrorData() map[string]interface{} {
+	return map[string]interface{}{
+		"installLocationId": e.InstallLocationID,
+		"limit":             e.Shortage.Limit,
+		"neededSize":        e.Shortage.NeededSize,
+		"availableSize":     e.Shortage.AvailableSize,
+	}
+}
+
+func (e *InsufficientDiskSpaceError) Error() string {
+	return fmt.Sprintf("install location (%s): needs %s, only %s available (%s)",
+		e.InstallLocationID,
+		united.FormatBytes(e.Shortage.NeededSize),
+		united.FormatBytes(e.Shortage.AvailableSize),
+		e.Shortage.Limit,
+	)
+}
+
+// UnsupportedDownloadError is returned when an external upload's link
+// leads somewhere that isn't a file, like a web page. Its code is
+// CodeUnsupportedDownload.
+type UnsupportedDownloadError struct {
+	URL    string
+	Reason string
+}
+
+var _ Error = (*UnsupportedDownloadError)(nil)
+
+func (e *UnsupportedDownloadError) RpcErrorCode() int64 {
+	return CodeUnsupportedDownload.RpcErrorCode()
+}
+
+func (e *UnsupportedDownloadError) RpcErrorMessage() string {
+	return CodeUnsupportedDownload.RpcErrorMessage()
+}
+
+func (e *UnsupportedDownloadError) RpcErrorData() map[string]interface{} {
+	return map[string]interface{}{
+		"url":    e.URL,
+		"reason": e.Reason,
+	}
+}
+
+func (e *UnsupportedDownloadError) Error() string {
+	return fmt.Sprintf("can't download from (%s): %s", e.URL, e.Reason)
+}
+
+// DownloadVerificationError is returned when a downloaded file still
+// doesn't match its expected hash after the last attempt at repairing
+// it. Its code is CodeDownloadVerificationFailed.
+type DownloadVerificationError struct {
+	Algo     string
+	Expected string
+	Actual   string
+	Attempts int
+}
+
+var _ Error = (*DownloadVerificationError)(nil)
+
+func (e *DownloadVerificationError) RpcErrorCode() int64 {
+	return CodeDownloadVerificationFailed.RpcErrorCode()
+}
+
+func (e *DownloadVerificationError) RpcErrorMessage() string {
+	return CodeDownloadVerificationFailed.RpcErrorMessage()
+}
+
+func (e *DownloadVerificationError) RpcErrorData() map[string]interface{} {
+	return map[string]interface{}{
+		"algo":     e.Algo,
+		"expected": e.Expected,
+		"actual":   e.Actual,
+		"attempts": e.Attempts,
+	}
+}
+
+func (e *DownloadVerificationError) Error() string {
+	return fmt.Sprintf("%s hash mismatch after %d attempts: wanted %s, got %s", e.Algo, e.Attempts, e.Expected, e.Actual)
+}
+
 //
 
 type causer interface {
@@ -0,0 +1,90 @@
+package integrate
+
+import (
+	"testing"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/messages"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_InstallQueueMany(t *testing.T) {
+	require := require.New(t)
+
+	bi := newInstance(t)
+	rc, _, cancel := bi.Unwrap()
+	defer cancel()
+
+	bi.Authenticate()
+
+	store := bi.Server.Store()
+	_developer := store.MakeUser("Batch Baker")
+
+	_single := _developer.MakeGame("Single Upload")
+	_single.Publish()
+	_singleUpload := _single.MakeUpload("everywhere.zip")
+	_singleUpload.SetAllPlatforms()
+	_singleUpload.SetZipContents()
+
+	_ambiguous := _developer.MakeGame("Two Uploads")
+	_ambiguous.Publish()
+	_firstUpload := _ambiguous.MakeUpload("first.zip")
+	_firstUpload.SetAllPlatforms()
+	_firstUpload.SetZipContents()
+	_secondUpload := _ambiguous.MakeUpload("second.zip")
+	_secondUpload.SetAllPlatforms()
+	_secondUpload.SetZipContents()
+
+	_untagged := _developer.MakeGame("Untagged Upload")
+	_untagged.Publish()
+	_untaggedUpload := _untagged.MakeUpload("untagged.zip")
+	_untaggedUpload.SetZipContents()
+
+	games := []*itchio.Game{
+		bi.FetchGame(_single.ID),
+		bi.FetchGame(_ambiguous.ID),
+		bi.FetchGame(_untagged.ID),
+	}
+
+	res, err := messages.InstallQueueMany.TestCall(rc, butlerd.InstallQueueManyParams{
+		Games:             games,
+		InstallLocationID: "tmp",
+		UploadPolicy:      butlerd.InstallUploadPolicySkipIfAmbiguous,
+	})
+	require.NoError(err)
+	require.Len(res.Outcomes, 3)
+
+	require.EqualValues(butlerd.InstallQueueManyStatusQueued, res.Outcomes[0].Status)
+	require.NotNil(res.Outcomes[0].Item)
+	require.EqualValues(_singleUpload.ID, res.Outcomes[0].Item.Upload.ID)
+
+	require.EqualValues(butlerd.InstallQueueManyStatusSkipped, res.Outcomes[1].Status)
+	require.EqualValues("2 compatible uploads, can't pick one", res.Outcomes[1].Reason)
+	require.Nil(res.Outcomes[1].Item)
+
+	require.EqualValues(butlerd.InstallQueueManyStatusSkipped, res.Outcomes[2].Status)
+	require.EqualValues("no compatible upload", res.Outcomes[2].Reason)
+
+	item := res.Outcomes[0].Item
+	_, err = messages.InstallPerform.TestCall(rc, butlerd.InstallPerformParams{
+		ID:            item.ID,
+		StagingFolder: item.StagingFolder,
+	})
+	require.NoError(err)
+
+	// best match picks one of the two uploads, and installed games
+	// are left alone
+	res, err = messages.InstallQueueMany.TestCall(rc, butlerd.InstallQueueManyParams{
+		Games:             games[:2],
+		InstallLocationID: "tmp",
+	})
+	require.NoError(err)
+	require.Len(res.Outcomes, 2)
+
+	require.EqualValues(butlerd.InstallQueueManyStatusSkipped, res.Outcomes[0].Status)
+	require.EqualValues("already installed", res.Outcomes[0].Reason)
+
+	require.EqualValues(butlerd.InstallQueueManyStatusQueued, res.Outcomes[1].Status)
+	require.NotNil(res.Outcomes[1].Item)
+}
@@ -0,0 +1,94 @@
+package jsonrpc2
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+type inProcessConn struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// handles what's sent over this connection
+	handler Handler
+	// handed to handler, so it can talk back
+	peer *inProcessConn
+
+	idSeed *int64
+}
+
+var _ Conn = (*inProcessConn)(nil)
+
+// NewInProcessConn connects a client to a server living in the same process,
+// without a transport. It returns the client's side of the connection: its
+// calls and notifications go to server, while the server's go to client.
+// Params and results still go through JSON, so handlers see exactly what
+// they would over a socket.
+func NewInProcessConn(parentCtx context.Context, server Handler, client Handler) Conn {
+	ctx, cancel := context.WithCancel(parentCtx)
+	idSeed := new(int64)
+
+	clientSide := &inProcessConn{
+		ctx:     ctx,
+		cancel:  cancel,
+		handler: server,
+		idSeed:  idSeed,
+	}
+	serverSide := &inProcessConn{
+		ctx:     ctx,
+		cancel:  cancel,
+		handler: client,
+		idSeed:  idSeed,
+	}
+	clientSide.peer = serverSide
+	serverSide.peer = clientSide
+	return clientSide
+}
+
+func (c *inProcessConn) Context() context.Context {
+	return c.ctx
+}
+
+func (c *inProcessConn) Notify(method string, params interface{}) error {
+	paramsText, err := EncodeJSON(params)
+	if err != nil {
+		return err
+	}
+
+	c.handler.HandleNotification(c.peer, Notification{
+		Method: method,
+		Params: &paramsText,
+	})
+	return nil
+}
+
+func (c *inProcessConn) Call(method string, params interface{}, result interface{}) error {
+	if c.ctx.Err() != nil {
+		return errors.New("json-rpc2: connection closed")
+	}
+
+	paramsText, err := EncodeJSON(params)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.handler.HandleRequest(c.peer, Request{
+		ID:     atomic.AddInt64(c.idSeed, 1),
+		Method: method,
+		Params: &paramsText,
+	})
+	if err != nil {
+		return err
+	}
+
+	resultText, err := EncodeJSON(res)
+	if err != nil {
+		return err
+	}
+	return DecodeJSON(resultText, result)
+}
+
+func (c *inProcessConn) Close() {
+	c.cancel()
+}
@@ -0,0 +1,76 @@
+package jsonrpc2
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type funcHandler struct {
+	handleRequest      func(conn Conn, req Request) (interface{}, error)
+	handleNotification func(conn Conn, notif Notification)
+}
+
+func (h *funcHandler) HandleRequest(conn Conn, req Request) (interface{}, error) {
+	return h.handleRequest(conn, req)
+}
+
+func (h *funcHandler) HandleNotification(conn Conn, notif Notification) {
+	h.handleNotification(conn, notif)
+}
+
+func Test_InProcessConn(t *testing.T) {
+	assert := assert.New(t)
+
+	type pickParams struct {
+		Choices []string `json:"choices"`
+	}
+	type pickResult struct {
+		Index int `json:"index"`
+	}
+
+	var logged []string
+	client := &funcHandler{
+		handleRequest: func(conn Conn, req Request) (interface{}, error) {
+			var params pickParams
+			assert.NoError(DecodeJSON(*req.Params, &params))
+			return pickResult{Index: len(params.Choices) - 1}, nil
+		},
+		handleNotification: func(conn Conn, notif Notification) {
+			var msg string
+			assert.NoError(DecodeJSON(*notif.Params, &msg))
+			logged = append(logged, msg)
+		},
+	}
+	server := &funcHandler{
+		handleRequest: func(conn Conn, req Request) (interface{}, error) {
+			if req.Method == "Fail" {
+				return nil, errors.New("failed")
+			}
+
+			assert.NoError(conn.Notify("Log", "picking"))
+			var res pickResult
+			err := conn.Call("Pick", pickParams{Choices: []string{"a", "b", "c"}}, &res)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]int{"picked": res.Index}, nil
+		},
+	}
+
+	conn := NewInProcessConn(context.Background(), server, client)
+
+	var res struct {
+		Picked int `json:"picked"`
+	}
+	assert.NoError(conn.Call("Install", struct{}{}, &res))
+	assert.EqualValues(2, res.Picked)
+	assert.EqualValues([]string{"picking"}, logged)
+
+	assert.Error(conn.Call("Fail", struct{}{}, &res))
+
+	conn.Close()
+	assert.Error(conn.Call("Install", struct{}{}, &res))
+}
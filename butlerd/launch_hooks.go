@@ -0,0 +1,76 @@
+package butlerd
+
+import (
+	"fmt"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	shellquote "github.com/kballard/go-shellquote"
+)
+
+const defaultLaunchHookTimeout = 60 * time.Second
+
+// ValidateLaunchHooks checks that all hook commands can be parsed and
+// that their options are valid.
+func ValidateLaunchHooks(hooks *LaunchHooks) error {
+	if hooks == nil {
+		return nil
+	}
+
+	for i, hook := range hooks.PreLaunch {
+		if err := validateLaunchHook(hook); err != nil {
+			return fmt.Errorf("preLaunch[%d]: %w", i, err)
+		}
+	}
+	for i, hook := range hooks.PostExit {
+		if err := validateLaunchHook(hook); err != nil {
+			return fmt.Errorf("postExit[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateLaunchHook(hook *LaunchHook) error {
+	if hook == nil {
+		return fmt.Errorf("must not be null")
+	}
+	if _, err := hook.CommandTokens(); err != nil {
+		return err
+	}
+	if hook.TimeoutSeconds < 0 {
+		return fmt.Errorf("timeoutSeconds: must not be negative")
+	}
+	if hook.OnFailure != "" {
+		err := validation.Validate(hook.OnFailure, validation.In(LaunchHookFailurePolicyList...))
+		if err != nil {
+			return fmt.Errorf("onFailure: %w", err)
+		}
+	}
+	return nil
+}
+
+// CommandTokens splits the hook's command into an executable
+// and its arguments.
+func (hook *LaunchHook) CommandTokens() ([]string, error) {
+	tokens, err := shellquote.Split(hook.Command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hook command: %w", err)
+	}
+	if len(tokens) == 0 || tokens[0] == "" {
+		return nil, fmt.Errorf("hook command cannot be empty")
+	}
+	return tokens, nil
+}
+
+// Timeout returns how long the hook may run.
+func (hook *LaunchHook) Timeout() time.Duration {
+	if hook.TimeoutSeconds > 0 {
+		return time.Duration(hook.TimeoutSeconds) * time.Second
+	}
+	return defaultLaunchHookTimeout
+}
+
+// Aborts returns true if a failure of this hook should stop the launch.
+func (hook *LaunchHook) Aborts() bool {
+	return hook.OnFailure == LaunchHookFailurePolicyAbort
+}
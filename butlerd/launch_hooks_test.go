@@ -0,0 +1,56 @@
+package butlerd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ValidateLaunchHooks(t *testing.T) {
+	require := require.New(t)
+
+	require.NoError(ValidateLaunchHooks(nil))
+
+	hooks := &LaunchHooks{
+		PreLaunch: []*LaunchHook{
+			{Command: `mount-saves "My Game"`, OnFailure: LaunchHookFailurePolicyAbort},
+		},
+		PostExit: []*LaunchHook{
+			{Command: "backup-saves", TimeoutSeconds: 300},
+		},
+	}
+	require.NoError(ValidateLaunchHooks(hooks))
+
+	hooks.PostExit = append(hooks.PostExit, &LaunchHook{Command: `unterminated "quote`})
+	err := ValidateLaunchHooks(hooks)
+	require.Error(err)
+	require.Contains(err.Error(), "postExit[1]")
+
+	hooks.PostExit = nil
+	hooks.PreLaunch = []*LaunchHook{{Command: "  "}}
+	err = ValidateLaunchHooks(hooks)
+	require.Error(err)
+	require.Contains(err.Error(), "preLaunch[0]")
+
+	hooks.PreLaunch = []*LaunchHook{{Command: "true", OnFailure: "explode"}}
+	err = ValidateLaunchHooks(hooks)
+	require.Error(err)
+	require.Contains(err.Error(), "onFailure")
+}
+
+func Test_LaunchHook_Defaults(t *testing.T) {
+	require := require.New(t)
+
+	hook := &LaunchHook{Command: `overlay --port 1234 "with space"`}
+	tokens, err := hook.CommandTokens()
+	require.NoError(err)
+	require.Equal([]string{"overlay", "--port", "1234", "with space"}, tokens)
+	require.Equal(60*time.Second, hook.Timeout())
+	require.False(hook.Aborts())
+
+	hook.TimeoutSeconds = 5
+	hook.OnFailure = LaunchHookFailurePolicyAbort
+	require.Equal(5*time.Second, hook.Timeout())
+	require.True(hook.Aborts())
+}
@@ -468,6 +468,35 @@ func (r *DownloadsDriveNetworkStatusType) Register(router router, f func(butlerd
 
 var DownloadsDriveNetworkStatus *DownloadsDriveNetworkStatusType
 
+// Downloads.Drive.Paused (Notification)
+
+type DownloadsDrivePausedType struct {}
+
+var _ NotificationMessage = (*DownloadsDrivePausedType)(nil)
+
+func (r *DownloadsDrivePausedType) Method() string {
+  return "Downloads.Drive.Paused"
+}
+
+func (r *DownloadsDrivePausedType) Notify(rc *butlerd.RequestContext, params butlerd.DownloadsDrivePausedNotification) (error) {
+  return rc.Notify("Downloads.Drive.Paused", params)
+}
+
+func (r *DownloadsDrivePausedType) Register(router router, f func(butlerd.DownloadsDrivePausedNotification)) {
+  router.RegisterNotification("Downloads.Drive.Paused", func (notif jsonrpc2.Notification) {
+    var params butlerd.DownloadsDrivePausedNotification
+    if notif.Params != nil {
+      err := json.Unmarshal(*notif.Params, &params)
+      if err != nil {
+        return
+      }
+    }
+    f(params)
+  })
+}
+
+var DownloadsDrivePaused *DownloadsDrivePausedType
+
 // Log (Notification)
 
 type LogType struct {}
@@ -902,6 +931,126 @@ func (r *ProfileDataGetType) TestCall(rc *butlerd.RequestContext, params butlerd
 
 var ProfileDataGet *ProfileDataGetType
 
+// Profile.UploadRules.Get (Request)
+
+type ProfileUploadRulesGetType struct {}
+
+var _ RequestMessage = (*ProfileUploadRulesGetType)(nil)
+
+func (r *ProfileUploadRulesGetType) Method() string {
+  return "Profile.UploadRules.Get"
+}
+
+func (r *ProfileUploadRulesGetType) Register(router router, f func(*butlerd.RequestContext, butlerd.ProfileUploadRulesGetParams) (*butlerd.ProfileUploadRulesGetResult, error)) {
+  router.Register("Profile.UploadRules.Get", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.ProfileUploadRulesGetParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Profile.UploadRules.Get")
+    }
+    return res, nil
+  })
+}
+
+func (r *ProfileUploadRulesGetType) TestCall(rc *butlerd.RequestContext, params butlerd.ProfileUploadRulesGetParams) (*butlerd.ProfileUploadRulesGetResult, error) {
+  var result butlerd.ProfileUploadRulesGetResult
+  err := rc.Call("Profile.UploadRules.Get", params, &result)
+  return &result, err
+}
+
+var ProfileUploadRulesGet *ProfileUploadRulesGetType
+
+// Profile.UploadRules.Set (Request)
+
+type ProfileUploadRulesSetType struct {}
+
+var _ RequestMessage = (*ProfileUploadRulesSetType)(nil)
+
+func (r *ProfileUploadRulesSetType) Method() string {
+  return "Profile.UploadRules.Set"
+}
+
+func (r *ProfileUploadRulesSetType) Register(router router, f func(*butlerd.RequestContext, butlerd.ProfileUploadRulesSetParams) (*butlerd.ProfileUploadRulesSetResult, error)) {
+  router.Register("Profile.UploadRules.Set", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.ProfileUploadRulesSetParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Profile.UploadRules.Set")
+    }
+    return res, nil
+  })
+}
+
+func (r *ProfileUploadRulesSetType) TestCall(rc *butlerd.RequestContext, params butlerd.ProfileUploadRulesSetParams) (*butlerd.ProfileUploadRulesSetResult, error) {
+  var result butlerd.ProfileUploadRulesSetResult
+  err := rc.Call("Profile.UploadRules.Set", params, &result)
+  return &result, err
+}
+
+var ProfileUploadRulesSet *ProfileUploadRulesSetType
+
+// Profile.GetPlaytimeStats (Request)
+
+type ProfileGetPlaytimeStatsType struct {}
+
+var _ RequestMessage = (*ProfileGetPlaytimeStatsType)(nil)
+
+func (r *ProfileGetPlaytimeStatsType) Method() string {
+  return "Profile.GetPlaytimeStats"
+}
+
+func (r *ProfileGetPlaytimeStatsType) Register(router router, f func(*butlerd.RequestContext, butlerd.ProfileGetPlaytimeStatsParams) (*butlerd.ProfileGetPlaytimeStatsResult, error)) {
+  router.Register("Profile.GetPlaytimeStats", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.ProfileGetPlaytimeStatsParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Profile.GetPlaytimeStats")
+    }
+    return res, nil
+  })
+}
+
+func (r *ProfileGetPlaytimeStatsType) TestCall(rc *butlerd.RequestContext, params butlerd.ProfileGetPlaytimeStatsParams) (*butlerd.ProfileGetPlaytimeStatsResult, error) {
+  var result butlerd.ProfileGetPlaytimeStatsResult
+  err := rc.Call("Profile.GetPlaytimeStats", params, &result)
+  return &result, err
+}
+
+var ProfileGetPlaytimeStats *ProfileGetPlaytimeStatsType
+
 
 //==============================
 // Search
@@ -1032,6 +1181,35 @@ var SearchLocal *SearchLocalType
 // Fetch
 //==============================
 
+// Fetch.Refreshed (Notification)
+
+type FetchRefreshedType struct {}
+
+var _ NotificationMessage = (*FetchRefreshedType)(nil)
+
+func (r *FetchRefreshedType) Method() string {
+  return "Fetch.Refreshed"
+}
+
+func (r *FetchRefreshedType) Notify(rc *butlerd.RequestContext, params butlerd.FetchRefreshedNotification) (error) {
+  return rc.Notify("Fetch.Refreshed", params)
+}
+
+func (r *FetchRefreshedType) Register(router router, f func(butlerd.FetchRefreshedNotification)) {
+  router.RegisterNotification("Fetch.Refreshed", func (notif jsonrpc2.Notification) {
+    var params butlerd.FetchRefreshedNotification
+    if notif.Params != nil {
+      err := json.Unmarshal(*notif.Params, &params)
+      if err != nil {
+        return
+      }
+    }
+    f(params)
+  })
+}
+
+var FetchRefreshed *FetchRefreshedType
+
 // Fetch.Game (Request)
 
 type FetchGameType struct {}
@@ -1957,6 +2135,86 @@ func (r *InstallQueueType) TestCall(rc *butlerd.RequestContext, params butlerd.I
 
 var InstallQueue *InstallQueueType
 
+// Install.QueueMany (Request)
+
+type InstallQueueManyType struct {}
+
+var _ RequestMessage = (*InstallQueueManyType)(nil)
+
+func (r *InstallQueueManyType) Method() string {
+  return "Install.QueueMany"
+}
+
+func (r *InstallQueueManyType) Register(router router, f func(*butlerd.RequestContext, butlerd.InstallQueueManyParams) (*butlerd.InstallQueueManyResult, error)) {
+  router.Register("Install.QueueMany", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.InstallQueueManyParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Install.QueueMany")
+    }
+    return res, nil
+  })
+}
+
+func (r *InstallQueueManyType) TestCall(rc *butlerd.RequestContext, params butlerd.InstallQueueManyParams) (*butlerd.InstallQueueManyResult, error) {
+  var result butlerd.InstallQueueManyResult
+  err := rc.Call("Install.QueueMany", params, &result)
+  return &result, err
+}
+
+var InstallQueueMany *InstallQueueManyType
+
+// Install.Sync (Request)
+
+type InstallSyncType struct {}
+
+var _ RequestMessage = (*InstallSyncType)(nil)
+
+func (r *InstallSyncType) Method() string {
+  return "Install.Sync"
+}
+
+func (r *InstallSyncType) Register(router router, f func(*butlerd.RequestContext, butlerd.InstallSyncParams) (*butlerd.InstallSyncResult, error)) {
+  router.Register("Install.Sync", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.InstallSyncParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Install.Sync")
+    }
+    return res, nil
+  })
+}
+
+func (r *InstallSyncType) TestCall(rc *butlerd.RequestContext, params butlerd.InstallSyncParams) (*butlerd.InstallSyncResult, error) {
+  var result butlerd.InstallSyncResult
+  err := rc.Call("Install.Sync", params, &result)
+  return &result, err
+}
+
+var InstallSync *InstallSyncType
+
 // Install.Plan (Request)
 
 type InstallPlanType struct {}
@@ -2197,6 +2455,126 @@ func (r *CavesSetPinnedType) TestCall(rc *butlerd.RequestContext, params butlerd
 
 var CavesSetPinned *CavesSetPinnedType
 
+// Caves.ResetCompatPrefix (Request)
+
+type CavesResetCompatPrefixType struct {}
+
+var _ RequestMessage = (*CavesResetCompatPrefixType)(nil)
+
+func (r *CavesResetCompatPrefixType) Method() string {
+  return "Caves.ResetCompatPrefix"
+}
+
+func (r *CavesResetCompatPrefixType) Register(router router, f func(*butlerd.RequestContext, butlerd.CavesResetCompatPrefixParams) (*butlerd.CavesResetCompatPrefixResult, error)) {
+  router.Register("Caves.ResetCompatPrefix", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.CavesResetCompatPrefixParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Caves.ResetCompatPrefix")
+    }
+    return res, nil
+  })
+}
+
+func (r *CavesResetCompatPrefixType) TestCall(rc *butlerd.RequestContext, params butlerd.CavesResetCompatPrefixParams) (*butlerd.CavesResetCompatPrefixResult, error) {
+  var result butlerd.CavesResetCompatPrefixResult
+  err := rc.Call("Caves.ResetCompatPrefix", params, &result)
+  return &result, err
+}
+
+var CavesResetCompatPrefix *CavesResetCompatPrefixType
+
+// Caves.GetLockStatus (Request)
+
+type CavesGetLockStatusType struct {}
+
+var _ RequestMessage = (*CavesGetLockStatusType)(nil)
+
+func (r *CavesGetLockStatusType) Method() string {
+  return "Caves.GetLockStatus"
+}
+
+func (r *CavesGetLockStatusType) Register(router router, f func(*butlerd.RequestContext, butlerd.CavesGetLockStatusParams) (*butlerd.CavesGetLockStatusResult, error)) {
+  router.Register("Caves.GetLockStatus", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.CavesGetLockStatusParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Caves.GetLockStatus")
+    }
+    return res, nil
+  })
+}
+
+func (r *CavesGetLockStatusType) TestCall(rc *butlerd.RequestContext, params butlerd.CavesGetLockStatusParams) (*butlerd.CavesGetLockStatusResult, error) {
+  var result butlerd.CavesGetLockStatusResult
+  err := rc.Call("Caves.GetLockStatus", params, &result)
+  return &result, err
+}
+
+var CavesGetLockStatus *CavesGetLockStatusType
+
+// Caves.ForceUnlock (Request)
+
+type CavesForceUnlockType struct {}
+
+var _ RequestMessage = (*CavesForceUnlockType)(nil)
+
+func (r *CavesForceUnlockType) Method() string {
+  return "Caves.ForceUnlock"
+}
+
+func (r *CavesForceUnlockType) Register(router router, f func(*butlerd.RequestContext, butlerd.CavesForceUnlockParams) (*butlerd.CavesForceUnlockResult, error)) {
+  router.Register("Caves.ForceUnlock", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.CavesForceUnlockParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Caves.ForceUnlock")
+    }
+    return res, nil
+  })
+}
+
+func (r *CavesForceUnlockType) TestCall(rc *butlerd.RequestContext, params butlerd.CavesForceUnlockParams) (*butlerd.CavesForceUnlockResult, error) {
+  var result butlerd.CavesForceUnlockResult
+  err := rc.Call("Caves.ForceUnlock", params, &result)
+  return &result, err
+}
+
+var CavesForceUnlock *CavesForceUnlockType
+
 // Install.CreateShortcut (Request)
 
 type InstallCreateShortcutType struct {}
@@ -2644,13 +3022,53 @@ func (r *InstallLocationsAddType) TestCall(rc *butlerd.RequestContext, params bu
 
 var InstallLocationsAdd *InstallLocationsAddType
 
-// Install.Locations.Remove (Request)
+// Install.Locations.SetSpaceLimits (Request)
 
-type InstallLocationsRemoveType struct {}
+type InstallLocationsSetSpaceLimitsType struct {}
 
-var _ RequestMessage = (*InstallLocationsRemoveType)(nil)
+var _ RequestMessage = (*InstallLocationsSetSpaceLimitsType)(nil)
 
-func (r *InstallLocationsRemoveType) Method() string {
+func (r *InstallLocationsSetSpaceLimitsType) Method() string {
+  return "Install.Locations.SetSpaceLimits"
+}
+
+func (r *InstallLocationsSetSpaceLimitsType) Register(router router, f func(*butlerd.RequestContext, butlerd.InstallLocationsSetSpaceLimitsParams) (*butlerd.InstallLocationsSetSpaceLimitsResult, error)) {
+  router.Register("Install.Locations.SetSpaceLimits", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.InstallLocationsSetSpaceLimitsParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Install.Locations.SetSpaceLimits")
+    }
+    return res, nil
+  })
+}
+
+func (r *InstallLocationsSetSpaceLimitsType) TestCall(rc *butlerd.RequestContext, params butlerd.InstallLocationsSetSpaceLimitsParams) (*butlerd.InstallLocationsSetSpaceLimitsResult, error) {
+  var result butlerd.InstallLocationsSetSpaceLimitsResult
+  err := rc.Call("Install.Locations.SetSpaceLimits", params, &result)
+  return &result, err
+}
+
+var InstallLocationsSetSpaceLimits *InstallLocationsSetSpaceLimitsType
+
+// Install.Locations.Remove (Request)
+
+type InstallLocationsRemoveType struct {}
+
+var _ RequestMessage = (*InstallLocationsRemoveType)(nil)
+
+func (r *InstallLocationsRemoveType) Method() string {
   return "Install.Locations.Remove"
 }
 
@@ -2833,6 +3251,240 @@ func (r *InstallLocationsScanConfirmImportType) Call(rc *butlerd.RequestContext,
 
 var InstallLocationsScanConfirmImport *InstallLocationsScanConfirmImportType
 
+// Install.Locations.MoveCaves (Request)
+
+type InstallLocationsMoveCavesType struct {}
+
+var _ RequestMessage = (*InstallLocationsMoveCavesType)(nil)
+
+func (r *InstallLocationsMoveCavesType) Method() string {
+  return "Install.Locations.MoveCaves"
+}
+
+func (r *InstallLocationsMoveCavesType) Register(router router, f func(*butlerd.RequestContext, butlerd.InstallLocationsMoveCavesParams) (*butlerd.InstallLocationsMoveCavesResult, error)) {
+  router.Register("Install.Locations.MoveCaves", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.InstallLocationsMoveCavesParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Install.Locations.MoveCaves")
+    }
+    return res, nil
+  })
+}
+
+func (r *InstallLocationsMoveCavesType) TestCall(rc *butlerd.RequestContext, params butlerd.InstallLocationsMoveCavesParams) (*butlerd.InstallLocationsMoveCavesResult, error) {
+  var result butlerd.InstallLocationsMoveCavesResult
+  err := rc.Call("Install.Locations.MoveCaves", params, &result)
+  return &result, err
+}
+
+var InstallLocationsMoveCaves *InstallLocationsMoveCavesType
+
+// Install.Locations.MoveCaves.Yield (Notification)
+
+type InstallLocationsMoveCavesYieldType struct {}
+
+var _ NotificationMessage = (*InstallLocationsMoveCavesYieldType)(nil)
+
+func (r *InstallLocationsMoveCavesYieldType) Method() string {
+  return "Install.Locations.MoveCaves.Yield"
+}
+
+func (r *InstallLocationsMoveCavesYieldType) Notify(rc *butlerd.RequestContext, params butlerd.InstallLocationsMoveCavesYieldNotification) (error) {
+  return rc.Notify("Install.Locations.MoveCaves.Yield", params)
+}
+
+func (r *InstallLocationsMoveCavesYieldType) Register(router router, f func(butlerd.InstallLocationsMoveCavesYieldNotification)) {
+  router.RegisterNotification("Install.Locations.MoveCaves.Yield", func (notif jsonrpc2.Notification) {
+    var params butlerd.InstallLocationsMoveCavesYieldNotification
+    if notif.Params != nil {
+      err := json.Unmarshal(*notif.Params, &params)
+      if err != nil {
+        return
+      }
+    }
+    f(params)
+  })
+}
+
+var InstallLocationsMoveCavesYield *InstallLocationsMoveCavesYieldType
+
+// Install.Locations.Audit (Request)
+
+type InstallLocationsAuditType struct {}
+
+var _ RequestMessage = (*InstallLocationsAuditType)(nil)
+
+func (r *InstallLocationsAuditType) Method() string {
+  return "Install.Locations.Audit"
+}
+
+func (r *InstallLocationsAuditType) Register(router router, f func(*butlerd.RequestContext, butlerd.InstallLocationsAuditParams) (*butlerd.InstallLocationsAuditResult, error)) {
+  router.Register("Install.Locations.Audit", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.InstallLocationsAuditParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Install.Locations.Audit")
+    }
+    return res, nil
+  })
+}
+
+func (r *InstallLocationsAuditType) TestCall(rc *butlerd.RequestContext, params butlerd.InstallLocationsAuditParams) (*butlerd.InstallLocationsAuditResult, error) {
+  var result butlerd.InstallLocationsAuditResult
+  err := rc.Call("Install.Locations.Audit", params, &result)
+  return &result, err
+}
+
+var InstallLocationsAudit *InstallLocationsAuditType
+
+// Install.Locations.ApplyAudit (Request)
+
+type InstallLocationsApplyAuditType struct {}
+
+var _ RequestMessage = (*InstallLocationsApplyAuditType)(nil)
+
+func (r *InstallLocationsApplyAuditType) Method() string {
+  return "Install.Locations.ApplyAudit"
+}
+
+func (r *InstallLocationsApplyAuditType) Register(router router, f func(*butlerd.RequestContext, butlerd.InstallLocationsApplyAuditParams) (*butlerd.InstallLocationsApplyAuditResult, error)) {
+  router.Register("Install.Locations.ApplyAudit", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.InstallLocationsApplyAuditParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Install.Locations.ApplyAudit")
+    }
+    return res, nil
+  })
+}
+
+func (r *InstallLocationsApplyAuditType) TestCall(rc *butlerd.RequestContext, params butlerd.InstallLocationsApplyAuditParams) (*butlerd.InstallLocationsApplyAuditResult, error) {
+  var result butlerd.InstallLocationsApplyAuditResult
+  err := rc.Call("Install.Locations.ApplyAudit", params, &result)
+  return &result, err
+}
+
+var InstallLocationsApplyAudit *InstallLocationsApplyAuditType
+
+
+//==============================
+// Library
+//==============================
+
+// Library.Export (Request)
+
+type LibraryExportType struct {}
+
+var _ RequestMessage = (*LibraryExportType)(nil)
+
+func (r *LibraryExportType) Method() string {
+  return "Library.Export"
+}
+
+func (r *LibraryExportType) Register(router router, f func(*butlerd.RequestContext, butlerd.LibraryExportParams) (*butlerd.LibraryExportResult, error)) {
+  router.Register("Library.Export", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.LibraryExportParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Library.Export")
+    }
+    return res, nil
+  })
+}
+
+func (r *LibraryExportType) TestCall(rc *butlerd.RequestContext, params butlerd.LibraryExportParams) (*butlerd.LibraryExportResult, error) {
+  var result butlerd.LibraryExportResult
+  err := rc.Call("Library.Export", params, &result)
+  return &result, err
+}
+
+var LibraryExport *LibraryExportType
+
+// Library.Import (Request)
+
+type LibraryImportType struct {}
+
+var _ RequestMessage = (*LibraryImportType)(nil)
+
+func (r *LibraryImportType) Method() string {
+  return "Library.Import"
+}
+
+func (r *LibraryImportType) Register(router router, f func(*butlerd.RequestContext, butlerd.LibraryImportParams) (*butlerd.LibraryImportResult, error)) {
+  router.Register("Library.Import", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.LibraryImportParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Library.Import")
+    }
+    return res, nil
+  })
+}
+
+func (r *LibraryImportType) TestCall(rc *butlerd.RequestContext, params butlerd.LibraryImportParams) (*butlerd.LibraryImportResult, error) {
+  var result butlerd.LibraryImportResult
+  err := rc.Call("Library.Import", params, &result)
+  return &result, err
+}
+
+var LibraryImport *LibraryImportType
+
 
 //==============================
 // Downloads
@@ -3420,6 +4072,115 @@ func (r *LaunchExitedType) Register(router router, f func(butlerd.LaunchExitedNo
 
 var LaunchExited *LaunchExitedType
 
+// LaunchCrashed (Notification)
+
+type LaunchCrashedType struct {}
+
+var _ NotificationMessage = (*LaunchCrashedType)(nil)
+
+func (r *LaunchCrashedType) Method() string {
+  return "LaunchCrashed"
+}
+
+func (r *LaunchCrashedType) Notify(rc *butlerd.RequestContext, params butlerd.LaunchCrashedNotification) (error) {
+  return rc.Notify("LaunchCrashed", params)
+}
+
+func (r *LaunchCrashedType) Register(router router, f func(butlerd.LaunchCrashedNotification)) {
+  router.RegisterNotification("LaunchCrashed", func (notif jsonrpc2.Notification) {
+    var params butlerd.LaunchCrashedNotification
+    if notif.Params != nil {
+      err := json.Unmarshal(*notif.Params, &params)
+      if err != nil {
+        return
+      }
+    }
+    f(params)
+  })
+}
+
+var LaunchCrashed *LaunchCrashedType
+
+// Launch.ListCrashes (Request)
+
+type LaunchListCrashesType struct {}
+
+var _ RequestMessage = (*LaunchListCrashesType)(nil)
+
+func (r *LaunchListCrashesType) Method() string {
+  return "Launch.ListCrashes"
+}
+
+func (r *LaunchListCrashesType) Register(router router, f func(*butlerd.RequestContext, butlerd.LaunchListCrashesParams) (*butlerd.LaunchListCrashesResult, error)) {
+  router.Register("Launch.ListCrashes", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.LaunchListCrashesParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Launch.ListCrashes")
+    }
+    return res, nil
+  })
+}
+
+func (r *LaunchListCrashesType) TestCall(rc *butlerd.RequestContext, params butlerd.LaunchListCrashesParams) (*butlerd.LaunchListCrashesResult, error) {
+  var result butlerd.LaunchListCrashesResult
+  err := rc.Call("Launch.ListCrashes", params, &result)
+  return &result, err
+}
+
+var LaunchListCrashes *LaunchListCrashesType
+
+// Launch.GetCrash (Request)
+
+type LaunchGetCrashType struct {}
+
+var _ RequestMessage = (*LaunchGetCrashType)(nil)
+
+func (r *LaunchGetCrashType) Method() string {
+  return "Launch.GetCrash"
+}
+
+func (r *LaunchGetCrashType) Register(router router, f func(*butlerd.RequestContext, butlerd.LaunchGetCrashParams) (*butlerd.LaunchGetCrashResult, error)) {
+  router.Register("Launch.GetCrash", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.LaunchGetCrashParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for Launch.GetCrash")
+    }
+    return res, nil
+  })
+}
+
+func (r *LaunchGetCrashType) TestCall(rc *butlerd.RequestContext, params butlerd.LaunchGetCrashParams) (*butlerd.LaunchGetCrashResult, error) {
+  var result butlerd.LaunchGetCrashResult
+  err := rc.Call("Launch.GetCrash", params, &result)
+  return &result, err
+}
+
+var LaunchGetCrash *LaunchGetCrashType
+
 // AcceptLicense (Request)
 
 type AcceptLicenseType struct {}
@@ -3787,6 +4548,171 @@ func (r *PrereqsFailedType) Call(rc *butlerd.RequestContext, params butlerd.Prer
 
 var PrereqsFailed *PrereqsFailedType
 
+// LinuxDependenciesMissing (Request)
+
+type LinuxDependenciesMissingType struct {}
+
+var _ RequestMessage = (*LinuxDependenciesMissingType)(nil)
+
+func (r *LinuxDependenciesMissingType) Method() string {
+  return "LinuxDependenciesMissing"
+}
+
+func (r *LinuxDependenciesMissingType) TestRegister(router router, f func(*butlerd.RequestContext, butlerd.LinuxDependenciesMissingParams) (*butlerd.LinuxDependenciesMissingResult, error)) {
+  router.Register("LinuxDependenciesMissing", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.LinuxDependenciesMissingParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for LinuxDependenciesMissing")
+    }
+    return res, nil
+  })
+}
+
+func (r *LinuxDependenciesMissingType) Call(rc *butlerd.RequestContext, params butlerd.LinuxDependenciesMissingParams) (*butlerd.LinuxDependenciesMissingResult, error) {
+  var result butlerd.LinuxDependenciesMissingResult
+  err := rc.Call("LinuxDependenciesMissing", params, &result)
+  return &result, err
+}
+
+var LinuxDependenciesMissing *LinuxDependenciesMissingType
+
+
+//==============================
+// Compat Hosts
+//==============================
+
+// CompatHosts.List (Request)
+
+type CompatHostsListType struct {}
+
+var _ RequestMessage = (*CompatHostsListType)(nil)
+
+func (r *CompatHostsListType) Method() string {
+  return "CompatHosts.List"
+}
+
+func (r *CompatHostsListType) Register(router router, f func(*butlerd.RequestContext, butlerd.CompatHostsListParams) (*butlerd.CompatHostsListResult, error)) {
+  router.Register("CompatHosts.List", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.CompatHostsListParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for CompatHosts.List")
+    }
+    return res, nil
+  })
+}
+
+func (r *CompatHostsListType) TestCall(rc *butlerd.RequestContext, params butlerd.CompatHostsListParams) (*butlerd.CompatHostsListResult, error) {
+  var result butlerd.CompatHostsListResult
+  err := rc.Call("CompatHosts.List", params, &result)
+  return &result, err
+}
+
+var CompatHostsList *CompatHostsListType
+
+// CompatHosts.Save (Request)
+
+type CompatHostsSaveType struct {}
+
+var _ RequestMessage = (*CompatHostsSaveType)(nil)
+
+func (r *CompatHostsSaveType) Method() string {
+  return "CompatHosts.Save"
+}
+
+func (r *CompatHostsSaveType) Register(router router, f func(*butlerd.RequestContext, butlerd.CompatHostsSaveParams) (*butlerd.CompatHostsSaveResult, error)) {
+  router.Register("CompatHosts.Save", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.CompatHostsSaveParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for CompatHosts.Save")
+    }
+    return res, nil
+  })
+}
+
+func (r *CompatHostsSaveType) TestCall(rc *butlerd.RequestContext, params butlerd.CompatHostsSaveParams) (*butlerd.CompatHostsSaveResult, error) {
+  var result butlerd.CompatHostsSaveResult
+  err := rc.Call("CompatHosts.Save", params, &result)
+  return &result, err
+}
+
+var CompatHostsSave *CompatHostsSaveType
+
+// CompatHosts.Remove (Request)
+
+type CompatHostsRemoveType struct {}
+
+var _ RequestMessage = (*CompatHostsRemoveType)(nil)
+
+func (r *CompatHostsRemoveType) Method() string {
+  return "CompatHosts.Remove"
+}
+
+func (r *CompatHostsRemoveType) Register(router router, f func(*butlerd.RequestContext, butlerd.CompatHostsRemoveParams) (*butlerd.CompatHostsRemoveResult, error)) {
+  router.Register("CompatHosts.Remove", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.CompatHostsRemoveParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for CompatHosts.Remove")
+    }
+    return res, nil
+  })
+}
+
+func (r *CompatHostsRemoveType) TestCall(rc *butlerd.RequestContext, params butlerd.CompatHostsRemoveParams) (*butlerd.CompatHostsRemoveResult, error) {
+  var result butlerd.CompatHostsRemoveResult
+  err := rc.Call("CompatHosts.Remove", params, &result)
+  return &result, err
+}
+
+var CompatHostsRemove *CompatHostsRemoveType
+
 
 //==============================
 // Clean Downloads
@@ -3918,6 +4844,91 @@ func (r *SystemStatFSType) TestCall(rc *butlerd.RequestContext, params butlerd.S
 var SystemStatFS *SystemStatFSType
 
 
+//==============================
+// Background Tasks
+//==============================
+
+// BackgroundTasks.List (Request)
+
+type BackgroundTasksListType struct {}
+
+var _ RequestMessage = (*BackgroundTasksListType)(nil)
+
+func (r *BackgroundTasksListType) Method() string {
+  return "BackgroundTasks.List"
+}
+
+func (r *BackgroundTasksListType) Register(router router, f func(*butlerd.RequestContext, butlerd.BackgroundTasksListParams) (*butlerd.BackgroundTasksListResult, error)) {
+  router.Register("BackgroundTasks.List", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.BackgroundTasksListParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for BackgroundTasks.List")
+    }
+    return res, nil
+  })
+}
+
+func (r *BackgroundTasksListType) TestCall(rc *butlerd.RequestContext, params butlerd.BackgroundTasksListParams) (*butlerd.BackgroundTasksListResult, error) {
+  var result butlerd.BackgroundTasksListResult
+  err := rc.Call("BackgroundTasks.List", params, &result)
+  return &result, err
+}
+
+var BackgroundTasksList *BackgroundTasksListType
+
+// BackgroundTasks.Retry (Request)
+
+type BackgroundTasksRetryType struct {}
+
+var _ RequestMessage = (*BackgroundTasksRetryType)(nil)
+
+func (r *BackgroundTasksRetryType) Method() string {
+  return "BackgroundTasks.Retry"
+}
+
+func (r *BackgroundTasksRetryType) Register(router router, f func(*butlerd.RequestContext, butlerd.BackgroundTasksRetryParams) (*butlerd.BackgroundTasksRetryResult, error)) {
+  router.Register("BackgroundTasks.Retry", func (rc *butlerd.RequestContext) (interface{}, error) {
+    var params butlerd.BackgroundTasksRetryParams
+    err := json.Unmarshal(*rc.Params, &params)
+    if err != nil {
+    	return nil, &butlerd.RpcError{Code: jsonrpc2.CodeParseError, Message: err.Error()}
+    }
+    err = params.Validate()
+    if err != nil {
+    	return nil, err
+    }
+    res, err := f(rc, params)
+    if err != nil {
+    	return nil, err
+    }
+    if res == nil {
+    	return nil, errors.New("internal error: nil result for BackgroundTasks.Retry")
+    }
+    return res, nil
+  })
+}
+
+func (r *BackgroundTasksRetryType) TestCall(rc *butlerd.RequestContext, params butlerd.BackgroundTasksRetryParams) (*butlerd.BackgroundTasksRetryResult, error) {
+  var result butlerd.BackgroundTasksRetryResult
+  err := rc.Call("BackgroundTasks.Retry", params, &result)
+  return &result, err
+}
+
+var BackgroundTasksRetry *BackgroundTasksRetryType
+
+
 //==============================
 // Test
 //==============================
@@ -4350,6 +5361,9 @@ func EnsureAllRequests(router *butlerd.Router) {
   if _, ok := router.Handlers["Profile.Forget"]; !ok { panic("missing request handler for (Profile.Forget)") }
   if _, ok := router.Handlers["Profile.Data.Put"]; !ok { panic("missing request handler for (Profile.Data.Put)") }
   if _, ok := router.Handlers["Profile.Data.Get"]; !ok { panic("missing request handler for (Profile.Data.Get)") }
+  if _, ok := router.Handlers["Profile.UploadRules.Get"]; !ok { panic("missing request handler for (Profile.UploadRules.Get)") }
+  if _, ok := router.Handlers["Profile.UploadRules.Set"]; !ok { panic("missing request handler for (Profile.UploadRules.Set)") }
+  if _, ok := router.Handlers["Profile.GetPlaytimeStats"]; !ok { panic("missing request handler for (Profile.GetPlaytimeStats)") }
   if _, ok := router.Handlers["Search.Games"]; !ok { panic("missing request handler for (Search.Games)") }
   if _, ok := router.Handlers["Search.Users"]; !ok { panic("missing request handler for (Search.Users)") }
   if _, ok := router.Handlers["Search.Local"]; !ok { panic("missing request handler for (Search.Local)") }
@@ -4376,12 +5390,17 @@ func EnsureAllRequests(router *butlerd.Router) {
   if _, ok := router.Handlers["Fetch.ExpireAll"]; !ok { panic("missing request handler for (Fetch.ExpireAll)") }
   if _, ok := router.Handlers["Game.FindUploads"]; !ok { panic("missing request handler for (Game.FindUploads)") }
   if _, ok := router.Handlers["Install.Queue"]; !ok { panic("missing request handler for (Install.Queue)") }
+  if _, ok := router.Handlers["Install.QueueMany"]; !ok { panic("missing request handler for (Install.QueueMany)") }
+  if _, ok := router.Handlers["Install.Sync"]; !ok { panic("missing request handler for (Install.Sync)") }
   if _, ok := router.Handlers["Install.Plan"]; !ok { panic("missing request handler for (Install.Plan)") }
   if _, ok := router.Handlers["Install.GetUploads"]; !ok { panic("missing request handler for (Install.GetUploads)") }
   if _, ok := router.Handlers["Install.PlanUpload"]; !ok { panic("missing request handler for (Install.PlanUpload)") }
   if _, ok := router.Handlers["Caves.GetSettings"]; !ok { panic("missing request handler for (Caves.GetSettings)") }
   if _, ok := router.Handlers["Caves.SetSettings"]; !ok { panic("missing request handler for (Caves.SetSettings)") }
   if _, ok := router.Handlers["Caves.SetPinned"]; !ok { panic("missing request handler for (Caves.SetPinned)") }
+  if _, ok := router.Handlers["Caves.ResetCompatPrefix"]; !ok { panic("missing request handler for (Caves.ResetCompatPrefix)") }
+  if _, ok := router.Handlers["Caves.GetLockStatus"]; !ok { panic("missing request handler for (Caves.GetLockStatus)") }
+  if _, ok := router.Handlers["Caves.ForceUnlock"]; !ok { panic("missing request handler for (Caves.ForceUnlock)") }
   if _, ok := router.Handlers["Install.CreateShortcut"]; !ok { panic("missing request handler for (Install.CreateShortcut)") }
   if _, ok := router.Handlers["Install.Perform"]; !ok { panic("missing request handler for (Install.Perform)") }
   if _, ok := router.Handlers["Install.Cancel"]; !ok { panic("missing request handler for (Install.Cancel)") }
@@ -4389,9 +5408,15 @@ func EnsureAllRequests(router *butlerd.Router) {
   if _, ok := router.Handlers["Install.VersionSwitch.Queue"]; !ok { panic("missing request handler for (Install.VersionSwitch.Queue)") }
   if _, ok := router.Handlers["Install.Locations.List"]; !ok { panic("missing request handler for (Install.Locations.List)") }
   if _, ok := router.Handlers["Install.Locations.Add"]; !ok { panic("missing request handler for (Install.Locations.Add)") }
+  if _, ok := router.Handlers["Install.Locations.SetSpaceLimits"]; !ok { panic("missing request handler for (Install.Locations.SetSpaceLimits)") }
   if _, ok := router.Handlers["Install.Locations.Remove"]; !ok { panic("missing request handler for (Install.Locations.Remove)") }
   if _, ok := router.Handlers["Install.Locations.GetByID"]; !ok { panic("missing request handler for (Install.Locations.GetByID)") }
   if _, ok := router.Handlers["Install.Locations.Scan"]; !ok { panic("missing request handler for (Install.Locations.Scan)") }
+  if _, ok := router.Handlers["Install.Locations.MoveCaves"]; !ok { panic("missing request handler for (Install.Locations.MoveCaves)") }
+  if _, ok := router.Handlers["Install.Locations.Audit"]; !ok { panic("missing request handler for (Install.Locations.Audit)") }
+  if _, ok := router.Handlers["Install.Locations.ApplyAudit"]; !ok { panic("missing request handler for (Install.Locations.ApplyAudit)") }
+  if _, ok := router.Handlers["Library.Export"]; !ok { panic("missing request handler for (Library.Export)") }
+  if _, ok := router.Handlers["Library.Import"]; !ok { panic("missing request handler for (Library.Import)") }
   if _, ok := router.Handlers["Downloads.Queue"]; !ok { panic("missing request handler for (Downloads.Queue)") }
   if _, ok := router.Handlers["Downloads.Prioritize"]; !ok { panic("missing request handler for (Downloads.Prioritize)") }
   if _, ok := router.Handlers["Downloads.List"]; !ok { panic("missing request handler for (Downloads.List)") }
@@ -4404,9 +5429,16 @@ func EnsureAllRequests(router *butlerd.Router) {
   if _, ok := router.Handlers["SnoozeCave"]; !ok { panic("missing request handler for (SnoozeCave)") }
   if _, ok := router.Handlers["Launch.GetTargets"]; !ok { panic("missing request handler for (Launch.GetTargets)") }
   if _, ok := router.Handlers["Launch"]; !ok { panic("missing request handler for (Launch)") }
+  if _, ok := router.Handlers["Launch.ListCrashes"]; !ok { panic("missing request handler for (Launch.ListCrashes)") }
+  if _, ok := router.Handlers["Launch.GetCrash"]; !ok { panic("missing request handler for (Launch.GetCrash)") }
+  if _, ok := router.Handlers["CompatHosts.List"]; !ok { panic("missing request handler for (CompatHosts.List)") }
+  if _, ok := router.Handlers["CompatHosts.Save"]; !ok { panic("missing request handler for (CompatHosts.Save)") }
+  if _, ok := router.Handlers["CompatHosts.Remove"]; !ok { panic("missing request handler for (CompatHosts.Remove)") }
   if _, ok := router.Handlers["CleanDownloads.Search"]; !ok { panic("missing request handler for (CleanDownloads.Search)") }
   if _, ok := router.Handlers["CleanDownloads.Apply"]; !ok { panic("missing request handler for (CleanDownloads.Apply)") }
   if _, ok := router.Handlers["System.StatFS"]; !ok { panic("missing request handler for (System.StatFS)") }
+  if _, ok := router.Handlers["BackgroundTasks.List"]; !ok { panic("missing request handler for (BackgroundTasks.List)") }
+  if _, ok := router.Handlers["BackgroundTasks.Retry"]; !ok { panic("missing request handler for (BackgroundTasks.Retry)") }
   if _, ok := router.Handlers["Test.DoubleTwice"]; !ok { panic("missing request handler for (Test.DoubleTwice)") }
   if _, ok := router.Handlers["Publish.Push"]; !ok { panic("missing request handler for (Publish.Push)") }
   if _, ok := router.Handlers["Publish.PushPreview"]; !ok { panic("missing request handler for (Publish.PushPreview)") }
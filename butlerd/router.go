@@ -43,6 +43,14 @@ type InFlightBackgroundTask struct {
 type BackgroundTask struct {
 	Desc string
 	Do   func(rc *RequestContext) error
+
+	// If set, the task is persisted before running and retried with
+	// exponential backoff, across daemon restarts, until it succeeds.
+	// The kind must have been registered with RegisterBackgroundTaskKind.
+	Kind string
+	// Passed back to the kind's factory when the task is rebuilt from
+	// the database. Must marshal to JSON.
+	Payload interface{}
 }
 
 type RequestHandler func(rc *RequestContext) (interface{}, error)
@@ -73,7 +81,14 @@ type Router struct {
 
 	backgroundTaskIDSeed BackgroundTaskID
 
+	backgroundTaskKinds map[string]BackgroundTaskFactory
+	taskQueueWake       chan struct{}
+	taskQueueStartOnce  sync.Once
+
 	globalConsumer *state.Consumer
+
+	// Daemon-wide settings, passed on to every request
+	Config Config
 }
 
 func NewRouter(dbPool *sqlitex.Pool, getClient GetClientFunc, httpClient *http.Client, httpTransport *http.Transport) *Router {
@@ -99,6 +114,9 @@ func NewRouter(dbPool *sqlitex.Pool, getClient GetClientFunc, httpClient *http.C
 
 		backgroundTaskIDSeed: 0,
 
+		backgroundTaskKinds: make(map[string]BackgroundTaskFactory),
+		taskQueueWake:       make(chan struct{}, 1),
+
 		globalConsumer: &state.Consumer{
 			OnMessage: func(lvl string, msg string) {
 				comm.Logf("[router] [%s] %s", lvl, msg)
@@ -252,13 +270,16 @@ func (r *Router) HandleRequest(conn jsonrpc2.Conn, req jsonrpc2.Request) (interf
 
 			HTTPClient:    r.httpClient,
 			HTTPTransport: r.httpTransport,
+			Config:        r.Config,
 
 			Group:    r.Group,
 			Shutdown: r.initiateShutdown,
 
 			method: method,
+			router: r,
 
 			QueueBackgroundTask: r.QueueBackgroundTask,
+			WakeTaskQueue:       r.WakeTaskQueue,
 		}
 
 		{
@@ -360,7 +381,13 @@ func (r *Router) HandleRequest(conn jsonrpc2.Conn, req jsonrpc2.Request) (interf
 	return nil, rpcErr
 }
 
-func (r *Router) doBackgroundTask(id BackgroundTaskID, bt BackgroundTask) {
+func (r *Router) doBackgroundTask(id BackgroundTaskID, bt BackgroundTask, onDone func(err error)) {
+	defer func() {
+		r.inflightLock.Lock()
+		r.onBackgroundTaskFinished(id)
+		r.inflightLock.Unlock()
+	}()
+
 	defer func() {
 		router := r
 		if r := recover(); r != nil {
@@ -368,12 +395,6 @@ func (r *Router) doBackgroundTask(id BackgroundTaskID, bt BackgroundTask) {
 		}
 	}()
 
-	defer func() {
-		r.inflightLock.Lock()
-		r.onBackgroundTaskFinished(id)
-		r.inflightLock.Unlock()
-	}()
-
 	consumer := r.globalConsumer
 	rc := &RequestContext{
 		Ctx:         r.backgroundContext,
@@ -386,13 +407,16 @@ func (r *Router) doBackgroundTask(id BackgroundTaskID, bt BackgroundTask) {
 
 		HTTPClient:    r.httpClient,
 		HTTPTransport: r.httpTransport,
+		Config:        r.Config,
 
 		Group:    r.Group,
 		Shutdown: r.initiateShutdown,
 
 		method: "",
+		router: r,
 
 		QueueBackgroundTask: r.QueueBackgroundTask,
+		WakeTaskQueue:       r.WakeTaskQueue,
 	}
 
 	err := func() (retErr error) {
@@ -403,9 +427,29 @@ func (r *Router) doBackgroundTask(id BackgroundTaskID, bt BackgroundTask) {
 	if err != nil {
 		consumer.Warnf("Background task error: %+v", err)
 	}
+
+	// called before the task is marked as finished, so that
+	// shutdown waits for its outcome to be recorded.
+	if onDone != nil {
+		onDone(err)
+	}
 }
 
 func (r *Router) QueueBackgroundTask(bt BackgroundTask) {
+	if bt.Kind != "" {
+		err := r.persistBackgroundTask(bt)
+		if err == nil {
+			return
+		}
+		r.globalConsumer.Warnf("Could not persist background task (%s), running it once: %+v", bt.Desc, err)
+	}
+
+	r.startBackgroundTask(bt, nil)
+}
+
+// startBackgroundTask runs bt in its own goroutine, keeping track of it so
+// shutdown waits for it. onDone, if non-nil, is called with the outcome.
+func (r *Router) startBackgroundTask(bt BackgroundTask, onDone func(err error)) {
 	r.inflightLock.Lock()
 	id := r.generateBackgroundTaskID()
 	r.onBackgroundTaskQueued(id, InFlightBackgroundTask{
@@ -414,7 +458,7 @@ func (r *Router) QueueBackgroundTask(bt BackgroundTask) {
 	})
 	r.inflightLock.Unlock()
 
-	go r.doBackgroundTask(id, bt)
+	go r.doBackgroundTask(id, bt, onDone)
 }
 
 func (r *Router) Logf(format string, args ...interface{}) {
@@ -428,9 +472,11 @@ type RequestContext struct {
 	Consumer            *state.Consumer
 	Client              GetClientFunc
 	QueueBackgroundTask func(bt BackgroundTask)
+	WakeTaskQueue       func()
 
 	HTTPClient    *http.Client
 	HTTPTransport *http.Transport
+	Config        Config
 
 	Params      *json.RawMessage
 	Conn        jsonrpc2.Conn
@@ -444,6 +490,7 @@ type RequestContext struct {
 	tracker                  tracker.Tracker
 
 	method string
+	router *Router
 }
 
 type WithParamsFunc func() (interface{}, error)
@@ -477,6 +524,64 @@ func (rc *RequestContext) Notify(method string, params interface{}) error {
 	return rc.Conn.Notify(method, params)
 }
 
+// Method returns the name of the request being handled, or an empty
+// string in background tasks.
+func (rc *RequestContext) Method() string {
+	return rc.method
+}
+
+// RedoInBackground handles the current request again in a background task,
+// with patch merged into its params. Notifications sent while doing so
+// still go to the client that made the request. onDone is called from the
+// background task with the outcome, even if the handler panics.
+func (rc *RequestContext) RedoInBackground(patch map[string]interface{}, onDone func(brc *RequestContext, err error)) error {
+	if rc.router == nil || rc.Params == nil || rc.method == "" {
+		return errors.New("only requests can be redone")
+	}
+
+	h, ok := rc.router.Handlers[rc.method]
+	if !ok {
+		return errors.Errorf("no handler for %s", rc.method)
+	}
+
+	params := make(map[string]interface{})
+	err := json.Unmarshal(*rc.Params, &params)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for k, v := range patch {
+		params[k] = v
+	}
+	rawParams, err := jsonrpc2.EncodeJSON(params)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	method := rc.method
+	conn := rc.Conn
+	rc.QueueBackgroundTask(BackgroundTask{
+		Desc: fmt.Sprintf("Redo %s", method),
+		Do: func(brc *RequestContext) error {
+			brc.Conn = conn
+			brc.Params = &rawParams
+			brc.method = method
+
+			// handlers report most errors by panicking, onDone
+			// must hear about those too.
+			err := func() (err error) {
+				defer horror.RecoverInto(&err)
+				_, err = h(brc)
+				return
+			}()
+			if onDone != nil {
+				onDone(brc, err)
+			}
+			return err
+		},
+	})
+	return nil
+}
+
 func (rc *RequestContext) RootClient() *itchio.Client {
 	return rc.Client("<keyless>")
 }
@@ -1,7 +1,53 @@
 package butlerd
 
-import "github.com/itchio/butler/manager"
+import (
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/manager"
+	"github.com/itchio/ox"
+)
 
 func (rc *RequestContext) HostEnumerator() manager.HostEnumerator {
-	return manager.DefaultHostEnumerator()
+	var compatHosts manager.Hosts
+	rc.WithConn(func(conn *sqlite.Conn) {
+		for _, mch := range models.AllCompatHosts(conn) {
+			ch, err := FormatCompatHost(mch)
+			if err != nil {
+				rc.Consumer.Warnf("Skipping compat host (%s): %v", mch.Name, err)
+				continue
+			}
+			compatHosts = append(compatHosts, ch.Host())
+		}
+	})
+	return manager.HostEnumeratorWithCompatHosts(compatHosts)
+}
+
+// FormatCompatHost converts a compat host from its database form.
+func FormatCompatHost(mch *models.CompatHost) (*CompatHost, error) {
+	ch := &CompatHost{
+		ID:       mch.ID,
+		Name:     mch.Name,
+		Platform: mch.Platform,
+		Is64:     mch.Is64,
+		Wrapper:  &manager.Wrapper{},
+		Position: mch.Position,
+	}
+	err := models.UnmarshalJSON(mch.Wrapper, ch.Wrapper, "compat host wrapper")
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// Host returns the launch host for a compat host.
+func (ch *CompatHost) Host() manager.Host {
+	return manager.Host{
+		Runtime: ox.Runtime{
+			Platform: ox.Platform(ch.Platform),
+			Is64:     ch.Is64,
+		},
+		ID:      ch.ID,
+		Name:    ch.Name,
+		Wrapper: ch.Wrapper,
+	}
 }
@@ -0,0 +1,242 @@
+package butlerd
+
+import (
+	"context"
+	"time"
+
+	"crawshaw.io/sqlite"
+	"github.com/google/uuid"
+	"github.com/helloeave/json"
+	"github.com/itchio/butler/butlerd/horror"
+	"github.com/itchio/butler/database/models"
+	"github.com/pkg/errors"
+)
+
+// BackgroundTaskFactory rebuilds a persisted background task from
+// its JSON payload.
+type BackgroundTaskFactory func(payload []byte) (BackgroundTask, error)
+
+const (
+	// How long to wait before the first retry, doubled on every attempt
+	backgroundTaskBaseBackoff = 30 * time.Second
+	backgroundTaskMaxBackoff  = 1 * time.Hour
+
+	// After that many failed attempts, a task is marked as failed
+	// and no longer retried automatically.
+	backgroundTaskMaxAttempts = 10
+
+	// Upper bound on how long the queue sleeps between checks
+	taskQueuePollInterval = 1 * time.Minute
+)
+
+func (r *Router) RegisterBackgroundTaskKind(kind string, factory BackgroundTaskFactory) {
+	if _, ok := r.backgroundTaskKinds[kind]; ok {
+		panic("Can't register background task kind twice: " + kind)
+	}
+	r.backgroundTaskKinds[kind] = factory
+}
+
+// StartTaskQueue starts running persisted background tasks, including
+// those left over from a previous daemon instance. All task kinds
+// should be registered before calling it.
+func (r *Router) StartTaskQueue() {
+	r.taskQueueStartOnce.Do(func() {
+		err := r.withTaskQueueConn(func(conn *sqlite.Conn) {
+			models.ResetRunningBackgroundTasks(conn)
+		})
+		if err != nil {
+			r.globalConsumer.Warnf("Could not reset interrupted background tasks: %+v", err)
+		}
+
+		go r.runTaskQueue()
+	})
+}
+
+// WakeTaskQueue makes the queue look for due tasks right away.
+func (r *Router) WakeTaskQueue() {
+	select {
+	case r.taskQueueWake <- struct{}{}:
+	default:
+		// already awake
+	}
+}
+
+func (r *Router) persistBackgroundTask(bt BackgroundTask) error {
+	if _, ok := r.backgroundTaskKinds[bt.Kind]; !ok {
+		return errors.Errorf("unknown background task kind (%s)", bt.Kind)
+	}
+
+	payloadBytes, err := json.Marshal(bt.Payload)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	payload := models.JSON(payloadBytes)
+
+	err = r.withTaskQueueConn(func(conn *sqlite.Conn) {
+		if models.PendingBackgroundTaskFor(conn, bt.Kind, payload) != nil {
+			r.globalConsumer.Debugf("Background task (%s) already queued", bt.Desc)
+			return
+		}
+
+		now := time.Now().UTC()
+		record := &models.BackgroundTask{
+			ID:        uuid.New().String(),
+			Kind:      bt.Kind,
+			Payload:   payload,
+			Desc:      bt.Desc,
+			NextRunAt: &now,
+			CreatedAt: &now,
+		}
+		record.Save(conn)
+	})
+	if err != nil {
+		return err
+	}
+
+	r.WakeTaskQueue()
+	return nil
+}
+
+func (r *Router) runTaskQueue() {
+	for {
+		wait := r.pollTaskQueue()
+
+		select {
+		case <-r.backgroundContext.Done():
+			return
+		case <-r.taskQueueWake:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// pollTaskQueue starts all due tasks and returns how long to wait
+// until the next one is due.
+func (r *Router) pollTaskQueue() time.Duration {
+	r.inflightLock.Lock()
+	shuttingDown := r.shuttingDown
+	r.inflightLock.Unlock()
+	if shuttingDown {
+		return taskQueuePollInterval
+	}
+
+	wait := taskQueuePollInterval
+	var due []*models.BackgroundTask
+
+	err := r.withTaskQueueConn(func(conn *sqlite.Conn) {
+		now := time.Now().UTC()
+		for _, record := range models.PendingBackgroundTasks(conn) {
+			if record.IsDue(now) {
+				record.StartedAt = &now
+				record.Save(conn)
+				due = append(due, record)
+			} else if record.StartedAt == nil && record.NextRunAt != nil {
+				if d := record.NextRunAt.Sub(now); d < wait {
+					wait = d
+				}
+			}
+		}
+	})
+	if err != nil {
+		r.globalConsumer.Warnf("Could not poll background task queue: %+v", err)
+		return taskQueuePollInterval
+	}
+
+	for _, record := range due {
+		r.startPersistedBackgroundTask(record)
+	}
+
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
+
+func (r *Router) startPersistedBackgroundTask(record *models.BackgroundTask) {
+	factory, ok := r.backgroundTaskKinds[record.Kind]
+	if !ok {
+		r.finishPersistedBackgroundTask(record, errors.Errorf("unknown background task kind (%s)", record.Kind))
+		return
+	}
+
+	bt, err := factory([]byte(record.Payload))
+	if err != nil {
+		r.finishPersistedBackgroundTask(record, errors.WithMessage(err, "rebuilding background task"))
+		return
+	}
+	if bt.Desc == "" {
+		bt.Desc = record.Desc
+	}
+
+	r.startBackgroundTask(bt, func(err error) {
+		r.finishPersistedBackgroundTask(record, err)
+	})
+}
+
+func (r *Router) finishPersistedBackgroundTask(record *models.BackgroundTask, taskErr error) {
+	err := r.withTaskQueueConn(func(conn *sqlite.Conn) {
+		record.StartedAt = nil
+
+		if taskErr == nil {
+			record.Delete(conn)
+			return
+		}
+
+		now := time.Now().UTC()
+		if r.backgroundContext.Err() != nil {
+			// interrupted by shutdown, that doesn't count as an attempt
+			record.NextRunAt = &now
+			record.Save(conn)
+			return
+		}
+
+		record.Attempts++
+		errString := taskErr.Error()
+		record.LastError = &errString
+		if record.Attempts >= backgroundTaskMaxAttempts {
+			r.globalConsumer.Warnf("Background task (%s) failed %d times, giving up", record.Desc, record.Attempts)
+			record.FailedAt = &now
+		} else {
+			nextRunAt := now.Add(backgroundTaskBackoff(record.Attempts))
+			r.globalConsumer.Infof("Background task (%s) will be retried at %s", record.Desc, nextRunAt.Format(time.RFC3339))
+			record.NextRunAt = &nextRunAt
+		}
+		record.Save(conn)
+	})
+	if err != nil {
+		r.globalConsumer.Warnf("Could not record outcome of background task (%s): %+v", record.Desc, err)
+	}
+	r.WakeTaskQueue()
+}
+
+// backgroundTaskBackoff returns how long to wait before retrying a task
+// that has failed `attempts` times.
+func backgroundTaskBackoff(attempts int64) time.Duration {
+	backoff := backgroundTaskBaseBackoff
+	for i := int64(1); i < attempts; i++ {
+		backoff *= 2
+		if backoff >= backgroundTaskMaxBackoff {
+			return backgroundTaskMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// withTaskQueueConn doesn't use the background context, so that task
+// outcomes can still be recorded while shutting down.
+func (r *Router) withTaskQueueConn(f func(conn *sqlite.Conn)) (retErr error) {
+	defer horror.RecoverInto(&retErr)
+
+	getCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	conn := r.dbPool.Get(getCtx)
+	if conn == nil {
+		return errors.WithStack(CodeDatabaseBusy)
+	}
+	// the timeout is only meant for acquiring the connection
+	conn.SetInterrupt(nil)
+	defer r.dbPool.Put(conn)
+
+	f(conn)
+	return nil
+}
@@ -0,0 +1,182 @@
+package butlerd
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"crawshaw.io/sqlite"
+	"crawshaw.io/sqlite/sqlitex"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackgroundTaskBackoffDoubles(t *testing.T) {
+	assert.Equal(t, 30*time.Second, backgroundTaskBackoff(1))
+	assert.Equal(t, 1*time.Minute, backgroundTaskBackoff(2))
+	assert.Equal(t, 2*time.Minute, backgroundTaskBackoff(3))
+	assert.Equal(t, 4*time.Minute, backgroundTaskBackoff(4))
+}
+
+func TestBackgroundTaskBackoffIsCapped(t *testing.T) {
+	assert.Equal(t, backgroundTaskMaxBackoff, backgroundTaskBackoff(8))
+	assert.Equal(t, backgroundTaskMaxBackoff, backgroundTaskBackoff(backgroundTaskMaxAttempts))
+	assert.Equal(t, backgroundTaskMaxBackoff, backgroundTaskBackoff(1000))
+}
+
+func taskQueueTestRouter(t *testing.T) *Router {
+	dbPool, err := sqlitex.Open(filepath.Join(t.TempDir(), "butler.db"), 0, 4)
+	require.NoError(t, err)
+	t.Cleanup(func() { dbPool.Close() })
+
+	conn := dbPool.Get(context.Background())
+	require.NoError(t, models.HadesContext().AutoMigrate(conn))
+	dbPool.Put(conn)
+
+	r := NewRouter(dbPool, nil, nil, nil)
+	r.globalConsumer = &state.Consumer{}
+	t.Cleanup(r.backgroundCancel)
+	return r
+}
+
+func queuedTask(t *testing.T, r *Router) *models.BackgroundTask {
+	var records []*models.BackgroundTask
+	require.NoError(t, r.withTaskQueueConn(func(conn *sqlite.Conn) {
+		records = models.AllBackgroundTasks(conn)
+	}))
+	if len(records) == 0 {
+		return nil
+	}
+	require.Len(t, records, 1)
+	return records[0]
+}
+
+// waitForTasks waits until no background task is running
+func waitForTasks(t *testing.T, r *Router) {
+	require.Eventually(t, func() bool {
+		r.inflightLock.Lock()
+		defer r.inflightLock.Unlock()
+		return len(r.inflightBackgroundTasks) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestTaskQueueRetriesWithBackoff(t *testing.T) {
+	r := taskQueueTestRouter(t)
+
+	var runs int64
+	r.RegisterBackgroundTaskKind("flaky", func(payload []byte) (BackgroundTask, error) {
+		return BackgroundTask{
+			Do: func(rc *RequestContext) error {
+				if atomic.AddInt64(&runs, 1) == 1 {
+					return errors.New("offline")
+				}
+				return nil
+			},
+		}, nil
+	})
+
+	r.QueueBackgroundTask(BackgroundTask{Kind: "flaky", Desc: "Flaky task", Payload: map[string]int64{"gameId": 1}})
+	// queueing the same task again doesn't duplicate it
+	r.QueueBackgroundTask(BackgroundTask{Kind: "flaky", Desc: "Flaky task", Payload: map[string]int64{"gameId": 1}})
+	require.NotNil(t, queuedTask(t, r))
+
+	before := time.Now().UTC()
+	r.pollTaskQueue()
+	waitForTasks(t, r)
+
+	record := queuedTask(t, r)
+	require.NotNil(t, record)
+	assert.EqualValues(t, 1, record.Attempts)
+	assert.Nil(t, record.StartedAt)
+	assert.Nil(t, record.FailedAt)
+	require.NotNil(t, record.LastError)
+	assert.Equal(t, "offline", *record.LastError)
+	require.NotNil(t, record.NextRunAt)
+	assert.False(t, record.NextRunAt.Before(before.Add(backgroundTaskBackoff(1))))
+
+	// not due yet, so polling doesn't run it
+	r.pollTaskQueue()
+	waitForTasks(t, r)
+	assert.EqualValues(t, 1, atomic.LoadInt64(&runs))
+
+	now := time.Now().UTC()
+	record.NextRunAt = &now
+	require.NoError(t, r.withTaskQueueConn(record.Save))
+
+	r.pollTaskQueue()
+	waitForTasks(t, r)
+	assert.EqualValues(t, 2, atomic.LoadInt64(&runs))
+	assert.Nil(t, queuedTask(t, r), "succeeded tasks are removed")
+}
+
+func TestTaskQueueGivesUp(t *testing.T) {
+	r := taskQueueTestRouter(t)
+
+	r.RegisterBackgroundTaskKind("broken", func(payload []byte) (BackgroundTask, error) {
+		return BackgroundTask{
+			Do: func(rc *RequestContext) error {
+				return errors.New("still broken")
+			},
+		}, nil
+	})
+
+	r.QueueBackgroundTask(BackgroundTask{Kind: "broken", Desc: "Broken task", Payload: 1})
+	record := queuedTask(t, r)
+	require.NotNil(t, record)
+	record.Attempts = backgroundTaskMaxAttempts - 1
+	require.NoError(t, r.withTaskQueueConn(record.Save))
+
+	r.pollTaskQueue()
+	waitForTasks(t, r)
+
+	record = queuedTask(t, r)
+	require.NotNil(t, record)
+	assert.EqualValues(t, backgroundTaskMaxAttempts, record.Attempts)
+	assert.NotNil(t, record.FailedAt)
+
+	var pending []*models.BackgroundTask
+	require.NoError(t, r.withTaskQueueConn(func(conn *sqlite.Conn) {
+		pending = models.PendingBackgroundTasks(conn)
+	}))
+	assert.Empty(t, pending, "failed tasks aren't picked up again")
+}
+
+func TestTaskQueueResumesInterruptedTasks(t *testing.T) {
+	r := taskQueueTestRouter(t)
+
+	ran := make(chan struct{}, 1)
+	r.RegisterBackgroundTaskKind("interrupted", func(payload []byte) (BackgroundTask, error) {
+		return BackgroundTask{
+			Do: func(rc *RequestContext) error {
+				ran <- struct{}{}
+				return nil
+			},
+		}, nil
+	})
+
+	// as left behind by a daemon that was killed mid-task
+	now := time.Now().UTC()
+	require.NoError(t, r.withTaskQueueConn(func(conn *sqlite.Conn) {
+		models.MustSave(conn, &models.BackgroundTask{
+			ID:        "interrupted",
+			Kind:      "interrupted",
+			Payload:   "{}",
+			NextRunAt: &now,
+			StartedAt: &now,
+		})
+	}))
+
+	r.StartTaskQueue()
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("interrupted task wasn't resumed")
+	}
+	waitForTasks(t, r)
+	assert.Nil(t, queuedTask(t, r))
+}
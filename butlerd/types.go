@@ -1,9 +1,12 @@
 package butlerd
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/itchio/butler/manager"
 	"github.com/itchio/hush"
 	"github.com/itchio/hush/manifest"
 
@@ -391,6 +394,99 @@ type ProfileDataGetResult struct {
 	Value string `json:"value"`
 }
 
+// Returns the upload preference rules of a profile.
+//
+// @name Profile.UploadRules.Get
+// @category Profile
+// @caller client
+type ProfileUploadRulesGetParams struct {
+	ProfileID int64 `json:"profileId"`
+}
+
+func (p ProfileUploadRulesGetParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.ProfileID, validation.Required),
+	)
+}
+
+type ProfileUploadRulesGetResult struct {
+	Rules []*manager.UploadRule `json:"rules"`
+}
+
+// Replaces the upload preference rules of a profile. When picking
+// among compatible uploads for games that profile owns, uploads
+// matching a prefer rule rank higher, and those matching an avoid
+// rule rank lower. An empty list removes all rules.
+//
+// @name Profile.UploadRules.Set
+// @category Profile
+// @caller client
+type ProfileUploadRulesSetParams struct {
+	ProfileID int64 `json:"profileId"`
+
+	Rules []*manager.UploadRule `json:"rules"`
+}
+
+func (p ProfileUploadRulesSetParams) Validate() error {
+	err := validation.ValidateStruct(&p,
+		validation.Field(&p.ProfileID, validation.Required),
+	)
+	if err != nil {
+		return err
+	}
+	return ValidateUploadRules(p.Rules)
+}
+
+type ProfileUploadRulesSetResult struct {
+}
+
+// Returns playtime per game for a profile, combining what itch.io
+// last reported with sessions recorded locally that haven't been
+// synced yet (for example, because they were played offline).
+//
+// @name Profile.GetPlaytimeStats
+// @category Profile
+// @caller client
+type ProfileGetPlaytimeStatsParams struct {
+	ProfileID int64 `json:"profileId"`
+}
+
+func (p ProfileGetPlaytimeStatsParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.ProfileID, validation.Required),
+	)
+}
+
+type ProfileGetPlaytimeStatsResult struct {
+	Games []*PlaytimeStats `json:"games"`
+}
+
+// PlaytimeStats describes how much a profile has played a game.
+type PlaytimeStats struct {
+	GameID int64 `json:"gameId"`
+
+	// The game itself, if it's in the local database
+	// @optional
+	Game *itchio.Game `json:"game,omitempty"`
+
+	// Best estimate of total playtime, in seconds: the total
+	// reported by itch.io, plus local sessions it doesn't know about yet
+	SecondsRun int64 `json:"secondsRun"`
+	// Total of all sessions recorded locally, in seconds
+	LocalSecondsRun int64 `json:"localSecondsRun"`
+	// Seconds recorded locally but not yet acknowledged by itch.io
+	UnsyncedSecondsRun int64 `json:"unsyncedSecondsRun"`
+
+	// Number of sessions recorded locally
+	NumSessions int64 `json:"numSessions"`
+	// Number of locally recorded sessions that ended in a crash
+	NumCrashes int64 `json:"numCrashes"`
+
+	// Last time the game was run
+	// @optional
+	LastRunAt *time.Time `json:"lastRunAt,omitempty"`
+}
+
 //----------------------------------------------------------------------
 // Search
 //----------------------------------------------------------------------
@@ -483,6 +579,21 @@ type SearchLocalResult struct {
 // Fetch
 //----------------------------------------------------------------------
 
+// Sent when butlerd runs with --offline-first, after a fetch request
+// was answered with cached data and that data has been refreshed in
+// the background. Making the same request again returns fresh data.
+//
+// @name Fetch.Refreshed
+// @category Fetch
+type FetchRefreshedNotification struct {
+	// Request that was answered from the cache, like "Fetch.Game"
+	Method string `json:"method"`
+	// What was refreshed, like "game" or "profile_collections"
+	TargetType string `json:"targetType"`
+	// ID of what was refreshed
+	TargetID string `json:"targetId"`
+}
+
 // Fetches information for an itch.io game.
 //
 // @name Fetch.Game
@@ -1727,6 +1838,108 @@ type CaveSettings struct {
 	// after a game update), the normal selection behavior applies.
 	// @optional
 	LaunchTarget string `json:"launchTarget,omitempty"`
+
+	// Named launch presets, selectable via LaunchParams.profile
+	// @optional
+	LaunchProfiles []*LaunchProfile `json:"launchProfiles,omitempty"`
+
+	// Commands to run before launching this game and after it exits.
+	// They run after the global hooks passed in LaunchParams.
+	// @optional
+	Hooks *LaunchHooks `json:"hooks,omitempty"`
+
+	// ID of the compat host (see @@CompatHostsListParams) to run this
+	// game with. If set, other compat hosts and automatically detected
+	// wrappers are not considered. If the host no longer exists, all
+	// hosts are considered.
+	// @optional
+	HostID string `json:"hostId,omitempty"`
+}
+
+// Commands run around a game launch, for example to mount save folders,
+// start an overlay, or back up saves.
+//
+// Hooks run with the install folder as working directory, and receive
+// the following environment variables: ITCHIO_HOOK (pre-launch or
+// post-exit), ITCHIO_CAVE_ID, ITCHIO_GAME_ID, ITCHIO_UPLOAD_ID,
+// ITCHIO_BUILD_ID, ITCHIO_INSTALL_FOLDER, and ITCHIO_LAUNCH_PROFILE if a
+// launch profile was selected. Post-exit hooks also receive
+// ITCHIO_LAUNCH_RESULT, set to "ok" or "error".
+type LaunchHooks struct {
+	// Run in order before the game starts
+	// @optional
+	PreLaunch []*LaunchHook `json:"preLaunch,omitempty"`
+
+	// Run in order after the game exits, if all pre-launch hooks
+	// ran (successfully or not)
+	// @optional
+	PostExit []*LaunchHook `json:"postExit,omitempty"`
+}
+
+type LaunchHook struct {
+	// Command line to run, split into arguments like a POSIX shell would,
+	// but not run through a shell.
+	Command string `json:"command"`
+
+	// How long the command may run before it's killed, in seconds.
+	// Defaults to 60.
+	// @optional
+	TimeoutSeconds int64 `json:"timeoutSeconds,omitempty"`
+
+	// What to do if the command fails or times out. Defaults to "warn".
+	// @optional
+	OnFailure LaunchHookFailurePolicy `json:"onFailure,omitempty"`
+}
+
+type LaunchHookFailurePolicy string
+
+const (
+	// Log the failure and carry on
+	LaunchHookFailurePolicyWarn LaunchHookFailurePolicy = "warn"
+	// For pre-launch hooks, don't launch the game. For post-exit
+	// hooks, skip the remaining hooks and make the launch fail.
+	LaunchHookFailurePolicyAbort LaunchHookFailurePolicy = "abort"
+)
+
+var LaunchHookFailurePolicyList = []interface{}{
+	LaunchHookFailurePolicyWarn,
+	LaunchHookFailurePolicyAbort,
+}
+
+// A named set of launch overrides for a cave, for example "Vulkan",
+// "DX11 debug" or "benchmark".
+type LaunchProfile struct {
+	// Unique (per cave) name of the profile
+	Name string `json:"name"`
+
+	// Environment variables passed to the game, in addition to (and
+	// taking precedence over) the ones butler sets. Only applies to
+	// native launches.
+	// @optional
+	Env map[string]string `json:"env,omitempty"`
+
+	// Arguments passed after the manifest action's arguments. Only
+	// applies to native launches.
+	// @optional
+	Args []string `json:"args,omitempty"`
+
+	// Working directory for the game. If relative, it's relative to the
+	// install folder. Only applies to native launches.
+	// @optional
+	WorkingDirectory string `json:"workingDirectory,omitempty"`
+
+	// Overrides the sandbox preference passed in LaunchParams
+	// @optional
+	Sandbox *bool `json:"sandbox,omitempty"`
+
+	// Overrides the sandbox options passed in LaunchParams
+	// @optional
+	SandboxOptions *SandboxOptions `json:"sandboxOptions,omitempty"`
+
+	// Launch target to use, matched like LaunchParams.target. An
+	// explicit LaunchParams.target takes precedence.
+	// @optional
+	Target string `json:"target,omitempty"`
 }
 
 type InstallLocationSummary struct {
@@ -1748,6 +1961,10 @@ type InstallLocationSizeInfo struct {
 	// Total space of this location (depends on the partition/disk on which
 	// it is), or a negative value if we can't find it
 	TotalSize int64 `json:"totalSize"`
+	// Maximum number of bytes caves in this location may use, 0 for no limit
+	QuotaSize int64 `json:"quotaSize"`
+	// Number of bytes installs always leave free on this location's disk
+	ReservedSize int64 `json:"reservedSize"`
 }
 
 // Retrieve info for all caves.
@@ -1945,10 +2162,75 @@ func (p GameFindUploadsParams) Validate() error {
 }
 
 type GameFindUploadsResult struct {
-	// A list of uploads that were found to be compatible.
+	// A list of uploads that were found to be compatible, except those
+	// already installed or being installed.
 	Uploads []*itchio.Upload `json:"uploads"`
+
+	// Why each of the game's uploads was picked or filtered out,
+	// in the order the game lists them
+	// @optional
+	Explanations []*UploadExplanation `json:"explanations,omitempty"`
+
+	// Profile upload rules that applied to compatible uploads,
+	// see @@ProfileUploadRulesSetParams
+	// @optional
+	MatchedRules []*manager.UploadRuleMatch `json:"matchedRules,omitempty"`
+}
+
+// Why an upload was kept or filtered out when narrowing down a game's
+// uploads, and how it ranks among the ones that were kept.
+type UploadExplanation struct {
+	UploadID int64 `json:"uploadId"`
+
+	// Position among compatible uploads, 1 being the one picked by
+	// default. 0 if the upload was filtered out.
+	Rank int64 `json:"rank"`
+
+	// Why the upload was filtered out, empty if it's compatible
+	// @optional
+	ExcludedBecause UploadExclusion `json:"excludedBecause,omitempty"`
+
+	// Human-readable details on why the upload was filtered out
+	// @optional
+	ExcludedDetail string `json:"excludedDetail,omitempty"`
+
+	// Sum of the score components, higher ranks first. Only set for
+	// compatible uploads.
+	Score int64 `json:"score"`
+
+	// @optional
+	ScoreComponents []*UploadScoreComponent `json:"scoreComponents,omitempty"`
+
+	// Hosts of this machine that can run the upload, natively or
+	// through a wrapper like wine
+	// @optional
+	CompatibleHosts []string `json:"compatibleHosts,omitempty"`
+}
+
+type UploadScoreComponent struct {
+	// One of "order", "preferred-format", "source-format",
+	// "launchable", "demo" or "exclusivity", or "rule: " followed
+	// by a description of a user preference rule
+	Name   string `json:"name"`
+	Points int64  `json:"points"`
 }
 
+// @category Install
+type UploadExclusion string
+
+const (
+	// Executable upload not tagged for any platform
+	UploadExclusionUntagged UploadExclusion = "untagged"
+	// Executable upload tagged for platforms none of the hosts run
+	UploadExclusionWrongPlatform UploadExclusion = "wrong-platform"
+	// Package manager format that can't be installed silently
+	UploadExclusionWrongFormat UploadExclusion = "wrong-format"
+	// There's an upload for an architecture that better matches the host
+	UploadExclusionWrongArch UploadExclusion = "wrong-arch"
+	// Already installed, or being installed
+	UploadExclusionInstalled UploadExclusion = "installed"
+)
+
 //----------------------------------------------------------------------
 // Install
 //----------------------------------------------------------------------
@@ -2047,133 +2329,450 @@ type InstallQueueResult struct {
 	InstallLocationID string        `json:"installLocationId"`
 }
 
-// @deprecated Install.Plan can take a long time calculating space requirements and can't be canceled. Use Install.GetUploads to quickly list available uploads, then Install.PlanUpload to calculate extraction details for a specific upload (with cancellation support).
+// Queues installs for several games at once, without asking the client
+// to pick uploads. Each game gets an outcome: games that are already
+// installed, or for which the upload policy can't settle on an upload,
+// are skipped.
 //
-// @name Install.Plan
+// Games come from exactly one of `games`, `collectionId` or `bundleId`.
+//
+// @name Install.QueueMany
 // @category Install
 // @caller client
-type InstallPlanParams struct {
-	GameID int64 `json:"gameId"`
-	// @optional
-	DownloadSessionID string `json:"downloadSessionId"`
+type InstallQueueManyParams struct {
+	// Games to install
 	// @optional
-	UploadID int64 `json:"uploadId"`
+	Games []*itchio.Game `json:"games,omitempty"`
 
-	// Profile to scope bundle ownership materialization to (this endpoint
-	// has install intent, so it may claim a download key for a bundle-owned
-	// game). When zero, falls back to any suitable profile.
+	// Install all games of this collection
 	// @optional
-	ProfileID int64 `json:"profileId,omitempty"`
-}
-
-func (p InstallPlanParams) Validate() error {
-	return validation.ValidateStruct(&p,
-		validation.Field(&p.GameID, validation.Required),
-	)
-}
+	CollectionID int64 `json:"collectionId,omitempty"`
 
-type InstallPlanResult struct {
-	Game    *itchio.Game     `json:"game"`
-	Uploads []*itchio.Upload `json:"uploads"`
-	// Null when the game has no compatible uploads
+	// Install all games of this bundle
 	// @optional
-	Info *InstallPlanInfo `json:"info,omitempty"`
-}
-
-// Returns the list of available uploads for a game, narrowed by platform/format.
-// This is the fast part of install planning (no file I/O).
-//
-// @name Install.GetUploads
-// @category Install
-// @caller client
-type InstallGetUploadsParams struct {
-	GameID int64 `json:"gameId"`
+	BundleID int64 `json:"bundleId,omitempty"`
 
-	// Profile to scope bundle ownership materialization to (this endpoint
-	// has install intent, so it may claim a download key for a bundle-owned
-	// game). When zero, falls back to any suitable profile.
+	// Profile to list collection or bundle games with, and to claim
+	// bundle games against. Required with `collectionId` or `bundleId`.
 	// @optional
 	ProfileID int64 `json:"profileId,omitempty"`
-}
 
-func (p InstallGetUploadsParams) Validate() error {
-	return validation.ValidateStruct(&p,
-		validation.Field(&p.GameID, validation.Required),
-	)
-}
+	// Install location to install all games to
+	InstallLocationID string `json:"installLocationId"`
 
-type InstallGetUploadsResult struct {
-	Game    *itchio.Game     `json:"game"`
-	Uploads []*itchio.Upload `json:"uploads"`
+	// How to pick an upload for each game, defaults to "best-match"
+	// @optional
+	UploadPolicy InstallUploadPolicy `json:"uploadPolicy,omitempty"`
 
-	// Uploads that were filtered out as not compatible with the current
-	// runtime — untagged, or tagged for other platforms. They can still be
-	// installed by explicitly passing them to @@InstallQueueParams.
+	// With the "platform" upload policy, one of "windows", "linux"
+	// or "osx"
 	// @optional
-	IncompatibleUploads []*itchio.Upload `json:"incompatibleUploads,omitempty"`
-}
+	Platform string `json:"platform,omitempty"`
 
-// Returns installer type and disk usage info for a specific upload.
-// This is the slow part of install planning (network I/O + file inspection).
-//
-// @name Install.PlanUpload
-// @category Install
-// @caller client
-type InstallPlanUploadParams struct {
-	// ID for cancellation support. If provided, can be cancelled via Install.Cancel
+	// If true, do not run windows installers, just extract
+	// whatever to the install folder.
 	// @optional
-	ID string `json:"id"`
+	IgnoreInstallers bool `json:"ignoreInstallers,omitempty"`
 
-	UploadID int64 `json:"uploadId"`
+	// If set, queues a download for every install that was queued,
+	// all in one transaction. See @@DownloadsDriveParams.
 	// @optional
-	DownloadSessionID string `json:"downloadSessionId"`
+	QueueDownload bool `json:"queueDownload"`
+
+	// Don't run install prepare (assume we can just run it at perform time)
+	// @optional
+	FastQueue bool `json:"fastQueue"`
 }
 
-func (p InstallPlanUploadParams) Validate() error {
-	return validation.ValidateStruct(&p,
-		validation.Field(&p.UploadID, validation.Required),
+func (p InstallQueueManyParams) Validate() error {
+	err := validation.ValidateStruct(&p,
+		validation.Field(&p.InstallLocationID, validation.Required),
+		validation.Field(&p.UploadPolicy, validation.In(InstallUploadPolicyList...)),
 	)
+	if err != nil {
+		return err
+	}
+
+	sources := 0
+	if len(p.Games) > 0 {
+		sources++
+	}
+	if p.CollectionID != 0 {
+		sources++
+	}
+	if p.BundleID != 0 {
+		sources++
+	}
+	if sources != 1 {
+		return errors.New("exactly one of games, collectionId or bundleId must be set")
+	}
+
+	if p.CollectionID != 0 || p.BundleID != 0 {
+		err = validation.ValidateStruct(&p,
+			validation.Field(&p.ProfileID, validation.Required),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.UploadPolicy == InstallUploadPolicyPlatform {
+		return validation.ValidateStruct(&p,
+			validation.Field(&p.Platform, validation.Required, validation.In("windows", "linux", "osx")),
+		)
+	}
+	return nil
 }
 
-type InstallPlanUploadResult struct {
-	Info *InstallPlanInfo `json:"info"`
+type InstallQueueManyResult struct {
+	// One outcome per game, in the order games were given or listed
+	Outcomes []*InstallQueueManyOutcome `json:"outcomes"`
 }
 
-type InstallPlanInfo struct {
-	Upload *itchio.Upload `json:"upload"`
-	// Null for non-wharf uploads
-	// @optional
-	Build *itchio.Build `json:"build,omitempty"`
-	Type  string        `json:"type"`
-	// Null when planning failed before disk usage could be assessed
-	// (see Error / ErrorCode)
-	// @optional
-	DiskUsage *DiskUsageInfo `json:"diskUsage,omitempty"`
+// @category Install
+type InstallQueueManyOutcome struct {
+	Game *itchio.Game `json:"game"`
 
+	Status InstallQueueManyStatus `json:"status"`
+
+	// Queued install, if status is "queued"
 	// @optional
-	Error string `json:"error,omitempty"`
+	Item *InstallQueueResult `json:"item,omitempty"`
+
+	// Why the game was skipped or failed
 	// @optional
-	ErrorMessage string `json:"errorMessage,omitempty"`
+	Reason string `json:"reason,omitempty"`
+
+	// Error code, if status is "failed"
 	// @optional
 	ErrorCode int64 `json:"errorCode,omitempty"`
 }
 
-type DiskUsageInfo struct {
-	FinalDiskUsage  int64  `json:"finalDiskUsage"`
-	NeededFreeSpace int64  `json:"neededFreeSpace"`
-	Accuracy        string `json:"accuracy"`
-}
+// @category Install
+type InstallQueueManyStatus string
+
+const (
+	InstallQueueManyStatusQueued  InstallQueueManyStatus = "queued"
+	InstallQueueManyStatusSkipped InstallQueueManyStatus = "skipped"
+	InstallQueueManyStatusFailed  InstallQueueManyStatus = "failed"
+)
 
-// @name Caves.GetSettings
 // @category Install
-// @caller client
-type CavesGetSettingsParams struct {
-	// ID of the cave to retrieve settings for.
-	CaveID string `json:"caveId"`
-}
+type InstallUploadPolicy string
 
-func (p CavesGetSettingsParams) Validate() error {
-	return validation.ValidateStruct(&p,
+const (
+	// Pick the best compatible upload, as sorted by @@InstallGetUploadsParams
+	InstallUploadPolicyBestMatch InstallUploadPolicy = "best-match"
+	// Pick the best compatible upload tagged for `platform`
+	InstallUploadPolicyPlatform InstallUploadPolicy = "platform"
+	// Skip games with more than one compatible upload
+	InstallUploadPolicySkipIfAmbiguous InstallUploadPolicy = "skip-if-ambiguous"
+)
+
+var InstallUploadPolicyList = []interface{}{
+	InstallUploadPolicyBestMatch,
+	InstallUploadPolicyPlatform,
+	InstallUploadPolicySkipIfAmbiguous,
+}
+
+// Mirrors a collection or a bundle to an install location: games that
+// aren't installed there yet are installed, caves of games that are no
+// longer part of it are uninstalled, and direct updates are applied.
+//
+// The install location should be dedicated to the collection or bundle,
+// since every other cave in it will be uninstalled.
+//
+// Uploads for new games are picked like in @@InstallQueueManyParams.
+//
+// @name Install.Sync
+// @category Install
+// @caller client
+type InstallSyncParams struct {
+	// Collection to mirror
+	// @optional
+	CollectionID int64 `json:"collectionId,omitempty"`
+
+	// Bundle to mirror
+	// @optional
+	BundleID int64 `json:"bundleId,omitempty"`
+
+	// Profile to list the collection or bundle games with
+	ProfileID int64 `json:"profileId"`
+
+	// Install location to mirror to
+	InstallLocationID string `json:"installLocationId"`
+
+	// How to pick an upload for new games, defaults to "best-match"
+	// @optional
+	UploadPolicy InstallUploadPolicy `json:"uploadPolicy,omitempty"`
+
+	// With the "platform" upload policy, one of "windows", "linux"
+	// or "osx"
+	// @optional
+	Platform string `json:"platform,omitempty"`
+
+	// If true, do not run windows installers, just extract
+	// whatever to the install folder.
+	// @optional
+	IgnoreInstallers bool `json:"ignoreInstallers,omitempty"`
+
+	// If set, only returns the actions that would be taken
+	// @optional
+	DryRun bool `json:"dryRun"`
+
+	// If set, installed games are uninstalled even if the collection
+	// or bundle lists no games at all. Otherwise, that's taken for
+	// a listing problem and the sync fails.
+	// @optional
+	AllowEmpty bool `json:"allowEmpty"`
+
+	// If set, queues a download for every install and update,
+	// all in one transaction. Otherwise they're returned for the
+	// client to perform with @@InstallPerformParams.
+	// @optional
+	QueueDownload bool `json:"queueDownload"`
+}
+
+func (p InstallSyncParams) Validate() error {
+	err := validation.ValidateStruct(&p,
+		validation.Field(&p.ProfileID, validation.Required),
+		validation.Field(&p.InstallLocationID, validation.Required),
+		validation.Field(&p.UploadPolicy, validation.In(InstallUploadPolicyList...)),
+	)
+	if err != nil {
+		return err
+	}
+
+	if (p.CollectionID == 0) == (p.BundleID == 0) {
+		return errors.New("exactly one of collectionId or bundleId must be set")
+	}
+
+	if p.UploadPolicy == InstallUploadPolicyPlatform {
+		return validation.ValidateStruct(&p,
+			validation.Field(&p.Platform, validation.Required, validation.In("windows", "linux", "osx")),
+		)
+	}
+	return nil
+}
+
+type InstallSyncResult struct {
+	// Everything that was (or, for a dry run, would be) done
+	Actions []*InstallSyncAction `json:"actions"`
+}
+
+// @category Install
+type InstallSyncAction struct {
+	Type InstallSyncActionType `json:"type"`
+
+	Game *itchio.Game `json:"game"`
+
+	// Cave to uninstall or update
+	// @optional
+	CaveID string `json:"caveId,omitempty"`
+
+	// Upload to install or update to
+	// @optional
+	Upload *itchio.Upload `json:"upload,omitempty"`
+
+	// Build to install or update to, null for non-wharf uploads
+	// @optional
+	Build *itchio.Build `json:"build,omitempty"`
+
+	Status InstallSyncActionStatus `json:"status"`
+
+	// Queued install or update, if status is "queued"
+	// @optional
+	Item *InstallQueueResult `json:"item,omitempty"`
+
+	// Why the action was skipped or failed
+	// @optional
+	Reason string `json:"reason,omitempty"`
+
+	// Error code, if status is "failed"
+	// @optional
+	ErrorCode int64 `json:"errorCode,omitempty"`
+}
+
+// @category Install
+type InstallSyncActionType string
+
+const (
+	InstallSyncActionInstall   InstallSyncActionType = "install"
+	InstallSyncActionUninstall InstallSyncActionType = "uninstall"
+	InstallSyncActionUpdate    InstallSyncActionType = "update"
+)
+
+// @category Install
+type InstallSyncActionStatus string
+
+const (
+	// Would be done, for a dry run
+	InstallSyncActionStatusPlanned InstallSyncActionStatus = "planned"
+	// Install or update queued
+	InstallSyncActionStatusQueued InstallSyncActionStatus = "queued"
+	// Uninstall done
+	InstallSyncActionStatusDone    InstallSyncActionStatus = "done"
+	InstallSyncActionStatusSkipped InstallSyncActionStatus = "skipped"
+	InstallSyncActionStatusFailed  InstallSyncActionStatus = "failed"
+)
+
+// @deprecated Install.Plan can take a long time calculating space requirements and can't be canceled. Use Install.GetUploads to quickly list available uploads, then Install.PlanUpload to calculate extraction details for a specific upload (with cancellation support).
+//
+// @name Install.Plan
+// @category Install
+// @caller client
+type InstallPlanParams struct {
+	GameID int64 `json:"gameId"`
+	// @optional
+	DownloadSessionID string `json:"downloadSessionId"`
+	// @optional
+	UploadID int64 `json:"uploadId"`
+
+	// Profile to scope bundle ownership materialization to (this endpoint
+	// has install intent, so it may claim a download key for a bundle-owned
+	// game). When zero, falls back to any suitable profile.
+	// @optional
+	ProfileID int64 `json:"profileId,omitempty"`
+
+	// If set, the plan's info errors out with @@CodeInsufficientDiskSpace
+	// when the upload doesn't fit in that install location
+	// @optional
+	InstallLocationID string `json:"installLocationId,omitempty"`
+
+	// If set, the upload would replace that cave, so the space it
+	// currently uses doesn't count against the install location's quota
+	// @optional
+	CaveID string `json:"caveId,omitempty"`
+}
+
+func (p InstallPlanParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.GameID, validation.Required),
+	)
+}
+
+type InstallPlanResult struct {
+	Game    *itchio.Game     `json:"game"`
+	Uploads []*itchio.Upload `json:"uploads"`
+	// Null when the game has no compatible uploads
+	// @optional
+	Info *InstallPlanInfo `json:"info,omitempty"`
+
+	// Why each of the game's uploads was picked or filtered out,
+	// in the order the game lists them
+	// @optional
+	Explanations []*UploadExplanation `json:"explanations,omitempty"`
+}
+
+// Returns the list of available uploads for a game, narrowed by platform/format.
+// This is the fast part of install planning (no file I/O).
+//
+// @name Install.GetUploads
+// @category Install
+// @caller client
+type InstallGetUploadsParams struct {
+	GameID int64 `json:"gameId"`
+
+	// Profile to scope bundle ownership materialization to (this endpoint
+	// has install intent, so it may claim a download key for a bundle-owned
+	// game). When zero, falls back to any suitable profile.
+	// @optional
+	ProfileID int64 `json:"profileId,omitempty"`
+}
+
+func (p InstallGetUploadsParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.GameID, validation.Required),
+	)
+}
+
+type InstallGetUploadsResult struct {
+	Game    *itchio.Game     `json:"game"`
+	Uploads []*itchio.Upload `json:"uploads"`
+
+	// Uploads that were filtered out as not compatible with the current
+	// runtime — untagged, or tagged for other platforms. They can still be
+	// installed by explicitly passing them to @@InstallQueueParams.
+	// @optional
+	IncompatibleUploads []*itchio.Upload `json:"incompatibleUploads,omitempty"`
+
+	// Why each of the game's uploads was picked or filtered out,
+	// in the order the game lists them
+	// @optional
+	Explanations []*UploadExplanation `json:"explanations,omitempty"`
+}
+
+// Returns installer type and disk usage info for a specific upload.
+// This is the slow part of install planning (network I/O + file inspection).
+//
+// @name Install.PlanUpload
+// @category Install
+// @caller client
+type InstallPlanUploadParams struct {
+	// ID for cancellation support. If provided, can be cancelled via Install.Cancel
+	// @optional
+	ID string `json:"id"`
+
+	UploadID int64 `json:"uploadId"`
+	// @optional
+	DownloadSessionID string `json:"downloadSessionId"`
+
+	// If set, the plan's info errors out with @@CodeInsufficientDiskSpace
+	// when the upload doesn't fit in that install location
+	// @optional
+	InstallLocationID string `json:"installLocationId,omitempty"`
+
+	// If set, the upload would replace that cave, so the space it
+	// currently uses doesn't count against the install location's quota
+	// @optional
+	CaveID string `json:"caveId,omitempty"`
+}
+
+func (p InstallPlanUploadParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.UploadID, validation.Required),
+	)
+}
+
+type InstallPlanUploadResult struct {
+	Info *InstallPlanInfo `json:"info"`
+}
+
+type InstallPlanInfo struct {
+	Upload *itchio.Upload `json:"upload"`
+	// Null for non-wharf uploads
+	// @optional
+	Build *itchio.Build `json:"build,omitempty"`
+	Type  string        `json:"type"`
+	// Null when planning failed before disk usage could be assessed
+	// (see Error / ErrorCode)
+	// @optional
+	DiskUsage *DiskUsageInfo `json:"diskUsage,omitempty"`
+
+	// @optional
+	Error string `json:"error,omitempty"`
+	// @optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+	// @optional
+	ErrorCode int64 `json:"errorCode,omitempty"`
+}
+
+type DiskUsageInfo struct {
+	FinalDiskUsage  int64  `json:"finalDiskUsage"`
+	NeededFreeSpace int64  `json:"neededFreeSpace"`
+	Accuracy        string `json:"accuracy"`
+}
+
+// @name Caves.GetSettings
+// @category Install
+// @caller client
+type CavesGetSettingsParams struct {
+	// ID of the cave to retrieve settings for.
+	CaveID string `json:"caveId"`
+}
+
+func (p CavesGetSettingsParams) Validate() error {
+	return validation.ValidateStruct(&p,
 		validation.Field(&p.CaveID, validation.Required),
 	)
 }
@@ -2226,13 +2825,114 @@ func (p CavesSetPinnedParams) Validate() error {
 
 type CavesSetPinnedResult struct{}
 
+// Deletes the cave's isolated wine prefix, along with the prereqs
+// installed in it. A fresh one is created on next launch. Waits for
+// the game to exit if it's running.
+//
+// @name Caves.ResetCompatPrefix
+// @category Install
+// @caller client
+type CavesResetCompatPrefixParams struct {
+	// ID of the cave whose prefix should be reset
+	CaveID string `json:"caveId"`
+}
+
+func (p CavesResetCompatPrefixParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.CaveID, validation.Required),
+	)
+}
+
+type CavesResetCompatPrefixResult struct {
+	// False if the cave had no prefix to reset
+	Reset bool `json:"reset"`
+}
+
+// Tells whether a cave's install folder is locked by a launch, an
+// install, or another operation, and by whom.
+//
+// @name Caves.GetLockStatus
+// @category Install
+// @caller client
+type CavesGetLockStatusParams struct {
+	CaveID string `json:"caveId"`
+}
+
+func (p CavesGetLockStatusParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.CaveID, validation.Required),
+	)
+}
+
+type CavesGetLockStatusResult struct {
+	// Set if the cave's install folder is locked
+	// @optional
+	Lock *CaveLock `json:"lock,omitempty"`
+}
+
+// @category Install
+type CaveLock struct {
+	// What the lock was taken for, like "install" or "launch"
+	Task string `json:"task"`
+
+	// When the lock was taken
+	// @optional
+	LockedAt *time.Time `json:"lockedAt,omitempty"`
+
+	// PID of the butler process that took the lock
+	ButlerPID int64 `json:"butlerPid"`
+
+	// Name of the machine the lock was taken from
+	// @optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// True if the lock was taken from another machine, sharing the
+	// install location over the network. Its owner can't be checked.
+	Remote bool `json:"remote"`
+
+	// True if the process that took the lock isn't running anymore.
+	// Stale locks are broken automatically by the next operation.
+	Stale bool `json:"stale"`
+}
+
+// Removes a cave's install folder lock, whoever holds it. Meant to
+// recover from remote locks whose owner crashed: forcing a lock that's
+// held by a running operation may corrupt the install.
+//
+// @name Caves.ForceUnlock
+// @category Install
+// @caller client
+type CavesForceUnlockParams struct {
+	CaveID string `json:"caveId"`
+}
+
+func (p CavesForceUnlockParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.CaveID, validation.Required),
+	)
+}
+
+type CavesForceUnlockResult struct {
+	// False if the cave wasn't locked
+	Unlocked bool `json:"unlocked"`
+}
+
 // Create a shortcut for an existing cave .
 //
+// On Linux, this writes a desktop entry so the game shows up in
+// application menus, with the game's cover as its icon. Shortcuts are
+// refreshed when the cave is reinstalled or upgraded, and removed
+// when it's uninstalled.
+//
 // @name Install.CreateShortcut
 // @category Install
 // @caller client
 type InstallCreateShortcutParams struct {
 	CaveID string `json:"caveId"`
+
+	// Also place the shortcut on the desktop. Only supported on Linux.
+	// @optional
+	Desktop bool `json:"desktop"`
 }
 
 func (p InstallCreateShortcutParams) Validate() error {
@@ -2526,6 +3226,38 @@ type InstallLocationsAddResult struct {
 	InstallLocation *InstallLocationSummary `json:"installLocation,omitempty"`
 }
 
+// Limits how much disk space installs to a location may use. Installs
+// and downloads that would go over them fail, or pause in the case of
+// @@DownloadsDriveParams, with @@CodeInsufficientDiskSpace.
+//
+// @name Install.Locations.SetSpaceLimits
+// @category Install
+// @caller client
+type InstallLocationsSetSpaceLimitsParams struct {
+	ID string `json:"id"`
+
+	// Maximum number of bytes caves in this location may use, 0 for no limit
+	// @optional
+	QuotaSize int64 `json:"quotaSize"`
+
+	// Number of bytes installs must leave free on this location's disk,
+	// 0 to only stop them from filling it up
+	// @optional
+	ReservedSize int64 `json:"reservedSize"`
+}
+
+func (p InstallLocationsSetSpaceLimitsParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.ID, validation.Required),
+		validation.Field(&p.QuotaSize, validation.Min(int64(0))),
+		validation.Field(&p.ReservedSize, validation.Min(int64(0))),
+	)
+}
+
+type InstallLocationsSetSpaceLimitsResult struct {
+	InstallLocation *InstallLocationSummary `json:"installLocation"`
+}
+
 // @name Install.Locations.Remove
 // @category Install
 // @caller client
@@ -2567,45 +3299,325 @@ type InstallLocationsGetByIDResult struct {
 type InstallLocationsScanParams struct {
 	// path to a legacy marketDB
 	// @optional
-	LegacyMarketPath string `json:"legacyMarketPath"`
+	LegacyMarketPath string `json:"legacyMarketPath"`
+}
+
+func (p InstallLocationsScanParams) Validate() error {
+	return nil
+}
+
+// Sent during @@InstallLocationsScanParams whenever
+// a game is found.
+//
+// @name Install.Locations.Scan.Yield
+// @category Install
+type InstallLocationsScanYieldNotification struct {
+	Game *itchio.Game `json:"game"`
+}
+
+// Sent at the end of @@InstallLocationsScanParams
+//
+// @name Install.Locations.Scan.ConfirmImport
+// @category Install
+// @caller server
+type InstallLocationsScanConfirmImportParams struct {
+	// number of items that will be imported
+	NumItems int64 `json:"numItems"`
+}
+
+func (p InstallLocationsScanConfirmImportParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.NumItems, validation.Required),
+	)
+}
+
+type InstallLocationsScanConfirmImportResult struct {
+	Confirm bool `json:"confirm"`
+}
+
+type InstallLocationsScanResult struct {
+	NumFoundItems    int64 `json:"numFoundItems"`
+	NumImportedItems int64 `json:"numImportedItems"`
+}
+
+// Moves caves to another install location, for example when a drive
+// fills up. Folders are renamed when both locations are on the same
+// drive, otherwise they're copied, verified against the install's
+// receipt, then removed from the old location.
+//
+// Caves are moved one at a time, waiting for their game to exit if it's
+// running. Sends @@ProgressNotification.
+//
+// Can be cancelled by passing the same `ID` to @@InstallCancelParams:
+// the cave being moved stays in its old location, those that were
+// already moved stay in the new one.
+//
+// @name Install.Locations.MoveCaves
+// @category Install
+// @tags Cancellable
+// @caller client
+type InstallLocationsMoveCavesParams struct {
+	// ID that can be later used in @@InstallCancelParams
+	ID string `json:"id"`
+
+	// IDs of the caves to move
+	CaveIDs []string `json:"caveIds"`
+
+	// ID of the install location to move them to
+	InstallLocationID string `json:"installLocationId"`
+}
+
+func (p InstallLocationsMoveCavesParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.ID, validation.Required),
+		validation.Field(&p.CaveIDs, validation.Required),
+		validation.Field(&p.InstallLocationID, validation.Required),
+	)
+}
+
+// Sent during @@InstallLocationsMoveCavesParams whenever a cave
+// has been moved.
+//
+// @name Install.Locations.MoveCaves.Yield
+// @category Install
+type InstallLocationsMoveCavesYieldNotification struct {
+	CaveID string `json:"caveId"`
+}
+
+type InstallLocationsMoveCavesResult struct {
+	// IDs of the caves that were moved. Caves that were already in
+	// the install location aren't included.
+	MovedCaveIDs []string `json:"movedCaveIds"`
+}
+
+// Checks install locations for folders no cave or download refers to
+// (orphans), caves whose folder is gone (missing), and caves whose
+// folder doesn't match their receipt anymore (drifted). Caves installed
+// in custom folders are checked when auditing all install locations.
+//
+// Nothing is changed: fixes are applied with
+// @@InstallLocationsApplyAuditParams.
+//
+// @name Install.Locations.Audit
+// @category Install
+// @caller client
+type InstallLocationsAuditParams struct {
+	// IDs of the install locations to audit, all of them if empty
+	// @optional
+	InstallLocationIDs []string `json:"installLocationIds"`
+}
+
+func (p InstallLocationsAuditParams) Validate() error {
+	return nil
+}
+
+type InstallLocationsAuditResult struct {
+	Entries []*InstallAuditEntry `json:"entries"`
+
+	// Bytes used by orphans
+	WastedSize int64 `json:"wastedSize"`
+}
+
+// @category Install
+type InstallAuditEntry struct {
+	Kind   InstallAuditKind   `json:"kind"`
+	Status InstallAuditStatus `json:"status"`
+
+	// @optional
+	InstallLocationID string `json:"installLocationId,omitempty"`
+	// Absolute path of the folder
+	Path string `json:"path"`
+	// Cave the folder belongs to, if any
+	// @optional
+	CaveID string `json:"caveId,omitempty"`
+	// Game the folder holds, if known
+	// @optional
+	GameID int64 `json:"gameId,omitempty"`
+
+	// Bytes used on disk
+	Size int64 `json:"size"`
+
+	// Whether the folder has a receipt, for install folders
+	HasReceipt bool `json:"hasReceipt"`
+	// Hash the install source matched, as in "sha256:abcd", for caves
+	// installed from a verified source
+	// @optional
+	VerifiedSourceHash string `json:"verifiedSourceHash,omitempty"`
+
+	// For drifted caves: files that aren't in the receipt. At most
+	// 20 are listed.
+	// @optional
+	NumAddedFiles int64 `json:"numAddedFiles,omitempty"`
+	// @optional
+	AddedSize int64 `json:"addedSize,omitempty"`
+	// @optional
+	AddedFiles []string `json:"addedFiles,omitempty"`
+	// For drifted caves: files in the receipt that are gone. At most
+	// 20 are listed.
+	// @optional
+	NumRemovedFiles int64 `json:"numRemovedFiles,omitempty"`
+	// @optional
+	RemovedFiles []string `json:"removedFiles,omitempty"`
+
+	// Fixes that can be applied
+	// @optional
+	Actions []InstallAuditAction `json:"actions,omitempty"`
+}
+
+type InstallAuditKind string
+
+const (
+	InstallAuditKindInstallFolder InstallAuditKind = "install-folder"
+	InstallAuditKindStagingFolder InstallAuditKind = "staging-folder"
+	InstallAuditKindCompatPrefix  InstallAuditKind = "compat-prefix"
+)
+
+type InstallAuditStatus string
+
+const (
+	InstallAuditStatusHealthy InstallAuditStatus = "healthy"
+	InstallAuditStatusDrifted InstallAuditStatus = "drifted"
+	InstallAuditStatusMissing InstallAuditStatus = "missing"
+	InstallAuditStatusOrphan  InstallAuditStatus = "orphan"
+)
+
+type InstallAuditAction string
+
+const (
+	// Remove the folder
+	InstallAuditActionWipe InstallAuditAction = "wipe"
+	// Create a cave from the receipt found in the folder
+	InstallAuditActionRegister InstallAuditAction = "register"
+	// Delete a cave whose folder is gone
+	InstallAuditActionForget InstallAuditAction = "forget"
+)
+
+// Applies fixes offered by @@InstallLocationsAuditParams. Install
+// locations are audited again first, and fixes that don't apply anymore
+// fail instead of being applied.
+//
+// @name Install.Locations.ApplyAudit
+// @category Install
+// @caller client
+type InstallLocationsApplyAuditParams struct {
+	Fixes []*InstallAuditFix `json:"fixes"`
+}
+
+func (p InstallLocationsApplyAuditParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Fixes, validation.Required),
+	)
+}
+
+// @category Install
+type InstallAuditFix struct {
+	Action InstallAuditAction `json:"action"`
+	// Path of the audit entry to fix
+	Path string `json:"path"`
+}
+
+type InstallLocationsApplyAuditResult struct {
+	Outcomes []*InstallAuditFixOutcome `json:"outcomes"`
+}
+
+// @category Install
+type InstallAuditFixOutcome struct {
+	Fix *InstallAuditFix `json:"fix"`
+	// Set if the fix couldn't be applied
+	// @optional
+	Error string `json:"error,omitempty"`
+	// Set when a folder was registered as a new cave
+	// @optional
+	CaveID string `json:"caveId,omitempty"`
+}
+
+//----------------------------------------------------------------------
+// Library
+//----------------------------------------------------------------------
+
+// Exports the library to a file, to move it to another machine: caves
+// with their settings, pinned state and play time, install locations,
+// compat hosts and pending downloads. Games themselves aren't included,
+// their install locations have to be copied separately.
+//
+// @name Library.Export
+// @category Library
+// @caller client
+type LibraryExportParams struct {
+	// Path of the file to write
+	Path string `json:"path"`
+
+	// Also export logged-in profiles, with their API keys, so there's
+	// no need to log in again. The file must then be kept private.
+	// @optional
+	IncludeCredentials bool `json:"includeCredentials"`
 }
 
-func (p InstallLocationsScanParams) Validate() error {
-	return nil
+func (p LibraryExportParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Path, validation.Required),
+	)
 }
 
-// Sent during @@InstallLocationsScanParams whenever
-// a game is found.
-//
-// @name Install.Locations.Scan.Yield
-// @category Install
-type InstallLocationsScanYieldNotification struct {
-	Game *itchio.Game `json:"game"`
+type LibraryExportResult struct {
+	NumCaves            int64 `json:"numCaves"`
+	NumInstallLocations int64 `json:"numInstallLocations"`
+	NumDownloads        int64 `json:"numDownloads"`
 }
 
-// Sent at the end of @@InstallLocationsScanParams
+// Imports a library written by @@LibraryExportParams. Caves are only
+// imported if their install folder is there and matches its receipt.
+// Caves that are already known, for example because their install
+// location was scanned, get their settings and play time from the file.
 //
-// @name Install.Locations.Scan.ConfirmImport
-// @category Install
-// @caller server
-type InstallLocationsScanConfirmImportParams struct {
-	// number of items that will be imported
-	NumItems int64 `json:"numItems"`
+// @name Library.Import
+// @category Library
+// @caller client
+type LibraryImportParams struct {
+	// Path of the file to read
+	Path string `json:"path"`
+
+	// Path prefixes to replace, for install locations that aren't at
+	// the same place on this machine. The longest matching one wins.
+	// @optional
+	PathRemaps []*LibraryPathRemap `json:"pathRemaps"`
 }
 
-func (p InstallLocationsScanConfirmImportParams) Validate() error {
+func (p LibraryImportParams) Validate() error {
 	return validation.ValidateStruct(&p,
-		validation.Field(&p.NumItems, validation.Required),
+		validation.Field(&p.Path, validation.Required),
 	)
 }
 
-type InstallLocationsScanConfirmImportResult struct {
-	Confirm bool `json:"confirm"`
+// @category Library
+type LibraryPathRemap struct {
+	// Prefix as it was on the exporting machine, like "D:\Games"
+	From string `json:"from"`
+	// Prefix on this machine, like "/mnt/games"
+	To string `json:"to"`
 }
 
-type InstallLocationsScanResult struct {
-	NumFoundItems    int64 `json:"numFoundItems"`
-	NumImportedItems int64 `json:"numImportedItems"`
+type LibraryImportResult struct {
+	ImportedInstallLocations int64 `json:"importedInstallLocations"`
+	ImportedProfiles         int64 `json:"importedProfiles"`
+	ImportedCaves            int64 `json:"importedCaves"`
+	// Caves that were already known, and got their settings and
+	// play time from the file
+	MergedCaves       int64 `json:"mergedCaves"`
+	ImportedDownloads int64 `json:"importedDownloads"`
+
+	// Things that weren't imported, and why
+	Skipped []*LibraryImportSkip `json:"skipped"`
+}
+
+// @category Library
+type LibraryImportSkip struct {
+	// "installLocation", "cave" or "download"
+	Kind string `json:"kind"`
+	// ID of the skipped record
+	ID string `json:"id"`
+	// Human-readable reason
+	Reason string `json:"reason"`
 }
 
 //----------------------------------------------------------------------
@@ -2749,6 +3761,33 @@ type DownloadsDriveNetworkStatusNotification struct {
 	Status NetworkStatus `json:"status"`
 }
 
+// Sent during @@DownloadsDriveParams when a download doesn't fit in
+// its install location. The drive waits for space to be freed, or the
+// install location's limits to be raised, then resumes it.
+//
+// @name Downloads.Drive.Paused
+type DownloadsDrivePausedNotification struct {
+	Download *Download `json:"download"`
+
+	// Why the download was paused
+	Reason DownloadPauseReason `json:"reason"`
+
+	// Bytes the download needs
+	NeededSize int64 `json:"neededSize"`
+
+	// Bytes it may use
+	AvailableSize int64 `json:"availableSize"`
+}
+
+type DownloadPauseReason string
+
+const (
+	// The install location's quota would be exceeded
+	DownloadPauseReasonQuota DownloadPauseReason = "quota"
+	// The install location's disk is too full
+	DownloadPauseReasonFreeSpace DownloadPauseReason = "free-space"
+)
+
 type NetworkStatus string
 
 const (
@@ -3014,6 +4053,32 @@ type LaunchParams struct {
 	// Butler resolves any suitable profile (legacy behavior).
 	// @optional
 	ProfileID int64 `json:"profileId,omitempty"`
+
+	// Name of one of the cave's launch profiles to apply, see
+	// CaveSettings.launchProfiles. If it doesn't exist, the launch fails
+	// with CodeLaunchProfileNotFound.
+	// @optional
+	Profile string `json:"profile,omitempty"`
+
+	// Global hook commands, run before the cave's own hooks
+	// (see CaveSettings.hooks)
+	// @optional
+	Hooks *LaunchHooks `json:"hooks,omitempty"`
+
+	// If set, butlerd serves HTML5 games itself on a loopback port, and
+	// passes the resulting address in HTMLLaunchParams.url
+	// @optional
+	HTMLServer *HTMLServerOptions `json:"htmlServer,omitempty"`
+}
+
+// Options for the HTTP server butlerd runs for HTML5 game launches
+type HTMLServerOptions struct {
+	// Send Cross-Origin-Opener-Policy and Cross-Origin-Embedder-Policy
+	// headers, so that the game runs cross-origin isolated and can use
+	// SharedArrayBuffer (needed for threaded wasm builds). May break
+	// games that load resources from other origins.
+	// @optional
+	CrossOriginIsolated bool `json:"crossOriginIsolated,omitempty"`
 }
 
 type SandboxType string
@@ -3063,6 +4128,42 @@ func validateCaveSettings(settings *CaveSettings) error {
 		return fmt.Errorf("settings.commandTemplate: %w", err)
 	}
 
+	if err := ValidateLaunchHooks(settings.Hooks); err != nil {
+		return fmt.Errorf("settings.hooks: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for i, profile := range settings.LaunchProfiles {
+		if err := validateLaunchProfile(profile); err != nil {
+			return fmt.Errorf("settings.launchProfiles[%d]: %w", i, err)
+		}
+		if names[profile.Name] {
+			return fmt.Errorf("settings.launchProfiles[%d]: duplicate name %q", i, profile.Name)
+		}
+		names[profile.Name] = true
+	}
+
+	return nil
+}
+
+func validateLaunchProfile(profile *LaunchProfile) error {
+	if profile == nil {
+		return errors.New("must not be null")
+	}
+	if profile.Name == "" {
+		return errors.New("name: cannot be blank")
+	}
+	for name := range profile.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("env: invalid variable name %q", name)
+		}
+	}
+	if profile.SandboxOptions != nil && profile.SandboxOptions.Type != "" {
+		err := validation.Validate(profile.SandboxOptions.Type, validation.In(SandboxTypeList...))
+		if err != nil {
+			return fmt.Errorf("sandboxOptions.type: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -3083,6 +4184,9 @@ func (p LaunchParams) Validate() error {
 	if err := ValidateCommandTemplate(p.CommandTemplate); err != nil {
 		return fmt.Errorf("commandTemplate: %w", err)
 	}
+	if err := ValidateLaunchHooks(p.Hooks); err != nil {
+		return fmt.Errorf("hooks: %w", err)
+	}
 	return nil
 }
 
@@ -3100,6 +4204,253 @@ type LaunchRunningNotification struct{}
 // @category Launch
 type LaunchExitedNotification struct{}
 
+// Sent during @@LaunchParams, when the game has exited abnormally (non-zero
+// exit code, or killed by a signal). The crash has been recorded and can be
+// retrieved later with @@LaunchGetCrashParams, for example to offer the
+// user to report a problem.
+//
+// @category Launch
+type LaunchCrashedNotification struct {
+	Crash *CrashSummary `json:"crash"`
+}
+
+// Lists recorded crashes, most recent first.
+//
+// @name Launch.ListCrashes
+// @category Launch
+// @caller client
+type LaunchListCrashesParams struct {
+	// Only list crashes for this cave
+	// @optional
+	CaveID string `json:"caveId,omitempty"`
+
+	// Only list crashes for this game
+	// @optional
+	GameID int64 `json:"gameId,omitempty"`
+}
+
+func (p LaunchListCrashesParams) Validate() error {
+	return nil
+}
+
+type LaunchListCrashesResult struct {
+	Crashes []*CrashSummary `json:"crashes"`
+}
+
+// Retrieves a recorded crash, including captured output.
+//
+// @name Launch.GetCrash
+// @category Launch
+// @caller client
+type LaunchGetCrashParams struct {
+	CrashID string `json:"crashId"`
+}
+
+func (p LaunchGetCrashParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.CrashID, validation.Required),
+	)
+}
+
+type LaunchGetCrashResult struct {
+	Crash *CrashReport `json:"crash"`
+}
+
+// Brief information about a recorded crash
+type CrashSummary struct {
+	// An UUID
+	ID string `json:"id"`
+
+	CaveID   string `json:"caveId"`
+	GameID   int64  `json:"gameId"`
+	UploadID int64  `json:"uploadId"`
+	// @optional
+	BuildID int64 `json:"buildId,omitempty"`
+
+	// The game that crashed, if it's in the local database
+	// @optional
+	Game *itchio.Game `json:"game,omitempty"`
+
+	CrashedAt *time.Time `json:"crashedAt"`
+	// How long the game ran before crashing, in seconds
+	SecondsRun int64 `json:"secondsRun"`
+
+	// Exit code of the game process
+	ExitCode int64 `json:"exitCode"`
+	// Name of the signal that terminated the game, if any
+	// @optional
+	Signal string `json:"signal,omitempty"`
+}
+
+// Everything recorded about a crash
+type CrashReport struct {
+	Summary *CrashSummary `json:"summary"`
+
+	// Last few kilobytes of standard output
+	Stdout string `json:"stdout"`
+	// Last few kilobytes of standard error
+	Stderr string `json:"stderr"`
+
+	LaunchInfo  *CrashLaunchInfo  `json:"launchInfo"`
+	Environment *CrashEnvironment `json:"environment"`
+}
+
+// How the game was launched when it crashed
+type CrashLaunchInfo struct {
+	Strategy LaunchStrategy `json:"strategy"`
+	// Name of the manifest action, if any
+	// @optional
+	ActionName string `json:"actionName,omitempty"`
+	// Name of the launch profile, if any
+	// @optional
+	LaunchProfile string `json:"launchProfile,omitempty"`
+
+	// Path of the executable, after wrappers and command templates
+	// were applied
+	TargetPath string   `json:"targetPath"`
+	Args       []string `json:"args"`
+	// @optional
+	WorkingDirectory string `json:"workingDirectory,omitempty"`
+
+	Sandbox bool `json:"sandbox"`
+	// Name of the sandbox runner, if any
+	// @optional
+	SandboxType string `json:"sandboxType,omitempty"`
+}
+
+// The environment the game ran in when it crashed
+type CrashEnvironment struct {
+	OS            string `json:"os"`
+	Arch          string `json:"arch"`
+	ButlerVersion string `json:"butlerVersion"`
+
+	// Names of the environment variables set by butler for the game.
+	// Values are not recorded, since they may contain credentials.
+	EnvKeys []string `json:"envKeys"`
+}
+
+// Lists user-defined compat hosts, most preferred first.
+//
+// @name CompatHosts.List
+// @category Compat Hosts
+// @caller client
+type CompatHostsListParams struct {
+}
+
+func (p CompatHostsListParams) Validate() error {
+	return nil
+}
+
+type CompatHostsListResult struct {
+	CompatHosts []*CompatHost `json:"compatHosts"`
+}
+
+// Creates or updates a compat host.
+//
+// @name CompatHosts.Save
+// @category Compat Hosts
+// @caller client
+type CompatHostsSaveParams struct {
+	// If its ID is empty, a new compat host is created
+	CompatHost *CompatHost `json:"compatHost"`
+}
+
+func (p CompatHostsSaveParams) Validate() error {
+	err := validation.ValidateStruct(&p,
+		validation.Field(&p.CompatHost, validation.Required),
+	)
+	if err != nil {
+		return err
+	}
+	if err := validateCompatHost(p.CompatHost); err != nil {
+		return fmt.Errorf("compatHost.%w", err)
+	}
+	return nil
+}
+
+type CompatHostsSaveResult struct {
+	CompatHost *CompatHost `json:"compatHost"`
+}
+
+// Removes a compat host. Caves that were set to use it go back
+// to considering all hosts.
+//
+// @name CompatHosts.Remove
+// @category Compat Hosts
+// @caller client
+type CompatHostsRemoveParams struct {
+	ID string `json:"id"`
+}
+
+func (p CompatHostsRemoveParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.ID, validation.Required),
+	)
+}
+
+type CompatHostsRemoveResult struct {
+}
+
+// A user-defined compatibility layer, like a wine prefix, a Proton
+// build or DOSBox, that runs games made for another platform.
+// Compat hosts are considered after the native host, and before
+// automatically detected ones.
+//
+// @category Compat Hosts
+type CompatHost struct {
+	// An UUID
+	// @optional
+	ID string `json:"id"`
+
+	// Human-readable name, like "Proton 9"
+	Name string `json:"name"`
+
+	// Platform of the games it runs: "windows", "linux" or "osx"
+	Platform string `json:"platform"`
+
+	// True if it can run 64-bit games
+	// @optional
+	Is64 bool `json:"is64,omitempty"`
+
+	// How to invoke it. The wrapper binary is looked up in PATH
+	// if it's not an absolute path. Hosts whose binary can't be
+	// found are skipped.
+	Wrapper *manager.Wrapper `json:"wrapper"`
+
+	// Hosts with a lower position are preferred
+	// @optional
+	Position int64 `json:"position,omitempty"`
+}
+
+// Accepted values for CompatHost.platform
+var CompatHostPlatformList = []interface{}{
+	"windows",
+	"linux",
+	"osx",
+}
+
+func validateCompatHost(ch *CompatHost) error {
+	if ch.Name == "" {
+		return errors.New("name: cannot be blank")
+	}
+	err := validation.Validate(ch.Platform, validation.Required, validation.In(CompatHostPlatformList...))
+	if err != nil {
+		return fmt.Errorf("platform: %w", err)
+	}
+	if ch.Wrapper == nil {
+		return errors.New("wrapper: cannot be blank")
+	}
+	if ch.Wrapper.WrapperBinary == "" {
+		return errors.New("wrapper.wrapperBinary: cannot be blank")
+	}
+	for name := range ch.Wrapper.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("wrapper.env: invalid variable name %q", name)
+		}
+	}
+	return nil
+}
+
 // Sent during @@LaunchParams if the game/application comes with a service license
 // agreement.
 //
@@ -3183,6 +4534,12 @@ type HTMLLaunchParams struct {
 	Args []string `json:"args"`
 	// Environment variables, to pass as `global.Itch.env`
 	Env map[string]string `json:"env"`
+
+	// Address of the index file, served by butlerd on a loopback port,
+	// if LaunchParams.htmlServer was set. The server only runs until
+	// this request returns.
+	// @optional
+	URL string `json:"url,omitempty"`
 }
 
 func (p HTMLLaunchParams) Validate() error {
@@ -3324,6 +4681,66 @@ type PrereqsFailedResult struct {
 	Continue bool `json:"continue"`
 }
 
+// Sent during @@LaunchParams, before launching a native Linux game that
+// needs shared libraries which aren't installed. The report tells which
+// distro packages provide them. The user may choose to proceed with the
+// launch anyway.
+//
+// @category Launch
+// @caller server
+type LinuxDependenciesMissingParams struct {
+	Report *LinuxDependencyReport `json:"report"`
+}
+
+func (p LinuxDependenciesMissingParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Report, validation.Required),
+	)
+}
+
+type LinuxDependenciesMissingResult struct {
+	// Set to true if the user wants to proceed with the launch in spite of the missing libraries
+	Continue bool `json:"continue"`
+}
+
+// Shared libraries a native Linux game needs, but that aren't installed
+//
+// @category Launch
+type LinuxDependencyReport struct {
+	// Architecture of the game's executable: "386", "amd64" or "arm64"
+	Arch string `json:"arch"`
+
+	// Distro ID from os-release, like "ubuntu" or "fedora"
+	// @optional
+	DistroID string `json:"distroId,omitempty"`
+
+	// Human-readable distro name, like "Ubuntu 24.04 LTS"
+	// @optional
+	DistroName string `json:"distroName,omitempty"`
+
+	// Missing libraries, sorted by name
+	Missing []*LinuxMissingLibrary `json:"missing"`
+
+	// Shell command that installs all packages providing the missing
+	// libraries. Only set if they're all known for this distro.
+	// @optional
+	InstallCommand string `json:"installCommand,omitempty"`
+}
+
+// @category Launch
+type LinuxMissingLibrary struct {
+	// Shared object name, like "libSDL2-2.0.so.0"
+	Name string `json:"name"`
+
+	// Files that need it, relative to the install folder when
+	// they're part of the game
+	NeededBy []string `json:"neededBy"`
+
+	// Package that provides it on this distro, if known
+	// @optional
+	Package string `json:"package,omitempty"`
+}
+
 //----------------------------------------------------------------------
 // CleanDownloads
 //----------------------------------------------------------------------
@@ -3405,6 +4822,72 @@ type SystemStatFSResult struct {
 	TotalSize int64 `json:"totalSize"`
 }
 
+//----------------------------------------------------------------------
+// Background tasks
+//----------------------------------------------------------------------
+
+// Lists background tasks that are persisted by butlerd: those waiting
+// to run, running, waiting to be retried after an error, or that failed
+// too many times.
+//
+// @name BackgroundTasks.List
+// @category Background Tasks
+// @caller client
+type BackgroundTasksListParams struct{}
+
+func (p BackgroundTasksListParams) Validate() error {
+	return nil
+}
+
+type BackgroundTasksListResult struct {
+	Tasks []*BackgroundTaskInfo `json:"tasks"`
+}
+
+// Schedules a background task to run again as soon as possible,
+// resetting its attempt count. Useful for tasks that have failed.
+// Errors out if the task is running.
+//
+// @name BackgroundTasks.Retry
+// @category Background Tasks
+// @caller client
+type BackgroundTasksRetryParams struct {
+	TaskID string `json:"taskId"`
+}
+
+func (p BackgroundTasksRetryParams) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.TaskID, validation.Required),
+	)
+}
+
+type BackgroundTasksRetryResult struct{}
+
+// A unit of work butlerd performs in the background, like syncing
+// play time. Tasks survive restarts and are retried with exponential
+// backoff when they fail.
+type BackgroundTaskInfo struct {
+	ID string `json:"id"`
+	// Something like `fetch-user-game-sessions`
+	Kind string `json:"kind"`
+	// Human-readable description of the task
+	Desc string `json:"desc"`
+	// Number of failed attempts so far
+	Attempts int64 `json:"attempts"`
+	// True if the task is currently running
+	Running   bool       `json:"running"`
+	CreatedAt *time.Time `json:"createdAt"`
+	// When the task is next going to run
+	// @optional
+	NextRunAt *time.Time `json:"nextRunAt,omitempty"`
+	// Error from the last failed attempt
+	// @optional
+	LastError *string `json:"lastError,omitempty"`
+	// Set if the task failed too many times and won't be retried
+	// unless @@BackgroundTasksRetryParams is called
+	// @optional
+	FailedAt *time.Time `json:"failedAt,omitempty"`
+}
+
 //----------------------------------------------------------------------
 // Misc.
 //----------------------------------------------------------------------
@@ -3504,6 +4987,14 @@ const (
 	// This title is hosted on an incompatible third-party website
 	CodeUnsupportedHost Code = 3001
 
+	// This title's external download link doesn't lead to a file that
+	// can be downloaded without a browser
+	CodeUnsupportedDownload Code = 3002
+
+	// A downloaded file kept not matching the hash itch.io has for it,
+	// even after re-downloading the parts that differed
+	CodeDownloadVerificationFailed Code = 3003
+
 	// Nothing that can be launched was found
 	CodeNoLaunchCandidates Code = 5000
 
@@ -3511,6 +5002,10 @@ const (
 	// did not match any launch target
 	CodeLaunchTargetNotFound Code = 5001
 
+	// The launch profile requested via LaunchParams.profile does not
+	// exist in the cave's settings
+	CodeLaunchProfileNotFound Code = 5002
+
 	// Java Runtime Environment is required to launch this title.
 	CodeJavaRuntimeNeeded Code = 6000
 
@@ -3526,6 +5021,10 @@ const (
 	// An install location could not be removed because it has active downloads
 	CodeCantRemoveLocationBecauseOfActiveDownloads Code = 18000
 
+	// An install would go over its install location's quota, or leave
+	// less free space than its reserve
+	CodeInsufficientDiskSpace Code = 18001
+
 	// The selected sandbox is not available on this system
 	CodeSandboxNotAvailable Code = 19000
 
@@ -76,3 +76,33 @@ func Test_LaunchParams_Validate_CommandTemplate(t *testing.T) {
 	require.Error(err)
 	require.Contains(err.Error(), "commandTemplate")
 }
+
+func Test_CavesSetSettingsParams_Validate_LaunchProfiles(t *testing.T) {
+	require := require.New(t)
+
+	params := CavesSetSettingsParams{
+		CaveID: "cave-1",
+		Settings: &CaveSettings{
+			LaunchProfiles: []*LaunchProfile{
+				{Name: "Vulkan", Args: []string{"-vulkan"}},
+				{Name: "DX11 debug", Env: map[string]string{"DXVK_HUD": "1"}},
+			},
+		},
+	}
+	require.NoError(params.Validate())
+
+	params.Settings.LaunchProfiles = append(params.Settings.LaunchProfiles, &LaunchProfile{Name: "Vulkan"})
+	err := params.Validate()
+	require.Error(err)
+	require.Contains(err.Error(), "settings.launchProfiles[2]: duplicate name")
+
+	params.Settings.LaunchProfiles = []*LaunchProfile{{Name: ""}}
+	err = params.Validate()
+	require.Error(err)
+	require.Contains(err.Error(), "settings.launchProfiles[0]: name")
+
+	params.Settings.LaunchProfiles = []*LaunchProfile{{Name: "bad env", Env: map[string]string{"A=B": "1"}}}
+	err = params.Validate()
+	require.Error(err)
+	require.Contains(err.Error(), "invalid variable name")
+}
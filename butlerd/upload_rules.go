@@ -0,0 +1,57 @@
+package butlerd
+
+import (
+	"encoding/json"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/manager"
+	"github.com/pkg/errors"
+	"xorm.io/builder"
+)
+
+// UploadRulesKey is the profile data key upload rules are stored under,
+// as JSON.
+const UploadRulesKey = "butler:upload-rules"
+
+// ValidateUploadRules checks every rule, so bad rules are refused
+// when they're set rather than ignored when uploads are picked.
+func ValidateUploadRules(rules []*manager.UploadRule) error {
+	for i, rule := range rules {
+		if rule == nil {
+			return errors.Errorf("rules[%d]: must not be null", i)
+		}
+		if err := rule.Validate(); err != nil {
+			return errors.WithMessagef(err, "rules[%d]", i)
+		}
+	}
+	return nil
+}
+
+// UploadRules returns the upload rules of a profile, or nil if it has none
+// or profileID is zero.
+func (rc *RequestContext) UploadRules(profileID int64) []*manager.UploadRule {
+	if profileID == 0 {
+		return nil
+	}
+
+	var ok bool
+	var pd models.ProfileData
+	rc.WithConn(func(conn *sqlite.Conn) {
+		ok = models.MustSelectOne(conn, &pd, builder.Eq{
+			"profile_id": profileID,
+			"key":        UploadRulesKey,
+		})
+	})
+	if !ok {
+		return nil
+	}
+
+	var rules []*manager.UploadRule
+	err := json.Unmarshal([]byte(pd.Value), &rules)
+	if err != nil {
+		rc.Consumer.Warnf("Ignoring upload rules of profile %d: %v", profileID, err)
+		return nil
+	}
+	return rules
+}
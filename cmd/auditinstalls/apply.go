@@ -0,0 +1,136 @@
+package auditinstalls
+
+import (
+	"os"
+	"path/filepath"
+
+	"crawshaw.io/sqlite"
+	"github.com/google/uuid"
+	"github.com/itchio/butler/cmd/wipe"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/hush/bfs"
+	"github.com/pkg/errors"
+)
+
+type Fix struct {
+	Action Action `json:"action"`
+	// Path of the entry, as reported by Audit
+	Path string `json:"path"`
+}
+
+type FixOutcome struct {
+	Fix *Fix `json:"fix"`
+	// Set if the fix couldn't be applied
+	Error string `json:"error,omitempty"`
+	// Set when registering a folder
+	CaveID string `json:"caveId,omitempty"`
+}
+
+// Apply audits again, then applies fixes to the entries that still
+// offer them, so nothing is wiped or forgotten based on stale results.
+// withConn isn't held while wiping folders.
+func Apply(withConn WithConn, consumer *state.Consumer, fixes []*Fix) ([]*FixOutcome, error) {
+	r, err := Audit(withConn, consumer, nil)
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]*Entry)
+	for _, e := range r.Entries {
+		byPath[filepath.Clean(e.Path)] = e
+	}
+
+	var outcomes []*FixOutcome
+	for _, fix := range fixes {
+		outcome := &FixOutcome{Fix: fix}
+		err := func() error {
+			e, ok := byPath[filepath.Clean(fix.Path)]
+			if !ok {
+				return errors.Errorf("nothing to fix at (%s)", fix.Path)
+			}
+			if !hasAction(e, fix.Action) {
+				return errors.Errorf("can't %s (%s), it's %s", fix.Action, fix.Path, e.Status)
+			}
+
+			switch fix.Action {
+			case ActionWipe:
+				var il *models.InstallLocation
+				withConn(func(conn *sqlite.Conn) {
+					il = models.InstallLocationByID(conn, e.InstallLocationID)
+				})
+				if il == nil || !isInside(e.Path, il.Path) {
+					return errors.Errorf("refusing to wipe (%s), it's not in an install location", e.Path)
+				}
+				consumer.Opf("Wiping (%s)", e.Path)
+				return wipe.Do(consumer, e.Path)
+			case ActionForget:
+				consumer.Opf("Forgetting cave (%s)", e.CaveID)
+				withConn(func(conn *sqlite.Conn) {
+					models.DiscardDownloadsByCaveID(conn, e.CaveID)
+					(&models.Cave{ID: e.CaveID}).Delete(conn)
+				})
+				return nil
+			case ActionRegister:
+				cave, err := caveFromReceipt(e)
+				if err != nil {
+					return err
+				}
+				withConn(func(conn *sqlite.Conn) {
+					cave.SaveWithAssocs(conn)
+				})
+				consumer.Opf("Registered (%s) as cave (%s)", e.Path, cave.ID)
+				outcome.CaveID = cave.ID
+				return nil
+			}
+			return errors.Errorf("unknown action (%s)", fix.Action)
+		}()
+		if err != nil {
+			consumer.Warnf("%v", err)
+			outcome.Error = err.Error()
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes, nil
+}
+
+func hasAction(e *Entry, action Action) bool {
+	for _, a := range e.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// caveFromReceipt makes a cave out of the receipt of an orphan install folder.
+func caveFromReceipt(e *Entry) (*models.Cave, error) {
+	receipt, err := bfs.ReadReceipt(e.Path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "reading receipt")
+	}
+	if receipt == nil || receipt.Game == nil || receipt.Upload == nil {
+		return nil, errors.Errorf("no usable receipt in (%s)", e.Path)
+	}
+
+	cave := &models.Cave{
+		ID:                uuid.New().String(),
+		GameID:            receipt.Game.ID,
+		Game:              receipt.Game,
+		UploadID:          receipt.Upload.ID,
+		Upload:            receipt.Upload,
+		InstallLocationID: e.InstallLocationID,
+		InstallFolderName: filepath.Base(e.Path),
+		InstalledSize:     e.Size,
+	}
+	if receipt.Build != nil {
+		cave.BuildID = receipt.Build.ID
+		cave.Build = receipt.Build
+	}
+	if stats, err := os.Stat(bfs.ReceiptPath(e.Path)); err == nil {
+		installedAt := stats.ModTime().UTC()
+		cave.InstalledAt = &installedAt
+	} else {
+		cave.UpdateInstallTime()
+	}
+	return cave, nil
+}
@@ -0,0 +1,339 @@
+package auditinstalls
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/cmd/operate"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/walkutil"
+	"github.com/itchio/hades"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/hush/bfs"
+	"github.com/pkg/errors"
+	"xorm.io/builder"
+)
+
+// WithConn runs f with a connection to butlerd's database
+type WithConn func(f func(conn *sqlite.Conn))
+
+type Status string
+
+const (
+	// A cave whose folder matches its receipt
+	StatusHealthy Status = "healthy"
+	// A cave whose folder has files its receipt doesn't list, or misses
+	// some it does
+	StatusDrifted Status = "drifted"
+	// A cave whose folder is gone
+	StatusMissing Status = "missing"
+	// A folder no cave or download refers to
+	StatusOrphan Status = "orphan"
+)
+
+type Kind string
+
+const (
+	KindInstallFolder Kind = "install-folder"
+	KindStagingFolder Kind = "staging-folder"
+	KindCompatPrefix  Kind = "compat-prefix"
+)
+
+type Action string
+
+const (
+	// Remove the folder
+	ActionWipe Action = "wipe"
+	// Create a cave from the receipt found in the folder
+	ActionRegister Action = "register"
+	// Delete a cave whose folder is gone
+	ActionForget Action = "forget"
+)
+
+// at most this many added or removed files are listed per entry
+const maxListedFiles = 20
+
+type Entry struct {
+	Kind   Kind   `json:"kind"`
+	Status Status `json:"status"`
+
+	InstallLocationID string `json:"installLocationId,omitempty"`
+	Path              string `json:"path"`
+	CaveID            string `json:"caveId,omitempty"`
+	GameID            int64  `json:"gameId,omitempty"`
+
+	// Bytes used on disk
+	Size int64 `json:"size"`
+
+	// Whether the folder has a receipt, for install folders
+	HasReceipt bool `json:"hasReceipt"`
+
+	// Hash the install source matched, as in "sha256:abcd", for caves
+	// installed from a verified source
+	VerifiedSourceHash string `json:"verifiedSourceHash,omitempty"`
+
+	NumAddedFiles   int64    `json:"numAddedFiles,omitempty"`
+	AddedSize       int64    `json:"addedSize,omitempty"`
+	AddedFiles      []string `json:"addedFiles,omitempty"`
+	NumRemovedFiles int64    `json:"numRemovedFiles,omitempty"`
+	RemovedFiles    []string `json:"removedFiles,omitempty"`
+
+	// What can be done about it
+	Actions []Action `json:"actions,omitempty"`
+}
+
+type Report struct {
+	Entries []*Entry `json:"entries"`
+
+	// Bytes used by orphans
+	WastedSize int64 `json:"wastedSize"`
+}
+
+// Audit checks install locations (all of them if installLocationIDs is
+// empty), along with caves installed in custom folders.
+// The database is only used before walking the filesystem, so withConn
+// doesn't hold a connection for long.
+func Audit(withConn WithConn, consumer *state.Consumer, installLocationIDs []string) (*Report, error) {
+	var locations []*models.InstallLocation
+	var caves []*models.Cave
+	var downloads []*models.Download
+	withConn(func(conn *sqlite.Conn) {
+		cond := builder.NewCond()
+		if len(installLocationIDs) > 0 {
+			cond = builder.In("id", installLocationIDs)
+		}
+		models.MustSelect(conn, &locations, cond, hades.Search{})
+		models.MustSelect(conn, &caves, builder.NewCond(), hades.Search{})
+		models.MustSelect(conn, &downloads, builder.Not{builder.Expr("discarded")}, hades.Search{})
+	})
+
+	r := &Report{
+		Entries: []*Entry{},
+	}
+	for _, il := range locations {
+		consumer.Opf("Auditing install location (%s)", il.Path)
+		err := r.auditLocation(il, caves, downloads)
+		if err != nil {
+			consumer.Warnf("Could not audit (%s): %v", il.Path, err)
+		}
+	}
+
+	if len(installLocationIDs) == 0 {
+		for _, cave := range caves {
+			if cave.CustomInstallFolder != "" {
+				r.Entries = append(r.Entries, auditCave(cave, cave.CustomInstallFolder))
+			}
+		}
+	}
+
+	for _, e := range r.Entries {
+		if e.Status == StatusOrphan {
+			r.WastedSize += e.Size
+		}
+	}
+	return r, nil
+}
+
+func (r *Report) auditLocation(il *models.InstallLocation, caves []*models.Cave, downloads []*models.Download) error {
+	folderCaves := make(map[string]*models.Cave)
+	caveIDs := make(map[string]bool)
+	for _, cave := range caves {
+		caveIDs[cave.ID] = true
+		if cave.InstallLocationID == il.ID && cave.CustomInstallFolder == "" {
+			folderCaves[cave.InstallFolderName] = cave
+		}
+	}
+	stagingFolders := make(map[string]bool)
+	downloadFolders := make(map[string]bool)
+	for _, d := range downloads {
+		stagingFolders[filepath.Clean(d.StagingFolder)] = true
+		if d.InstallFolder != "" {
+			downloadFolders[filepath.Clean(d.InstallFolder)] = true
+		}
+	}
+
+	entries, err := ioutil.ReadDir(il.Path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		path := filepath.Join(il.Path, name)
+
+		switch name {
+		case "downloads":
+			r.auditSubfolders(il, path, KindStagingFolder, func(p string) bool {
+				return stagingFolders[filepath.Clean(p)]
+			})
+			continue
+		case "compat-prefixes":
+			r.auditSubfolders(il, path, KindCompatPrefix, func(p string) bool {
+				return caveIDs[filepath.Base(p)]
+			})
+			continue
+		}
+
+		if cave, ok := folderCaves[name]; ok {
+			e := auditCave(cave, path)
+			r.Entries = append(r.Entries, e)
+			delete(folderCaves, name)
+			continue
+		}
+		if downloadFolders[filepath.Clean(path)] {
+			// being installed
+			continue
+		}
+
+		e := &Entry{
+			Kind:              KindInstallFolder,
+			Status:            StatusOrphan,
+			InstallLocationID: il.ID,
+			Path:              path,
+			Size:              folderSize(path),
+			Actions:           []Action{ActionWipe},
+		}
+		receipt, _ := bfs.ReadReceipt(path)
+		if receipt != nil && receipt.Game != nil && receipt.Upload != nil {
+			e.HasReceipt = true
+			e.GameID = receipt.Game.ID
+			e.Actions = append(e.Actions, ActionRegister)
+		}
+		r.Entries = append(r.Entries, e)
+	}
+
+	// whatever is left wasn't found on disk
+	for name, cave := range folderCaves {
+		r.Entries = append(r.Entries, auditCave(cave, filepath.Join(il.Path, name)))
+	}
+	return nil
+}
+
+func (r *Report) auditSubfolders(il *models.InstallLocation, folder string, kind Kind, isKnown func(path string) bool) {
+	entries, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		path := filepath.Join(folder, entry.Name())
+		if !entry.IsDir() || isKnown(path) {
+			continue
+		}
+		r.Entries = append(r.Entries, &Entry{
+			Kind:              kind,
+			Status:            StatusOrphan,
+			InstallLocationID: il.ID,
+			Path:              path,
+			Size:              folderSize(path),
+			Actions:           []Action{ActionWipe},
+		})
+	}
+}
+
+func auditCave(cave *models.Cave, installFolder string) *Entry {
+	e := &Entry{
+		Kind:              KindInstallFolder,
+		Status:            StatusHealthy,
+		InstallLocationID: cave.InstallLocationID,
+		Path:              installFolder,
+		CaveID:            cave.ID,
+		GameID:            cave.GameID,
+	}
+
+	if _, err := os.Stat(installFolder); err != nil {
+		e.Status = StatusMissing
+		e.Actions = []Action{ActionForget}
+		return e
+	}
+	e.Size = folderSize(installFolder)
+
+	vs, _ := operate.ReadVerifiedSource(installFolder)
+	if vs != nil && vs.UploadID == cave.UploadID {
+		e.VerifiedSourceHash = vs.Algo + ":" + vs.Hash
+	}
+
+	receipt, _ := bfs.ReadReceipt(installFolder)
+	if receipt == nil || !receipt.HasFiles() {
+		// nothing to compare against
+		return e
+	}
+	e.HasReceipt = true
+
+	expected := make(map[string]bool)
+	for _, f := range receipt.Files {
+		expected[filepath.ToSlash(filepath.Clean(f))] = true
+	}
+
+	var added []string
+	filepath.Walk(installFolder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(installFolder, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			if rel == ".itch" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if expected[rel] {
+			delete(expected, rel)
+			return nil
+		}
+		added = append(added, rel)
+		e.AddedSize += info.Size()
+		return nil
+	})
+
+	var removed []string
+	for f := range expected {
+		// receipts may list folders
+		if stats, err := os.Stat(filepath.Join(installFolder, filepath.FromSlash(f))); err == nil && stats.IsDir() {
+			continue
+		}
+		removed = append(removed, f)
+	}
+
+	if len(added) > 0 || len(removed) > 0 {
+		e.Status = StatusDrifted
+		e.NumAddedFiles = int64(len(added))
+		e.AddedFiles = firstFiles(added)
+		e.NumRemovedFiles = int64(len(removed))
+		e.RemovedFiles = firstFiles(removed)
+	}
+	return e
+}
+
+func firstFiles(files []string) []string {
+	sort.Strings(files)
+	if len(files) > maxListedFiles {
+		return files[:maxListedFiles]
+	}
+	return files
+}
+
+// folderSize ignores unreadable entries, sizes are only informational here
+func folderSize(folder string) int64 {
+	size, _ := walkutil.FolderSize(folder)
+	return size
+}
+
+// isInside returns true if path is strictly inside folder.
+func isInside(path string, folder string) bool {
+	rel, err := filepath.Rel(folder, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
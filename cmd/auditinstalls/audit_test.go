@@ -0,0 +1,172 @@
+package auditinstalls
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/database/models"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/hush/bfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func auditTestConn(t *testing.T) (*sqlite.Conn, WithConn) {
+	conn, err := sqlite.OpenConn("file::memory:?mode=memory", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, models.HadesContext().AutoMigrate(conn))
+	return conn, func(f func(conn *sqlite.Conn)) { f(conn) }
+}
+
+func writeFile(t *testing.T, path string, contents string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+}
+
+// installGame writes the given files and a receipt listing them
+func installGame(t *testing.T, installFolder string, files ...string) {
+	for _, file := range files {
+		writeFile(t, filepath.Join(installFolder, filepath.FromSlash(file)), file)
+	}
+	receipt := &bfs.Receipt{
+		Game:   &itchio.Game{ID: 1},
+		Upload: &itchio.Upload{ID: 10},
+		Files:  files,
+	}
+	require.NoError(t, receipt.WriteReceipt(installFolder))
+}
+
+func entriesByPath(r *Report) map[string]*Entry {
+	res := make(map[string]*Entry)
+	for _, e := range r.Entries {
+		res[e.Path] = e
+	}
+	return res
+}
+
+func TestAudit(t *testing.T) {
+	conn, withConn := auditTestConn(t)
+	il := &models.InstallLocation{ID: "il", Path: t.TempDir()}
+	models.MustSave(conn, il)
+
+	installGame(t, il.GetInstallFolder("healthy"), "game.exe", "data/level1.dat")
+	writeFile(t, filepath.Join(il.GetInstallFolder("healthy"), ".itch", "verified-source.json"), `{"uploadId":10,"algo":"sha256","hash":"abcd"}`)
+	models.MustSave(conn, &models.Cave{ID: "healthy", GameID: 1, UploadID: 10, InstallLocationID: "il", InstallFolderName: "healthy"})
+
+	installGame(t, il.GetInstallFolder("drifted"), "game.exe", "data/level1.dat")
+	require.NoError(t, os.Remove(filepath.Join(il.GetInstallFolder("drifted"), "data", "level1.dat")))
+	writeFile(t, filepath.Join(il.GetInstallFolder("drifted"), "mods", "mod.dat"), "modded")
+	models.MustSave(conn, &models.Cave{ID: "drifted", GameID: 1, InstallLocationID: "il", InstallFolderName: "drifted"})
+
+	models.MustSave(conn, &models.Cave{ID: "missing", GameID: 1, InstallLocationID: "il", InstallFolderName: "missing"})
+
+	installGame(t, il.GetInstallFolder("orphan-with-receipt"), "game.exe")
+	writeFile(t, filepath.Join(il.GetInstallFolder("orphan"), "leftover.dat"), "12345")
+
+	// being installed
+	writeFile(t, filepath.Join(il.GetInstallFolder("installing"), "game.exe"), "partial")
+	writeFile(t, filepath.Join(il.GetStagingFolder("dl-1"), "source.zip"), "zip")
+	models.MustSave(conn, &models.Download{ID: "dl-1", InstallLocationID: "il", StagingFolder: il.GetStagingFolder("dl-1"), InstallFolder: il.GetInstallFolder("installing")})
+
+	writeFile(t, filepath.Join(il.GetStagingFolder("old-dl"), "source.zip"), "stale")
+	writeFile(t, filepath.Join(il.GetCompatPrefixFolder("healthy"), "drive_c", "x"), "x")
+	writeFile(t, filepath.Join(il.GetCompatPrefixFolder("gone"), "drive_c", "x"), "x")
+
+	r, err := Audit(withConn, &state.Consumer{}, nil)
+	require.NoError(t, err)
+	entries := entriesByPath(r)
+
+	assertEntry := func(path string, kind Kind, status Status, actions ...Action) *Entry {
+		e := entries[path]
+		if assert.NotNil(t, e, "%s should be audited", path) {
+			assert.EqualValues(t, kind, e.Kind, path)
+			assert.EqualValues(t, status, e.Status, path)
+			assert.EqualValues(t, actions, e.Actions, path)
+		}
+		return e
+	}
+
+	healthy := assertEntry(il.GetInstallFolder("healthy"), KindInstallFolder, StatusHealthy)
+	assert.True(t, healthy.HasReceipt)
+	assert.EqualValues(t, "healthy", healthy.CaveID)
+	assert.EqualValues(t, "sha256:abcd", healthy.VerifiedSourceHash)
+
+	drifted := assertEntry(il.GetInstallFolder("drifted"), KindInstallFolder, StatusDrifted)
+	assert.EqualValues(t, []string{"mods/mod.dat"}, drifted.AddedFiles)
+	assert.EqualValues(t, len("modded"), drifted.AddedSize)
+	assert.EqualValues(t, []string{"data/level1.dat"}, drifted.RemovedFiles)
+
+	assertEntry(il.GetInstallFolder("missing"), KindInstallFolder, StatusMissing, ActionForget)
+
+	withReceipt := assertEntry(il.GetInstallFolder("orphan-with-receipt"), KindInstallFolder, StatusOrphan, ActionWipe, ActionRegister)
+	assert.EqualValues(t, 1, withReceipt.GameID)
+	orphan := assertEntry(il.GetInstallFolder("orphan"), KindInstallFolder, StatusOrphan, ActionWipe)
+	assert.EqualValues(t, len("12345"), orphan.Size)
+
+	assert.Nil(t, entries[il.GetInstallFolder("installing")], "install folders of downloads aren't orphans")
+	assert.Nil(t, entries[il.GetStagingFolder("dl-1")], "staging folders of downloads aren't orphans")
+	assertEntry(il.GetStagingFolder("old-dl"), KindStagingFolder, StatusOrphan, ActionWipe)
+	assert.Nil(t, entries[il.GetCompatPrefixFolder("healthy")], "prefixes of caves aren't orphans")
+	assertEntry(il.GetCompatPrefixFolder("gone"), KindCompatPrefix, StatusOrphan, ActionWipe)
+
+	var wasted int64
+	for _, e := range r.Entries {
+		if e.Status == StatusOrphan {
+			wasted += e.Size
+		}
+	}
+	assert.EqualValues(t, wasted, r.WastedSize)
+	assert.Len(t, r.Entries, 7)
+}
+
+func TestApply(t *testing.T) {
+	conn, withConn := auditTestConn(t)
+	il := &models.InstallLocation{ID: "il", Path: t.TempDir()}
+	models.MustSave(conn, il)
+
+	installGame(t, il.GetInstallFolder("registered"), "game.exe")
+	writeFile(t, filepath.Join(il.GetInstallFolder("wiped"), "leftover.dat"), "x")
+	models.MustSave(conn, &models.Cave{ID: "missing", GameID: 1, InstallLocationID: "il", InstallFolderName: "missing"})
+	installGame(t, il.GetInstallFolder("healthy"), "game.exe")
+	models.MustSave(conn, &models.Cave{ID: "healthy", GameID: 1, InstallLocationID: "il", InstallFolderName: "healthy"})
+
+	outcomes, err := Apply(withConn, &state.Consumer{}, []*Fix{
+		{Action: ActionRegister, Path: il.GetInstallFolder("registered")},
+		{Action: ActionWipe, Path: il.GetInstallFolder("wiped")},
+		{Action: ActionForget, Path: il.GetInstallFolder("missing")},
+		{Action: ActionWipe, Path: il.GetInstallFolder("healthy")},
+	})
+	require.NoError(t, err)
+	require.Len(t, outcomes, 4)
+
+	assert.Empty(t, outcomes[0].Error)
+	cave := models.CaveByID(conn, outcomes[0].CaveID)
+	if assert.NotNil(t, cave) {
+		assert.EqualValues(t, "registered", cave.InstallFolderName)
+		assert.EqualValues(t, 10, cave.UploadID)
+	}
+
+	assert.Empty(t, outcomes[1].Error)
+	_, err = os.Stat(il.GetInstallFolder("wiped"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Empty(t, outcomes[2].Error)
+	assert.Nil(t, models.CaveByID(conn, "missing"))
+
+	assert.Contains(t, outcomes[3].Error, "it's healthy")
+	_, err = os.Stat(il.GetInstallFolder("healthy"))
+	assert.NoError(t, err)
+}
+
+func TestIsInside(t *testing.T) {
+	base := filepath.Join("games", "il")
+	assert.True(t, isInside(filepath.Join(base, "alpha"), base))
+	assert.True(t, isInside(filepath.Join(base, "..alpha"), base), "names starting with dots are inside")
+	assert.False(t, isInside(base, base))
+	assert.False(t, isInside(filepath.Join("games", "other"), base))
+	assert.False(t, isInside("games", base))
+}
@@ -0,0 +1,81 @@
+// Finds install folders no cave refers to, caves whose folder is gone, and
+// install folders that don't match their receipt anymore, then optionally
+// cleans them up. Used by butlerd's Install.Locations.Audit, and available
+// as a command that works directly on butlerd's database.
+package auditinstalls
+
+import (
+	"context"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/comm"
+	"github.com/itchio/butler/database"
+	"github.com/itchio/butler/mansion"
+	"github.com/itchio/headway/united"
+	"github.com/pkg/errors"
+)
+
+var args = struct {
+	installLocationIDs []string
+	wipeOrphans        bool
+	registerOrphans    bool
+	forgetMissing      bool
+}{}
+
+func Register(ctx *mansion.Context) {
+	cmd := ctx.App.Command("audit-installs", "Find orphaned, missing and modified install folders").Hidden()
+	cmd.Arg("install-locations", "IDs of the install locations to audit, all of them if unspecified").StringsVar(&args.installLocationIDs)
+	cmd.Flag("wipe-orphans", "Remove folders no cave or download refers to").BoolVar(&args.wipeOrphans)
+	cmd.Flag("register-orphans", "Create caves for orphaned install folders that have a receipt, instead of wiping them").BoolVar(&args.registerOrphans)
+	cmd.Flag("forget-missing", "Remove caves whose install folder is gone").BoolVar(&args.forgetMissing)
+	ctx.Register(cmd, do)
+}
+
+func do(ctx *mansion.Context) {
+	ctx.EnsureDBPath()
+	consumer := comm.NewStateConsumer()
+	dbPool, err := database.OpenPool(consumer, ctx.DBPath)
+	ctx.Must(errors.WithMessagef(err, "opening butlerd db at %s", ctx.DBPath))
+	defer dbPool.Close()
+
+	withConn := func(f func(conn *sqlite.Conn)) {
+		conn := dbPool.Get(context.Background())
+		defer dbPool.Put(conn)
+		f(conn)
+	}
+	r, err := Audit(withConn, consumer, args.installLocationIDs)
+	ctx.Must(err)
+
+	var fixes []*Fix
+	for _, e := range r.Entries {
+		switch {
+		case args.registerOrphans && hasAction(e, ActionRegister):
+			fixes = append(fixes, &Fix{Action: ActionRegister, Path: e.Path})
+		case args.wipeOrphans && hasAction(e, ActionWipe):
+			fixes = append(fixes, &Fix{Action: ActionWipe, Path: e.Path})
+		case args.forgetMissing && hasAction(e, ActionForget):
+			fixes = append(fixes, &Fix{Action: ActionForget, Path: e.Path})
+		}
+	}
+
+	comm.ResultOrPrint(r, func() {
+		for _, e := range r.Entries {
+			if e.Status == StatusHealthy {
+				continue
+			}
+			comm.Logf("%-8s %-14s %s (%s)", e.Status, e.Kind, e.Path, united.FormatBytes(e.Size))
+			if e.Status == StatusDrifted {
+				comm.Logf("         %d files added (%s), %d removed", e.NumAddedFiles, united.FormatBytes(e.AddedSize), e.NumRemovedFiles)
+			}
+		}
+		comm.Statf("%d entries audited, %s wasted by orphans", len(r.Entries), united.FormatBytes(r.WastedSize))
+	})
+
+	if len(fixes) > 0 {
+		outcomes, err := Apply(withConn, consumer, fixes)
+		ctx.Must(err)
+		comm.ResultOrPrint(outcomes, func() {
+			comm.Statf("Applied %d fixes", len(outcomes))
+		})
+	}
+}
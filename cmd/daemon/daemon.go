@@ -6,9 +6,6 @@ import (
 	"log/slog"
 	"net"
 	"os"
-	"path/filepath"
-
-	"github.com/itchio/butler/butlerd/horror"
 
 	"crawshaw.io/sqlite/sqlitex"
 	"github.com/google/gops/agent"
@@ -20,7 +17,6 @@ import (
 
 	"github.com/itchio/butler/comm"
 	"github.com/itchio/butler/mansion"
-	"github.com/pkg/errors"
 )
 
 var args = struct {
@@ -28,6 +24,17 @@ var args = struct {
 	transport   string
 	keepAlive   bool
 	log         bool
+
+	prereqsMirror string
+	fetchTTLs     []string
+	offlineFirst  bool
+
+	peerCacheDir    string
+	peerCacheMaxGB  int64
+	peerCacheListen string
+	peerCachePeers  []string
+	peerCacheMDNS   bool
+	peerCacheSecret string
 }{}
 
 // origStdout holds the real stdout before redirecting it for stdio transport.
@@ -39,6 +46,15 @@ func Register(ctx *mansion.Context) {
 	cmd.Flag("transport", "Which transport to use").Default("tcp").EnumVar(&args.transport, "http", "tcp", "stdio")
 	cmd.Flag("keep-alive", "Accept multiple TCP connections, stay up until killed or a destiny PID shuts down").BoolVar(&args.keepAlive)
 	cmd.Flag("log", "Log all requests to stderr").BoolVar(&args.log)
+	cmd.Flag("prereqs-mirror", "Directory or URL of a prereqs mirror made with 'butler prereqs-mirror', used instead of itch.io").Envar("BUTLER_PREREQS_MIRROR").StringVar(&args.prereqsMirror)
+	cmd.Flag("fetch-ttl", "How long fetched data stays fresh, by type, as in 'game=1h' (repeatable)").StringsVar(&args.fetchTTLs)
+	cmd.Flag("offline-first", "Answer fetch requests from the cache and refresh it in the background").BoolVar(&args.offlineFirst)
+	cmd.Flag("peer-cache-dir", "Keep verified install sources in this directory, and ask LAN peers for them before itch.io. Off unless set").StringVar(&args.peerCacheDir)
+	cmd.Flag("peer-cache-max-gb", "Size of the peer cache above which the least recently used files are removed, 0 for no limit").Default("100").Int64Var(&args.peerCacheMaxGB)
+	cmd.Flag("peer-cache-listen", "Address to serve the peer cache on, as in ':7676', requires --peer-cache-secret. Only asks peers if unset").StringVar(&args.peerCacheListen)
+	cmd.Flag("peer-cache-peer", "Address of a peer, as in '192.168.1.20:7676' (repeatable)").StringsVar(&args.peerCachePeers)
+	cmd.Flag("peer-cache-mdns", "Find peers, and announce the peer cache, with mDNS").BoolVar(&args.peerCacheMDNS)
+	cmd.Flag("peer-cache-secret", "Secret shared with peers, required with --peer-cache-listen. Peers prove they know it, it is never sent").Envar("BUTLER_PEER_CACHE_SECRET").StringVar(&args.peerCacheSecret)
 	ctx.Register(cmd, do)
 }
 
@@ -90,48 +106,44 @@ func do(ctx *mansion.Context) {
 	}
 	secret := generateSecret()
 
-	err = os.MkdirAll(filepath.Dir(ctx.DBPath), 0o755)
-	if err != nil {
-		ctx.Must(errors.WithMessage(err, "creating DB directory if necessary"))
-	}
-
-	justCreated := false
-	_, statErr := os.Stat(ctx.DBPath)
-	if statErr != nil {
-		comm.Logf("butlerd: creating new DB at %s", ctx.DBPath)
-		justCreated = true
-	}
-
-	dbPool, err := sqlitex.Open(ctx.DBPath, 0, 100)
-	if err != nil {
-		ctx.Must(errors.WithMessage(err, "opening DB for the first time"))
-	}
+	dbPool := OpenDB(ctx)
 	defer dbPool.Close()
 
-	dbPrepareLogger := slog.New(comm.NewSlogHandler(slog.LevelDebug)).With("source", "db_prepare")
-
-	err = func() (retErr error) {
-		defer horror.RecoverInto(&retErr)
+	ctx.Must(Do(ctx, context.Background(), dbPool, secret))
+}
 
-		conn := dbPool.Get(context.Background())
-		defer dbPool.Put(conn)
-		return database.Prepare(&state.Consumer{
-			OnMessage: func(lvl string, msg string) {
-				dbPrepareLogger.Log(context.Background(), stateLevelToSlogLevel(lvl), msg)
-			},
-		}, conn, justCreated)
-	}()
-	if err != nil {
-		ctx.Must(errors.WithMessage(err, "preparing DB"))
-	}
+// OpenDB opens butlerd's database, creating and migrating it
+// if needed.
+func OpenDB(ctx *mansion.Context) *sqlitex.Pool {
+	ctx.EnsureDBPath()
 
-	ctx.Must(Do(ctx, context.Background(), dbPool, secret))
+	dbPrepareLogger := slog.New(comm.NewSlogHandler(slog.LevelDebug)).With("source", "db_prepare")
+	dbPool, err := database.OpenPool(&state.Consumer{
+		OnMessage: func(lvl string, msg string) {
+			dbPrepareLogger.Log(context.Background(), stateLevelToSlogLevel(lvl), msg)
+		},
+	}, ctx.DBPath)
+	ctx.Must(err)
+	return dbPool
 }
 
 func Do(mansionContext *mansion.Context, ctx context.Context, dbPool *sqlitex.Pool, secret string) error {
 	s := butlerd.NewServer(secret)
 	router := GetRouter(dbPool, mansionContext)
 
+	if pc := router.Config.PeerCache; pc != nil {
+		peerCacheLogger := slog.New(comm.NewSlogHandler(slog.LevelInfo)).With("source", "peer_cache")
+		err := pc.Start(&state.Consumer{
+			OnMessage: func(lvl string, msg string) {
+				peerCacheLogger.Log(context.Background(), stateLevelToSlogLevel(lvl), msg)
+			},
+		})
+		if err != nil {
+			return err
+		}
+		defer pc.Close()
+	}
+
 	switch args.transport {
 	case "tcp":
 		listener, err := net.Listen("tcp", "127.0.0.1:")
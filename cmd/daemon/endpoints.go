@@ -1,23 +1,31 @@
 package daemon
 
 import (
+	"strings"
+	"time"
+
 	"crawshaw.io/sqlite/sqlitex"
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/butlerd/messages"
+	"github.com/itchio/butler/database/models"
 	"github.com/itchio/butler/endpoints/cleandownloads"
 	"github.com/itchio/butler/endpoints/downloads"
 	"github.com/itchio/butler/endpoints/fetch"
 	"github.com/itchio/butler/endpoints/install"
 	"github.com/itchio/butler/endpoints/launch"
+	"github.com/itchio/butler/endpoints/library"
 	"github.com/itchio/butler/endpoints/meta"
 	"github.com/itchio/butler/endpoints/profile"
 	"github.com/itchio/butler/endpoints/publish"
 	"github.com/itchio/butler/endpoints/search"
 	"github.com/itchio/butler/endpoints/system"
+	"github.com/itchio/butler/endpoints/tasks"
 	"github.com/itchio/butler/endpoints/tests"
 	"github.com/itchio/butler/endpoints/update"
 	"github.com/itchio/butler/endpoints/utilities"
 	"github.com/itchio/butler/mansion"
+	"github.com/itchio/butler/peercache"
+	"github.com/pkg/errors"
 )
 
 var mainRouter *butlerd.Router
@@ -27,7 +35,31 @@ func GetRouter(dbPool *sqlitex.Pool, mansionContext *mansion.Context) *butlerd.R
 		return mainRouter
 	}
 
+	fetchTTLs, err := parseFetchTTLs(args.fetchTTLs)
+	mansionContext.Must(err)
+	for targetType, ttl := range fetchTTLs {
+		mansionContext.Must(models.SetFetchTTL(targetType, ttl))
+	}
+
+	var peerCache *peercache.PeerCache
+	if args.peerCacheDir != "" {
+		peerCache, err = peercache.New(peercache.Params{
+			Dir:           args.peerCacheDir,
+			MaxSize:       args.peerCacheMaxGB * 1024 * 1024 * 1024,
+			ListenAddress: args.peerCacheListen,
+			Peers:         args.peerCachePeers,
+			MDNS:          args.peerCacheMDNS,
+			Secret:        args.peerCacheSecret,
+		})
+		mansionContext.Must(err)
+	}
+
 	mainRouter = butlerd.NewRouter(dbPool, mansionContext.NewClient, mansionContext.HTTPClient, mansionContext.HTTPTransport)
+	mainRouter.Config = butlerd.Config{
+		PrereqsMirror: args.prereqsMirror,
+		OfflineFirst:  args.offlineFirst,
+		PeerCache:     peerCache,
+	}
 
 	meta.Register(mainRouter)
 	utilities.Register(mainRouter)
@@ -35,6 +67,7 @@ func GetRouter(dbPool *sqlitex.Pool, mansionContext *mansion.Context) *butlerd.R
 	update.Register(mainRouter)
 	install.Register(mainRouter)
 	launch.Register(mainRouter)
+	library.Register(mainRouter)
 	cleandownloads.Register(mainRouter)
 	profile.Register(mainRouter)
 	fetch.Register(mainRouter)
@@ -42,8 +75,27 @@ func GetRouter(dbPool *sqlitex.Pool, mansionContext *mansion.Context) *butlerd.R
 	search.Register(mainRouter)
 	system.Register(mainRouter)
 	publish.Register(mainRouter)
+	tasks.Register(mainRouter)
 
 	messages.EnsureAllRequests(mainRouter)
+	mainRouter.StartTaskQueue()
 
 	return mainRouter
 }
+
+// parseFetchTTLs parses specs like "game=1h"
+func parseFetchTTLs(specs []string) (map[string]time.Duration, error) {
+	ttls := make(map[string]time.Duration)
+	for _, spec := range specs {
+		targetType, value, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid fetch TTL (%s), expected TYPE=DURATION, types are: %s", spec, strings.Join(models.FetchTargetTypes(), ", "))
+		}
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing fetch TTL (%s)", spec)
+		}
+		ttls[targetType] = ttl
+	}
+	return ttls, nil
+}
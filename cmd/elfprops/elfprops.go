@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
-	"net/url"
 	"sort"
 	"strings"
 
@@ -13,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/itchio/butler/comm"
+	"github.com/itchio/butler/manager/linuxdeps"
 	"github.com/itchio/butler/mansion"
 	"github.com/itchio/elefant"
 	"github.com/itchio/headway/state"
@@ -61,13 +61,8 @@ func do(ctx *mansion.Context) {
 			codeword := args.analyzeDistro
 			consumer.Infof("Analyzing for distro %s (%s)", codeword, info.Arch)
 
-			debarch := ""
-			switch info.Arch {
-			case elefant.Arch386:
-				debarch = "i386"
-			case elefant.ArchAmd64:
-				debarch = "amd64"
-			default:
+			debarch := linuxdeps.DebianArch(string(info.Arch))
+			if debarch == "" {
 				ctx.Must(errors.Errorf("Don't know equivalent debian arch for (%s)", info.Arch))
 			}
 
@@ -111,43 +106,17 @@ func do(ctx *mansion.Context) {
 			}
 			log.Printf("Recorded %d base packages", len(basePackageMap))
 
-			type PackageResponse struct {
-				Packages []string `json:"packages"`
-			}
-
 			requiredPackages := make(map[string]bool)
 			processLib := func(libName string) {
-				values := make(url.Values)
-				values.Add("file", libName)
-				searchURL := fmt.Sprintf("https://broth.itch.zone/debsearch/by-file-path/ubuntu/%s/%s?%s", codeword, debarch, values.Encode())
-				log.Printf("Querying broth (%s)...", searchURL)
-
-				res, err := client.Get(searchURL)
+				log.Printf("Querying broth for (%s)...", libName)
+				p, err := linuxdeps.DebianPackageForFile(client, codeword, debarch, libName)
 				ctx.Must(err)
 
-				if res.StatusCode != 200 {
-					log.Printf("Ignoring, got HTTP %d for %s", res.StatusCode, searchURL)
+				if p == "" {
+					log.Printf("Ignoring, no package found for %s", libName)
 					return
 				}
-
-				defer res.Body.Close()
-				resBytes, err := ioutil.ReadAll(res.Body)
-				ctx.Must(err)
-
-				var pr PackageResponse
-				ctx.Must(json.Unmarshal(resBytes, &pr))
-
-				for _, p := range pr.Packages {
-					if strings.HasSuffix(p, "-dbg") {
-						continue
-					}
-					if strings.HasSuffix(p, "-cross") {
-						continue
-					}
-
-					requiredPackages[p] = true
-					break
-				}
+				requiredPackages[p] = true
 			}
 
 			for _, libName := range sortedNames {
@@ -0,0 +1,56 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/jsonrpc2"
+	"github.com/itchio/butler/comm"
+)
+
+// client answers requests and notifications butlerd sends to clients
+type client struct {
+	handlers             map[string]butlerd.RequestHandler
+	notificationHandlers map[string]butlerd.NotificationHandler
+}
+
+var _ jsonrpc2.Handler = (*client)(nil)
+
+func newClient() *client {
+	return &client{
+		handlers:             make(map[string]butlerd.RequestHandler),
+		notificationHandlers: make(map[string]butlerd.NotificationHandler),
+	}
+}
+
+func (c *client) HandleNotification(conn jsonrpc2.Conn, notif jsonrpc2.Notification) {
+	if nh, ok := c.notificationHandlers[notif.Method]; ok {
+		nh(notif)
+	}
+}
+
+func (c *client) HandleRequest(conn jsonrpc2.Conn, req jsonrpc2.Request) (interface{}, error) {
+	rh, ok := c.handlers[req.Method]
+	if !ok {
+		return nil, &jsonrpc2.Error{
+			Code:    jsonrpc2.CodeMethodNotFound,
+			Message: fmt.Sprintf("%s isn't supported in headless mode", req.Method),
+		}
+	}
+
+	rc := &butlerd.RequestContext{
+		Ctx:      conn.Context(),
+		Conn:     conn,
+		Params:   req.Params,
+		Consumer: comm.NewStateConsumer(),
+	}
+	return rh(rc)
+}
+
+func (c *client) Register(method string, rh butlerd.RequestHandler) {
+	c.handlers[method] = rh
+}
+
+func (c *client) RegisterNotification(method string, nh butlerd.NotificationHandler) {
+	c.notificationHandlers[method] = nh
+}
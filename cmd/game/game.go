@@ -0,0 +1,325 @@
+// Installs, launches and uninstalls games without a client, for scripts
+// and test machines. Requests go through butlerd's own handlers, run
+// in-process, and dialogs they'd show are answered by flags instead.
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/messages"
+	"github.com/itchio/butler/comm"
+	"github.com/itchio/butler/mansion"
+	"github.com/itchio/headway/united"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+)
+
+var installArgs = struct {
+	game             string
+	location         string
+	upload           string
+	acceptLicenses   bool
+	ignoreInstallers bool
+}{}
+
+var launchArgs = struct {
+	cave              string
+	prereqsDir        string
+	target            string
+	allowSandboxSetup bool
+	ignorePrereqs     bool
+}{}
+
+var uninstallArgs = struct {
+	cave string
+	hard bool
+}{}
+
+func Register(ctx *mansion.Context) {
+	parentCmd := ctx.App.Command("game", "Install, launch and uninstall games without the app (butlerd must not be running)").Hidden()
+
+	{
+		cmd := parentCmd.Command("install", "Install a game")
+		cmd.Arg("game", "ID or page URL of the game to install").Required().StringVar(&installArgs.game)
+		cmd.Flag("location", "ID or path of the install location, required if there's more than one").StringVar(&installArgs.location)
+		cmd.Flag("upload", "ID of the upload to install, or 'auto' for the preferred one").Default("auto").StringVar(&installArgs.upload)
+		cmd.Flag("accept-licenses", "Accept license agreements shipped with the game").BoolVar(&installArgs.acceptLicenses)
+		cmd.Flag("ignore-installers", "Don't run installers, extract them instead").BoolVar(&installArgs.ignoreInstallers)
+		ctx.Register(cmd, doInstall)
+	}
+
+	{
+		cmd := parentCmd.Command("launch", "Launch an installed game and wait for it to exit")
+		cmd.Arg("cave", "ID of the cave to launch").Required().StringVar(&launchArgs.cave)
+		cmd.Flag("prereqs-dir", "Where to store prerequisite installers, defaults to next to the database").StringVar(&launchArgs.prereqsDir)
+		cmd.Flag("target", "Manifest action name or path of the executable to launch").StringVar(&launchArgs.target)
+		cmd.Flag("allow-sandbox-setup", "Set up the sandbox if the game asks for it").BoolVar(&launchArgs.allowSandboxSetup)
+		cmd.Flag("ignore-prereqs", "Launch even if prerequisites failed to install or libraries are missing").BoolVar(&launchArgs.ignorePrereqs)
+		ctx.Register(cmd, doLaunch)
+	}
+
+	{
+		cmd := parentCmd.Command("uninstall", "Uninstall a game")
+		cmd.Arg("cave", "ID of the cave to uninstall").Required().StringVar(&uninstallArgs.cave)
+		cmd.Flag("hard", "Don't run uninstallers, just remove the install folder").BoolVar(&uninstallArgs.hard)
+		ctx.Register(cmd, doUninstall)
+	}
+
+	{
+		cmd := parentCmd.Command("list", "List installed games")
+		ctx.Register(cmd, doList)
+	}
+}
+
+func doInstall(ctx *mansion.Context) {
+	s := newSession(ctx, &policy{
+		upload:         installArgs.upload,
+		acceptLicenses: installArgs.acceptLicenses,
+	})
+
+	// closed before exiting on errors, so background tasks can finish
+	err := install(ctx, s)
+	s.close()
+	ctx.Must(rpcError(err))
+}
+
+func install(ctx *mansion.Context, s *session) error {
+	gameID, err := resolveGameID(ctx, installArgs.game)
+	if err != nil {
+		return err
+	}
+
+	gameRes, err := messages.FetchGame.TestCall(s.rc, butlerd.FetchGameParams{
+		GameID: gameID,
+		Fresh:  true,
+	})
+	if err != nil {
+		return err
+	}
+	if gameRes.Game == nil {
+		return errors.Errorf("game %d not found", gameID)
+	}
+
+	locationID, err := resolveInstallLocation(s, installArgs.location)
+	if err != nil {
+		return err
+	}
+
+	queueParams := butlerd.InstallQueueParams{
+		Game:              gameRes.Game,
+		InstallLocationID: locationID,
+		IgnoreInstallers:  installArgs.ignoreInstallers,
+	}
+	if installArgs.upload != "auto" {
+		uploadsRes, err := messages.FetchGameUploads.TestCall(s.rc, butlerd.FetchGameUploadsParams{
+			GameID: gameID,
+			Fresh:  true,
+		})
+		if err != nil {
+			return err
+		}
+		for _, u := range uploadsRes.Uploads {
+			if strconv.FormatInt(u.ID, 10) == installArgs.upload {
+				queueParams.Upload = u
+			}
+		}
+		if queueParams.Upload == nil {
+			return errors.Errorf("game %d has no upload %s", gameID, installArgs.upload)
+		}
+	}
+
+	comm.Opf("Installing %s", gameRes.Game.Title)
+	queueRes, err := messages.InstallQueue.TestCall(s.rc, queueParams)
+	if err != nil {
+		return err
+	}
+
+	_, err = messages.InstallPerform.TestCall(s.rc, butlerd.InstallPerformParams{
+		ID:            queueRes.ID,
+		StagingFolder: queueRes.StagingFolder,
+	})
+	s.endProgress()
+	if err != nil {
+		return err
+	}
+
+	comm.ResultOrPrint(queueRes, func() {
+		comm.Statf("Installed to %s", queueRes.InstallFolder)
+		comm.Logf("Cave ID: %s", queueRes.CaveID)
+	})
+	return nil
+}
+
+func doLaunch(ctx *mansion.Context) {
+	s := newSession(ctx, &policy{
+		allowSandbox:  launchArgs.allowSandboxSetup,
+		ignorePrereqs: launchArgs.ignorePrereqs,
+	})
+
+	err := launch(ctx, s)
+	s.close()
+	ctx.Must(rpcError(err))
+}
+
+func launch(ctx *mansion.Context, s *session) error {
+	prereqsDir := launchArgs.prereqsDir
+	if prereqsDir == "" {
+		prereqsDir = filepath.Join(filepath.Dir(ctx.DBPath), "prereqs")
+	}
+
+	messages.LaunchRunning.Register(s.client, func(params butlerd.LaunchRunningNotification) {
+		comm.Opf("Game is running")
+	})
+	messages.LaunchExited.Register(s.client, func(params butlerd.LaunchExitedNotification) {
+		comm.Opf("Game has exited")
+	})
+
+	_, err := messages.Launch.TestCall(s.rc, butlerd.LaunchParams{
+		CaveID:     launchArgs.cave,
+		PrereqsDir: prereqsDir,
+		Target:     launchArgs.target,
+		HTMLServer: &butlerd.HTMLServerOptions{},
+	})
+	s.endProgress()
+	return err
+}
+
+func doUninstall(ctx *mansion.Context) {
+	s := newSession(ctx, &policy{})
+
+	err := uninstall(s)
+	s.close()
+	ctx.Must(rpcError(err))
+}
+
+func uninstall(s *session) error {
+	_, err := messages.UninstallPerform.TestCall(s.rc, butlerd.UninstallPerformParams{
+		CaveID: uninstallArgs.cave,
+		Hard:   uninstallArgs.hard,
+	})
+	s.endProgress()
+	if err != nil {
+		return err
+	}
+
+	comm.Statf("Uninstalled %s", uninstallArgs.cave)
+	return nil
+}
+
+func doList(ctx *mansion.Context) {
+	s := newSession(ctx, &policy{})
+
+	err := list(s)
+	s.close()
+	ctx.Must(rpcError(err))
+}
+
+func list(s *session) error {
+	var caves []*butlerd.Cave
+	var cursor butlerd.Cursor
+	for {
+		res, err := messages.FetchCaves.TestCall(s.rc, butlerd.FetchCavesParams{
+			Cursor: cursor,
+		})
+		if err != nil {
+			return err
+		}
+
+		caves = append(caves, res.Items...)
+		if res.NextCursor == "" {
+			break
+		}
+		cursor = res.NextCursor
+	}
+
+	comm.ResultOrPrint(caves, func() {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Cave", "Game", "Location", "Size"})
+		for _, cave := range caves {
+			table.Append([]string{
+				cave.ID,
+				formatCave(cave),
+				cave.InstallInfo.InstallLocation,
+				united.FormatBytes(cave.InstallInfo.InstalledSize),
+			})
+		}
+		table.Render()
+	})
+	return nil
+}
+
+// resolveGameID accepts a game ID, an itch://games/ID address, or the
+// address of a game's page.
+func resolveGameID(ctx *mansion.Context, s string) (int64, error) {
+	if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return id, nil
+	}
+	if idString, ok := strings.CutPrefix(s, "itch://games/"); ok {
+		id, err := strconv.ParseInt(idString, 10, 64)
+		if err != nil {
+			return 0, errors.Errorf("invalid game address (%s)", s)
+		}
+		return id, nil
+	}
+	if !strings.HasPrefix(s, "https://") && !strings.HasPrefix(s, "http://") {
+		return 0, errors.Errorf("expected a game ID or the address of a game page, got (%s)", s)
+	}
+
+	// game pages describe themselves at data.json
+	res, err := ctx.HTTPClient.Get(strings.TrimSuffix(s, "/") + "/data.json")
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, errors.Errorf("HTTP %d while looking up game at %s", res.StatusCode, s)
+	}
+
+	var data struct {
+		ID int64 `json:"id"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&data)
+	if err != nil {
+		return 0, errors.Wrapf(err, "looking up game at %s", s)
+	}
+	if data.ID == 0 {
+		return 0, errors.Errorf("no game found at %s", s)
+	}
+	return data.ID, nil
+}
+
+// resolveInstallLocation matches an install location by ID or path.
+// If none is given, there must be exactly one.
+func resolveInstallLocation(s *session, location string) (string, error) {
+	res, err := messages.InstallLocationsList.TestCall(s.rc, butlerd.InstallLocationsListParams{})
+	if err != nil {
+		return "", err
+	}
+
+	if location == "" {
+		if len(res.InstallLocations) != 1 {
+			return "", errors.Errorf("there are %d install locations, pick one with --location", len(res.InstallLocations))
+		}
+		return res.InstallLocations[0].ID, nil
+	}
+
+	for _, il := range res.InstallLocations {
+		if il.ID == location || filepath.Clean(il.Path) == filepath.Clean(location) {
+			return il.ID, nil
+		}
+	}
+
+	var known []string
+	for _, il := range res.InstallLocations {
+		known = append(known, fmt.Sprintf("%s (%s)", il.ID, il.Path))
+	}
+	return "", errors.Errorf("no install location (%s), known ones are: %s", location, strings.Join(known, ", "))
+}
@@ -0,0 +1,115 @@
+package game
+
+import (
+	"strconv"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/messages"
+	"github.com/itchio/butler/comm"
+	"github.com/pkg/errors"
+	"github.com/skratchdot/open-golang/open"
+)
+
+// policy decides how dialogs butlerd would show through a client are
+// answered, since nobody's there to answer them.
+type policy struct {
+	// "auto" picks butlerd's preferred upload, otherwise an upload ID
+	upload string
+
+	acceptLicenses bool
+	allowSandbox   bool
+	// keep launching when prerequisites fail or libraries are missing
+	ignorePrereqs bool
+}
+
+func (p *policy) register(c *client) {
+	messages.PickUpload.TestRegister(c, func(rc *butlerd.RequestContext, params butlerd.PickUploadParams) (*butlerd.PickUploadResult, error) {
+		if len(params.Uploads) == 0 {
+			return nil, errors.New("no uploads to pick from")
+		}
+
+		if p.upload == "auto" {
+			// uploads are sorted by preference
+			comm.Logf("Picking upload (%s)", params.Uploads[0].Filename)
+			return &butlerd.PickUploadResult{Index: 0}, nil
+		}
+
+		for i, u := range params.Uploads {
+			if strconv.FormatInt(u.ID, 10) == p.upload {
+				return &butlerd.PickUploadResult{Index: int64(i)}, nil
+			}
+		}
+		return nil, errors.Errorf("upload %s is not one of the compatible uploads", p.upload)
+	})
+
+	messages.AcceptLicense.TestRegister(c, func(rc *butlerd.RequestContext, params butlerd.AcceptLicenseParams) (*butlerd.AcceptLicenseResult, error) {
+		if !p.acceptLicenses {
+			comm.Warnf("Declining license agreement, pass --accept-licenses to accept it")
+		}
+		return &butlerd.AcceptLicenseResult{Accept: p.acceptLicenses}, nil
+	})
+
+	messages.PickManifestAction.TestRegister(c, func(rc *butlerd.RequestContext, params butlerd.PickManifestActionParams) (*butlerd.PickManifestActionResult, error) {
+		if len(params.Actions) == 0 {
+			return nil, errors.New("no manifest actions to pick from")
+		}
+
+		comm.Logf("Picking first manifest action (%s), pass --target to pick another one", params.Actions[0].Name)
+		return &butlerd.PickManifestActionResult{Index: 0}, nil
+	})
+
+	messages.AllowSandboxSetup.TestRegister(c, func(rc *butlerd.RequestContext, params butlerd.AllowSandboxSetupParams) (*butlerd.AllowSandboxSetupResult, error) {
+		if !p.allowSandbox {
+			comm.Warnf("Not setting up the sandbox, pass --allow-sandbox-setup to allow it")
+		}
+		return &butlerd.AllowSandboxSetupResult{Allow: p.allowSandbox}, nil
+	})
+
+	messages.PrereqsFailed.TestRegister(c, func(rc *butlerd.RequestContext, params butlerd.PrereqsFailedParams) (*butlerd.PrereqsFailedResult, error) {
+		comm.Warnf("Prerequisites failed: %s", params.Error)
+		return &butlerd.PrereqsFailedResult{Continue: p.ignorePrereqs}, nil
+	})
+
+	messages.LinuxDependenciesMissing.TestRegister(c, func(rc *butlerd.RequestContext, params butlerd.LinuxDependenciesMissingParams) (*butlerd.LinuxDependenciesMissingResult, error) {
+		for _, ml := range params.Report.Missing {
+			comm.Warnf("Missing library: %s", ml.Name)
+		}
+		if params.Report.InstallCommand != "" {
+			comm.Logf("To install them: %s", params.Report.InstallCommand)
+		}
+		return &butlerd.LinuxDependenciesMissingResult{Continue: p.ignorePrereqs}, nil
+	})
+
+	messages.ShellLaunch.TestRegister(c, func(rc *butlerd.RequestContext, params butlerd.ShellLaunchParams) (*butlerd.ShellLaunchResult, error) {
+		comm.Opf("Opening (%s)", params.ItemPath)
+		err := open.Start(params.ItemPath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return &butlerd.ShellLaunchResult{}, nil
+	})
+
+	messages.URLLaunch.TestRegister(c, func(rc *butlerd.RequestContext, params butlerd.URLLaunchParams) (*butlerd.URLLaunchResult, error) {
+		comm.Opf("Opening (%s)", params.URL)
+		err := open.Start(params.URL)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return &butlerd.URLLaunchResult{}, nil
+	})
+
+	messages.HTMLLaunch.TestRegister(c, func(rc *butlerd.RequestContext, params butlerd.HTMLLaunchParams) (*butlerd.HTMLLaunchResult, error) {
+		if params.URL == "" {
+			return nil, errors.New("HTML games can only be launched when butlerd serves them")
+		}
+
+		// the game is only served until we return
+		comm.Opf("Serving game at %s, press Ctrl-C to stop", params.URL)
+		err := open.Start(params.URL)
+		if err != nil {
+			comm.Warnf("Could not open browser: %v", err)
+		}
+		<-rc.Ctx.Done()
+		return &butlerd.HTMLLaunchResult{}, nil
+	})
+}
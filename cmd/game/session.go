@@ -0,0 +1,138 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"crawshaw.io/sqlite/sqlitex"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/jsonrpc2"
+	"github.com/itchio/butler/butlerd/messages"
+	"github.com/itchio/butler/cmd/daemon"
+	"github.com/itchio/butler/comm"
+	"github.com/itchio/butler/mansion"
+	"github.com/pkg/errors"
+)
+
+// session runs butlerd's router in-process, and answers the requests it
+// makes to clients with non-interactive policies.
+type session struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	dbPool *sqlitex.Pool
+	router *butlerd.Router
+	client *client
+	conn   jsonrpc2.Conn
+	rc     *butlerd.RequestContext
+
+	// Progress notifications are handled on the connection's goroutine
+	progressMu  sync.Mutex
+	progressing bool
+}
+
+func newSession(ctx *mansion.Context, p *policy) *session {
+	dbPool := daemon.OpenDB(ctx)
+	router := daemon.GetRouter(dbPool, ctx)
+
+	sctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	s := &session{
+		ctx:    sctx,
+		cancel: cancel,
+		dbPool: dbPool,
+		router: router,
+		client: newClient(),
+	}
+	s.conn = jsonrpc2.NewInProcessConn(sctx, router, s.client)
+	s.rc = &butlerd.RequestContext{
+		Ctx:      sctx,
+		Conn:     s.conn,
+		Consumer: comm.NewStateConsumer(),
+	}
+
+	messages.Log.Register(s.client, func(params butlerd.LogNotification) {
+		switch params.Level {
+		case butlerd.LogLevelError, butlerd.LogLevelWarning:
+			comm.Warnf("%s", params.Message)
+		case butlerd.LogLevelDebug:
+			comm.Debugf("%s", params.Message)
+		default:
+			comm.Logf("%s", params.Message)
+		}
+	})
+	messages.Progress.Register(s.client, func(params butlerd.ProgressNotification) {
+		s.progressMu.Lock()
+		defer s.progressMu.Unlock()
+
+		if !s.progressing {
+			s.progressing = true
+			comm.StartProgress()
+		}
+		comm.Progress(params.Progress)
+	})
+	p.register(s.client)
+
+	return s
+}
+
+// endProgress stops showing progress started by Progress notifications
+func (s *session) endProgress() {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	if s.progressing {
+		s.progressing = false
+		comm.EndProgress()
+	}
+}
+
+// close lets background tasks started by requests finish, then
+// closes the database.
+func (s *session) close() {
+	s.endProgress()
+
+	_, err := messages.MetaShutdown.TestCall(s.rc, butlerd.MetaShutdownParams{})
+	if err != nil {
+		comm.Warnf("Could not shut down: %+v", err)
+	} else {
+		select {
+		case <-s.router.ShutdownChan:
+		case <-time.After(10 * time.Second):
+			comm.Warnf("Background tasks still running, exiting anyway")
+		}
+	}
+
+	s.cancel()
+	s.dbPool.Close()
+}
+
+// rpcError turns errors returned by the router back into readable ones
+func rpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var rpcErr *jsonrpc2.Error
+	if errors.As(err, &rpcErr) {
+		return errors.New(rpcErr.Message)
+	}
+	return err
+}
+
+func formatCave(cave *butlerd.Cave) string {
+	var title, upload string
+	if cave.Game != nil {
+		title = cave.Game.Title
+	}
+	if cave.Upload != nil {
+		upload = cave.Upload.DisplayName
+		if upload == "" {
+			upload = cave.Upload.Filename
+		}
+	}
+	return fmt.Sprintf("%s (%s)", title, upload)
+}
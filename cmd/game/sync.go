@@ -0,0 +1,161 @@
+package game
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/messages"
+	"github.com/itchio/butler/comm"
+	"github.com/itchio/butler/mansion"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+)
+
+var syncArgs = struct {
+	id               int64
+	bundle           bool
+	profile          int64
+	location         string
+	uploadPolicy     string
+	platform         string
+	ignoreInstallers bool
+	dryRun           bool
+	allowEmpty       bool
+}{}
+
+func RegisterSync(ctx *mansion.Context) {
+	cmd := ctx.App.Command("sync-collection", "Mirror a collection or an owned bundle to an install location (butlerd must not be running)").Hidden()
+	cmd.Arg("id", "ID of the collection, or of the bundle with --bundle").Required().Int64Var(&syncArgs.id)
+	cmd.Flag("bundle", "Mirror a bundle instead of a collection").BoolVar(&syncArgs.bundle)
+	cmd.Flag("profile", "ID of the profile to list games with, required if there's more than one").Int64Var(&syncArgs.profile)
+	cmd.Flag("location", "ID or path of the install location, required if there's more than one. Games not in the collection are uninstalled from it!").StringVar(&syncArgs.location)
+	cmd.Flag("upload-policy", "How to pick uploads for new games").Default(string(butlerd.InstallUploadPolicyBestMatch)).EnumVar(&syncArgs.uploadPolicy,
+		string(butlerd.InstallUploadPolicyBestMatch),
+		string(butlerd.InstallUploadPolicyPlatform),
+		string(butlerd.InstallUploadPolicySkipIfAmbiguous),
+	)
+	cmd.Flag("platform", "Platform to pick uploads for, with --upload-policy platform").EnumVar(&syncArgs.platform, "windows", "linux", "osx")
+	cmd.Flag("ignore-installers", "Don't run installers, extract them instead").BoolVar(&syncArgs.ignoreInstallers)
+	cmd.Flag("dry-run", "Only show what would be done").BoolVar(&syncArgs.dryRun)
+	cmd.Flag("allow-empty", "Uninstall everything if the collection or bundle has no games").BoolVar(&syncArgs.allowEmpty)
+	ctx.Register(cmd, doSync)
+}
+
+func doSync(ctx *mansion.Context) {
+	s := newSession(ctx, &policy{})
+
+	err := syncCollection(s)
+	s.close()
+	ctx.Must(rpcError(err))
+}
+
+func syncCollection(s *session) error {
+	profileID, err := resolveProfileID(s, syncArgs.profile)
+	if err != nil {
+		return err
+	}
+
+	locationID, err := resolveInstallLocation(s, syncArgs.location)
+	if err != nil {
+		return err
+	}
+
+	params := butlerd.InstallSyncParams{
+		ProfileID:         profileID,
+		InstallLocationID: locationID,
+		UploadPolicy:      butlerd.InstallUploadPolicy(syncArgs.uploadPolicy),
+		Platform:          syncArgs.platform,
+		IgnoreInstallers:  syncArgs.ignoreInstallers,
+		DryRun:            syncArgs.dryRun,
+		AllowEmpty:        syncArgs.allowEmpty,
+	}
+	if syncArgs.bundle {
+		params.BundleID = syncArgs.id
+	} else {
+		params.CollectionID = syncArgs.id
+	}
+
+	res, err := messages.InstallSync.TestCall(s.rc, params)
+	s.endProgress()
+	if err != nil {
+		return err
+	}
+
+	// queued installs and updates are performed right away, one at a time
+	for _, action := range res.Actions {
+		if action.Item == nil {
+			continue
+		}
+
+		comm.Opf("Installing %s", action.Game.Title)
+		_, err := messages.InstallPerform.TestCall(s.rc, butlerd.InstallPerformParams{
+			ID:            action.Item.ID,
+			StagingFolder: action.Item.StagingFolder,
+		})
+		s.endProgress()
+		if err != nil {
+			comm.Warnf("Could not install %s: %s", action.Game.Title, err.Error())
+			action.Status = butlerd.InstallSyncActionStatusFailed
+			action.Reason = err.Error()
+			continue
+		}
+		action.Status = butlerd.InstallSyncActionStatusDone
+		comm.Statf("Installed %s to %s", action.Game.Title, action.Item.InstallFolder)
+	}
+
+	comm.ResultOrPrint(res, func() {
+		if len(res.Actions) == 0 {
+			comm.Statf("Already in sync")
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Action", "Game", "Upload", "Status", "Reason"})
+		for _, action := range res.Actions {
+			game := ""
+			if action.Game != nil {
+				game = fmt.Sprintf("%s (#%d)", action.Game.Title, action.Game.ID)
+			}
+			upload := ""
+			if action.Upload != nil {
+				upload = action.Upload.DisplayName
+				if upload == "" {
+					upload = action.Upload.Filename
+				}
+			}
+			table.Append([]string{
+				string(action.Type),
+				game,
+				upload,
+				string(action.Status),
+				action.Reason,
+			})
+		}
+		table.Render()
+	})
+	return nil
+}
+
+// resolveProfileID checks the profile exists. If none is given, there
+// must be exactly one.
+func resolveProfileID(s *session, profileID int64) (int64, error) {
+	res, err := messages.ProfileList.TestCall(s.rc, butlerd.ProfileListParams{})
+	if err != nil {
+		return 0, err
+	}
+
+	if profileID == 0 {
+		if len(res.Profiles) != 1 {
+			return 0, errors.Errorf("there are %d profiles, pick one with --profile", len(res.Profiles))
+		}
+		return res.Profiles[0].ID, nil
+	}
+
+	for _, p := range res.Profiles {
+		if p.ID == profileID {
+			return p.ID, nil
+		}
+	}
+	return 0, errors.Errorf("no profile %d", profileID)
+}
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/messages"
+	"github.com/itchio/butler/comm"
+	"github.com/itchio/butler/mansion"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+)
+
+var uploadsArgs = struct {
+	game string
+}{}
+
+func RegisterUploads(ctx *mansion.Context) {
+	cmd := ctx.App.Command("uploads", "Show which uploads of a game would be installed, and why others wouldn't (butlerd must not be running)").Hidden()
+	cmd.Arg("game", "ID or page URL of the game").Required().StringVar(&uploadsArgs.game)
+	ctx.Register(cmd, doUploads)
+}
+
+func doUploads(ctx *mansion.Context) {
+	s := newSession(ctx, &policy{})
+
+	err := explainUploads(ctx, s)
+	s.close()
+	ctx.Must(rpcError(err))
+}
+
+func explainUploads(ctx *mansion.Context, s *session) error {
+	gameID, err := resolveGameID(ctx, uploadsArgs.game)
+	if err != nil {
+		return err
+	}
+
+	gameRes, err := messages.FetchGame.TestCall(s.rc, butlerd.FetchGameParams{
+		GameID: gameID,
+		Fresh:  true,
+	})
+	if err != nil {
+		return err
+	}
+	if gameRes.Game == nil {
+		return errors.Errorf("game %d not found", gameID)
+	}
+
+	uploadsRes, err := messages.FetchGameUploads.TestCall(s.rc, butlerd.FetchGameUploadsParams{
+		GameID: gameID,
+		Fresh:  true,
+	})
+	if err != nil {
+		return err
+	}
+	uploads := make(map[int64]*itchio.Upload)
+	for _, u := range uploadsRes.Uploads {
+		uploads[u.ID] = u
+	}
+
+	findRes, err := messages.GameFindUploads.TestCall(s.rc, butlerd.GameFindUploadsParams{
+		Game: gameRes.Game,
+	})
+	if err != nil {
+		return err
+	}
+
+	comm.ResultOrPrint(findRes.Explanations, func() {
+		comm.Opf("Uploads of %s", gameRes.Game.Title)
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Upload", "Name", "Platforms", "Rank", "Score or reason", "Hosts"})
+		for _, e := range findRes.Explanations {
+			name := ""
+			platforms := ""
+			if u, ok := uploads[e.UploadID]; ok {
+				name = u.DisplayName
+				if name == "" {
+					name = u.Filename
+				}
+				platforms = formatPlatforms(u.Platforms)
+			}
+
+			rank := "-"
+			scoreOrReason := string(e.ExcludedBecause)
+			if e.ExcludedDetail != "" {
+				scoreOrReason = fmt.Sprintf("%s: %s", e.ExcludedBecause, e.ExcludedDetail)
+			}
+			if e.Rank > 0 {
+				rank = fmt.Sprintf("%d", e.Rank)
+				var components []string
+				for _, c := range e.ScoreComponents {
+					components = append(components, fmt.Sprintf("%s %+d", c.Name, c.Points))
+				}
+				scoreOrReason = fmt.Sprintf("%d (%s)", e.Score, strings.Join(components, ", "))
+			}
+
+			table.Append([]string{
+				fmt.Sprintf("%d", e.UploadID),
+				name,
+				platforms,
+				rank,
+				scoreOrReason,
+				strings.Join(e.CompatibleHosts, ", "),
+			})
+		}
+		table.Render()
+	})
+	return nil
+}
+
+func formatPlatforms(p itchio.Platforms) string {
+	var res []string
+	if p.Windows != "" {
+		res = append(res, fmt.Sprintf("windows (%s)", p.Windows))
+	}
+	if p.Linux != "" {
+		res = append(res, fmt.Sprintf("linux (%s)", p.Linux))
+	}
+	if p.OSX != "" {
+		res = append(res, fmt.Sprintf("osx (%s)", p.OSX))
+	}
+	return strings.Join(res, ", ")
+}
@@ -0,0 +1,127 @@
+package library
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/hades"
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+	"xorm.io/builder"
+)
+
+// ArchiveVersion is bumped whenever an archive written by this version
+// of butler can't be imported by older ones.
+const ArchiveVersion = 1
+
+// Archive is everything needed to bring a library to another machine,
+// except for the games themselves.
+type Archive struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exportedAt"`
+
+	// Only exported when asked to, since they contain API keys
+	Profiles []*models.Profile `json:"profiles,omitempty"`
+
+	InstallLocations []*models.InstallLocation `json:"installLocations"`
+	CompatHosts      []*models.CompatHost      `json:"compatHosts"`
+
+	// Including their game, upload and build, so they can be imported
+	// while offline
+	Caves               []*models.Cave                   `json:"caves"`
+	PlaySessions        []*models.PlaySession            `json:"playSessions"`
+	HistoricalPlayTimes []*models.CaveHistoricalPlayTime `json:"historicalPlayTimes"`
+
+	// Downloads that haven't finished yet, their staging folders have to
+	// come along for them to be imported
+	Downloads []*models.Download `json:"downloads"`
+}
+
+type ExportParams struct {
+	Consumer *state.Consumer
+
+	// Where to write the archive
+	File string
+
+	// Whether to include logged-in profiles, with their API keys
+	IncludeCredentials bool
+}
+
+// Export writes the library stored in the database to an archive.
+func Export(conn *sqlite.Conn, params ExportParams) (*Archive, error) {
+	consumer := params.Consumer
+
+	a := &Archive{
+		Version:    ArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+	}
+	if params.IncludeCredentials {
+		a.Profiles = models.AllProfiles(conn)
+		models.MustPreload(conn, a.Profiles, hades.Assoc("User"))
+	}
+	models.MustSelect(conn, &a.InstallLocations, builder.NewCond(), hades.Search{})
+	a.CompatHosts = models.AllCompatHosts(conn)
+	models.MustSelect(conn, &a.Caves, builder.NewCond(), hades.Search{})
+	models.MustPreload(conn, a.Caves,
+		hades.Assoc("Game"),
+		hades.Assoc("Upload"),
+		hades.Assoc("Build"),
+	)
+	a.PlaySessions = models.AllPlaySessions(conn)
+	models.MustSelect(conn, &a.HistoricalPlayTimes, builder.NewCond(), hades.Search{})
+	models.MustSelect(conn, &a.Downloads,
+		builder.And(
+			builder.IsNull{"finished_at"},
+			builder.Not{builder.Expr("discarded")},
+		),
+		hades.Search{}.OrderBy("position ASC"),
+	)
+	models.PreloadDownloads(conn, a.Downloads)
+
+	contents, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(params.File), 0o755)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// only readable by the user if it has API keys in it
+	perm := os.FileMode(0o644)
+	if len(a.Profiles) > 0 {
+		perm = 0o600
+	}
+	err = ioutil.WriteFile(params.File, contents, perm)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	consumer.Statf("Exported %d caves, %d install locations and %d downloads to (%s)",
+		len(a.Caves), len(a.InstallLocations), len(a.Downloads), params.File)
+	return a, nil
+}
+
+// ReadArchive reads an archive written by Export.
+func ReadArchive(file string) (*Archive, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var a Archive
+	err = json.Unmarshal(contents, &a)
+	if err != nil {
+		return nil, errors.WithMessage(err, "parsing library archive")
+	}
+	if a.Version > ArchiveVersion {
+		return nil, errors.Errorf("library archive is version %d, this version of butler only supports up to %d", a.Version, ArchiveVersion)
+	}
+	return &a, nil
+}
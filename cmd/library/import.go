@@ -0,0 +1,331 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"crawshaw.io/sqlite"
+	"crawshaw.io/sqlite/sqlitex"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/manager/installscan"
+	"github.com/itchio/hades"
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+	"xorm.io/builder"
+)
+
+// PathRemap replaces a path prefix with another, for when drives or
+// home folders aren't at the same place on the new machine.
+type PathRemap struct {
+	From string
+	To   string
+}
+
+type ImportParams struct {
+	Consumer *state.Consumer
+
+	Archive *Archive
+
+	// Longest matching prefix wins
+	Remaps []PathRemap
+}
+
+type ImportResult struct {
+	ImportedInstallLocations int64 `json:"importedInstallLocations"`
+	ImportedProfiles         int64 `json:"importedProfiles"`
+	ImportedCaves            int64 `json:"importedCaves"`
+	// Caves that were already known, for example from a previous scan
+	// of their install location, and got their settings and play time
+	// from the archive
+	MergedCaves       int64 `json:"mergedCaves"`
+	ImportedDownloads int64 `json:"importedDownloads"`
+
+	Skipped []*ImportSkip `json:"skipped"`
+}
+
+// ImportSkip is something from the archive that wasn't imported.
+type ImportSkip struct {
+	// "installLocation", "cave" or "download"
+	Kind string `json:"kind"`
+	// ID of the skipped record
+	ID string `json:"id"`
+	// Human-readable reason
+	Reason string `json:"reason"`
+}
+
+type importer struct {
+	conn   *sqlite.Conn
+	params ImportParams
+	res    *ImportResult
+
+	// archived install location ID => local install location
+	locations map[string]*models.InstallLocation
+	// archived cave ID => local cave ID
+	caveIDs map[string]string
+}
+
+// Import merges an archive into the database. Caves are only imported if
+// their install folder is there, and matches their receipt. Nothing that
+// already exists locally is overwritten, except for merged caves.
+func Import(conn *sqlite.Conn, params ImportParams) (res *ImportResult, retErr error) {
+	im := &importer{
+		conn:   conn,
+		params: params,
+		res: &ImportResult{
+			Skipped: []*ImportSkip{},
+		},
+		locations: make(map[string]*models.InstallLocation),
+		caveIDs:   make(map[string]string),
+	}
+
+	defer sqlitex.Save(conn)(&retErr)
+	defer func() {
+		if r := recover(); r != nil {
+			if rErr, ok := r.(error); ok {
+				retErr = errors.WithStack(rErr)
+			} else {
+				retErr = errors.Errorf("%v", r)
+			}
+		}
+	}()
+
+	im.importProfiles()
+	im.importCompatHosts()
+	im.importInstallLocations()
+	im.importCaves()
+	im.importPlayTime()
+	im.importDownloads()
+
+	consumer := params.Consumer
+	consumer.Statf("Imported %d caves (%d merged), %d install locations, %d downloads",
+		im.res.ImportedCaves, im.res.MergedCaves, im.res.ImportedInstallLocations, im.res.ImportedDownloads)
+	for _, skip := range im.res.Skipped {
+		consumer.Warnf("Skipped %s (%s): %s", skip.Kind, skip.ID, skip.Reason)
+	}
+	return im.res, nil
+}
+
+func (im *importer) skip(kind string, id string, reason string) {
+	im.res.Skipped = append(im.res.Skipped, &ImportSkip{
+		Kind:   kind,
+		ID:     id,
+		Reason: reason,
+	})
+}
+
+// remap applies the longest matching remap to a path.
+func (im *importer) remap(path string) string {
+	remaps := append([]PathRemap{}, im.params.Remaps...)
+	sort.Slice(remaps, func(i, j int) bool {
+		return len(remaps[i].From) > len(remaps[j].From)
+	})
+
+	for _, r := range remaps {
+		from := filepath.Clean(r.From)
+		if path == from {
+			return filepath.Clean(r.To)
+		}
+		prefix := from
+		if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+			prefix += string(filepath.Separator)
+		}
+		if strings.HasPrefix(path, prefix) {
+			return filepath.Join(r.To, strings.TrimPrefix(path, prefix))
+		}
+	}
+	return path
+}
+
+func (im *importer) importProfiles() {
+	for _, p := range im.params.Archive.Profiles {
+		if models.ProfileByID(im.conn, p.ID) != nil {
+			continue
+		}
+		p.Save(im.conn)
+		im.res.ImportedProfiles++
+	}
+}
+
+func (im *importer) importCompatHosts() {
+	for _, ch := range im.params.Archive.CompatHosts {
+		if models.CompatHostByID(im.conn, ch.ID) != nil {
+			continue
+		}
+		ch.Save(im.conn)
+	}
+}
+
+func (im *importer) importInstallLocations() {
+	var existing []*models.InstallLocation
+	models.MustSelect(im.conn, &existing, builder.NewCond(), hades.Search{})
+
+	for _, ail := range im.params.Archive.InstallLocations {
+		path := im.remap(ail.Path)
+
+		var local *models.InstallLocation
+		for _, il := range existing {
+			if il.ID == ail.ID || filepath.Clean(il.Path) == path {
+				local = il
+				break
+			}
+		}
+
+		if local == nil {
+			stats, err := os.Stat(path)
+			if err != nil || !stats.IsDir() {
+				im.skip("installLocation", ail.ID, "folder not found: "+path)
+				continue
+			}
+			local = &models.InstallLocation{
+				ID:           ail.ID,
+				Path:         path,
+				QuotaSize:    ail.QuotaSize,
+				ReservedSize: ail.ReservedSize,
+			}
+			models.MustSave(im.conn, local)
+			existing = append(existing, local)
+			im.res.ImportedInstallLocations++
+		} else if filepath.Clean(local.Path) != path {
+			im.skip("installLocation", ail.ID, "already exists locally with path "+local.Path)
+			continue
+		}
+		im.locations[ail.ID] = local
+	}
+}
+
+func (im *importer) importCaves() {
+	for _, ac := range im.params.Archive.Caves {
+		if models.CaveByID(im.conn, ac.ID) != nil {
+			im.caveIDs[ac.ID] = ac.ID
+			continue
+		}
+
+		var installFolder string
+		if ac.CustomInstallFolder != "" {
+			ac.CustomInstallFolder = im.remap(ac.CustomInstallFolder)
+			installFolder = ac.CustomInstallFolder
+		} else {
+			il, ok := im.locations[ac.InstallLocationID]
+			if !ok {
+				im.skip("cave", ac.ID, "its install location wasn't imported")
+				continue
+			}
+			ac.InstallLocationID = il.ID
+			installFolder = il.GetInstallFolder(ac.InstallFolderName)
+		}
+
+		err := installscan.Verify(im.params.Consumer, installFolder, ac.UploadID, ac.BuildID)
+		if err != nil {
+			im.skip("cave", ac.ID, err.Error())
+			continue
+		}
+
+		// a scan of the install location may have found it already
+		var scanned models.Cave
+		found := models.MustSelectOne(im.conn, &scanned, builder.Eq{
+			"install_location_id":   ac.InstallLocationID,
+			"install_folder_name":   ac.InstallFolderName,
+			"custom_install_folder": ac.CustomInstallFolder,
+		})
+		if found {
+			mergeCave(&scanned, ac)
+			scanned.Save(im.conn)
+			im.caveIDs[ac.ID] = scanned.ID
+			im.res.MergedCaves++
+			continue
+		}
+
+		ac.InstallLocation = nil
+		ac.SaveWithAssocs(im.conn)
+		im.caveIDs[ac.ID] = ac.ID
+		im.res.ImportedCaves++
+	}
+}
+
+// mergeCave brings the user's data from an archived cave to a local one.
+func mergeCave(local *models.Cave, archived *models.Cave) {
+	local.Pinned = archived.Pinned
+	local.Settings = archived.Settings
+	if archived.SecondsRun > local.SecondsRun {
+		local.SecondsRun = archived.SecondsRun
+	}
+	if archived.LocalSecondsRun > local.LocalSecondsRun {
+		local.LocalSecondsRun = archived.LocalSecondsRun
+	}
+	if archived.LastTouchedAt != nil && (local.LastTouchedAt == nil || archived.LastTouchedAt.After(*local.LastTouchedAt)) {
+		local.LastTouchedAt = archived.LastTouchedAt
+	}
+	if archived.LocalLastRunAt != nil && (local.LocalLastRunAt == nil || archived.LocalLastRunAt.After(*local.LocalLastRunAt)) {
+		local.LocalLastRunAt = archived.LocalLastRunAt
+	}
+	if archived.InstalledAt != nil {
+		local.InstalledAt = archived.InstalledAt
+	}
+}
+
+func (im *importer) importPlayTime() {
+	for _, ps := range im.params.Archive.PlaySessions {
+		caveID, ok := im.caveIDs[ps.CaveID]
+		if !ok || models.PlaySessionByID(im.conn, ps.ID) != nil {
+			continue
+		}
+		ps.CaveID = caveID
+		models.MustSave(im.conn, ps)
+	}
+
+	for _, chpt := range im.params.Archive.HistoricalPlayTimes {
+		caveID, ok := im.caveIDs[chpt.CaveID]
+		if !ok {
+			continue
+		}
+		if models.MustSelectOne(im.conn, &models.CaveHistoricalPlayTime{}, builder.Eq{"cave_id": caveID}) {
+			continue
+		}
+		chpt.CaveID = caveID
+		models.MustSave(im.conn, chpt)
+	}
+}
+
+func (im *importer) importDownloads() {
+	for _, ad := range im.params.Archive.Downloads {
+		if models.DownloadByID(im.conn, ad.ID) != nil {
+			continue
+		}
+
+		if ad.CaveID != "" {
+			caveID, ok := im.caveIDs[ad.CaveID]
+			if !ok && !ad.Fresh {
+				im.skip("download", ad.ID, "its cave wasn't imported")
+				continue
+			}
+			if ok {
+				ad.CaveID = caveID
+			}
+		}
+
+		il, ok := im.locations[ad.InstallLocationID]
+		if !ok {
+			im.skip("download", ad.ID, "its install location wasn't imported")
+			continue
+		}
+		ad.InstallLocationID = il.ID
+		ad.StagingFolder = im.remap(ad.StagingFolder)
+		ad.InstallFolder = im.remap(ad.InstallFolder)
+
+		// the staging folder holds what's needed to resume it
+		if _, err := os.Stat(ad.StagingFolder); err != nil {
+			im.skip("download", ad.ID, "staging folder not found, it needs to be queued again: "+ad.StagingFolder)
+			continue
+		}
+
+		ad.Position = models.DownloadMaxPosition(im.conn) + 1
+		models.MustSave(im.conn, ad,
+			hades.Assoc("Game"),
+			hades.Assoc("Upload"),
+			hades.Assoc("Build"),
+		)
+		im.res.ImportedDownloads++
+	}
+}
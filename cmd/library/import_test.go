@@ -0,0 +1,135 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/database/models"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/hush/bfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func importTestConn(t *testing.T) *sqlite.Conn {
+	conn, err := sqlite.OpenConn("file::memory:?mode=memory", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, models.HadesContext().AutoMigrate(conn))
+	return conn
+}
+
+func TestRemap(t *testing.T) {
+	im := &importer{
+		params: ImportParams{
+			Remaps: []PathRemap{
+				{From: "/old/games", To: "/new/games"},
+				{From: "/old/games/big", To: "/mnt/big"},
+				{From: "/old/home/", To: "/home/someone"},
+			},
+		},
+	}
+
+	assert.EqualValues(t, "/new/games/alpha", im.remap("/old/games/alpha"))
+	assert.EqualValues(t, "/new/games", im.remap("/old/games"))
+	assert.EqualValues(t, "/mnt/big/beta", im.remap("/old/games/big/beta"), "longest prefix wins")
+	assert.EqualValues(t, "/home/someone/saves", im.remap("/old/home/saves"), "trailing separators are fine")
+	assert.EqualValues(t, "/old/games2/gamma", im.remap("/old/games2/gamma"), "prefixes only match whole folders")
+	assert.EqualValues(t, "/elsewhere/delta", im.remap("/elsewhere/delta"))
+}
+
+// installGame writes an install folder with a receipt for the given upload
+func installGame(t *testing.T, installFolder string, uploadID int64, files ...string) {
+	for _, file := range files {
+		path := filepath.Join(installFolder, filepath.FromSlash(file))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(file), 0o644))
+	}
+	receipt := &bfs.Receipt{
+		Game:   &itchio.Game{ID: 1},
+		Upload: &itchio.Upload{ID: uploadID},
+		Files:  files,
+	}
+	require.NoError(t, receipt.WriteReceipt(installFolder))
+}
+
+func TestImportVerifiesInstallFolders(t *testing.T) {
+	conn := importTestConn(t)
+	newPath := t.TempDir()
+
+	installGame(t, filepath.Join(newPath, "good"), 10, "game.exe")
+	installGame(t, filepath.Join(newPath, "other-upload"), 99, "game.exe")
+	installGame(t, filepath.Join(newPath, "incomplete"), 30, "game.exe", "data/level1.dat")
+	require.NoError(t, os.Remove(filepath.Join(newPath, "incomplete", "data", "level1.dat")))
+
+	game := &itchio.Game{ID: 1, Title: "Alpha"}
+	archive := &Archive{
+		Version: ArchiveVersion,
+		InstallLocations: []*models.InstallLocation{
+			{ID: "il", Path: "/old/games"},
+		},
+		Caves: []*models.Cave{
+			{ID: "good", GameID: 1, Game: game, UploadID: 10, Upload: &itchio.Upload{ID: 10}, InstallLocationID: "il", InstallFolderName: "good", SecondsRun: 60},
+			{ID: "other-upload", GameID: 1, Game: game, UploadID: 20, Upload: &itchio.Upload{ID: 20}, InstallLocationID: "il", InstallFolderName: "other-upload"},
+			{ID: "incomplete", GameID: 1, Game: game, UploadID: 30, Upload: &itchio.Upload{ID: 30}, InstallLocationID: "il", InstallFolderName: "incomplete"},
+			{ID: "gone", GameID: 1, Game: game, UploadID: 40, Upload: &itchio.Upload{ID: 40}, InstallLocationID: "il", InstallFolderName: "gone"},
+		},
+	}
+
+	res, err := Import(conn, ImportParams{
+		Consumer: &state.Consumer{},
+		Archive:  archive,
+		Remaps:   []PathRemap{{From: "/old/games", To: newPath}},
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, res.ImportedInstallLocations)
+	assert.EqualValues(t, 1, res.ImportedCaves)
+
+	skipped := make(map[string]string)
+	for _, skip := range res.Skipped {
+		skipped[skip.ID] = skip.Reason
+	}
+	assert.Contains(t, skipped["other-upload"], "different upload")
+	assert.Contains(t, skipped["incomplete"], "is missing data/level1.dat")
+	assert.Contains(t, skipped["gone"], "no receipt")
+
+	assert.EqualValues(t, newPath, models.InstallLocationByID(conn, "il").Path)
+	cave := models.CaveByID(conn, "good")
+	require.NotNil(t, cave)
+	assert.EqualValues(t, 60, cave.SecondsRun)
+	assert.Nil(t, models.CaveByID(conn, "incomplete"))
+}
+
+func TestImportMergesScannedCaves(t *testing.T) {
+	conn := importTestConn(t)
+	path := t.TempDir()
+	installGame(t, filepath.Join(path, "alpha"), 10, "game.exe")
+
+	// as InstallLocations.Scan would have found it
+	models.MustSave(conn, &models.InstallLocation{ID: "il", Path: path})
+	models.MustSave(conn, &models.Cave{ID: "scanned", GameID: 1, UploadID: 10, InstallLocationID: "il", InstallFolderName: "alpha", SecondsRun: 10})
+
+	archive := &Archive{
+		Version:          ArchiveVersion,
+		InstallLocations: []*models.InstallLocation{{ID: "il", Path: path}},
+		Caves: []*models.Cave{
+			{ID: "archived", GameID: 1, Game: &itchio.Game{ID: 1}, UploadID: 10, Upload: &itchio.Upload{ID: 10}, InstallLocationID: "il", InstallFolderName: "alpha", SecondsRun: 3600, Pinned: true},
+		},
+	}
+
+	res, err := Import(conn, ImportParams{
+		Consumer: &state.Consumer{},
+		Archive:  archive,
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, res.ImportedCaves)
+	assert.EqualValues(t, 1, res.MergedCaves)
+
+	cave := models.CaveByID(conn, "scanned")
+	assert.EqualValues(t, 3600, cave.SecondsRun)
+	assert.True(t, cave.Pinned)
+	assert.Nil(t, models.CaveByID(conn, "archived"))
+}
@@ -0,0 +1,96 @@
+// Exports a library (caves, install locations, settings, play time and
+// pending downloads) to a file, and imports it on another machine. Used by
+// butlerd's Library.Export and Library.Import, and available as commands
+// that work directly on butlerd's database.
+package library
+
+import (
+	"context"
+	"strings"
+
+	"crawshaw.io/sqlite/sqlitex"
+	"github.com/itchio/butler/comm"
+	"github.com/itchio/butler/database"
+	"github.com/itchio/butler/mansion"
+	"github.com/pkg/errors"
+)
+
+var exportArgs = struct {
+	file               string
+	includeCredentials bool
+}{}
+
+var importArgs = struct {
+	file   string
+	remaps []string
+}{}
+
+func Register(ctx *mansion.Context) {
+	parentCmd := ctx.App.Command("library", "Export or import a library, to move it to another machine").Hidden()
+
+	{
+		cmd := parentCmd.Command("export", "Export caves, install locations, settings and play time to a file (butlerd must not be running)")
+		cmd.Arg("file", "Path of the file to write").Required().StringVar(&exportArgs.file)
+		cmd.Flag("include-credentials", "Also export logged-in profiles, with their API keys").BoolVar(&exportArgs.includeCredentials)
+		ctx.Register(cmd, doExport)
+	}
+
+	{
+		cmd := parentCmd.Command("import", "Import a library exported with 'library export' (butlerd must not be running)")
+		cmd.Arg("file", "Path of the file to read").Required().StringVar(&importArgs.file)
+		cmd.Flag("remap", "Replace a path prefix, as in 'D:\\Games=E:\\Games' (repeatable)").StringsVar(&importArgs.remaps)
+		ctx.Register(cmd, doImport)
+	}
+}
+
+// openDB opens butlerd's database, creating and migrating it if needed
+func openDB(ctx *mansion.Context) *sqlitex.Pool {
+	ctx.EnsureDBPath()
+	dbPool, err := database.OpenPool(comm.NewStateConsumer(), ctx.DBPath)
+	ctx.Must(errors.WithMessagef(err, "opening butlerd db at %s", ctx.DBPath))
+	return dbPool
+}
+
+func doExport(ctx *mansion.Context) {
+	dbPool := openDB(ctx)
+	defer dbPool.Close()
+	conn := dbPool.Get(context.Background())
+	defer dbPool.Put(conn)
+
+	_, err := Export(conn, ExportParams{
+		Consumer:           comm.NewStateConsumer(),
+		File:               exportArgs.file,
+		IncludeCredentials: exportArgs.includeCredentials,
+	})
+	ctx.Must(err)
+}
+
+func doImport(ctx *mansion.Context) {
+	var remaps []PathRemap
+	for _, s := range importArgs.remaps {
+		from, to, ok := strings.Cut(s, "=")
+		if !ok || from == "" || to == "" {
+			ctx.Must(errors.Errorf("invalid remap (%s), expected FROM=TO", s))
+		}
+		remaps = append(remaps, PathRemap{From: from, To: to})
+	}
+
+	archive, err := ReadArchive(importArgs.file)
+	ctx.Must(err)
+
+	dbPool := openDB(ctx)
+	defer dbPool.Close()
+	conn := dbPool.Get(context.Background())
+	defer dbPool.Put(conn)
+
+	res, err := Import(conn, ImportParams{
+		Consumer: comm.NewStateConsumer(),
+		Archive:  archive,
+		Remaps:   remaps,
+	})
+	ctx.Must(err)
+
+	comm.ResultOrPrint(res, func() {
+		comm.Statf("Imported %d caves, merged %d, skipped %d things", res.ImportedCaves, res.MergedCaves, len(res.Skipped))
+	})
+}
@@ -0,0 +1,209 @@
+package movecaves
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/itchio/headway/state"
+	"github.com/itchio/hush/bfs"
+	"github.com/pkg/errors"
+)
+
+const copyChunkSize = 4 * 1024 * 1024
+
+// moveFolder renames src to dst if they're on the same device, otherwise
+// it copies it, then verifies the copy. src is never modified, and dst is
+// removed if anything goes wrong, including cancellation.
+func moveFolder(ctx context.Context, consumer *state.Consumer, src string, dst string, onProgress func(copied int64)) (renamed bool, err error) {
+	err = os.MkdirAll(filepath.Dir(dst), 0o755)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	err = os.Rename(src, dst)
+	if err == nil {
+		return true, nil
+	}
+	consumer.Debugf("Can't rename (%v), copying instead", err)
+
+	return false, copyVerified(ctx, consumer, src, dst, onProgress)
+}
+
+// copyVerified copies src to dst then verifies the copy. If anything
+// goes wrong, including cancellation, dst is removed.
+func copyVerified(ctx context.Context, consumer *state.Consumer, src string, dst string, onProgress func(copied int64)) error {
+	err := func() error {
+		copied, err := copyFolder(ctx, src, dst, onProgress)
+		if err != nil {
+			return err
+		}
+		return verifyCopy(consumer, src, dst, copied)
+	}()
+	if err != nil {
+		consumer.Warnf("Rolling back, removing (%s)", dst)
+		if rmErr := os.RemoveAll(dst); rmErr != nil {
+			consumer.Warnf("Could not remove (%s): %v", dst, rmErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// copiedFile is a regular file copyFolder copied, along with the
+// hash of what it read from the source.
+type copiedFile struct {
+	// relative to src, slash-separated
+	path string
+	size int64
+	hash []byte
+}
+
+// copyFolder copies src to dst, preserving symlinks and permissions.
+// It returns the regular files it copied.
+func copyFolder(ctx context.Context, src string, dst string, onProgress func(copied int64)) ([]copiedFile, error) {
+	var copied []copiedFile
+	var copiedSize int64
+
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := checkCancelled(ctx); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if rel == filepath.Join(".itch", "runlock.json") {
+			return nil
+		}
+		dstPath := filepath.Join(dst, rel)
+
+		mode := info.Mode()
+		switch {
+		case mode.IsDir():
+			return errors.WithStack(os.MkdirAll(dstPath, mode.Perm()|0o700))
+		case mode&os.ModeSymlink != 0:
+			linkname, err := os.Readlink(path)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			return errors.WithStack(os.Symlink(linkname, dstPath))
+		case mode.IsRegular():
+			hash, err := copyFile(ctx, path, dstPath, mode.Perm(), func(n int64) {
+				copiedSize += n
+				onProgress(copiedSize)
+			})
+			if err != nil {
+				return err
+			}
+			copied = append(copied, copiedFile{
+				path: filepath.ToSlash(rel),
+				size: info.Size(),
+				hash: hash,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
+// copyFile copies src to dst and returns the hash of what it read.
+func copyFile(ctx context.Context, src string, dst string, perm os.FileMode, onCopied func(n int64)) ([]byte, error) {
+	reader, err := os.Open(src)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer reader.Close()
+
+	writer, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer writer.Close()
+
+	hasher := sha256.New()
+	for {
+		if err := checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+
+		n, err := io.CopyN(io.MultiWriter(writer, hasher), reader, copyChunkSize)
+		onCopied(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	err = writer.Close()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return hasher.Sum(nil), nil
+}
+
+// verifyCopy reads back every copied file from dst and checks it has the
+// contents that were read from src. It also checks that every file of the
+// install's receipt that's still in src made it to dst.
+func verifyCopy(consumer *state.Consumer, src string, dst string, copied []copiedFile) error {
+	for _, file := range copied {
+		dstPath := filepath.Join(dst, filepath.FromSlash(file.path))
+		stats, err := os.Lstat(dstPath)
+		if err != nil {
+			return errors.Errorf("verifying copy: (%s) is missing", file.path)
+		}
+		if stats.Size() != file.size {
+			return errors.Errorf("verifying copy: (%s) should be %d bytes, is %d", file.path, file.size, stats.Size())
+		}
+		hash, err := hashFile(dstPath)
+		if err != nil {
+			return errors.WithMessage(err, "verifying copy")
+		}
+		if !bytes.Equal(hash, file.hash) {
+			return errors.Errorf("verifying copy: (%s) differs from the original", file.path)
+		}
+	}
+
+	receipt, err := bfs.ReadReceipt(src)
+	if err != nil {
+		consumer.Warnf("Could not read receipt, verifying copied files only: %v", err)
+	} else if receipt != nil {
+		for _, file := range receipt.Files {
+			if _, err := os.Lstat(filepath.Join(src, filepath.FromSlash(file))); err != nil {
+				// receipts may list files the game deleted since
+				continue
+			}
+			if _, err := os.Lstat(filepath.Join(dst, filepath.FromSlash(file))); err != nil {
+				return errors.Errorf("verifying copy: (%s) is missing", file)
+			}
+		}
+	}
+	consumer.Infof("Verified %d files", len(copied))
+	return nil
+}
+
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	_, err = io.Copy(hasher, f)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return hasher.Sum(nil), nil
+}
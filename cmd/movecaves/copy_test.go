@@ -0,0 +1,100 @@
+package movecaves
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/itchio/headway/state"
+	"github.com/itchio/wharf/werrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path string, contents string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+}
+
+// makeInstallFolder returns a folder with a few files, a symlink and a
+// run lock, and the total size of its regular files
+func makeInstallFolder(t *testing.T) (string, int64) {
+	src := filepath.Join(t.TempDir(), "game")
+	writeTestFile(t, filepath.Join(src, "game.exe"), "MZ game")
+	writeTestFile(t, filepath.Join(src, "data", "level1.dat"), "level one")
+	writeTestFile(t, filepath.Join(src, ".itch", "runlock.json"), "{}")
+	require.NoError(t, os.Symlink("data/level1.dat", filepath.Join(src, "current.dat")))
+	return src, int64(len("MZ game") + len("level one") + len("{}"))
+}
+
+func TestCopyVerified(t *testing.T) {
+	src, size := makeInstallFolder(t)
+	dst := filepath.Join(t.TempDir(), "moved")
+
+	var progress int64
+	err := copyVerified(context.Background(), &state.Consumer{}, src, dst, func(copied int64) {
+		progress = copied
+	})
+	require.NoError(t, err)
+
+	contents, err := os.ReadFile(filepath.Join(dst, "data", "level1.dat"))
+	require.NoError(t, err)
+	assert.EqualValues(t, "level one", string(contents))
+
+	linkname, err := os.Readlink(filepath.Join(dst, "current.dat"))
+	require.NoError(t, err)
+	assert.EqualValues(t, "data/level1.dat", linkname)
+
+	_, err = os.Stat(filepath.Join(dst, ".itch", "runlock.json"))
+	assert.True(t, os.IsNotExist(err), "the run lock shouldn't be copied")
+	assert.EqualValues(t, size-int64(len("{}")), progress)
+
+	// the source is left alone
+	_, err = os.Stat(filepath.Join(src, "game.exe"))
+	assert.NoError(t, err)
+}
+
+func TestCopyVerifiedRollsBackWhenCancelled(t *testing.T) {
+	src, _ := makeInstallFolder(t)
+	dst := filepath.Join(t.TempDir(), "moved")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := copyVerified(ctx, &state.Consumer{}, src, dst, func(int64) {})
+	assert.EqualValues(t, werrors.ErrCancelled, err)
+
+	_, err = os.Stat(dst)
+	assert.True(t, os.IsNotExist(err), "the partial copy should be removed")
+	_, err = os.Stat(filepath.Join(src, "data", "level1.dat"))
+	assert.NoError(t, err)
+}
+
+func TestVerifyCopy(t *testing.T) {
+	consumer := &state.Consumer{}
+	src, _ := makeInstallFolder(t)
+	dst := filepath.Join(t.TempDir(), "moved")
+
+	copied, err := copyFolder(context.Background(), src, dst, func(int64) {})
+	require.NoError(t, err)
+	assert.NoError(t, verifyCopy(consumer, src, dst, copied))
+
+	// same size, different contents
+	writeTestFile(t, filepath.Join(dst, "data", "level1.dat"), "level two")
+	err = verifyCopy(consumer, src, dst, copied)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "differs from the original")
+	}
+
+	writeTestFile(t, filepath.Join(dst, "data", "level1.dat"), "level 1")
+	err = verifyCopy(consumer, src, dst, copied)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "should be 9 bytes, is 7")
+	}
+
+	require.NoError(t, os.Remove(filepath.Join(dst, "game.exe")))
+	err = verifyCopy(consumer, src, dst, copied)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "is missing")
+	}
+}
@@ -0,0 +1,287 @@
+// Moves installed games (caves) from one install location to another,
+// for example when a drive fills up. Used by butlerd's
+// InstallLocations.MoveCaves, and available as a command that works
+// directly on butlerd's database, for when the app isn't running.
+package movecaves
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/cmd/wipe"
+	"github.com/itchio/butler/comm"
+	"github.com/itchio/butler/database"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/manager/runlock"
+	"github.com/itchio/butler/mansion"
+	"github.com/itchio/butler/shortcut"
+	"github.com/itchio/butler/walkutil"
+	"github.com/itchio/hades"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/headway/united"
+	"github.com/itchio/wharf/werrors"
+	"github.com/pkg/errors"
+	"xorm.io/builder"
+)
+
+var args = struct {
+	installLocationID string
+	caveIDs           []string
+}{}
+
+func Register(ctx *mansion.Context) {
+	cmd := ctx.App.Command("move-caves", "Move installed games to another install location (butlerd must not be running)").Hidden()
+	cmd.Arg("install-location", "ID of the install location to move the caves to").Required().StringVar(&args.installLocationID)
+	cmd.Arg("caves", "IDs of the caves to move").Required().StringsVar(&args.caveIDs)
+	ctx.Register(cmd, do)
+}
+
+func do(ctx *mansion.Context) {
+	ctx.EnsureDBPath()
+
+	consumer := comm.NewStateConsumer()
+	dbPool, err := database.OpenPool(consumer, ctx.DBPath)
+	ctx.Must(errors.WithMessagef(err, "opening butlerd db at %s", ctx.DBPath))
+	defer dbPool.Close()
+
+	cctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	comm.StartProgress()
+	res, err := Do(Params{
+		Ctx:      cctx,
+		Consumer: consumer,
+		WithConn: func(f func(conn *sqlite.Conn)) {
+			// not cctx: caves that were moved must be updated even
+			// when interrupted
+			conn := dbPool.Get(context.Background())
+			defer dbPool.Put(conn)
+			f(conn)
+		},
+		CaveIDs:           args.caveIDs,
+		InstallLocationID: args.installLocationID,
+	})
+	comm.EndProgress()
+	ctx.Must(err)
+
+	comm.ResultOrPrint(res, func() {
+		comm.Statf("Moved %d caves", len(res.MovedCaveIDs))
+	})
+}
+
+type Params struct {
+	Ctx      context.Context
+	Consumer *state.Consumer
+
+	// Runs f with a connection to butlerd's database
+	WithConn func(f func(conn *sqlite.Conn))
+
+	CaveIDs []string
+
+	// ID of the install location to move caves to
+	InstallLocationID string
+
+	// Called whenever a cave has been moved, optional
+	OnCaveMoved func(caveID string)
+}
+
+type Result struct {
+	MovedCaveIDs []string `json:"movedCaveIds"`
+}
+
+type caveMove struct {
+	cave *models.Cave
+
+	// compat prefix, if any
+	srcPrefix string
+
+	src  string
+	dst  string
+	size int64
+}
+
+// Do moves caves one by one. If it's cancelled or fails, the cave being
+// moved is left untouched in its original location, but the ones that
+// were moved before stay in their new location.
+func Do(params Params) (*Result, error) {
+	consumer := params.Consumer
+
+	var il *models.InstallLocation
+	var moves []*caveMove
+	var err error
+	params.WithConn(func(conn *sqlite.Conn) {
+		il = models.InstallLocationByID(conn, params.InstallLocationID)
+		if il == nil {
+			err = errors.Errorf("install location (%s) not found", params.InstallLocationID)
+			return
+		}
+		moves, err = planMoves(conn, il, params.CaveIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var totalSize int64
+	for _, m := range moves {
+		totalSize += m.size
+	}
+	consumer.Infof("Moving %d caves (%s) to (%s)", len(moves), united.FormatBytes(totalSize), il.Path)
+
+	res := &Result{
+		MovedCaveIDs: []string{},
+	}
+	var doneSize int64
+	for _, m := range moves {
+		onProgress := func(copied int64) {
+			if totalSize > 0 {
+				consumer.Progress(float64(doneSize+copied) / float64(totalSize))
+			}
+		}
+
+		err := moveCave(params, il, m, onProgress)
+		if err != nil {
+			return nil, err
+		}
+		doneSize += m.size
+		res.MovedCaveIDs = append(res.MovedCaveIDs, m.cave.ID)
+		if params.OnCaveMoved != nil {
+			params.OnCaveMoved(m.cave.ID)
+		}
+	}
+	return res, nil
+}
+
+func planMoves(conn *sqlite.Conn, il *models.InstallLocation, caveIDs []string) ([]*caveMove, error) {
+	var moves []*caveMove
+	dsts := make(map[string]bool)
+	for _, caveID := range caveIDs {
+		cave := models.CaveByID(conn, caveID)
+		if cave == nil {
+			return nil, errors.Errorf("cave (%s) not found", caveID)
+		}
+		if cave.InstallLocationID == il.ID {
+			continue
+		}
+
+		downloadsCount := models.MustCount(conn, &models.Download{}, builder.And(
+			builder.IsNull{"finished_at"},
+			builder.Eq{"cave_id": cave.ID},
+		))
+		if downloadsCount > 0 {
+			return nil, errors.Errorf("cave (%s) has a download in progress", cave.ID)
+		}
+
+		models.PreloadCaves(conn, cave)
+		m := &caveMove{
+			cave: cave,
+			src:  cave.GetInstallFolder(conn),
+		}
+		if cave.CustomInstallFolder == "" {
+			m.srcPrefix = cave.GetCompatPrefixFolder(conn)
+		}
+		if cave.InstallFolderName == "" {
+			cave.InstallFolderName = filepath.Base(m.src)
+		}
+		m.dst = il.GetInstallFolder(cave.InstallFolderName)
+
+		if dsts[m.dst] {
+			return nil, errors.Errorf("cave (%s) would be moved to (%s), along with another cave", cave.ID, m.dst)
+		}
+		dsts[m.dst] = true
+		if _, err := os.Stat(m.dst); err == nil {
+			return nil, errors.Errorf("cave (%s) can't be moved: (%s) already exists", cave.ID, m.dst)
+		}
+		var conflicts []*models.Cave
+		models.MustSelect(conn, &conflicts, builder.Eq{
+			"install_location_id": il.ID,
+			"install_folder_name": cave.InstallFolderName,
+		}, hades.Search{})
+		if len(conflicts) > 0 {
+			return nil, errors.Errorf("cave (%s) can't be moved: cave (%s) is installed in (%s)", cave.ID, conflicts[0].ID, m.dst)
+		}
+
+		size, err := walkutil.FolderSize(m.src)
+		if err != nil {
+			return nil, errors.Errorf("cave (%s) can't be moved: %v", cave.ID, err)
+		}
+		m.size = size
+		moves = append(moves, m)
+	}
+	return moves, nil
+}
+
+func moveCave(params Params, il *models.InstallLocation, m *caveMove, onProgress func(copied int64)) error {
+	consumer := params.Consumer
+
+	rlock := runlock.New(consumer, m.src)
+	err := rlock.Lock(params.Ctx, "move")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer rlock.Unlock()
+
+	consumer.Infof("Moving cave (%s) from (%s) to (%s)", m.cave.ID, m.src, m.dst)
+	renamed, err := moveFolder(params.Ctx, consumer, m.src, m.dst, onProgress)
+	if err != nil {
+		return err
+	}
+	if renamed {
+		// the lock came along
+		runlock.ForceUnlock(m.dst)
+	}
+
+	if m.srcPrefix != "" {
+		if _, err := os.Stat(m.srcPrefix); err == nil {
+			dstPrefix := il.GetCompatPrefixFolder(m.cave.ID)
+			consumer.Infof("Moving wine prefix to (%s)", dstPrefix)
+			// prefixes get re-created on launch, so they're not worth
+			// failing over
+			_, err := moveFolder(context.Background(), consumer, m.srcPrefix, dstPrefix, func(int64) {})
+			if err != nil {
+				consumer.Warnf("Could not move wine prefix, a fresh one will be created: %v", err)
+			} else {
+				wipeSource(consumer, m.srcPrefix)
+			}
+		}
+	}
+
+	params.WithConn(func(conn *sqlite.Conn) {
+		models.MustUpdate(conn, &models.Cave{},
+			hades.Where(builder.Eq{"id": m.cave.ID}),
+			builder.Eq{
+				"install_location_id":   il.ID,
+				"install_folder_name":   m.cave.InstallFolderName,
+				"custom_install_folder": "",
+			},
+		)
+	})
+
+	if !renamed {
+		wipeSource(consumer, m.src)
+	}
+	if m.cave.Game != nil {
+		shortcut.RefreshCave(consumer, m.cave.ID, m.cave.Game.Title)
+	}
+	onProgress(m.size)
+	consumer.Statf("Moved cave (%s)", m.cave.ID)
+	return nil
+}
+
+func wipeSource(consumer *state.Consumer, src string) {
+	err := wipe.Do(consumer, src)
+	if err != nil {
+		consumer.Warnf("Moved, but could not remove (%s): %v", src, err)
+	}
+}
+
+func checkCancelled(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return werrors.ErrCancelled
+	default:
+		return nil
+	}
+}
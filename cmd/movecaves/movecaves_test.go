@@ -0,0 +1,78 @@
+package movecaves
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/headway/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func moveTestParams(t *testing.T, caveIDs ...string) (Params, *sqlite.Conn) {
+	conn, err := sqlite.OpenConn("file::memory:?mode=memory", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, models.HadesContext().AutoMigrate(conn))
+
+	models.MustSave(conn, &models.InstallLocation{ID: "old", Path: t.TempDir()})
+	models.MustSave(conn, &models.InstallLocation{ID: "new", Path: t.TempDir()})
+
+	return Params{
+		Ctx:               context.Background(),
+		Consumer:          &state.Consumer{},
+		WithConn:          func(f func(conn *sqlite.Conn)) { f(conn) },
+		CaveIDs:           caveIDs,
+		InstallLocationID: "new",
+	}, conn
+}
+
+func TestMoveCaves(t *testing.T) {
+	params, conn := moveTestParams(t, "cave-1")
+	old := models.InstallLocationByID(conn, "old")
+	writeTestFile(t, filepath.Join(old.GetInstallFolder("alpha"), "game.exe"), "MZ alpha")
+	models.MustSave(conn, &models.Cave{ID: "cave-1", GameID: 1, InstallLocationID: "old", InstallFolderName: "alpha"})
+
+	var moved []string
+	params.OnCaveMoved = func(caveID string) { moved = append(moved, caveID) }
+	res, err := Do(params)
+	require.NoError(t, err)
+	assert.EqualValues(t, []string{"cave-1"}, res.MovedCaveIDs)
+	assert.EqualValues(t, []string{"cave-1"}, moved)
+
+	cave := models.CaveByID(conn, "cave-1")
+	assert.EqualValues(t, "new", cave.InstallLocationID)
+	assert.EqualValues(t, "alpha", cave.InstallFolderName)
+
+	contents, err := os.ReadFile(filepath.Join(cave.GetInstallFolder(conn), "game.exe"))
+	require.NoError(t, err)
+	assert.EqualValues(t, "MZ alpha", string(contents))
+	_, err = os.Stat(old.GetInstallFolder("alpha"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMoveCavesRefusesTakenDestination(t *testing.T) {
+	params, conn := moveTestParams(t, "cave-1")
+	old := models.InstallLocationByID(conn, "old")
+	writeTestFile(t, filepath.Join(old.GetInstallFolder("alpha"), "game.exe"), "MZ alpha")
+	models.MustSave(conn, &models.Cave{ID: "cave-1", GameID: 1, InstallLocationID: "old", InstallFolderName: "alpha"})
+
+	taken := models.InstallLocationByID(conn, "new").GetInstallFolder("alpha")
+	writeTestFile(t, filepath.Join(taken, "other.exe"), "MZ other")
+
+	_, err := Do(params)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "already exists")
+	}
+
+	// nothing was touched
+	assert.EqualValues(t, "old", models.CaveByID(conn, "cave-1").InstallLocationID)
+	_, err = os.Stat(filepath.Join(old.GetInstallFolder("alpha"), "game.exe"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(taken, "game.exe"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -33,13 +33,13 @@ func (a Accuracy) String() string {
 
 type DiskUsageInfo struct {
 	// Space we'll use once the install is all said and done
-	FinalDiskUsage int64
+	FinalDiskUsage int64 `json:"finalDiskUsage"`
 
 	// Space we need to perform that operation
-	NeededFreeSpace int64
+	NeededFreeSpace int64 `json:"neededFreeSpace"`
 
 	// Accuracy of our information
-	Accuracy Accuracy
+	Accuracy Accuracy `json:"accuracy"`
 }
 
 func AssessDiskUsage(sourceFile eos.File, receiptIn *bfs.Receipt, installFolder string, installerInfo *hush.InstallerInfo) (*DiskUsageInfo, error) {
@@ -86,20 +86,23 @@ func AssessDiskUsage(sourceFile eos.File, receiptIn *bfs.Receipt, installFolder
 	}
 
 	// if we have no entries listed, let's take a guess
-	dui.Accuracy = AccuracyGuess
 	stats, err := sourceFile.Stat()
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
+	return guessDiskUsage(stats.Size()), nil
+}
+
+func guessDiskUsage(downloadSize int64) *DiskUsageInfo {
 	// let's assume the uncompressed game is 1.3x as
 	// large as the install source. this could be completely
 	// inaccurate in either direction.
-	downloadSize := stats.Size()
 	installSize := downloadSize * 130 / 100
 
-	dui.NeededFreeSpace = downloadSize + installSize
-	dui.FinalDiskUsage = installSize
-
-	return dui, nil
+	return &DiskUsageInfo{
+		NeededFreeSpace: downloadSize + installSize,
+		FinalDiskUsage:  installSize,
+		Accuracy:        AccuracyGuess,
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/butlerd/messages"
 	"github.com/itchio/butler/manager"
+	"github.com/itchio/butler/shortcut"
 	itchio "github.com/itchio/go-itchio"
 	"github.com/itchio/hush"
 	"github.com/itchio/hush/bfs"
@@ -20,6 +21,9 @@ type CommitInstallParams struct {
 	Build  *itchio.Build
 
 	InstallResult *hush.InstallResult
+
+	// What the install source was verified against, nil if it wasn't
+	VerifiedSource *VerifiedSource
 }
 
 func commitInstall(oc *OperationContext, params *CommitInstallParams) error {
@@ -54,6 +58,11 @@ func commitInstall(oc *OperationContext, params *CommitInstallParams) error {
 		return errors.WithStack(err)
 	}
 
+	err = writeVerifiedSource(params.InstallFolder, params.VerifiedSource)
+	if err != nil {
+		consumer.Warnf("Could not record install source hash: %+v", err)
+	}
+
 	cave := oc.cave
 	if cave != nil {
 		// TODO: pass runtime in params?
@@ -70,6 +79,9 @@ func commitInstall(oc *OperationContext, params *CommitInstallParams) error {
 		cave.Build = params.Build
 		cave.UpdateInstallTime()
 		oc.rc.WithConn(cave.SaveWithAssocs)
+
+		// the game may have been renamed since the shortcut was created
+		shortcut.RefreshCave(consumer, cave.ID, params.Game.Title)
 	}
 
 	return nil
@@ -0,0 +1,71 @@
+package operate
+
+import (
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/statfs"
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+)
+
+// CheckDiskSpace fails with a *butlerd.InsufficientDiskSpaceError if an
+// install with the given disk usage doesn't fit in its install location.
+// What the cave (if any) currently uses doesn't count against the quota,
+// since it's being replaced.
+func CheckDiskSpace(conn *sqlite.Conn, consumer *state.Consumer, installLocationID string, caveID string, dui *DiskUsageInfo) error {
+	if dui == nil || dui.Accuracy == AccuracyNone {
+		return nil
+	}
+
+	il := models.InstallLocationByID(conn, installLocationID)
+	if il == nil {
+		return errors.Errorf("install location (%s) not found", installLocationID)
+	}
+
+	installedSize := il.GetInstalledSize(conn)
+	if caveID != "" {
+		if cave := models.CaveByID(conn, caveID); cave != nil && cave.InstallLocationID == il.ID {
+			installedSize -= cave.InstalledSize
+		}
+	}
+
+	freeSize := int64(-1)
+	stats, err := statfs.Do(il.Path)
+	if err != nil {
+		consumer.Warnf("Could not statFS (%s), not checking free space: %s", il.Path, err.Error())
+	} else {
+		freeSize = stats.FreeSize
+	}
+
+	shortage := il.CheckSpace(installedSize, freeSize, dui.FinalDiskUsage, dui.NeededFreeSpace)
+	if shortage != nil {
+		return errors.WithStack(&butlerd.InsufficientDiskSpaceError{
+			InstallLocationID: il.ID,
+			Shortage:          shortage,
+		})
+	}
+	return nil
+}
+
+// CheckStagingSpace fails with a *butlerd.InsufficientDiskSpaceError if
+// there's no room to stage stagedSize bytes in an install location, like
+// an install source that has to be downloaded before being extracted, and
+// then install from it. dui is the estimated disk usage of the install;
+// when it's not known yet, the install is guessed to be 1.3x the staged
+// size.
+func CheckStagingSpace(conn *sqlite.Conn, consumer *state.Consumer, installLocationID string, caveID string, stagedSize int64, dui *DiskUsageInfo) error {
+	switch {
+	case dui == nil || dui.Accuracy == AccuracyNone:
+		dui = guessDiskUsage(stagedSize)
+	case dui.Accuracy == AccuracyGuess:
+		// guesses already count a local copy of the install source
+	default:
+		dui = &DiskUsageInfo{
+			FinalDiskUsage:  dui.FinalDiskUsage,
+			NeededFreeSpace: stagedSize + dui.NeededFreeSpace,
+			Accuracy:        dui.Accuracy,
+		}
+	}
+	return CheckDiskSpace(conn, consumer, installLocationID, caveID, dui)
+}
@@ -0,0 +1,60 @@
+package operate
+
+import (
+	"testing"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDiskSpaceIgnoresReplacedCave(t *testing.T) {
+	conn := accessTestConn(t)
+	consumer := &state.Consumer{}
+	models.MustSave(conn, &models.InstallLocation{ID: "il", Path: t.TempDir(), QuotaSize: 100})
+	models.MustSave(conn, &models.Cave{ID: "cave", GameID: 1, InstallLocationID: "il", InstalledSize: 80})
+
+	dui := &DiskUsageInfo{FinalDiskUsage: 50, NeededFreeSpace: 50, Accuracy: AccuracyComputed}
+
+	err := CheckDiskSpace(conn, consumer, "il", "", dui)
+	var dsErr *butlerd.InsufficientDiskSpaceError
+	require.True(t, errors.As(err, &dsErr), "a new cave shouldn't fit: %v", err)
+	require.EqualValues(t, models.SpaceLimitQuota, dsErr.Shortage.Limit)
+
+	require.NoError(t, CheckDiskSpace(conn, consumer, "il", "cave", dui))
+}
+
+func TestCheckStagingSpace(t *testing.T) {
+	conn := accessTestConn(t)
+	consumer := &state.Consumer{}
+	models.MustSave(conn, &models.InstallLocation{ID: "il", Path: t.TempDir()})
+
+	require.NoError(t, CheckStagingSpace(conn, consumer, "il", "", 1024, nil))
+
+	err := CheckStagingSpace(conn, consumer, "il", "", 1<<61, nil)
+	var dsErr *butlerd.InsufficientDiskSpaceError
+	require.True(t, errors.As(err, &dsErr), "nothing has that much free space: %v", err)
+	require.EqualValues(t, models.SpaceLimitFreeSpace, dsErr.Shortage.Limit)
+}
+
+func TestCheckStagingSpaceCountsInstall(t *testing.T) {
+	conn := accessTestConn(t)
+	consumer := &state.Consumer{}
+	models.MustSave(conn, &models.InstallLocation{ID: "il", Path: t.TempDir(), QuotaSize: 100})
+
+	// without an estimate, the install is guessed from the staged size
+	require.NoError(t, CheckStagingSpace(conn, consumer, "il", "", 40, nil))
+	err := CheckStagingSpace(conn, consumer, "il", "", 80, nil)
+	var dsErr *butlerd.InsufficientDiskSpaceError
+	require.True(t, errors.As(err, &dsErr), "a guessed 104 bytes install shouldn't fit: %v", err)
+	require.EqualValues(t, models.SpaceLimitQuota, dsErr.Shortage.Limit)
+
+	// the staged copy doesn't count against the quota, only the install does
+	dui := &DiskUsageInfo{FinalDiskUsage: 50, NeededFreeSpace: 50, Accuracy: AccuracyComputed}
+	require.NoError(t, CheckStagingSpace(conn, consumer, "il", "", 80, dui))
+
+	dui = &DiskUsageInfo{FinalDiskUsage: 150, NeededFreeSpace: 150, Accuracy: AccuracyComputed}
+	require.Error(t, CheckStagingSpace(conn, consumer, "il", "", 1, dui))
+}
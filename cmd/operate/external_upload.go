@@ -0,0 +1,86 @@
+package operate
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/cmd/operate/externalhost"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/httpkit/eos"
+	"github.com/itchio/httpkit/eos/option"
+	"github.com/pkg/errors"
+)
+
+// ResolveExternalUpload finds the file an external upload's download
+// address leads to, through release pages and redirects. It returns the
+// address to download from, along with the file's name and size when the
+// host gave them.
+func ResolveExternalUpload(ctx context.Context, httpClient *http.Client, consumer *state.Consumer, sourceURL string, upload *itchio.Upload) (*externalhost.Resolved, error) {
+	if IsBadExternalHost(upload.Host) {
+		consumer.Warnf("Host (%s) is known not to work, failing early.", upload.Host)
+		return nil, errors.WithStack(butlerd.CodeUnsupportedHost)
+	}
+
+	resolved, err := externalhost.Resolve(externalhost.Params{
+		Ctx:        ctx,
+		HTTPClient: httpClient,
+		Consumer:   consumer,
+		Filename:   upload.Filename,
+	}, sourceURL)
+	if err != nil {
+		var ue *externalhost.UnsupportedError
+		if errors.As(err, &ue) {
+			return nil, &butlerd.UnsupportedDownloadError{URL: ue.URL, Reason: ue.Reason}
+		}
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+// OpenExternalUpload opens a resolved external upload. Its stats carry
+// the name and size the host gave, since the address it's downloaded from
+// often says nothing useful (presigned links, release asset IDs), and
+// installer detection goes by the file name.
+func OpenExternalUpload(resolved *externalhost.Resolved, opts ...option.Option) (eos.File, error) {
+	file, err := eos.Open(resolved.URL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &externalFile{File: file, resolved: resolved}, nil
+}
+
+type externalFile struct {
+	eos.File
+	resolved *externalhost.Resolved
+}
+
+func (ef *externalFile) Stat() (os.FileInfo, error) {
+	stats, err := ef.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &externalFileInfo{FileInfo: stats, resolved: ef.resolved}, nil
+}
+
+type externalFileInfo struct {
+	os.FileInfo
+	resolved *externalhost.Resolved
+}
+
+func (efi *externalFileInfo) Name() string {
+	if efi.resolved.Filename != "" {
+		return efi.resolved.Filename
+	}
+	return efi.FileInfo.Name()
+}
+
+func (efi *externalFileInfo) Size() int64 {
+	if efi.resolved.Size >= 0 {
+		return efi.resolved.Size
+	}
+	return efi.FileInfo.Size()
+}
@@ -0,0 +1,33 @@
+package operate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/itchio/butler/cmd/operate/externalhost"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenExternalUpload(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "download")
+	require.NoError(t, os.WriteFile(source, []byte("hello"), 0o644))
+
+	file, err := OpenExternalUpload(&externalhost.Resolved{URL: source, Filename: "game-setup.exe", Size: 5})
+	require.NoError(t, err)
+	defer file.Close()
+
+	stats, err := file.Stat()
+	require.NoError(t, err)
+	require.EqualValues(t, "game-setup.exe", stats.Name())
+	require.EqualValues(t, 5, stats.Size())
+
+	unknown, err := OpenExternalUpload(&externalhost.Resolved{URL: source, Size: -1})
+	require.NoError(t, err)
+	defer unknown.Close()
+
+	stats, err = unknown.Stat()
+	require.NoError(t, err)
+	require.EqualValues(t, "download", stats.Name())
+	require.EqualValues(t, 5, stats.Size())
+}
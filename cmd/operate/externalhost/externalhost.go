@@ -0,0 +1,216 @@
+// Package externalhost turns links to uploads hosted outside of itch.io
+// into addresses that can be downloaded directly, and finds out the name
+// and size of the file behind them.
+package externalhost
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+)
+
+const maxRedirects = 10
+
+type Params struct {
+	Ctx        context.Context
+	HTTPClient *http.Client
+	Consumer   *state.Consumer
+
+	// Name of the upload's file on itch.io, used to pick among the
+	// assets of a release
+	Filename string
+}
+
+// Resolver turns pages of a host (like a release page) into the address
+// of the file to download.
+type Resolver interface {
+	// For logging
+	Name() string
+
+	// Resolve returns the address of the file to download, or an empty
+	// string if it doesn't handle u. Errors stop the resolution.
+	Resolve(params Params, u *url.URL) (string, error)
+}
+
+var resolvers = []Resolver{
+	&gitHubResolver{host: "github.com", apiBase: "https://api.github.com"},
+	&gitLabResolver{host: "gitlab.com", apiBase: "https://gitlab.com/api/v4"},
+	&presignedResolver{},
+}
+var resolversLock sync.RWMutex
+
+// Register adds a resolver, tried before the built-in ones.
+func Register(r Resolver) {
+	resolversLock.Lock()
+	defer resolversLock.Unlock()
+	resolvers = append([]Resolver{r}, resolvers...)
+}
+
+// UnsupportedError means a link doesn't lead to a file that can be
+// downloaded without a browser.
+type UnsupportedError struct {
+	URL    string
+	Reason string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("can't download from (%s): %s", e.URL, e.Reason)
+}
+
+type Resolved struct {
+	// Address to download from. Redirects are left for the downloader
+	// to follow, since they often lead to short-lived addresses.
+	URL string
+
+	// Name of the file, from Content-Disposition or the address
+	Filename string
+
+	// Size of the file in bytes, -1 if unknown
+	Size int64
+}
+
+// Resolve follows redirects from address one at a time, so resolvers can
+// step in wherever the chain leads to a page they know, then makes sure
+// it ends with a file.
+func Resolve(params Params, address string) (*Resolved, error) {
+	if params.Consumer == nil {
+		params.Consumer = &state.Consumer{}
+	}
+	if params.Ctx == nil {
+		params.Ctx = context.Background()
+	}
+
+	client := *params.HTTPClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resolved := &Resolved{
+		URL:  address,
+		Size: -1,
+	}
+
+	current := address
+	for hop := 0; hop <= maxRedirects; hop++ {
+		u, err := url.Parse(current)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		direct, err := resolveWith(params, u)
+		if err != nil {
+			return nil, err
+		}
+		if direct != "" && direct != current {
+			resolved.URL = direct
+			current = direct
+			continue
+		}
+
+		res, err := probe(params.Ctx, &client, current)
+		if err != nil {
+			return nil, err
+		}
+
+		if isRedirect(res.StatusCode) {
+			res.Body.Close()
+			location, err := res.Location()
+			if err != nil {
+				return nil, errors.Wrapf(err, "following redirect from (%s)", current)
+			}
+			current = location.String()
+			continue
+		}
+
+		defer res.Body.Close()
+		err = describe(res, resolved)
+		if err != nil {
+			return nil, err
+		}
+		params.Consumer.Infof("External file (%s) is at (%s), %d bytes", resolved.Filename, current, resolved.Size)
+		return resolved, nil
+	}
+	return nil, errors.Errorf("too many redirects from (%s)", address)
+}
+
+func resolveWith(params Params, u *url.URL) (string, error) {
+	resolversLock.RLock()
+	defer resolversLock.RUnlock()
+
+	for _, r := range resolvers {
+		direct, err := r.Resolve(params, u)
+		if err != nil {
+			return "", errors.WithMessagef(err, "%s", r.Name())
+		}
+		if direct != "" {
+			params.Consumer.Infof("Resolved (%s) with %s", u, r.Name())
+			return direct, nil
+		}
+	}
+	return "", nil
+}
+
+// probe asks for the first byte only. Presigned links are only valid
+// for GET, so HEAD isn't an option.
+func probe(ctx context.Context, client *http.Client, address string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Range", "bytes=0-0")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return res, nil
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+func describe(res *http.Response, resolved *Resolved) error {
+	address := res.Request.URL.String()
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		return errors.Errorf("HTTP %d from (%s)", res.StatusCode, address)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		return &UnsupportedError{URL: address, Reason: "it's a web page, not a file"}
+	}
+
+	if _, params, err := mime.ParseMediaType(res.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		resolved.Filename = path.Base(params["filename"])
+	} else {
+		resolved.Filename = path.Base(res.Request.URL.Path)
+	}
+
+	if res.StatusCode == http.StatusPartialContent {
+		// like "bytes 0-0/1234"
+		contentRange := res.Header.Get("Content-Range")
+		if i := strings.LastIndex(contentRange, "/"); i >= 0 {
+			if size, err := strconv.ParseInt(contentRange[i+1:], 10, 64); err == nil {
+				resolved.Size = size
+			}
+		}
+	} else if res.ContentLength >= 0 {
+		resolved.Size = res.ContentLength
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package externalhost
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+
+	mux.HandleFunc("/itch/download", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, srv.URL+"/owner/repo/releases/tag/v1", http.StatusFound)
+	})
+	mux.HandleFunc("/owner/repo/releases/tag/v1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<html>release page</html>")
+	})
+	mux.HandleFunc("/api/repos/owner/repo/releases/tags/v1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"assets": [
+			{"name": "game-linux.zip", "browser_download_url": "%s/assets/1"},
+			{"name": "game-windows.zip", "browser_download_url": "%s/assets/2"}
+		]}`, srv.URL, srv.URL)
+	})
+	mux.HandleFunc("/assets/2", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, srv.URL+"/blobs/abcdef", http.StatusFound)
+	})
+	mux.HandleFunc("/blobs/abcdef", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="game-windows.zip"`)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(make([]byte, 1234)))
+	})
+	mux.HandleFunc("/plain/game.zip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write(make([]byte, 42))
+	})
+
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	previous := resolvers
+	resolvers = []Resolver{
+		&gitHubResolver{host: u.Host, apiBase: srv.URL + "/api"},
+		&presignedResolver{},
+	}
+	t.Cleanup(func() { resolvers = previous })
+
+	return srv
+}
+
+func Test_ResolveRelease(t *testing.T) {
+	srv := testServer(t)
+
+	params := Params{
+		HTTPClient: http.DefaultClient,
+		Filename:   "game-windows.zip",
+	}
+	res, err := Resolve(params, srv.URL+"/itch/download")
+	require.NoError(t, err)
+	assert.EqualValues(t, srv.URL+"/assets/2", res.URL)
+	assert.EqualValues(t, "game-windows.zip", res.Filename)
+	assert.EqualValues(t, 1234, res.Size)
+
+	// no asset named like the upload
+	params.Filename = "game-mac.zip"
+	_, err = Resolve(params, srv.URL+"/itch/download")
+	var ue *UnsupportedError
+	require.ErrorAs(t, err, &ue)
+	assert.Contains(t, ue.Reason, "game-linux.zip, game-windows.zip")
+}
+
+func Test_ResolvePlain(t *testing.T) {
+	srv := testServer(t)
+
+	res, err := Resolve(Params{HTTPClient: http.DefaultClient}, srv.URL+"/plain/game.zip")
+	require.NoError(t, err)
+	assert.EqualValues(t, srv.URL+"/plain/game.zip", res.URL)
+	assert.EqualValues(t, "game.zip", res.Filename)
+	assert.EqualValues(t, 42, res.Size)
+}
+
+func Test_ResolveWebPage(t *testing.T) {
+	srv := testServer(t)
+	resolvers = nil
+
+	_, err := Resolve(Params{HTTPClient: http.DefaultClient}, srv.URL+"/itch/download")
+	var ue *UnsupportedError
+	require.ErrorAs(t, err, &ue)
+	assert.EqualValues(t, srv.URL+"/owner/repo/releases/tag/v1", ue.URL)
+}
+
+func Test_PresignedExpiry(t *testing.T) {
+	r := &presignedResolver{now: func() time.Time {
+		return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	}}
+
+	check := func(rawURL string) error {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		direct, err := r.Resolve(Params{}, u)
+		assert.EqualValues(t, "", direct)
+		return err
+	}
+
+	assert.NoError(t, check("https://bucket.s3.amazonaws.com/game.zip?X-Amz-Date=20240101T113000Z&X-Amz-Expires=3600&X-Amz-Signature=abc"))
+	assert.Error(t, check("https://bucket.s3.amazonaws.com/game.zip?X-Amz-Date=20240101T103000Z&X-Amz-Expires=3600&X-Amz-Signature=abc"))
+	assert.Error(t, check("https://bucket.s3.amazonaws.com/game.zip?AWSAccessKeyId=a&Expires=1704100000&Signature=abc"))
+	assert.NoError(t, check("https://example.com/game.zip"))
+}
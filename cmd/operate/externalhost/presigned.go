@@ -0,0 +1,62 @@
+package externalhost
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// presignedResolver fails early on S3-style presigned links that have
+// expired, instead of letting the download fail with a 403.
+type presignedResolver struct {
+	now func() time.Time
+}
+
+func (r *presignedResolver) Name() string {
+	return "presigned links"
+}
+
+func (r *presignedResolver) Resolve(params Params, u *url.URL) (string, error) {
+	expiresAt, ok := presignedExpiry(u.Query())
+	if !ok {
+		return "", nil
+	}
+
+	now := time.Now
+	if r.now != nil {
+		now = r.now
+	}
+	if now().After(expiresAt) {
+		return "", &UnsupportedError{
+			URL:    u.String(),
+			Reason: "the presigned link expired at " + expiresAt.Format(time.RFC3339),
+		}
+	}
+	return "", nil
+}
+
+func presignedExpiry(q url.Values) (time.Time, bool) {
+	// signature version 4
+	if q.Get("X-Amz-Signature") != "" {
+		signedAt, err := time.Parse("20060102T150405Z", q.Get("X-Amz-Date"))
+		if err != nil {
+			return time.Time{}, false
+		}
+		seconds, err := strconv.ParseInt(q.Get("X-Amz-Expires"), 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return signedAt.Add(time.Duration(seconds) * time.Second), true
+	}
+
+	// signature version 2
+	if q.Get("Signature") != "" && q.Get("AWSAccessKeyId") != "" {
+		expires, err := strconv.ParseInt(q.Get("Expires"), 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(expires, 0), true
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,167 @@
+package externalhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type asset struct {
+	Name string
+	URL  string
+}
+
+// pickAsset picks the release asset named like the upload, or the only
+// asset if there's just one.
+func pickAsset(pageURL string, assets []asset, filename string) (string, error) {
+	for _, a := range assets {
+		if a.Name == filename {
+			return a.URL, nil
+		}
+	}
+	if len(assets) == 1 {
+		return assets[0].URL, nil
+	}
+
+	if len(assets) == 0 {
+		return "", &UnsupportedError{URL: pageURL, Reason: "the release has no files"}
+	}
+	var names []string
+	for _, a := range assets {
+		names = append(names, a.Name)
+	}
+	return "", &UnsupportedError{
+		URL:    pageURL,
+		Reason: fmt.Sprintf("the release has %d files (%s), none named (%s)", len(assets), strings.Join(names, ", "), filename),
+	}
+}
+
+func getJSON(params Params, address string, v interface{}) error {
+	req, err := http.NewRequestWithContext(params.Ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := params.HTTPClient.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return &UnsupportedError{URL: address, Reason: "release not found"}
+	}
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("HTTP %d from (%s)", res.StatusCode, address)
+	}
+	return errors.WithStack(json.NewDecoder(res.Body).Decode(v))
+}
+
+// gitHubResolver handles release pages, like
+// https://github.com/owner/repo/releases/tag/v1.0 or .../releases/latest.
+// Direct asset and archive links need no resolving.
+type gitHubResolver struct {
+	host    string
+	apiBase string
+}
+
+func (r *gitHubResolver) Name() string {
+	return "GitHub releases"
+}
+
+func (r *gitHubResolver) Resolve(params Params, u *url.URL) (string, error) {
+	if strings.TrimPrefix(u.Host, "www.") != r.host {
+		return "", nil
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 3 || parts[2] != "releases" {
+		return "", nil
+	}
+	owner, repo := parts[0], parts[1]
+
+	var release string
+	switch {
+	case len(parts) == 3, len(parts) == 4 && parts[3] == "latest":
+		release = "latest"
+	case len(parts) >= 5 && parts[3] == "tag":
+		release = "tags/" + url.PathEscape(strings.Join(parts[4:], "/"))
+	default:
+		return "", nil
+	}
+
+	var payload struct {
+		Assets []struct {
+			Name               string `json:"name"`
+			BrowserDownloadURL string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/releases/%s", r.apiBase, url.PathEscape(owner), url.PathEscape(repo), release)
+	err := getJSON(params, apiURL, &payload)
+	if err != nil {
+		return "", err
+	}
+
+	var assets []asset
+	for _, a := range payload.Assets {
+		assets = append(assets, asset{Name: a.Name, URL: a.BrowserDownloadURL})
+	}
+	return pickAsset(u.String(), assets, params.Filename)
+}
+
+// gitLabResolver handles release pages, like
+// https://gitlab.com/group/project/-/releases/v1.0
+type gitLabResolver struct {
+	host    string
+	apiBase string
+}
+
+func (r *gitLabResolver) Name() string {
+	return "GitLab releases"
+}
+
+func (r *gitLabResolver) Resolve(params Params, u *url.URL) (string, error) {
+	if strings.TrimPrefix(u.Host, "www.") != r.host {
+		return "", nil
+	}
+
+	project, rest, ok := strings.Cut(strings.Trim(u.Path, "/"), "/-/releases/")
+	if !ok || project == "" || rest == "" {
+		return "", nil
+	}
+
+	release := url.PathEscape(rest)
+	if rest == "permalink/latest" {
+		release = rest
+	}
+
+	var payload struct {
+		Assets struct {
+			Links []struct {
+				Name           string `json:"name"`
+				URL            string `json:"url"`
+				DirectAssetURL string `json:"direct_asset_url"`
+			} `json:"links"`
+		} `json:"assets"`
+	}
+	apiURL := fmt.Sprintf("%s/projects/%s/releases/%s", r.apiBase, url.PathEscape(project), release)
+	err := getJSON(params, apiURL, &payload)
+	if err != nil {
+		return "", err
+	}
+
+	var assets []asset
+	for _, l := range payload.Assets.Links {
+		a := asset{Name: l.Name, URL: l.DirectAssetURL}
+		if a.URL == "" {
+			a.URL = l.URL
+		}
+		assets = append(assets, a)
+	}
+	return pickAsset(u.String(), assets, params.Filename)
+}
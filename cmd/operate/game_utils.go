@@ -28,6 +28,19 @@ func GameToString(game *itchio.Game) string {
 }
 
 func GetFilteredUploads(rc *butlerd.RequestContext, game *itchio.Game) (*manager.NarrowDownUploadsResult, error) {
+	return GetFilteredUploadsFor(rc, game, rc.HostEnumerator())
+}
+
+// GetFilteredUploadsFor is GetFilteredUploads, narrowing down for
+// the hosts of hostEnum instead of those of this machine.
+func GetFilteredUploadsFor(rc *butlerd.RequestContext, game *itchio.Game, hostEnum manager.HostEnumerator) (*manager.NarrowDownUploadsResult, error) {
+	res, _, err := ExplainFilteredUploads(rc, game, hostEnum)
+	return res, err
+}
+
+// ExplainFilteredUploads is GetFilteredUploadsFor, also returning what
+// narrowing down decided for each of the game's uploads.
+func ExplainFilteredUploads(rc *butlerd.RequestContext, game *itchio.Game, hostEnum manager.HostEnumerator) (*manager.NarrowDownUploadsResult, []*manager.UploadDecision, error) {
 	consumer := rc.Consumer
 
 	var access *GameAccess
@@ -41,7 +54,7 @@ func GetFilteredUploads(rc *butlerd.RequestContext, game *itchio.Game) (*manager
 		Credentials: access.Credentials,
 	})
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, nil, errors.WithStack(err)
 	}
 	consumer.Debugf("API returned %d uploads", len(uploads.Uploads))
 
@@ -49,9 +62,9 @@ func GetFilteredUploads(rc *butlerd.RequestContext, game *itchio.Game) (*manager
 	if numInputs == 0 {
 		consumer.Infof("No uploads found at all (that we can access)")
 	}
-	uploadsFilterResult, err := manager.NarrowDownUploads(consumer, game, uploads.Uploads, rc.HostEnumerator())
+	uploadsFilterResult, decisions, err := manager.ExplainUploads(consumer, game, uploads.Uploads, hostEnum, rc.UploadRules(access.ProfileID))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	consumer.Debugf("Narrow returned %d uploads", len(uploadsFilterResult.Uploads))
 
@@ -72,7 +85,7 @@ func GetFilteredUploads(rc *butlerd.RequestContext, game *itchio.Game) (*manager
 		}
 	}
 
-	return uploadsFilterResult, nil
+	return uploadsFilterResult, decisions, nil
 }
 
 func LogUpload(consumer *state.Consumer, u *itchio.Upload, b *itchio.Build) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/itchio/butler/manager/runlock"
 	itchio "github.com/itchio/go-itchio"
@@ -79,15 +80,26 @@ func doForceLocal(file eos.File, oc *OperationContext, meta *MetaSubcontext, isu
 				return errors.WithStack(err)
 			}
 
+			if params.InstallLocationID != "" {
+				oc.rc.WithConn(func(conn *sqlite.Conn) {
+					err = CheckStagingSpace(conn, consumer, params.InstallLocationID, params.CaveID, stats.Size(), istate.DiskUsage)
+				})
+				if err != nil {
+					return err
+				}
+			}
+
 			oc.rc.StartProgress()
-			err = download.DownloadInstallSource(download.DownloadInstallSourceParams{
-				Context:       oc.ctx,
-				Consumer:      oc.Consumer(),
-				StageFolder:   oc.StageFolder(),
-				OperationName: "force-local",
-				File:          file,
-				DestPath:      destPath,
-			})
+			if !fetchFromPeers(oc, isub, stats.Size(), destPath) {
+				err = download.DownloadInstallSource(download.DownloadInstallSourceParams{
+					Context:       oc.ctx,
+					Consumer:      oc.Consumer(),
+					StageFolder:   oc.StageFolder(),
+					OperationName: "force-local",
+					File:          file,
+					DestPath:      destPath,
+				})
+			}
 			oc.rc.EndProgress()
 			oc.consumer.Progress(0)
 			if err != nil {
@@ -114,6 +126,29 @@ func doForceLocal(file eos.File, oc *OperationContext, meta *MetaSubcontext, isu
 		}
 	}
 
+	if istate.SourceHashes != nil && istate.VerifiedSource == nil {
+		err := verifySourceFile(oc.ctx, consumer, file, destPath, istate.SourceHashes)
+		if err != nil {
+			return nil, errors.Wrap(err, "verifying install source")
+		}
+
+		algo, hash := istate.SourceHashes.strongest()
+		istate.VerifiedSource = &VerifiedSource{
+			UploadID:   params.Upload.ID,
+			Algo:       algo,
+			Hash:       hash,
+			VerifiedAt: time.Now().UTC(),
+		}
+		if params.Build != nil {
+			istate.VerifiedSource.BuildID = params.Build.ID
+		}
+		err = oc.Save(isub)
+		if err != nil {
+			return nil, err
+		}
+		addToPeerCache(oc, destPath, istate.VerifiedSource)
+	}
+
 	ret, err := eos.Open(destPath, option.WithConsumer(consumer))
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -290,6 +325,16 @@ func doInstallPerformInner(oc *OperationContext, meta *MetaSubcontext, isub *Ins
 				// keep going!
 			}
 
+			// staging may have used up space since InstallPrepare checked
+			if params.InstallLocationID != "" {
+				rc.WithConn(func(conn *sqlite.Conn) {
+					err = CheckDiskSpace(conn, consumer, params.InstallLocationID, params.CaveID, istate.DiskUsage)
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+
 			err = messages.TaskStarted.Notify(oc.rc, butlerd.TaskStartedNotification{
 				Reason:    butlerd.TaskReasonInstall,
 				Type:      butlerd.TaskTypeInstall,
@@ -323,7 +368,7 @@ func doInstallPerformInner(oc *OperationContext, meta *MetaSubcontext, isub *Ins
 			if err != nil && errors.Cause(err) == hush.ErrNeedLocal {
 				lf, localErr := doForceLocal(prepareRes.File, oc, meta, isub)
 				if localErr != nil {
-					return errors.WithStack(err)
+					return errors.WithStack(localErr)
 				}
 
 				consumer.Infof("Re-invoking manager with local file...")
@@ -365,7 +410,8 @@ func doInstallPerformInner(oc *OperationContext, meta *MetaSubcontext, isub *Ins
 			Upload:        params.Upload,
 			Build:         params.Build,
 
-			InstallResult: installResult,
+			InstallResult:  installResult,
+			VerifiedSource: istate.VerifiedSource,
 		})
 
 	})
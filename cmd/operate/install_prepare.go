@@ -4,7 +4,9 @@ import (
 	"io"
 	"time"
 
-	"github.com/itchio/butler/butlerd"
+	"crawshaw.io/sqlite"
+
+	"github.com/itchio/butler/cmd/operate/externalhost"
 	itchio "github.com/itchio/go-itchio"
 	"github.com/itchio/headway/united"
 	"github.com/itchio/httpkit/eos"
@@ -184,8 +186,24 @@ func InstallPrepare(oc *OperationContext, meta *MetaSubcontext, isub *InstallSub
 	}
 	installSourceURL := MakeSourceURL(client, consumer, istate.DownloadSessionID, params, installSourceFileType)
 
+	if params.Upload.Storage == itchio.UploadStorageExternal && !allowDownloads {
+		consumer.Warnf("Dealing with an external upload (from %s), all bets are off.", params.Upload.Host)
+		consumer.Warnf("Can't determine source information at that time")
+		return nil
+	}
+
+	var file eos.File
 	beforeOpen := time.Now()
-	file, err := eos.Open(installSourceURL, option.WithConsumer(consumer))
+	if params.Upload.Storage == itchio.UploadStorageExternal {
+		var resolved *externalhost.Resolved
+		resolved, err = ResolveExternalUpload(rc.Ctx, rc.HTTPClient, consumer, installSourceURL, params.Upload)
+		if err != nil {
+			return err
+		}
+		file, err = OpenExternalUpload(resolved, option.WithConsumer(consumer))
+	} else {
+		file, err = eos.Open(installSourceURL, option.WithConsumer(consumer))
+	}
 	consumer.Infof("(opening file took %s)", time.Since(beforeOpen))
 	if err != nil {
 		return errors.WithStack(err)
@@ -195,17 +213,6 @@ func InstallPrepare(oc *OperationContext, meta *MetaSubcontext, isub *InstallSub
 
 	if params.Upload.Storage == itchio.UploadStorageExternal {
 		consumer.Warnf("Dealing with an external upload (from %s), all bets are off.", params.Upload.Host)
-
-		if IsBadExternalHost(params.Upload.Host) {
-			consumer.Warnf("Host (%s) is known not to work, failing early.", params.Upload.Host)
-			return errors.WithStack(butlerd.CodeUnsupportedHost)
-		}
-
-		if !allowDownloads {
-			consumer.Warnf("Can't determine source information at that time")
-			return nil
-		}
-
 		consumer.Warnf("Forcing download before we check anything else.")
 		lf, err := doForceLocal(file, oc, meta, isub)
 		if err != nil {
@@ -257,6 +264,7 @@ func InstallPrepare(oc *OperationContext, meta *MetaSubcontext, isub *InstallSub
 		consumer.Infof("  ✓ %s final disk usage", united.FormatBytes(dui.FinalDiskUsage))
 
 		istate.InstallerInfo = installerInfo
+		istate.DiskUsage = dui
 		err = oc.Save(isub)
 		if err != nil {
 			return err
@@ -265,5 +273,62 @@ func InstallPrepare(oc *OperationContext, meta *MetaSubcontext, isub *InstallSub
 		consumer.Infof("Using cached source information")
 	}
 
+	// checked every time, space may have been used up since queuing
+	if params.InstallLocationID != "" {
+		rc.WithConn(func(conn *sqlite.Conn) {
+			err = CheckDiskSpace(conn, consumer, params.InstallLocationID, params.CaveID, istate.DiskUsage)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if params.Upload.Storage != itchio.UploadStorageExternal && allowDownloads {
+		hashes, err := getSourceHashes(oc, meta, isub, installSourceFileType)
+		if err != nil {
+			return err
+		}
+
+		if hashes != nil {
+			// the staged copy is checked for space before downloading
+			consumer.Infof("itch.io has hashes for the install source, downloading it to verify them before extracting")
+			lf, err := doForceLocal(file, oc, meta, isub)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			file.Close()
+			file = lf
+			res.File = lf
+		}
+	}
+
 	return task(res)
 }
+
+// getSourceHashes returns the hashes itch.io has for the install source,
+// asking for them only once per install. Not being able to get them isn't
+// fatal, the source just can't be verified.
+func getSourceHashes(oc *OperationContext, meta *MetaSubcontext, isub *InstallSubcontext, fileType string) (*SourceHashes, error) {
+	rc := oc.rc
+	params := meta.Data
+	consumer := oc.Consumer()
+	istate := isub.Data
+
+	if !istate.FetchedSourceHashes {
+		client := rc.Client(params.Access.APIKey)
+		hashes, err := FetchSourceHashes(rc.Ctx, client, params, fileType)
+		if err != nil {
+			consumer.Warnf("Could not get hashes of the install source, won't verify it: %s", err.Error())
+		}
+
+		istate.FetchedSourceHashes = true
+		istate.SourceHashes = hashes
+		err = oc.Save(isub)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return istate.SourceHashes, nil
+}
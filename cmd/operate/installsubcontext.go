@@ -15,6 +15,10 @@ type InstallSubcontextState struct {
 	UpgradePathIndex    int                 `json:"upgradePathIndex,omitempty"`
 	UsingHealFallback   bool                `json:"usingHealFallback,omitempty"`
 	RefreshedGame       bool                `json:"refreshedGame,omitempty"`
+	DiskUsage           *DiskUsageInfo      `json:"diskUsage,omitempty"`
+	FetchedSourceHashes bool                `json:"fetchedSourceHashes,omitempty"`
+	SourceHashes        *SourceHashes       `json:"sourceHashes,omitempty"`
+	VerifiedSource      *VerifiedSource     `json:"verifiedSource,omitempty"`
 
 	Events []hush.InstallEvent
 }
@@ -0,0 +1,53 @@
+package operate
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/itchio/butler/peercache"
+)
+
+// fetchFromPeers tries to get the install source from the peer cache,
+// if enabled and itch.io gave us its hash. Whatever peers send is
+// verified afterwards like any download, so failures here only mean
+// falling back to itch.io.
+func fetchFromPeers(oc *OperationContext, isub *InstallSubcontext, size int64, destPath string) bool {
+	pc := oc.rc.Config.PeerCache
+	hashes := isub.Data.SourceHashes
+	if pc == nil || hashes == nil {
+		return false
+	}
+	consumer := oc.rc.Consumer
+
+	err := os.MkdirAll(filepath.Dir(destPath), 0o755)
+	if err != nil {
+		consumer.Warnf("Could not prepare for peer cache download: %v", err)
+		return false
+	}
+
+	algo, hash := hashes.strongest()
+	source, err := pc.Fetch(oc.ctx, oc.Consumer(), algo, hash, size, destPath)
+	if err != nil {
+		if err != peercache.ErrNotFound {
+			consumer.Warnf("Could not get install source from peers: %+v", err)
+		}
+		_ = os.Remove(destPath)
+		return false
+	}
+
+	consumer.Infof("Got install source from peer (%s)", source)
+	return true
+}
+
+// addToPeerCache keeps a verified install source for peers, if enabled
+func addToPeerCache(oc *OperationContext, path string, verified *VerifiedSource) {
+	pc := oc.rc.Config.PeerCache
+	if pc == nil {
+		return
+	}
+
+	err := pc.Add(oc.rc.Consumer, path, verified.Algo, verified.Hash)
+	if err != nil {
+		oc.rc.Consumer.Warnf("Could not add install source to peer cache: %v", err)
+	}
+}
@@ -2,11 +2,13 @@ package operate
 
 import (
 	"context"
+	"os"
 
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/butlerd/messages"
 	"github.com/itchio/butler/cmd/wipe"
 	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/shortcut"
 	"github.com/itchio/hush"
 	"github.com/itchio/hush/bfs"
 	"github.com/itchio/hush/installers"
@@ -81,9 +83,26 @@ func UninstallPerform(ctx context.Context, rc *butlerd.RequestContext, params bu
 		}
 	}
 
+	compatPrefix := cave.GetCompatPrefixFolder(conn)
+
 	consumer.Infof("Deleting cave...")
 	cave.Delete(conn)
 
+	// the game may be gone from the database, Remove then only
+	// finds shortcuts by cave ID
+	var displayName string
+	if cave.Game != nil {
+		displayName = cave.Game.Title
+	}
+	err := shortcut.Remove(shortcut.RemoveParams{
+		ID:          cave.ID,
+		DisplayName: displayName,
+		Consumer:    consumer,
+	})
+	if err != nil {
+		consumer.Warnf("While removing shortcut: %+v", err)
+	}
+
 	consumer.Infof("Clearing out downloads...")
 	models.DiscardDownloadsByCaveID(conn, cave.ID)
 
@@ -99,5 +118,15 @@ func UninstallPerform(ctx context.Context, rc *butlerd.RequestContext, params bu
 		models.Must(wipe.Do(consumer, installFolder))
 	}()
 
+	if compatPrefix != "" {
+		if _, err := os.Stat(compatPrefix); err == nil {
+			consumer.Infof("Wiping wine prefix...")
+			err = wipe.Do(consumer, compatPrefix)
+			if err != nil {
+				consumer.Warnf("While wiping wine prefix: %+v", err)
+			}
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,356 @@
+package operate
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/itchio/butler/butlerd"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/headway/united"
+	"github.com/itchio/httpkit/eos"
+	"github.com/pkg/errors"
+)
+
+// How many times a downloaded install source is hashed before giving up.
+// Every failed attempt but the last fetches the whole source again and
+// rewrites the chunks that differ.
+const maxSourceVerifyAttempts = 3
+
+const sourceRepairChunkSize = 4 * 1024 * 1024
+
+// SourceHashes are the hashes itch.io has for an install source,
+// hex-encoded. Any of them may be missing.
+type SourceHashes struct {
+	MD5    string `json:"md5,omitempty"`
+	SHA1   string `json:"sha1,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+func (sh *SourceHashes) empty() bool {
+	return sh.MD5 == "" && sh.SHA1 == "" && sh.SHA256 == ""
+}
+
+// strongest returns the strongest hash known, as (algo, hex)
+func (sh *SourceHashes) strongest() (string, string) {
+	switch {
+	case sh.SHA256 != "":
+		return "sha256", sh.SHA256
+	case sh.SHA1 != "":
+		return "sha1", sh.SHA1
+	default:
+		return "md5", sh.MD5
+	}
+}
+
+// VerifiedSource records which hash an install source matched. Receipts
+// are defined by hush and have no room for it, so it's written next to
+// the receipt when the install is committed, and audits read it back.
+type VerifiedSource struct {
+	UploadID   int64     `json:"uploadId"`
+	BuildID    int64     `json:"buildId,omitempty"`
+	Algo       string    `json:"algo"`
+	Hash       string    `json:"hash"`
+	VerifiedAt time.Time `json:"verifiedAt"`
+}
+
+// hashes as the API returns them, for uploads and build files
+type apiFileHashes struct {
+	Type       string `json:"type"`
+	SubType    string `json:"subType"`
+	MD5Hash    string `json:"md5Hash"`
+	SHA1Hash   string `json:"sha1Hash"`
+	SHA256Hash string `json:"sha256Hash"`
+}
+
+func (h *apiFileHashes) sourceHashes() *SourceHashes {
+	sh := &SourceHashes{
+		MD5:    strings.ToLower(h.MD5Hash),
+		SHA1:   strings.ToLower(h.SHA1Hash),
+		SHA256: strings.ToLower(h.SHA256Hash),
+	}
+	if sh.empty() {
+		return nil
+	}
+	return sh
+}
+
+// FetchSourceHashes asks itch.io for the hashes of the file MakeSourceURL
+// points to. Those aren't part of go-itchio's types, so the upload or build
+// is fetched again and only the hash fields are decoded. Returns nil
+// if itch.io doesn't have any.
+func FetchSourceHashes(ctx context.Context, client *itchio.Client, params *InstallParams, fileType string) (*SourceHashes, error) {
+	if params.Build != nil {
+		if fileType == "" {
+			fileType = "archive"
+		}
+
+		q := itchio.NewQuery(client, "/builds/%d", params.Build.ID)
+		q.AddGameCredentials(params.Access.Credentials)
+		var res struct {
+			Build struct {
+				Files []*apiFileHashes `json:"files"`
+			} `json:"build"`
+		}
+		err := q.Get(ctx, &res)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		for _, f := range res.Build.Files {
+			if f.Type == fileType && (f.SubType == "" || f.SubType == string(itchio.BuildFileSubTypeDefault)) {
+				return f.sourceHashes(), nil
+			}
+		}
+		return nil, nil
+	}
+
+	q := itchio.NewQuery(client, "/uploads/%d", params.Upload.ID)
+	q.AddGameCredentials(params.Access.Credentials)
+	var res struct {
+		Upload *apiFileHashes `json:"upload"`
+	}
+	err := q.Get(ctx, &res)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if res.Upload == nil {
+		return nil, nil
+	}
+	return res.Upload.sourceHashes(), nil
+}
+
+// hashFile computes the hashes of path that are set in expected, in one pass
+func hashFile(path string, expected *SourceHashes) (*SourceHashes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	var md5h, sha1h, sha256h hash.Hash
+	var writers []io.Writer
+	if expected.MD5 != "" {
+		md5h = md5.New()
+		writers = append(writers, md5h)
+	}
+	if expected.SHA1 != "" {
+		sha1h = sha1.New()
+		writers = append(writers, sha1h)
+	}
+	if expected.SHA256 != "" {
+		sha256h = sha256.New()
+		writers = append(writers, sha256h)
+	}
+
+	_, err = io.Copy(io.MultiWriter(writers...), f)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	actual := &SourceHashes{}
+	if md5h != nil {
+		actual.MD5 = hex.EncodeToString(md5h.Sum(nil))
+	}
+	if sha1h != nil {
+		actual.SHA1 = hex.EncodeToString(sha1h.Sum(nil))
+	}
+	if sha256h != nil {
+		actual.SHA256 = hex.EncodeToString(sha256h.Sum(nil))
+	}
+	return actual, nil
+}
+
+// firstMismatch returns the first hash of expected that actual doesn't
+// match, as (algo, expected, actual), or empty strings if they all match.
+func firstMismatch(expected *SourceHashes, actual *SourceHashes) (string, string, string) {
+	switch {
+	case expected.MD5 != actual.MD5:
+		return "md5", expected.MD5, actual.MD5
+	case expected.SHA1 != actual.SHA1:
+		return "sha1", expected.SHA1, actual.SHA1
+	case expected.SHA256 != actual.SHA256:
+		return "sha256", expected.SHA256, actual.SHA256
+	}
+	return "", "", ""
+}
+
+// verifySourceFile checks that the local copy of an install source at path
+// matches the expected hashes. When it doesn't, the ranges that differ from
+// remote are downloaded again and the file is checked again, up to
+// maxSourceVerifyAttempts times in total.
+func verifySourceFile(ctx context.Context, consumer *state.Consumer, remote eos.File, path string, expected *SourceHashes) error {
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		actual, err := hashFile(path, expected)
+		if err != nil {
+			return err
+		}
+
+		algo, wanted, got := firstMismatch(expected, actual)
+		if algo == "" {
+			algo, _ := expected.strongest()
+			consumer.Infof("✓ Install source matches its %s hash (took %s)", algo, time.Since(start))
+			return nil
+		}
+		consumer.Warnf("Install source failed verification (attempt %d/%d): %s wanted %s, got %s", attempt, maxSourceVerifyAttempts, algo, wanted, got)
+
+		if attempt == maxSourceVerifyAttempts {
+			return &butlerd.DownloadVerificationError{
+				Algo:     algo,
+				Expected: wanted,
+				Actual:   got,
+				Attempts: attempt,
+			}
+		}
+
+		repaired, err := repairRanges(ctx, consumer, remote, path)
+		if err != nil {
+			return errors.WithMessage(err, "re-fetching install source")
+		}
+		if repaired == 0 {
+			// the server sends exactly what we have, trying again won't help
+			return &butlerd.DownloadVerificationError{
+				Algo:     algo,
+				Expected: wanted,
+				Actual:   got,
+				Attempts: attempt,
+			}
+		}
+	}
+}
+
+// repairRanges re-fetches all of remote, chunk by chunk, and rewrites the
+// chunks of the file at path that differ. It saves disk writes, not
+// bandwidth: every call transfers the whole source. Returns how many
+// chunks were rewritten.
+func repairRanges(ctx context.Context, consumer *state.Consumer, remote eos.File, path string) (int, error) {
+	stats, err := remote.Stat()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	size := stats.Size()
+
+	local, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer local.Close()
+
+	localStats, err := local.Stat()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	repaired := 0
+	if localStats.Size() != size {
+		consumer.Infof("Install source is %s on disk, should be %s", united.FormatBytes(localStats.Size()), united.FormatBytes(size))
+		err = local.Truncate(size)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		repaired++
+	}
+
+	consumer.Infof("Re-fetching the whole install source (%s) to repair it", united.FormatBytes(size))
+	remoteBuf := make([]byte, sourceRepairChunkSize)
+	localBuf := make([]byte, sourceRepairChunkSize)
+	for offset := int64(0); offset < size; offset += sourceRepairChunkSize {
+		select {
+		case <-ctx.Done():
+			return 0, errors.WithStack(butlerd.CodeOperationCancelled)
+		default:
+		}
+
+		n := sourceRepairChunkSize
+		if size-offset < int64(n) {
+			n = int(size - offset)
+		}
+
+		_, err := remote.ReadAt(remoteBuf[:n], offset)
+		if err != nil && err != io.EOF {
+			return 0, errors.WithStack(err)
+		}
+		_, err = local.ReadAt(localBuf[:n], offset)
+		if err != nil && err != io.EOF {
+			return 0, errors.WithStack(err)
+		}
+
+		if bytes.Equal(remoteBuf[:n], localBuf[:n]) {
+			continue
+		}
+
+		consumer.Infof("Rewriting %s at offset %d", united.FormatBytes(int64(n)), offset)
+		_, err = local.WriteAt(remoteBuf[:n], offset)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		repaired++
+	}
+
+	consumer.Infof("Rewrote %d chunks of the install source", repaired)
+	return repaired, nil
+}
+
+func verifiedSourcePath(installFolder string) string {
+	return filepath.Join(installFolder, ".itch", "verified-source.json")
+}
+
+// writeVerifiedSource records the hash the install source matched. When
+// vs is nil, any previous record is removed, as it doesn't describe the
+// installed files anymore.
+func writeVerifiedSource(installFolder string, vs *VerifiedSource) error {
+	path := verifiedSourcePath(installFolder)
+	if vs == nil {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.WithStack(err)
+		}
+		return nil
+	}
+
+	payload, err := json.Marshal(vs)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.WriteFile(path, payload, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ReadVerifiedSource returns what the install source of the files in
+// installFolder was verified against, or nil if it wasn't.
+func ReadVerifiedSource(installFolder string) (*VerifiedSource, error) {
+	payload, err := os.ReadFile(verifiedSourcePath(installFolder))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	var vs VerifiedSource
+	err = json.Unmarshal(payload, &vs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &vs, nil
+}
@@ -0,0 +1,113 @@
+package operate
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/httpkit/eos"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func makeSourceFiles(t *testing.T) (eos.File, string, *SourceHashes) {
+	dir := t.TempDir()
+
+	data := make([]byte, sourceRepairChunkSize*2+1234)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	md5Sum := md5.Sum(data)
+	sha256Sum := sha256.Sum256(data)
+	expected := &SourceHashes{
+		MD5:    hex.EncodeToString(md5Sum[:]),
+		SHA256: hex.EncodeToString(sha256Sum[:]),
+	}
+
+	remotePath := filepath.Join(dir, "remote.zip")
+	require.NoError(t, os.WriteFile(remotePath, data, 0o644))
+	remote, err := eos.Open(remotePath)
+	require.NoError(t, err)
+	t.Cleanup(func() { remote.Close() })
+
+	// corrupt the middle chunk and lose the tail
+	corrupted := append([]byte{}, data[:len(data)-10]...)
+	corrupted[sourceRepairChunkSize+42] ^= 0xff
+	localPath := filepath.Join(dir, "local.zip")
+	require.NoError(t, os.WriteFile(localPath, corrupted, 0o644))
+
+	return remote, localPath, expected
+}
+
+func TestVerifySourceFileRepairsRanges(t *testing.T) {
+	remote, localPath, expected := makeSourceFiles(t)
+	consumer := &state.Consumer{}
+
+	actual, err := hashFile(localPath, expected)
+	require.NoError(t, err)
+	algo, _, _ := firstMismatch(expected, actual)
+	require.Equal(t, "md5", algo)
+	require.Empty(t, actual.SHA1, "only expected hashes are computed")
+
+	err = verifySourceFile(context.Background(), consumer, remote, localPath, expected)
+	require.NoError(t, err)
+
+	actual, err = hashFile(localPath, expected)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+}
+
+func TestVerifySourceFileGivesUp(t *testing.T) {
+	remote, localPath, expected := makeSourceFiles(t)
+	consumer := &state.Consumer{}
+
+	// what the server sends never matches: repairing stops making progress
+	expected.SHA256 = "00"
+	err := verifySourceFile(context.Background(), consumer, remote, localPath, expected)
+	require.Error(t, err)
+
+	be, ok := butlerd.AsButlerdError(err)
+	require.True(t, ok)
+	require.EqualValues(t, butlerd.CodeDownloadVerificationFailed, be.RpcErrorCode())
+
+	var dve *butlerd.DownloadVerificationError
+	require.True(t, errors.As(err, &dve))
+	require.Equal(t, "sha256", dve.Algo)
+	require.Equal(t, 2, dve.Attempts)
+}
+
+func TestVerifiedSourceRecord(t *testing.T) {
+	installFolder := t.TempDir()
+
+	vs, err := ReadVerifiedSource(installFolder)
+	require.NoError(t, err)
+	require.Nil(t, vs)
+
+	written := &VerifiedSource{
+		UploadID:   12,
+		BuildID:    34,
+		Algo:       "sha256",
+		Hash:       "abcd",
+		VerifiedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	require.NoError(t, writeVerifiedSource(installFolder, written))
+	vs, err = ReadVerifiedSource(installFolder)
+	require.NoError(t, err)
+	require.Equal(t, written, vs)
+
+	// installing without verification removes the stale record
+	require.NoError(t, writeVerifiedSource(installFolder, nil))
+	vs, err = ReadVerifiedSource(installFolder)
+	require.NoError(t, err)
+	require.Nil(t, vs)
+
+	// ...and doesn't mind if there was none
+	require.NoError(t, writeVerifiedSource(installFolder, nil))
+}
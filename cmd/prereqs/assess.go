@@ -67,6 +67,11 @@ func (h *handler) AssessPrereqs(names []string) (*PrereqAssessment, error) {
 }
 
 func (h *handler) MarkerPath(name string) string {
+	if wr := h.host().Wrapper; wr != nil && wr.Env["WINEPREFIX"] != "" {
+		// prereqs installed in a wine prefix are only there, not
+		// in other prefixes
+		return filepath.Join(wr.Env["WINEPREFIX"], ".itch-prereqs", name+".installed")
+	}
 	return filepath.Join(h.GetEntryDir(name), ".installed")
 }
 
@@ -52,6 +52,22 @@ func (h *handler) FetchPrereqs(tsc *TaskStateConsumer, names []string) error {
 			return errors.Wrap(err, "opening prereqs library")
 		}
 
+		if extractor, ok := library.(Extractor); ok {
+			tsc.OnState(butlerd.PrereqsTaskStateNotification{
+				Name:   name,
+				Status: butlerd.PrereqStatusDownloading,
+			})
+			err = extractor.Extract(consumer, name, destDir)
+			if err != nil {
+				return errors.Wrapf(err, "extracting prereq %s", name)
+			}
+			tsc.OnState(butlerd.PrereqsTaskStateNotification{
+				Name:   name,
+				Status: butlerd.PrereqStatusReady,
+			})
+			return nil
+		}
+
 		upload := library.GetUpload(name)
 		if upload == nil {
 			consumer.Warnf("Prereq (%s) not found in library, skipping", name)
@@ -43,6 +43,10 @@ type Params struct {
 	Consumer       *state.Consumer
 	PrereqsDir     string
 	Force          bool
+
+	// If set, a directory or URL made by `butler prereqs-mirror`
+	// that's used instead of itch.io
+	Mirror string
 }
 
 type handler struct {
@@ -91,6 +95,11 @@ func (h *handler) platform() ox.Platform {
 
 func (h *handler) GetLibrary() (Library, error) {
 	if h.library == nil {
+		if h.params.Mirror != "" {
+			h.library = NewMirrorLibrary(h.params.Mirror, h.runtime())
+			return h.library, nil
+		}
+
 		library, err := NewLibrary(h.rc(), h.runtime(), h.params.APIKey)
 		if err != nil {
 			return nil, errors.Wrap(err, "opening prereqs library")
@@ -139,7 +148,12 @@ func (h *handler) GetRegistry() (*redist.RedistRegistry, error) {
 
 		if needFetch || wantFetch {
 			err := func() error {
-				src, err := eos.Open("https://broth.itch.zone/itch-redists/info/LATEST/unpacked", option.WithConsumer(h.consumer()))
+				registryURL := brothBaseURL + "/info/LATEST/unpacked"
+				if h.params.Mirror != "" {
+					registryURL = mirrorPath(h.params.Mirror, mirrorRegistryName)
+				}
+
+				src, err := eos.Open(registryURL, option.WithConsumer(h.consumer()))
 				if err != nil {
 					return errors.Wrap(err, "opening remote registry file")
 				}
@@ -0,0 +1,166 @@
+package prereqs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/itchio/butler/cmd/dl"
+	"github.com/itchio/butler/comm"
+	"github.com/itchio/butler/mansion"
+	"github.com/itchio/butler/redist"
+	"github.com/itchio/ox"
+	"github.com/pkg/errors"
+)
+
+const brothBaseURL = "https://broth.itch.zone/itch-redists"
+
+// A prereqs mirror is a directory, possibly served over HTTP, containing:
+//
+//	info.json                the redist registry
+//	{name}-{platform}.zip    an archive for each prereq and platform
+const mirrorRegistryName = "info.json"
+
+func mirrorArchiveName(name string, platform ox.Platform) string {
+	return fmt.Sprintf("%s-%s.zip", name, platform)
+}
+
+// mirrorPath returns the location of a file in a mirror, which
+// may be a local directory or an http(s) URL.
+func mirrorPath(mirror string, name string) string {
+	if strings.HasPrefix(mirror, "http://") || strings.HasPrefix(mirror, "https://") {
+		return strings.TrimSuffix(mirror, "/") + "/" + name
+	}
+	return filepath.Join(mirror, name)
+}
+
+// Mirror snapshots the current redist registry and the archives of the
+// given prereqs (or all of them) into dir, so it can be used as a mirror.
+// Archives already mirrored at the same version are not downloaded again.
+func Mirror(ctx *mansion.Context, dir string, platforms []string, names []string) error {
+	return mirrorFrom(ctx, brothBaseURL, dir, platforms, names)
+}
+
+// mirrorFrom is Mirror, with broth at baseURL
+func mirrorFrom(ctx *mansion.Context, baseURL string, dir string, platforms []string, names []string) error {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	comm.Opf("Fetching registry...")
+	registryBytes, err := fetchRemoteRegistry(ctx, baseURL)
+	if err != nil {
+		return err
+	}
+	registry := &redist.RedistRegistry{}
+	err = json.Unmarshal(registryBytes, registry)
+	if err != nil {
+		return errors.WithMessage(err, "decoding redist registry")
+	}
+
+	// what's already mirrored, to avoid downloading unchanged archives
+	previous := &redist.RedistRegistry{}
+	registryPath := filepath.Join(dir, mirrorRegistryName)
+	if previousBytes, err := os.ReadFile(registryPath); err == nil {
+		err = json.Unmarshal(previousBytes, previous)
+		if err != nil {
+			comm.Warnf("Ignoring unreadable previous registry: %v", err)
+		}
+	}
+
+	if len(names) == 0 {
+		for name := range registry.Entries {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	wantPlatform := func(platform string) bool {
+		if len(platforms) == 0 {
+			return true
+		}
+		for _, p := range platforms {
+			if p == platform {
+				return true
+			}
+		}
+		return false
+	}
+
+	numArchives := 0
+	for _, name := range names {
+		entry, ok := registry.Entries[name]
+		if !ok {
+			comm.Warnf("Unknown prereq %s, skipping", name)
+			continue
+		}
+
+		for _, platform := range entry.Platforms {
+			if !wantPlatform(platform) {
+				continue
+			}
+
+			archivePath := filepath.Join(dir, mirrorArchiveName(name, ox.Platform(platform)))
+			if prev, ok := previous.Entries[name]; ok && prev.Version == entry.Version {
+				if _, err := os.Stat(archivePath); err == nil {
+					comm.Logf("%s (%s) is up-to-date at version %s", name, platform, entry.Version)
+					numArchives++
+					continue
+				}
+			}
+
+			comm.Opf("Downloading %s (%s) version %s", name, platform, entry.Version)
+			url := fmt.Sprintf("%s/%s-%s/LATEST/archive/default", baseURL, name, platform)
+			partialPath := archivePath + ".part"
+			_, err := dl.Do(ctx, url, partialPath)
+			if err != nil {
+				return errors.WithMessage(err, fmt.Sprintf("downloading %s (%s)", name, platform))
+			}
+			err = os.Rename(partialPath, archivePath)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			numArchives++
+		}
+	}
+
+	// written last, so an interrupted snapshot doesn't pass for up-to-date
+	err = os.WriteFile(registryPath, registryBytes, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	comm.Statf("Mirrored %d archives to (%s)", numArchives, dir)
+	return nil
+}
+
+func fetchRemoteRegistry(ctx *mansion.Context, baseURL string) ([]byte, error) {
+	infoURL := fmt.Sprintf("%s/info/LATEST/unpacked/default", baseURL)
+	req, err := http.NewRequest("GET", infoURL, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("User-Agent", ctx.UserAgent())
+
+	res, err := ctx.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return nil, errors.Errorf("Got HTTP %d when fetching registry (%s)", res.StatusCode, infoURL)
+	}
+
+	registryBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return registryBytes, nil
+}
@@ -0,0 +1,85 @@
+package prereqs
+
+import (
+	"fmt"
+
+	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/httpkit/eos"
+	"github.com/itchio/httpkit/eos/option"
+	"github.com/itchio/ox"
+	"github.com/itchio/savior"
+	"github.com/itchio/savior/zipextractor"
+	"github.com/pkg/errors"
+)
+
+// Extractor is implemented by libraries that serve prereqs as plain
+// archives, which are extracted directly instead of being installed
+// like itch.io uploads.
+type Extractor interface {
+	Extract(consumer *state.Consumer, name string, destDir string) error
+}
+
+// mirrorLibrary serves prereqs from a mirror made by `butler prereqs-mirror`,
+// for machines that can't reach itch.io.
+type mirrorLibrary struct {
+	mirror  string
+	runtime ox.Runtime
+}
+
+var _ Library = (*mirrorLibrary)(nil)
+var _ Extractor = (*mirrorLibrary)(nil)
+
+func NewMirrorLibrary(mirror string, runtime ox.Runtime) Library {
+	return &mirrorLibrary{
+		mirror:  mirror,
+		runtime: runtime,
+	}
+}
+
+func (l *mirrorLibrary) GetURL(name string, fileType itchio.BuildFileType) (string, error) {
+	if fileType != itchio.BuildFileTypeArchive {
+		return "", fmt.Errorf("Prereqs mirrors only have archives, can't get (%s) for prereq (%s)", fileType, name)
+	}
+	return mirrorPath(l.mirror, mirrorArchiveName(name, l.runtime.Platform)), nil
+}
+
+// GetUpload always returns nil: mirrored prereqs aren't uploads.
+func (l *mirrorLibrary) GetUpload(name string) *itchio.Upload {
+	return nil
+}
+
+func (l *mirrorLibrary) Extract(consumer *state.Consumer, name string, destDir string) error {
+	url, err := l.GetURL(name, itchio.BuildFileTypeArchive)
+	if err != nil {
+		return err
+	}
+	consumer.Infof("Extracting prereq (%s) from mirror (%s)", name, url)
+
+	f, err := eos.Open(url, option.WithConsumer(consumer))
+	if err != nil {
+		return errors.WithMessage(err, "opening mirrored archive")
+	}
+	defer f.Close()
+
+	stats, err := f.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ze, err := zipextractor.New(f, stats.Size())
+	if err != nil {
+		return errors.WithMessage(err, "reading mirrored archive")
+	}
+	ze.SetConsumer(consumer)
+
+	sink := &savior.FolderSink{
+		Directory: destDir,
+		Consumer:  consumer,
+	}
+	_, err = ze.Resume(nil, sink)
+	if err != nil {
+		return errors.WithMessage(err, "extracting mirrored archive")
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package prereqs
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/itchio/butler/manager"
+	"github.com/itchio/butler/mansion"
+	"github.com/itchio/butler/redist"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/ox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zipped(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, contents := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// fakeBroth serves a registry and archives like broth does, and counts
+// archive downloads.
+func fakeBroth(t *testing.T, downloads *int64) *httptest.Server {
+	registry := &redist.RedistRegistry{
+		Entries: map[string]*redist.RedistEntry{
+			"libfoo":  {FullName: "libfoo", Version: "1.0", Platforms: []string{"linux", "windows"}},
+			"xna-4.0": {FullName: "XNA 4.0", Version: "4.0", Platforms: []string{"windows"}},
+		},
+	}
+	registryBytes, err := json.Marshal(registry)
+	require.NoError(t, err)
+
+	archive := zipped(t, map[string]string{"lib/libfoo.so": "not really a library"})
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/info/LATEST/unpacked/default":
+			w.Write(registryBytes)
+		case strings.HasSuffix(r.URL.Path, "/LATEST/archive/default"):
+			atomic.AddInt64(downloads, 1)
+			w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func buildTestMirror(t *testing.T) string {
+	var downloads int64
+	broth := fakeBroth(t, &downloads)
+	ctx := &mansion.Context{HTTPClient: http.DefaultClient}
+
+	dir := t.TempDir()
+	require.NoError(t, mirrorFrom(ctx, broth.URL, dir, []string{"linux"}, nil))
+	assert.EqualValues(t, 1, downloads)
+
+	assert.FileExists(t, filepath.Join(dir, mirrorRegistryName))
+	assert.FileExists(t, filepath.Join(dir, "libfoo-linux.zip"))
+	assert.NoFileExists(t, filepath.Join(dir, "libfoo-windows.zip"))
+	assert.NoFileExists(t, filepath.Join(dir, "xna-4.0-windows.zip"))
+
+	// unchanged archives aren't downloaded again
+	require.NoError(t, mirrorFrom(ctx, broth.URL, dir, []string{"linux"}, nil))
+	assert.EqualValues(t, 1, downloads)
+	return dir
+}
+
+func mirrorTestHandler(t *testing.T, mirror string) *handler {
+	return &handler{
+		params: Params{
+			Consumer:   &state.Consumer{},
+			PrereqsDir: t.TempDir(),
+			Host:       manager.Host{Runtime: ox.Runtime{Platform: ox.PlatformLinux, Is64: true}},
+			Mirror:     mirror,
+		},
+	}
+}
+
+func assertResolvesFromMirror(t *testing.T, h *handler) {
+	registry, err := h.GetRegistry()
+	require.NoError(t, err)
+	require.Contains(t, registry.Entries, "libfoo")
+	assert.Equal(t, "1.0", registry.Entries["libfoo"].Version)
+
+	library, err := h.GetLibrary()
+	require.NoError(t, err)
+	assert.Nil(t, library.GetUpload("libfoo"))
+	_, err = library.GetURL("libfoo", itchio.BuildFileTypePatch)
+	assert.Error(t, err, "mirrors only have archives")
+
+	extractor, ok := library.(Extractor)
+	require.True(t, ok)
+	destDir := filepath.Join(t.TempDir(), "libfoo")
+	require.NoError(t, extractor.Extract(h.consumer(), "libfoo", destDir))
+
+	contents, err := os.ReadFile(filepath.Join(destDir, "lib", "libfoo.so"))
+	require.NoError(t, err)
+	assert.Equal(t, "not really a library", string(contents))
+}
+
+func TestDirectoryMirror(t *testing.T) {
+	dir := buildTestMirror(t)
+	h := mirrorTestHandler(t, dir)
+
+	library, err := h.GetLibrary()
+	require.NoError(t, err)
+	url, err := library.GetURL("libfoo", itchio.BuildFileTypeArchive)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "libfoo-linux.zip"), url)
+
+	assertResolvesFromMirror(t, h)
+}
+
+func TestURLMirror(t *testing.T) {
+	dir := buildTestMirror(t)
+	s := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	t.Cleanup(s.Close)
+
+	h := mirrorTestHandler(t, s.URL+"/")
+
+	library, err := h.GetLibrary()
+	require.NoError(t, err)
+	url, err := library.GetURL("libfoo", itchio.BuildFileTypeArchive)
+	require.NoError(t, err)
+	assert.Equal(t, s.URL+"/libfoo-linux.zip", url)
+
+	assertResolvesFromMirror(t, h)
+}
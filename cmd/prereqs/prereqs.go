@@ -15,6 +15,12 @@ var testArgs = struct {
 	prereqs *[]string
 }{}
 
+var mirrorArgs = struct {
+	dir       *string
+	platforms *[]string
+	prereqs   *[]string
+}{}
+
 func Register(ctx *mansion.Context) {
 	{
 		cmd := ctx.App.Command("install-prereqs", "Install prerequisites from an install plan").Hidden()
@@ -28,6 +34,14 @@ func Register(ctx *mansion.Context) {
 		ctx.Register(cmd, doTest)
 		testArgs.prereqs = cmd.Arg("prereqs", "Which prereqs to install (space-separated). Leave empty to get a list").Strings()
 	}
+
+	{
+		cmd := ctx.App.Command("prereqs-mirror", "Snapshot the prerequisites registry and archives into a folder, for use with 'butler daemon --prereqs-mirror'")
+		mirrorArgs.dir = cmd.Arg("dir", "Folder to mirror to, can be served over HTTP").Required().String()
+		mirrorArgs.platforms = cmd.Flag("platform", "Only mirror prereqs for this platform (windows, linux, osx). Can be repeated").Strings()
+		mirrorArgs.prereqs = cmd.Arg("prereqs", "Which prereqs to mirror (space-separated). Leave empty for all").Strings()
+		ctx.Register(cmd, doMirror)
+	}
 }
 
 func doInstall(ctx *mansion.Context) {
@@ -38,6 +52,10 @@ func doTest(ctx *mansion.Context) {
 	ctx.Must(Test(ctx, *testArgs.prereqs))
 }
 
+func doMirror(ctx *mansion.Context) {
+	ctx.Must(Mirror(ctx, *mirrorArgs.dir, *mirrorArgs.platforms, *mirrorArgs.prereqs))
+}
+
 // PrereqTask describes something the prereq installer has to do
 type PrereqTask struct {
 	Name    string              `json:"name"`
@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/itchio/butler/cmd/apply"
+	"github.com/itchio/butler/cmd/auditinstalls"
 	"github.com/itchio/butler/cmd/auditzip"
 	"github.com/itchio/butler/cmd/configure"
 	"github.com/itchio/butler/cmd/cp"
@@ -17,12 +18,15 @@ import (
 	"github.com/itchio/butler/cmd/fetch"
 	"github.com/itchio/butler/cmd/file"
 	"github.com/itchio/butler/cmd/fujicmd"
+	"github.com/itchio/butler/cmd/game"
 	"github.com/itchio/butler/cmd/heal"
+	"github.com/itchio/butler/cmd/library"
 	"github.com/itchio/butler/cmd/login"
 	"github.com/itchio/butler/cmd/logout"
 	"github.com/itchio/butler/cmd/ls"
 	"github.com/itchio/butler/cmd/mkdir"
 	"github.com/itchio/butler/cmd/mkzip"
+	"github.com/itchio/butler/cmd/movecaves"
 	"github.com/itchio/butler/cmd/msi"
 	"github.com/itchio/butler/cmd/pipe"
 	"github.com/itchio/butler/cmd/prereqs"
@@ -88,6 +92,12 @@ func registerCommands(ctx *mansion.Context) {
 
 	prereqs.Register(ctx)
 	msi.Register(ctx)
+	movecaves.Register(ctx)
+	library.Register(ctx)
+	auditinstalls.Register(ctx)
+	game.Register(ctx)
+	game.RegisterSync(ctx)
+	game.RegisterUploads(ctx)
 
 	extract.Register(ctx)
 	unzip.Register(ctx)
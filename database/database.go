@@ -1,7 +1,12 @@
 package database
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+
 	"crawshaw.io/sqlite"
+	"crawshaw.io/sqlite/sqlitex"
 	"github.com/itchio/butler/butlerd/horror"
 	"github.com/itchio/butler/database/models"
 	"github.com/itchio/butler/database/models/migrations"
@@ -29,3 +34,36 @@ func Prepare(consumer *state.Consumer, conn *sqlite.Conn, justCreated bool) (ret
 
 	return nil
 }
+
+// OpenPool opens butlerd's database at dbPath, creating and migrating it
+// if needed.
+func OpenPool(consumer *state.Consumer, dbPath string) (*sqlitex.Pool, error) {
+	err := os.MkdirAll(filepath.Dir(dbPath), 0o755)
+	if err != nil {
+		return nil, errors.WithMessage(err, "creating DB directory if necessary")
+	}
+
+	justCreated := false
+	_, statErr := os.Stat(dbPath)
+	if statErr != nil {
+		consumer.Infof("butlerd: creating new DB at %s", dbPath)
+		justCreated = true
+	}
+
+	dbPool, err := sqlitex.Open(dbPath, 0, 100)
+	if err != nil {
+		return nil, errors.WithMessage(err, "opening DB for the first time")
+	}
+
+	err = func() error {
+		conn := dbPool.Get(context.Background())
+		defer dbPool.Put(conn)
+		return Prepare(consumer, conn, justCreated)
+	}()
+	if err != nil {
+		dbPool.Close()
+		return nil, errors.WithMessage(err, "preparing DB")
+	}
+
+	return dbPool, nil
+}
@@ -31,6 +31,10 @@ var AllModels = []interface{}{
 	&GameUpload{},
 	&CaveHistoricalPlayTime{},
 	&UserGameInteraction{},
+	&BackgroundTask{},
+	&PlaySession{},
+	&Crash{},
+	&CompatHost{},
 }
 
 // declareIndexes registers secondary indexes for the game-to-profile
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/hades"
+	"xorm.io/builder"
+)
+
+// BackgroundTask is a persisted unit of work queued by butlerd's router.
+// Tasks survive daemon restarts and are retried with exponential backoff
+// until they succeed or run out of attempts.
+type BackgroundTask struct {
+	// An UUID
+	ID string `json:"id" hades:"primary_key"`
+
+	// Identifies which factory can rebuild the task, something like
+	// "fetch-user-game-sessions"
+	Kind string `json:"kind"`
+	// Kind-specific parameters, JSON-encoded
+	Payload JSON `json:"payload"`
+	// Human-readable description, for logs and listings
+	Desc string `json:"desc"`
+
+	Attempts  int64      `json:"attempts"`
+	NextRunAt *time.Time `json:"nextRunAt"`
+	LastError *string    `json:"lastError"`
+
+	CreatedAt *time.Time `json:"createdAt"`
+	// Set while the task is running, cleared when it finishes
+	StartedAt *time.Time `json:"startedAt"`
+	// Set once the task has exhausted all its attempts
+	FailedAt *time.Time `json:"failedAt"`
+}
+
+func BackgroundTaskByID(conn *sqlite.Conn, id string) *BackgroundTask {
+	var bt BackgroundTask
+	if MustSelectOne(conn, &bt, builder.Eq{"id": id}) {
+		return &bt
+	}
+	return nil
+}
+
+// AllBackgroundTasks returns all persisted tasks, failed ones included.
+func AllBackgroundTasks(conn *sqlite.Conn) []*BackgroundTask {
+	var bts []*BackgroundTask
+	MustSelect(conn, &bts, builder.NewCond(), hades.Search{})
+	sort.SliceStable(bts, func(i, j int) bool {
+		return timeBefore(bts[i].CreatedAt, bts[j].CreatedAt)
+	})
+	return bts
+}
+
+// PendingBackgroundTasks returns tasks that haven't given up yet, running ones
+// included, soonest first.
+func PendingBackgroundTasks(conn *sqlite.Conn) []*BackgroundTask {
+	var bts []*BackgroundTask
+	MustSelect(conn, &bts, builder.IsNull{"failed_at"}, hades.Search{})
+	sort.SliceStable(bts, func(i, j int) bool {
+		return timeBefore(bts[i].NextRunAt, bts[j].NextRunAt)
+	})
+	return bts
+}
+
+// PendingBackgroundTaskFor returns a task of the same kind and payload that
+// hasn't given up yet, if any.
+func PendingBackgroundTaskFor(conn *sqlite.Conn, kind string, payload JSON) *BackgroundTask {
+	var bt BackgroundTask
+	if MustSelectOne(conn, &bt, builder.And(
+		builder.Eq{"kind": kind, "payload": payload},
+		builder.IsNull{"failed_at"},
+	)) {
+		return &bt
+	}
+	return nil
+}
+
+// ResetRunningBackgroundTasks clears StartedAt on all tasks. Any task still
+// marked as running when the daemon starts was interrupted, and should
+// be picked up again.
+func ResetRunningBackgroundTasks(conn *sqlite.Conn) {
+	var bts []*BackgroundTask
+	MustSelect(conn, &bts, builder.NotNull{"started_at"}, hades.Search{})
+	for _, bt := range bts {
+		bt.StartedAt = nil
+		bt.Save(conn)
+	}
+}
+
+// IsDue returns true if the task hasn't given up, isn't running, and its
+// next run time has passed.
+func (bt *BackgroundTask) IsDue(now time.Time) bool {
+	if bt.FailedAt != nil || bt.StartedAt != nil {
+		return false
+	}
+	return bt.NextRunAt == nil || !bt.NextRunAt.After(now)
+}
+
+func (bt *BackgroundTask) Save(conn *sqlite.Conn) {
+	MustSave(conn, bt)
+}
+
+func (bt *BackgroundTask) Delete(conn *sqlite.Conn) {
+	MustDelete(conn, &BackgroundTask{}, builder.Eq{"id": bt.ID})
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingBackgroundTasksSkipFailed(t *testing.T) {
+	conn := interactionTestConn(t)
+
+	now := time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	MustSave(conn, &BackgroundTask{ID: "later", Kind: "k", Payload: "1", NextRunAt: &later})
+	MustSave(conn, &BackgroundTask{ID: "now", Kind: "k", Payload: "2", NextRunAt: &now})
+	MustSave(conn, &BackgroundTask{ID: "failed", Kind: "k", Payload: "3", NextRunAt: &now, FailedAt: &now})
+
+	pending := PendingBackgroundTasks(conn)
+	require.Len(t, pending, 2)
+	require.EqualValues(t, "now", pending[0].ID)
+	require.EqualValues(t, "later", pending[1].ID)
+
+	require.Len(t, AllBackgroundTasks(conn), 3)
+}
+
+func TestPendingBackgroundTasksSortWithinASecond(t *testing.T) {
+	conn := interactionTestConn(t)
+
+	// as text, "12:00:00.5Z" sorts before "12:00:00Z"
+	whole := time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)
+	half := whole.Add(500 * time.Millisecond)
+	MustSave(conn, &BackgroundTask{ID: "half", Kind: "k", Payload: "1", NextRunAt: &half, CreatedAt: &half})
+	MustSave(conn, &BackgroundTask{ID: "whole", Kind: "k", Payload: "2", NextRunAt: &whole, CreatedAt: &whole})
+
+	pending := PendingBackgroundTasks(conn)
+	require.Len(t, pending, 2)
+	require.EqualValues(t, "whole", pending[0].ID)
+	require.EqualValues(t, "half", pending[1].ID)
+
+	all := AllBackgroundTasks(conn)
+	require.Len(t, all, 2)
+	require.EqualValues(t, "whole", all[0].ID)
+}
+
+func TestPendingBackgroundTaskForMatchesKindAndPayload(t *testing.T) {
+	conn := interactionTestConn(t)
+
+	now := time.Now().UTC()
+	MustSave(conn, &BackgroundTask{ID: "a", Kind: "k", Payload: `{"gameId":1}`})
+	MustSave(conn, &BackgroundTask{ID: "b", Kind: "k", Payload: `{"gameId":2}`, FailedAt: &now})
+
+	bt := PendingBackgroundTaskFor(conn, "k", `{"gameId":1}`)
+	require.NotNil(t, bt)
+	require.EqualValues(t, "a", bt.ID)
+
+	require.Nil(t, PendingBackgroundTaskFor(conn, "k", `{"gameId":2}`), "failed tasks don't count")
+	require.Nil(t, PendingBackgroundTaskFor(conn, "other", `{"gameId":1}`))
+}
+
+func TestResetRunningBackgroundTasks(t *testing.T) {
+	conn := interactionTestConn(t)
+
+	now := time.Now().UTC()
+	MustSave(conn, &BackgroundTask{ID: "a", Kind: "k", StartedAt: &now})
+
+	bt := BackgroundTaskByID(conn, "a")
+	require.NotNil(t, bt)
+	require.False(t, bt.IsDue(now))
+
+	ResetRunningBackgroundTasks(conn)
+
+	bt = BackgroundTaskByID(conn, "a")
+	require.NotNil(t, bt)
+	require.Nil(t, bt.StartedAt)
+	require.True(t, bt.IsDue(now))
+}
+
+func TestBackgroundTaskIsDue(t *testing.T) {
+	now := time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Minute)
+
+	require.True(t, (&BackgroundTask{}).IsDue(now))
+	require.True(t, (&BackgroundTask{NextRunAt: &now}).IsDue(now))
+	require.False(t, (&BackgroundTask{NextRunAt: &later}).IsDue(now))
+	require.False(t, (&BackgroundTask{FailedAt: &now}).IsDue(now))
+}
@@ -117,6 +117,16 @@ func (c *Cave) GetInstallFolder(conn *sqlite.Conn) string {
 	return c.GetInstallLocation(conn).GetInstallFolder(c.InstallFolderName)
 }
 
+// GetCompatPrefixFolder returns the folder of the cave's isolated wine
+// prefix, or an empty string if it has no install location.
+func (c *Cave) GetCompatPrefixFolder(conn *sqlite.Conn) string {
+	il := c.GetInstallLocation(conn)
+	if il == nil {
+		return ""
+	}
+	return il.GetCompatPrefixFolder(c.ID)
+}
+
 func (c *Cave) Preload(conn *sqlite.Conn) {
 	if c == nil {
 		return
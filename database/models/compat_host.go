@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/hades"
+	"xorm.io/builder"
+)
+
+// CompatHost is a user-defined compatibility layer (a wine prefix, a
+// Proton build, DOSBox, etc.) that can run games made for another
+// platform.
+type CompatHost struct {
+	// An UUID
+	ID string `json:"id" hades:"primary_key"`
+
+	// Human-readable name, like "Proton 9"
+	Name string `json:"name"`
+
+	// Platform of the games it can run, like "windows"
+	Platform string `json:"platform"`
+	// True if it can run 64-bit games
+	Is64 bool `json:"is64"`
+
+	// JSON-encoded manager.Wrapper
+	Wrapper JSON `json:"wrapper"`
+
+	// Hosts with a lower position are preferred
+	Position  int64      `json:"position"`
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+func CompatHostByID(conn *sqlite.Conn, id string) *CompatHost {
+	var ch CompatHost
+	if MustSelectOne(conn, &ch, builder.Eq{"id": id}) {
+		return &ch
+	}
+	return nil
+}
+
+// AllCompatHosts returns all compat hosts, most preferred first.
+func AllCompatHosts(conn *sqlite.Conn) []*CompatHost {
+	var chs []*CompatHost
+	MustSelect(conn, &chs, builder.NewCond(), hades.Search{}.OrderBy("position ASC"))
+	return chs
+}
+
+func (ch *CompatHost) Save(conn *sqlite.Conn) {
+	MustSave(conn, ch)
+}
+
+func (ch *CompatHost) Delete(conn *sqlite.Conn) {
+	MustDelete(conn, &CompatHost{}, builder.Eq{"id": ch.ID})
+}
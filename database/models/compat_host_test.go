@@ -0,0 +1,26 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompatHostsByPosition(t *testing.T) {
+	conn := interactionTestConn(t)
+
+	(&CompatHost{ID: "dosbox", Name: "DOSBox", Position: 2}).Save(conn)
+	(&CompatHost{ID: "proton", Name: "Proton", Position: 0}).Save(conn)
+	(&CompatHost{ID: "prefix", Name: "Wine prefix", Position: 1}).Save(conn)
+
+	chs := AllCompatHosts(conn)
+	require.Len(t, chs, 3)
+	require.EqualValues(t, "proton", chs[0].ID)
+	require.EqualValues(t, "prefix", chs[1].ID)
+	require.EqualValues(t, "dosbox", chs[2].ID)
+
+	chs[1].Delete(conn)
+	require.Nil(t, CompatHostByID(conn, "prefix"))
+	require.NotNil(t, CompatHostByID(conn, "dosbox"))
+	require.Len(t, AllCompatHosts(conn), 2)
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/hades"
+	"xorm.io/builder"
+)
+
+// How many crashes are kept per cave, older ones are pruned
+const maxCrashesPerCave = 20
+
+// Crash records an abnormal exit of a launched game, along with
+// what's needed to report it.
+type Crash struct {
+	// An UUID
+	ID string `json:"id" hades:"primary_key"`
+
+	ProfileID int64  `json:"profileId"`
+	CaveID    string `json:"caveId"`
+	GameID    int64  `json:"gameId"`
+	UploadID  int64  `json:"uploadId"`
+	BuildID   int64  `json:"buildId"`
+	// The play session that ended in this crash, if any
+	PlaySessionID string `json:"playSessionId"`
+
+	CrashedAt *time.Time `json:"crashedAt"`
+	// How long the game ran before crashing
+	SecondsRun int64 `json:"secondsRun"`
+
+	ExitCode int64 `json:"exitCode"`
+	// Name of the signal that terminated the game, if any
+	Signal string `json:"signal"`
+
+	// Last few kilobytes of output
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+
+	// JSON-encoded launch parameters (target, arguments, etc.)
+	LaunchParams JSON `json:"launchParams"`
+	// JSON-encoded summary of the environment the game ran in
+	Environment JSON `json:"environment"`
+}
+
+func CrashByID(conn *sqlite.Conn, id string) *Crash {
+	var c Crash
+	if MustSelectOne(conn, &c, builder.Eq{"id": id}) {
+		return &c
+	}
+	return nil
+}
+
+// CrashesMatching returns crashes matching the given condition,
+// most recent first.
+func CrashesMatching(conn *sqlite.Conn, cond builder.Cond) []*Crash {
+	var cs []*Crash
+	MustSelect(conn, &cs, cond, hades.Search{})
+	sortCrashes(cs)
+	return cs
+}
+
+// sortCrashes sorts most recent first, see timeBefore
+func sortCrashes(cs []*Crash) {
+	sort.SliceStable(cs, func(i, j int) bool {
+		a, b := cs[i].CrashedAt, cs[j].CrashedAt
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.After(*b)
+	})
+}
+
+func (c *Crash) Save(conn *sqlite.Conn) {
+	MustSave(conn, c)
+}
+
+// PruneCrashes removes the oldest crashes of a cave, keeping
+// at most maxCrashesPerCave.
+func PruneCrashes(conn *sqlite.Conn, caveID string) {
+	cs := CrashesMatching(conn, builder.Eq{"cave_id": caveID})
+	if len(cs) <= maxCrashesPerCave {
+		return
+	}
+
+	var ids []interface{}
+	for _, c := range cs[maxCrashesPerCave:] {
+		ids = append(ids, c.ID)
+	}
+	MustDelete(conn, &Crash{}, builder.In("id", ids...))
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"xorm.io/builder"
+)
+
+func TestCrashesMatchingMostRecentFirst(t *testing.T) {
+	conn := interactionTestConn(t)
+
+	base := time.Date(2026, 7, 20, 9, 0, 0, 0, time.UTC)
+	// 9:00 sorts after 10:00 as text, make sure that doesn't matter
+	for i, hour := range []int{9, 10, 8} {
+		crashedAt := base.Add(time.Duration(hour-9) * time.Hour)
+		(&Crash{ID: fmt.Sprintf("c%d", i), CaveID: "cave", CrashedAt: &crashedAt}).Save(conn)
+	}
+	(&Crash{ID: "other", CaveID: "other-cave"}).Save(conn)
+
+	cs := CrashesMatching(conn, builder.Eq{"cave_id": "cave"})
+	require.Len(t, cs, 3)
+	require.EqualValues(t, "c1", cs[0].ID)
+	require.EqualValues(t, "c0", cs[1].ID)
+	require.EqualValues(t, "c2", cs[2].ID)
+}
+
+func TestPruneCrashes(t *testing.T) {
+	conn := interactionTestConn(t)
+
+	base := time.Date(2026, 7, 20, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < maxCrashesPerCave+5; i++ {
+		crashedAt := base.Add(time.Duration(i) * time.Minute)
+		(&Crash{ID: fmt.Sprintf("c%d", i), CaveID: "cave", CrashedAt: &crashedAt}).Save(conn)
+	}
+	(&Crash{ID: "other", CaveID: "other-cave", CrashedAt: &base}).Save(conn)
+
+	PruneCrashes(conn, "cave")
+
+	cs := CrashesMatching(conn, builder.Eq{"cave_id": "cave"})
+	require.Len(t, cs, maxCrashesPerCave)
+	require.EqualValues(t, fmt.Sprintf("c%d", maxCrashesPerCave+4), cs[0].ID)
+	require.Nil(t, CrashByID(conn, "c0"), "oldest crash should be pruned")
+	require.NotNil(t, CrashByID(conn, "other"), "other caves are left alone")
+}
@@ -186,6 +186,14 @@ func MarkAllFresh(conn *sqlite.Conn, fts []FetchTarget) error {
 	return Save(conn, fis)
 }
 
+// ObjectID is the ID fetch info is stored under
+func (ft FetchTarget) ObjectID() string {
+	if ft.StringID != "" {
+		return ft.StringID
+	}
+	return strconv.FormatInt(ft.ID, 10)
+}
+
 func (ft FetchTarget) Key() string {
 	if ft.StringID != "" {
 		return fmt.Sprintf("%s-%s", ft.Type, ft.StringID)
@@ -1,7 +1,11 @@
 package models
 
 import (
+	"sort"
+	"sync"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 const defaultTTL = 2 * time.Minute
@@ -9,11 +13,70 @@ const longTTL = 10 * time.Minute
 const collectionGamesTTL = 7 * 24 * time.Hour
 const bundleGamesTTL = 7 * 24 * time.Hour
 
+// TTLs used unless overridden with SetFetchTTL, by fetch target type
+var defaultFetchTTLs = map[string]time.Duration{
+	"game":                      defaultTTL,
+	"upload":                    defaultTTL,
+	"game_uploads":              defaultTTL,
+	"user":                      longTTL,
+	"profile_collections":       defaultTTL,
+	"profile_games":             defaultTTL,
+	"profile_owned_keys":        defaultTTL,
+	"collection":                defaultTTL,
+	"collection_games":          collectionGamesTTL,
+	"profile_owned_bundles":     defaultTTL,
+	"bundle_games":              bundleGamesTTL,
+	"profile_bundle_ownerships": longTTL,
+}
+
+var fetchTTLOverrides = make(map[string]time.Duration)
+var fetchTTLOverridesLock sync.RWMutex
+
+// FetchTargetTypes returns the types of fetch targets whose TTL can be
+// changed, sorted by name.
+func FetchTargetTypes() []string {
+	var types []string
+	for t := range defaultFetchTTLs {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// SetFetchTTL changes the age after which fetch targets of a type are
+// considered stale. A zero TTL restores the default.
+func SetFetchTTL(targetType string, ttl time.Duration) error {
+	if _, ok := defaultFetchTTLs[targetType]; !ok {
+		return errors.Errorf("unknown fetch target type (%s)", targetType)
+	}
+	if ttl < 0 {
+		return errors.Errorf("TTL for %s can't be negative", targetType)
+	}
+
+	fetchTTLOverridesLock.Lock()
+	defer fetchTTLOverridesLock.Unlock()
+	if ttl == 0 {
+		delete(fetchTTLOverrides, targetType)
+	} else {
+		fetchTTLOverrides[targetType] = ttl
+	}
+	return nil
+}
+
+func fetchTTL(targetType string) time.Duration {
+	fetchTTLOverridesLock.RLock()
+	defer fetchTTLOverridesLock.RUnlock()
+	if ttl, ok := fetchTTLOverrides[targetType]; ok {
+		return ttl
+	}
+	return defaultFetchTTLs[targetType]
+}
+
 func FetchTargetForGame(gameID int64) FetchTarget {
 	return FetchTarget{
 		ID:   gameID,
 		Type: "game",
-		TTL:  defaultTTL,
+		TTL:  fetchTTL("game"),
 	}
 }
 
@@ -21,7 +84,7 @@ func FetchTargetForUpload(uploadID int64) FetchTarget {
 	return FetchTarget{
 		ID:   uploadID,
 		Type: "upload",
-		TTL:  defaultTTL,
+		TTL:  fetchTTL("upload"),
 	}
 }
 
@@ -29,7 +92,7 @@ func FetchTargetForGameUploads(gameID int64) FetchTarget {
 	return FetchTarget{
 		ID:   gameID,
 		Type: "game_uploads",
-		TTL:  defaultTTL,
+		TTL:  fetchTTL("game_uploads"),
 	}
 }
 
@@ -37,7 +100,7 @@ func FetchTargetForUser(userID int64) FetchTarget {
 	return FetchTarget{
 		ID:   userID,
 		Type: "user",
-		TTL:  longTTL,
+		TTL:  fetchTTL("user"),
 	}
 }
 
@@ -45,7 +108,7 @@ func FetchTargetForProfileCollections(profileID int64) FetchTarget {
 	return FetchTarget{
 		ID:   profileID,
 		Type: "profile_collections",
-		TTL:  defaultTTL,
+		TTL:  fetchTTL("profile_collections"),
 	}
 }
 
@@ -53,7 +116,7 @@ func FetchTargetForProfileGames(profileID int64) FetchTarget {
 	return FetchTarget{
 		ID:   profileID,
 		Type: "profile_games",
-		TTL:  defaultTTL,
+		TTL:  fetchTTL("profile_games"),
 	}
 }
 
@@ -61,7 +124,7 @@ func FetchTargetForProfileOwnedKeys(profileID int64) FetchTarget {
 	return FetchTarget{
 		ID:   profileID,
 		Type: "profile_owned_keys",
-		TTL:  defaultTTL,
+		TTL:  fetchTTL("profile_owned_keys"),
 	}
 }
 
@@ -69,7 +132,7 @@ func FetchTargetForCollection(collectionID int64) FetchTarget {
 	return FetchTarget{
 		ID:   collectionID,
 		Type: "collection",
-		TTL:  defaultTTL,
+		TTL:  fetchTTL("collection"),
 	}
 }
 
@@ -77,7 +140,7 @@ func FetchTargetForCollectionGames(collectionID int64) FetchTarget {
 	return FetchTarget{
 		ID:   collectionID,
 		Type: "collection_games",
-		TTL:  collectionGamesTTL,
+		TTL:  fetchTTL("collection_games"),
 	}
 }
 
@@ -85,7 +148,7 @@ func FetchTargetForProfileOwnedBundles(profileID int64) FetchTarget {
 	return FetchTarget{
 		ID:   profileID,
 		Type: "profile_owned_bundles",
-		TTL:  defaultTTL,
+		TTL:  fetchTTL("profile_owned_bundles"),
 	}
 }
 
@@ -93,7 +156,7 @@ func FetchTargetForBundleGames(bundleID int64) FetchTarget {
 	return FetchTarget{
 		ID:   bundleID,
 		Type: "bundle_games",
-		TTL:  bundleGamesTTL,
+		TTL:  fetchTTL("bundle_games"),
 	}
 }
 
@@ -101,6 +164,6 @@ func FetchTargetForProfileBundleOwnerships(profileID int64) FetchTarget {
 	return FetchTarget{
 		ID:   profileID,
 		Type: "profile_bundle_ownerships",
-		TTL:  longTTL,
+		TTL:  fetchTTL("profile_bundle_ownerships"),
 	}
 }
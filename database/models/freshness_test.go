@@ -0,0 +1,25 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFetchTTL(t *testing.T) {
+	require.EqualValues(t, defaultTTL, FetchTargetForGame(1).TTL)
+	require.EqualValues(t, collectionGamesTTL, FetchTargetForCollectionGames(1).TTL)
+
+	require.NoError(t, SetFetchTTL("game", time.Hour))
+	defer SetFetchTTL("game", 0)
+	require.EqualValues(t, time.Hour, FetchTargetForGame(1).TTL)
+	require.EqualValues(t, defaultTTL, FetchTargetForUpload(1).TTL)
+
+	require.NoError(t, SetFetchTTL("game", 0))
+	require.EqualValues(t, defaultTTL, FetchTargetForGame(1).TTL)
+
+	require.Error(t, SetFetchTTL("games", time.Hour))
+	require.Error(t, SetFetchTTL("game", -time.Hour))
+	require.Contains(t, FetchTargetTypes(), "profile_collections")
+}
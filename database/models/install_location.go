@@ -13,6 +13,14 @@ type InstallLocation struct {
 
 	Path string `json:"path"`
 
+	// Maximum number of bytes caves in this location may use,
+	// 0 for no limit
+	QuotaSize int64 `json:"quotaSize"`
+
+	// Number of bytes to always leave free on this location's
+	// disk, 0 for none
+	ReservedSize int64 `json:"reservedSize"`
+
 	Caves []*Cave `json:"caves"`
 }
 
@@ -32,9 +40,81 @@ func (il *InstallLocation) GetStagingFolder(installID string) string {
 	return filepath.Join(il.Path, "downloads", installID)
 }
 
+// GetCompatPrefixFolder returns where a cave's isolated wine prefix
+// lives, outside of its install folder so that it survives reinstalls.
+func (il *InstallLocation) GetCompatPrefixFolder(caveID string) string {
+	return filepath.Join(il.Path, "compat-prefixes", caveID)
+}
+
 func (il *InstallLocation) GetCaves(conn *sqlite.Conn) []*Cave {
 	MustPreload(conn, il,
 		hades.Assoc("Caves"),
 	)
 	return il.Caves
 }
+
+// GetInstalledSize returns the number of bytes used by caves
+// installed in this location.
+func (il *InstallLocation) GetInstalledSize(conn *sqlite.Conn) int64 {
+	var installedSize int64
+	MustExecRaw(conn, `
+		SELECT coalesce(sum(coalesce(installed_size, 0)), 0) AS installed_size
+		FROM caves
+		WHERE install_location_id = ?
+	`, func(stmt *sqlite.Stmt) error {
+		installedSize = stmt.ColumnInt64(0)
+		return nil
+	}, il.ID)
+	return installedSize
+}
+
+type SpaceLimit string
+
+const (
+	// The install location's quota would be exceeded
+	SpaceLimitQuota SpaceLimit = "quota"
+	// The disk would have less free space than the install
+	// location's reserve, or none at all
+	SpaceLimitFreeSpace SpaceLimit = "free-space"
+)
+
+// SpaceShortage tells why something doesn't fit in an install location.
+type SpaceShortage struct {
+	Limit SpaceLimit
+
+	// Bytes the operation needs
+	NeededSize int64
+
+	// Bytes it may use without crossing the limit
+	AvailableSize int64
+}
+
+// CheckSpace returns a shortage if an operation that needs neededFreeSpace
+// bytes to run, and uses finalDiskUsage bytes once done, would cross this
+// location's quota or reserve. installedSize is what caves already use,
+// not counting the one being replaced, and freeSize is negative if unknown.
+func (il *InstallLocation) CheckSpace(installedSize int64, freeSize int64, finalDiskUsage int64, neededFreeSpace int64) *SpaceShortage {
+	if il.QuotaSize > 0 && installedSize+finalDiskUsage > il.QuotaSize {
+		return &SpaceShortage{
+			Limit:         SpaceLimitQuota,
+			NeededSize:    finalDiskUsage,
+			AvailableSize: nonNegative(il.QuotaSize - installedSize),
+		}
+	}
+
+	if freeSize >= 0 && neededFreeSpace > freeSize-il.ReservedSize {
+		return &SpaceShortage{
+			Limit:         SpaceLimitFreeSpace,
+			NeededSize:    neededFreeSpace,
+			AvailableSize: nonNegative(freeSize - il.ReservedSize),
+		}
+	}
+	return nil
+}
+
+func nonNegative(n int64) int64 {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallLocationInstalledSize(t *testing.T) {
+	conn := interactionTestConn(t)
+
+	il := &InstallLocation{ID: "il", Path: "/games"}
+	MustSave(conn, il)
+	require.EqualValues(t, 0, il.GetInstalledSize(conn))
+
+	MustSave(conn, &Cave{ID: "a", InstallLocationID: "il", InstalledSize: 100})
+	MustSave(conn, &Cave{ID: "b", InstallLocationID: "il", InstalledSize: 50})
+	MustSave(conn, &Cave{ID: "c", InstallLocationID: "other", InstalledSize: 1000})
+	require.EqualValues(t, 150, il.GetInstalledSize(conn))
+}
+
+func TestInstallLocationCheckSpace(t *testing.T) {
+	il := &InstallLocation{}
+	require.Nil(t, il.CheckSpace(1000, 500, 400, 500))
+	require.EqualValues(t, &SpaceShortage{
+		Limit:         SpaceLimitFreeSpace,
+		NeededSize:    600,
+		AvailableSize: 500,
+	}, il.CheckSpace(1000, 500, 400, 600))
+	// unknown free space isn't enforced
+	require.Nil(t, il.CheckSpace(1000, -1, 400, 600))
+
+	il.ReservedSize = 200
+	require.Nil(t, il.CheckSpace(1000, 500, 200, 300))
+	require.EqualValues(t, &SpaceShortage{
+		Limit:         SpaceLimitFreeSpace,
+		NeededSize:    400,
+		AvailableSize: 300,
+	}, il.CheckSpace(1000, 500, 200, 400))
+	require.EqualValues(t, 0, il.CheckSpace(1000, 100, 0, 1).AvailableSize)
+
+	il.QuotaSize = 1200
+	require.Nil(t, il.CheckSpace(1000, 500, 200, 300))
+	require.EqualValues(t, &SpaceShortage{
+		Limit:         SpaceLimitQuota,
+		NeededSize:    250,
+		AvailableSize: 200,
+	}, il.CheckSpace(1000, 5000, 250, 300))
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"crawshaw.io/sqlite"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/hades"
+	"xorm.io/builder"
+)
+
+// How often running sessions are written to the journal, and
+// reported to itch.io
+const PlaySessionHeartbeatInterval = 1 * time.Minute
+
+// PlaySession is the local journal entry for a single launch of a game.
+// It's written on session start, every heartbeat and on session end,
+// whether or not itch.io can be reached, and is reconciled with the
+// remote user game session once online.
+type PlaySession struct {
+	// An UUID
+	ID string `json:"id" hades:"primary_key"`
+
+	ProfileID int64  `json:"profileId"`
+	CaveID    string `json:"caveId"`
+	GameID    int64  `json:"gameId"`
+	UploadID  int64  `json:"uploadId"`
+	BuildID   int64  `json:"buildId"`
+
+	Platform     string `json:"platform"`
+	Architecture string `json:"architecture"`
+
+	StartedAt       *time.Time `json:"startedAt"`
+	LastHeartbeatAt *time.Time `json:"lastHeartbeatAt"`
+	// Nil while the game is running, or if butler went away
+	// before the game exited
+	EndedAt    *time.Time `json:"endedAt"`
+	SecondsRun int64      `json:"secondsRun"`
+	Crashed    bool       `json:"crashed"`
+
+	// The itch.io user game session ID, zero until it's been created
+	RemoteSessionID int64 `json:"remoteSessionId"`
+	// Seconds run as last acknowledged by itch.io
+	SyncedSecondsRun int64 `json:"syncedSecondsRun"`
+	// Set once the ended session has been fully acknowledged by itch.io
+	SyncedAt *time.Time `json:"syncedAt"`
+
+	// Set just before asking itch.io to create the session from the
+	// journal, cleared once its ID is known. Still set on the next sync
+	// means the response was lost, and the session may exist already.
+	CreateSentAt *time.Time `json:"createSentAt"`
+	// itch.io's total seconds run for the game before that request
+	RemoteSecondsRunBefore int64 `json:"remoteSecondsRunBefore"`
+}
+
+func PlaySessionByID(conn *sqlite.Conn, id string) *PlaySession {
+	var ps PlaySession
+	if MustSelectOne(conn, &ps, builder.Eq{"id": id}) {
+		return &ps
+	}
+	return nil
+}
+
+// UnsyncedPlaySessions returns all sessions that itch.io doesn't fully
+// know about yet, oldest first.
+func UnsyncedPlaySessions(conn *sqlite.Conn) []*PlaySession {
+	var pss []*PlaySession
+	MustSelect(conn, &pss, builder.IsNull{"synced_at"}, hades.Search{})
+	sortPlaySessions(pss)
+	return pss
+}
+
+// AllPlaySessions returns every session, oldest first.
+func AllPlaySessions(conn *sqlite.Conn) []*PlaySession {
+	var pss []*PlaySession
+	MustSelect(conn, &pss, builder.NewCond(), hades.Search{})
+	sortPlaySessions(pss)
+	return pss
+}
+
+// sortPlaySessions sorts oldest first, see timeBefore
+func sortPlaySessions(pss []*PlaySession) {
+	sort.SliceStable(pss, func(i, j int) bool {
+		return timeBefore(pss[i].StartedAt, pss[j].StartedAt)
+	})
+}
+
+// CloseStalePlaySessions marks sessions that haven't received a heartbeat
+// since the given time as ended at their last heartbeat. Those were left
+// open by a butler instance that went away while the game was running.
+func CloseStalePlaySessions(conn *sqlite.Conn, heartbeatBefore time.Time) int {
+	var pss []*PlaySession
+	MustSelect(conn, &pss, builder.IsNull{"ended_at"}, hades.Search{})
+
+	closed := 0
+	for _, ps := range pss {
+		lastSeen := ps.LastHeartbeatAt
+		if lastSeen == nil {
+			lastSeen = ps.StartedAt
+		}
+		if lastSeen == nil || lastSeen.After(heartbeatBefore) {
+			continue
+		}
+		ps.EndedAt = lastSeen
+		ps.Save(conn)
+		closed++
+	}
+	return closed
+}
+
+// LocalPlaytime aggregates journaled sessions for a single game.
+type LocalPlaytime struct {
+	GameID             int64
+	SecondsRun         int64
+	UnsyncedSecondsRun int64
+	NumSessions        int64
+	NumCrashes         int64
+	LastRunAt          *time.Time
+}
+
+// LocalPlaytimeByGame sums up journaled sessions per game for a profile.
+func LocalPlaytimeByGame(conn *sqlite.Conn, profileID int64) []*LocalPlaytime {
+	var pss []*PlaySession
+	MustSelect(conn, &pss, builder.Eq{"profile_id": profileID}, hades.Search{})
+	sortPlaySessions(pss)
+
+	var result []*LocalPlaytime
+	byGame := make(map[int64]*LocalPlaytime)
+	for _, ps := range pss {
+		lp, ok := byGame[ps.GameID]
+		if !ok {
+			lp = &LocalPlaytime{GameID: ps.GameID}
+			byGame[ps.GameID] = lp
+			result = append(result, lp)
+		}
+
+		lp.NumSessions++
+		lp.SecondsRun += ps.SecondsRun
+		lp.UnsyncedSecondsRun += ps.UnsyncedSecondsRun()
+		if ps.Crashed {
+			lp.NumCrashes++
+		}
+
+		lastRunAt := ps.LastRunAt()
+		if lastRunAt != nil && (lp.LastRunAt == nil || lastRunAt.After(*lp.LastRunAt)) {
+			lp.LastRunAt = lastRunAt
+		}
+	}
+	return result
+}
+
+// CreateLanded returns whether a create request whose response was lost
+// made it to itch.io, given the game's summary from after it. That
+// request carried the whole session, so the summary must be at least as
+// recent and include its seconds on top of those from before. Another
+// session of the game synced in between can make a lost request look
+// like it landed: that's preferred to counting the session twice.
+func (ps *PlaySession) CreateLanded(summary *itchio.UserGameInteractionsSummary) bool {
+	if ps.CreateSentAt == nil || summary == nil || summary.LastRunAt == nil {
+		return false
+	}
+
+	// itch.io only keeps whole seconds
+	lastRunAt := ps.LastRunAt()
+	if lastRunAt != nil && summary.LastRunAt.Before(lastRunAt.Truncate(time.Second)) {
+		return false
+	}
+	return summary.SecondsRun >= ps.RemoteSecondsRunBefore+ps.SecondsRun
+}
+
+// UnsyncedSecondsRun returns how many seconds of this session itch.io
+// hasn't acknowledged yet.
+func (ps *PlaySession) UnsyncedSecondsRun() int64 {
+	if ps.RemoteSessionID == 0 {
+		return ps.SecondsRun
+	}
+	if delta := ps.SecondsRun - ps.SyncedSecondsRun; delta > 0 {
+		return delta
+	}
+	return 0
+}
+
+// LastRunAt returns the last time the game was known to be running
+// during this session.
+func (ps *PlaySession) LastRunAt() *time.Time {
+	if ps.EndedAt != nil {
+		return ps.EndedAt
+	}
+	if ps.LastHeartbeatAt != nil {
+		return ps.LastHeartbeatAt
+	}
+	return ps.StartedAt
+}
+
+func (ps *PlaySession) MarkSynced(conn *sqlite.Conn) {
+	now := time.Now().UTC()
+	ps.SyncedSecondsRun = ps.SecondsRun
+	ps.SyncedAt = &now
+	ps.Save(conn)
+}
+
+func (ps *PlaySession) Save(conn *sqlite.Conn) {
+	MustSave(conn, ps)
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseStalePlaySessions(t *testing.T) {
+	conn := interactionTestConn(t)
+
+	start := time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)
+	oldBeat := start.Add(10 * time.Minute)
+	freshBeat := start.Add(2 * time.Hour)
+	MustSave(conn, &PlaySession{ID: "stale", GameID: 1, StartedAt: &start, LastHeartbeatAt: &oldBeat})
+	MustSave(conn, &PlaySession{ID: "running", GameID: 1, StartedAt: &start, LastHeartbeatAt: &freshBeat})
+	MustSave(conn, &PlaySession{ID: "ended", GameID: 1, StartedAt: &start, EndedAt: &oldBeat})
+
+	closed := CloseStalePlaySessions(conn, start.Add(time.Hour))
+	require.EqualValues(t, 1, closed)
+
+	stale := PlaySessionByID(conn, "stale")
+	require.NotNil(t, stale.EndedAt)
+	require.True(t, oldBeat.Equal(*stale.EndedAt))
+
+	require.Nil(t, PlaySessionByID(conn, "running").EndedAt)
+}
+
+func TestUnsyncedPlaySessions(t *testing.T) {
+	conn := interactionTestConn(t)
+
+	start := time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)
+	MustSave(conn, &PlaySession{ID: "a", StartedAt: &start, SecondsRun: 60})
+	MustSave(conn, &PlaySession{ID: "b", StartedAt: &start, SecondsRun: 60})
+
+	PlaySessionByID(conn, "b").MarkSynced(conn)
+
+	unsynced := UnsyncedPlaySessions(conn)
+	require.Len(t, unsynced, 1)
+	require.EqualValues(t, "a", unsynced[0].ID)
+
+	b := PlaySessionByID(conn, "b")
+	require.EqualValues(t, 60, b.SyncedSecondsRun)
+	require.NotNil(t, b.SyncedAt)
+}
+
+func TestUnsyncedPlaySessionsOldestFirst(t *testing.T) {
+	conn := interactionTestConn(t)
+
+	// as text, "12:00:00.5Z" sorts before "12:00:00Z"
+	whole := time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)
+	half := whole.Add(500 * time.Millisecond)
+	MustSave(conn, &PlaySession{ID: "second", StartedAt: &half})
+	MustSave(conn, &PlaySession{ID: "first", StartedAt: &whole})
+
+	unsynced := UnsyncedPlaySessions(conn)
+	require.Len(t, unsynced, 2)
+	require.EqualValues(t, "first", unsynced[0].ID)
+	require.EqualValues(t, "second", unsynced[1].ID)
+
+	PlaySessionByID(conn, "first").MarkSynced(conn)
+	all := AllPlaySessions(conn)
+	require.Len(t, all, 2)
+	require.EqualValues(t, "first", all[0].ID)
+	require.EqualValues(t, "second", all[1].ID)
+}
+
+func TestLocalPlaytimeByGame(t *testing.T) {
+	conn := interactionTestConn(t)
+
+	t1 := time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	t3 := t2.Add(time.Hour)
+	MustSave(conn, &PlaySession{ID: "a", ProfileID: 1, GameID: 10, StartedAt: &t1, EndedAt: &t2, SecondsRun: 100, RemoteSessionID: 5, SyncedSecondsRun: 100})
+	MustSave(conn, &PlaySession{ID: "b", ProfileID: 1, GameID: 10, StartedAt: &t2, EndedAt: &t3, SecondsRun: 50, Crashed: true, RemoteSessionID: 6, SyncedSecondsRun: 20})
+	MustSave(conn, &PlaySession{ID: "c", ProfileID: 1, GameID: 20, StartedAt: &t1, LastHeartbeatAt: &t2, SecondsRun: 30})
+	MustSave(conn, &PlaySession{ID: "d", ProfileID: 2, GameID: 10, StartedAt: &t1, SecondsRun: 999})
+
+	stats := LocalPlaytimeByGame(conn, 1)
+	require.Len(t, stats, 2)
+
+	byGame := make(map[int64]*LocalPlaytime)
+	for _, lp := range stats {
+		byGame[lp.GameID] = lp
+	}
+
+	g10 := byGame[10]
+	require.EqualValues(t, 150, g10.SecondsRun)
+	require.EqualValues(t, 30, g10.UnsyncedSecondsRun)
+	require.EqualValues(t, 2, g10.NumSessions)
+	require.EqualValues(t, 1, g10.NumCrashes)
+	require.True(t, t3.Equal(*g10.LastRunAt))
+
+	g20 := byGame[20]
+	require.EqualValues(t, 30, g20.SecondsRun)
+	require.EqualValues(t, 30, g20.UnsyncedSecondsRun)
+	require.True(t, t2.Equal(*g20.LastRunAt))
+}
+
+func TestPlaySessionCreateLanded(t *testing.T) {
+	start := time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)
+	end := start.Add(10*time.Minute + 300*time.Millisecond)
+	sentAt := end.Add(time.Hour)
+	ps := &PlaySession{StartedAt: &start, EndedAt: &end, SecondsRun: 600, RemoteSecondsRunBefore: 1000}
+
+	landedAt := end.Truncate(time.Second)
+	require.False(t, ps.CreateLanded(summary(1600, &landedAt)), "no request was sent")
+
+	ps.CreateSentAt = &sentAt
+	require.True(t, ps.CreateLanded(summary(1600, &landedAt)))
+	require.False(t, ps.CreateLanded(summary(1000, &landedAt)), "seconds weren't counted")
+
+	earlier := start
+	require.False(t, ps.CreateLanded(summary(1600, &earlier)), "summary predates the session")
+	require.False(t, ps.CreateLanded(summary(1600, nil)))
+	require.False(t, ps.CreateLanded(nil))
+}
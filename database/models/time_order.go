@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// hades stores times as RFC3339Nano text, which drops trailing zeros from
+// fractions of a second, so "12:00:00.5Z" sorts before "12:00:00Z".
+// Ordering by a time column in SQL is only right to the second: sort in
+// Go when the exact order matters.
+
+// timeBefore orders times chronologically, nil ones last.
+func timeBefore(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a != nil && b == nil
+	}
+	return a.Before(*b)
+}
@@ -6,6 +6,7 @@ import (
 	"crawshaw.io/sqlite"
 	"crawshaw.io/sqlite/sqlitex"
 	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/hades"
 	"github.com/pkg/errors"
 	"xorm.io/builder"
 )
@@ -27,6 +28,12 @@ func UserGameInteractionByUserAndGame(conn *sqlite.Conn, userID, gameID int64) *
 	return nil
 }
 
+func UserGameInteractionsByUser(conn *sqlite.Conn, userID int64) []*UserGameInteraction {
+	var ugis []*UserGameInteraction
+	MustSelect(conn, &ugis, builder.Eq{"user_id": userID}, hades.Search{})
+	return ugis
+}
+
 func SaveUserGameInteractionSummary(conn *sqlite.Conn, userID, gameID int64, summary *itchio.UserGameInteractionsSummary) (retErr error) {
 	if summary == nil {
 		return nil
@@ -32,6 +32,9 @@ var downloadsDriveCancelID = "Downloads.Drive"
 
 const pingURL = "https://itch.io/static/ping.txt"
 
+// how long a download that doesn't fit waits before checking again
+var diskSpaceRetryDelay = 30 * time.Second
+
 type Status struct {
 	Online bool
 }
@@ -337,6 +340,11 @@ func performOne(parentCtx context.Context, rc *butlerd.RequestContext) error {
 			return nil
 		}
 
+		if ide, ok := errors.Cause(err).(*butlerd.InsufficientDiskSpaceError); ok {
+			waitForDiskSpace(ctx, rc, download, ide)
+			return nil
+		}
+
 		if be, ok := butlerd.AsButlerdError(err); ok {
 			switch butlerd.Code(be.RpcErrorCode()) {
 			case butlerd.CodeNetworkDisconnected:
@@ -399,3 +407,23 @@ func performOne(parentCtx context.Context, rc *butlerd.RequestContext) error {
 
 	return nil
 }
+
+// waitForDiskSpace lets the download sit in the queue for a while, so it's
+// retried later, hopefully once space has been freed.
+func waitForDiskSpace(ctx context.Context, rc *butlerd.RequestContext, download *models.Download, ide *butlerd.InsufficientDiskSpaceError) {
+	consumer := rc.Consumer
+
+	consumer.Warnf("Pausing download: %v", ide)
+	messages.DownloadsDrivePaused.Notify(rc, butlerd.DownloadsDrivePausedNotification{
+		Download:      formatDownload(download),
+		Reason:        butlerd.DownloadPauseReason(ide.Shortage.Limit),
+		NeededSize:    ide.Shortage.NeededSize,
+		AvailableSize: ide.Shortage.AvailableSize,
+	})
+
+	select {
+	case <-time.After(diskSpaceRetryDelay):
+	case <-ctx.Done():
+		// drive cancelled, or download discarded or deprioritized
+	}
+}
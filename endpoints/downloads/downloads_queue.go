@@ -4,6 +4,9 @@ import (
 	"os"
 	"time"
 
+	"crawshaw.io/sqlite"
+	"crawshaw.io/sqlite/sqlitex"
+	"github.com/itchio/headway/state"
 	"github.com/pkg/errors"
 	"xorm.io/builder"
 
@@ -14,7 +17,6 @@ import (
 )
 
 func DownloadsQueue(rc *butlerd.RequestContext, params butlerd.DownloadsQueueParams) (*butlerd.DownloadsQueueResult, error) {
-	consumer := rc.Consumer
 	conn := rc.GetConn()
 	defer rc.PutConn(conn)
 
@@ -23,6 +25,42 @@ func DownloadsQueue(rc *butlerd.RequestContext, params butlerd.DownloadsQueuePar
 		return nil, errors.Errorf("item cannot be nil")
 	}
 
+	err := queueDownload(conn, rc.Consumer, item)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &butlerd.DownloadsQueueResult{}
+	return res, nil
+}
+
+// QueueAll queues downloads for all items in a single transaction:
+// if any of them can't be queued, none are.
+func QueueAll(rc *butlerd.RequestContext, items []*butlerd.InstallQueueResult) (retErr error) {
+	conn := rc.GetConn()
+	defer rc.PutConn(conn)
+
+	defer sqlitex.Save(conn)(&retErr)
+	defer func() {
+		if r := recover(); r != nil {
+			if rErr, ok := r.(error); ok {
+				retErr = errors.WithStack(rErr)
+			} else {
+				retErr = errors.Errorf("%v", r)
+			}
+		}
+	}()
+
+	for _, item := range items {
+		err := queueDownload(conn, rc.Consumer, item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func queueDownload(conn *sqlite.Conn, consumer *state.Consumer, item *butlerd.InstallQueueResult) error {
 	startedAt := time.Now().UTC()
 
 	Fresh := false
@@ -31,7 +69,7 @@ func DownloadsQueue(rc *butlerd.RequestContext, params butlerd.DownloadsQueuePar
 		if os.IsNotExist(err) {
 			Fresh = true
 		} else {
-			return nil, errors.WithStack(err)
+			return errors.WithStack(err)
 		}
 	}
 
@@ -50,7 +88,7 @@ func DownloadsQueue(rc *butlerd.RequestContext, params butlerd.DownloadsQueuePar
 		)
 
 		if downloadsForCaveCount > 0 {
-			return nil, errors.Errorf("Already have downloads in progress for %s, refusing to queue another one", operate.GameToString(item.Game))
+			return errors.Errorf("Already have downloads in progress for %s, refusing to queue another one", operate.GameToString(item.Game))
 		}
 	}
 
@@ -93,13 +131,12 @@ func DownloadsQueue(rc *butlerd.RequestContext, params butlerd.DownloadsQueuePar
 		)
 	}
 
-	if params.Item.CaveID != "" && params.Item.Reason == butlerd.DownloadReasonVersionSwitch {
+	if item.CaveID != "" && item.Reason == butlerd.DownloadReasonVersionSwitch {
 		// if reverting, mark cave as pinned
-		cave := models.CaveByID(conn, params.Item.CaveID)
+		cave := models.CaveByID(conn, item.CaveID)
 		cave.Pinned = true
 		cave.Save(conn)
 	}
 
-	res := &butlerd.DownloadsQueueResult{}
-	return res, nil
+	return nil
 }
@@ -4,7 +4,7 @@ import (
 	"crawshaw.io/sqlite"
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/database/models"
-	"github.com/itchio/butler/endpoints/system"
+	"github.com/itchio/butler/statfs"
 	"github.com/itchio/hades"
 	"github.com/itchio/headway/state"
 	"xorm.io/builder"
@@ -80,19 +80,14 @@ func FormatInstallLocation(conn *sqlite.Conn, consumer *state.Consumer, il *mode
 			InstalledSize: -1,
 			FreeSize:      -1,
 			TotalSize:     -1,
+			QuotaSize:     il.QuotaSize,
+			ReservedSize:  il.ReservedSize,
 		},
 	}
 
-	models.MustExecRaw(conn, `
-		SELECT coalesce(sum(coalesce(installed_size, 0)), 0) AS installed_size
-		FROM caves
-		WHERE install_location_id = ?
-	`, func(stmt *sqlite.Stmt) error {
-		sum.SizeInfo.InstalledSize = stmt.ColumnInt64(0)
-		return nil
-	}, il.ID)
+	sum.SizeInfo.InstalledSize = il.GetInstalledSize(conn)
 
-	stats, err := system.StatFS(il.Path)
+	stats, err := statfs.Do(il.Path)
 	if err != nil {
 		consumer.Warnf("Could not statFS (%s): %s", il.Path, err.Error())
 	} else {
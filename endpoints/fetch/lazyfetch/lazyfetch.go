@@ -1,12 +1,14 @@
 package lazyfetch
 
 import (
+	"sync"
 	"time"
 
 	"github.com/itchio/butler/butlerd/horror"
 
 	"crawshaw.io/sqlite"
 	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/messages"
 	"github.com/itchio/butler/database/models"
 )
 
@@ -67,10 +69,52 @@ func Do(
 			rc.Consumer.Infof("Waited %s for fetch (non-shared)", time.Since(startTime))
 		}
 	} else if rc.WithConnBool(ft.MustIsStale) {
+		// in offline-first mode, anything fetched before is good enough
+		// for now, unless there's nothing to show.
+		if rc.Config.OfflineFirst && rc.WithConnBool(func(conn *sqlite.Conn) bool {
+			return ft.MustGetInfo(conn) != nil
+		}) && refreshInBackground(rc, ft) {
+			return
+		}
 		res.SetStale(true)
 	}
 }
 
+// keys of fetch targets being refreshed in the background
+var refreshing sync.Map
+
+// refreshInBackground handles the request again with fresh set,
+// and notifies the client once it's done.
+func refreshInBackground(rc *butlerd.RequestContext, ft models.FetchTarget) bool {
+	if _, loaded := refreshing.LoadOrStore(ft.Key(), true); loaded {
+		return true
+	}
+
+	method := rc.Method()
+	err := rc.RedoInBackground(map[string]interface{}{"fresh": true}, func(brc *butlerd.RequestContext, err error) {
+		refreshing.Delete(ft.Key())
+		if err != nil {
+			brc.Consumer.Warnf("Could not refresh (%s) in the background: %+v", ft.Key(), err)
+			return
+		}
+
+		err = messages.FetchRefreshed.Notify(brc, butlerd.FetchRefreshedNotification{
+			Method:     method,
+			TargetType: ft.Type,
+			TargetID:   ft.ObjectID(),
+		})
+		if err != nil {
+			brc.Consumer.Warnf("Could not notify that (%s) was refreshed: %+v", ft.Key(), err)
+		}
+	})
+	if err != nil {
+		refreshing.Delete(ft.Key())
+		rc.Consumer.Warnf("Could not refresh (%s) in the background: %+v", ft.Key(), err)
+		return false
+	}
+	return true
+}
+
 //
 
 type targets struct {
@@ -0,0 +1,55 @@
+package install
+
+import (
+	"os"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/cmd/wipe"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/manager/runlock"
+	"github.com/pkg/errors"
+)
+
+func CavesResetCompatPrefix(rc *butlerd.RequestContext, params butlerd.CavesResetCompatPrefixParams) (*butlerd.CavesResetCompatPrefixResult, error) {
+	consumer := rc.Consumer
+
+	var cave *models.Cave
+	var installFolder, prefix string
+	rc.WithConn(func(conn *sqlite.Conn) {
+		cave = models.CaveByID(conn, params.CaveID)
+		if cave == nil {
+			return
+		}
+		installFolder = cave.GetInstallFolder(conn)
+		prefix = cave.GetCompatPrefixFolder(conn)
+	})
+	if cave == nil {
+		return nil, errors.Errorf("cave (%s) not found", params.CaveID)
+	}
+
+	res := &butlerd.CavesResetCompatPrefixResult{}
+	if prefix == "" {
+		return res, nil
+	}
+	if _, err := os.Stat(prefix); err != nil {
+		return res, nil
+	}
+
+	// wine keeps files in the prefix open while the game runs
+	rlock := runlock.New(consumer, installFolder)
+	err := rlock.Lock(rc.Ctx, "reset-compat-prefix")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer rlock.Unlock()
+
+	consumer.Infof("Resetting wine prefix (%s)", prefix)
+	err = wipe.Do(consumer, prefix)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res.Reset = true
+	return res, nil
+}
@@ -0,0 +1,76 @@
+package install
+
+import (
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/manager/runlock"
+	"github.com/pkg/errors"
+)
+
+func CavesGetLockStatus(rc *butlerd.RequestContext, params butlerd.CavesGetLockStatusParams) (*butlerd.CavesGetLockStatusResult, error) {
+	installFolder, err := caveInstallFolder(rc, params.CaveID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := runlock.Inspect(installFolder)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := &butlerd.CavesGetLockStatusResult{}
+	if status != nil {
+		res.Lock = &butlerd.CaveLock{
+			Task:      status.Task,
+			ButlerPID: status.ButlerPID,
+			Hostname:  status.Hostname,
+			Remote:    status.Remote,
+			Stale:     status.Stale,
+		}
+		if !status.LockedAt.IsZero() {
+			res.Lock.LockedAt = &status.LockedAt
+		}
+	}
+	return res, nil
+}
+
+func CavesForceUnlock(rc *butlerd.RequestContext, params butlerd.CavesForceUnlockParams) (*butlerd.CavesForceUnlockResult, error) {
+	consumer := rc.Consumer
+
+	installFolder, err := caveInstallFolder(rc, params.CaveID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &butlerd.CavesForceUnlockResult{}
+	status, _ := runlock.Inspect(installFolder)
+	if status == nil {
+		return res, nil
+	}
+
+	consumer.Warnf("Forcing unlock of (%s), locked for %s by PID (%d) on (%s)", installFolder, status.Task, status.ButlerPID, status.Hostname)
+	err = runlock.ForceUnlock(installFolder)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res.Unlocked = true
+	return res, nil
+}
+
+func caveInstallFolder(rc *butlerd.RequestContext, caveID string) (string, error) {
+	var cave *models.Cave
+	var installFolder string
+	rc.WithConn(func(conn *sqlite.Conn) {
+		cave = models.CaveByID(conn, caveID)
+		if cave == nil {
+			return
+		}
+		installFolder = cave.GetInstallFolder(conn)
+	})
+	if cave == nil {
+		return "", errors.Errorf("cave (%s) not found", caveID)
+	}
+	return installFolder, nil
+}
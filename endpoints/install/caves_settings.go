@@ -4,6 +4,7 @@ import (
 	"crawshaw.io/sqlite"
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/shortcut"
 	"github.com/pkg/errors"
 )
 
@@ -39,26 +40,40 @@ func CavesGetSettings(rc *butlerd.RequestContext, params butlerd.CavesGetSetting
 
 func CavesSetSettings(rc *butlerd.RequestContext, params butlerd.CavesSetSettingsParams) (*butlerd.CavesSetSettingsResult, error) {
 	var opErr error
+	var cave *models.Cave
+	var targetChanged bool
 
 	rc.WithConn(func(conn *sqlite.Conn) {
-		cave := models.CaveByID(conn, params.CaveID)
+		cave = models.CaveByID(conn, params.CaveID)
 		if cave == nil {
 			opErr = errors.Errorf("cave (%s) not found", params.CaveID)
 			return
 		}
 
-		err := models.MarshalJSON(params.Settings, &cave.Settings, "cave settings")
+		var previous butlerd.CaveSettings
+		err := models.UnmarshalJSONAllowEmpty(cave.Settings, &previous, "cave settings")
+		if err != nil {
+			rc.Consumer.Warnf("Replacing unreadable settings: %v", err)
+		}
+		targetChanged = previous.LaunchTarget != params.Settings.LaunchTarget
+
+		err = models.MarshalJSON(params.Settings, &cave.Settings, "cave settings")
 		if err != nil {
 			opErr = errors.WithStack(err)
 			return
 		}
 
 		cave.Save(conn)
+		models.PreloadCaves(conn, cave)
 	})
 
 	if opErr != nil {
 		return nil, opErr
 	}
 
+	if targetChanged && cave.Game != nil {
+		shortcut.RefreshCave(rc.Consumer, cave.ID, cave.Game.Title)
+	}
+
 	return &butlerd.CavesSetSettingsResult{}, nil
 }
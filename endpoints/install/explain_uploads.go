@@ -0,0 +1,41 @@
+package install
+
+import (
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/manager"
+	itchio "github.com/itchio/go-itchio"
+)
+
+// explainUploads turns what narrowing down decided into explanations,
+// in the same order. Uploads it kept that aren't in available were
+// excluded for being already installed, or queued for install.
+func explainUploads(decisions []*manager.UploadDecision, available []*itchio.Upload) []*butlerd.UploadExplanation {
+	isAvailable := make(map[int64]bool, len(available))
+	for _, u := range available {
+		isAvailable[u.ID] = true
+	}
+
+	var res []*butlerd.UploadExplanation
+	for _, d := range decisions {
+		e := &butlerd.UploadExplanation{
+			UploadID:        d.Upload.ID,
+			Rank:            d.Rank,
+			ExcludedBecause: butlerd.UploadExclusion(d.ExcludedBecause),
+			ExcludedDetail:  d.ExcludedDetail,
+			Score:           d.Score,
+			CompatibleHosts: d.CompatibleHosts,
+		}
+		for _, c := range d.ScoreComponents {
+			e.ScoreComponents = append(e.ScoreComponents, &butlerd.UploadScoreComponent{
+				Name:   c.Name,
+				Points: c.Points,
+			})
+		}
+		if e.ExcludedBecause == "" && !isAvailable[d.Upload.ID] {
+			e.ExcludedBecause = butlerd.UploadExclusionInstalled
+			e.ExcludedDetail = "already installed, or being installed"
+		}
+		res = append(res, e)
+	}
+	return res
+}
@@ -0,0 +1,46 @@
+package install
+
+import (
+	"testing"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/manager"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainUploads(t *testing.T) {
+	kept := &itchio.Upload{ID: 1}
+	installed := &itchio.Upload{ID: 2}
+	untagged := &itchio.Upload{ID: 3}
+
+	decisions := []*manager.UploadDecision{
+		{
+			Upload:          kept,
+			Rank:            1,
+			Score:           900,
+			ScoreComponents: []*manager.UploadScoreComponent{{Name: "launchable", Points: 400}, {Name: "order", Points: 500}},
+			CompatibleHosts: []string{"linux-amd64 (native)"},
+		},
+		{Upload: installed, Rank: 2, Score: 899},
+		{Upload: untagged, ExcludedBecause: manager.UploadExclusionUntagged, ExcludedDetail: "not tagged for any platform"},
+	}
+
+	explanations := explainUploads(decisions, []*itchio.Upload{kept})
+	require.Len(t, explanations, 3)
+
+	require.EqualValues(t, &butlerd.UploadExplanation{
+		UploadID: 1,
+		Rank:     1,
+		Score:    900,
+		ScoreComponents: []*butlerd.UploadScoreComponent{
+			{Name: "launchable", Points: 400},
+			{Name: "order", Points: 500},
+		},
+		CompatibleHosts: []string{"linux-amd64 (native)"},
+	}, explanations[0])
+
+	require.EqualValues(t, butlerd.UploadExclusionInstalled, explanations[1].ExcludedBecause)
+	require.EqualValues(t, butlerd.UploadExclusionUntagged, explanations[2].ExcludedBecause)
+	require.EqualValues(t, "not tagged for any platform", explanations[2].ExcludedDetail)
+}
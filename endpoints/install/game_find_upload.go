@@ -4,6 +4,7 @@ import (
 	"crawshaw.io/sqlite"
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/cmd/operate"
+	itchio "github.com/itchio/go-itchio"
 	"github.com/pkg/errors"
 )
 
@@ -25,13 +26,22 @@ func GameFindUploads(rc *butlerd.RequestContext, params butlerd.GameFindUploadsP
 		return nil, errors.WithStack(materializeErr)
 	}
 
-	uploads, err := operate.GetFilteredUploads(rc, params.Game)
+	uploads, decisions, err := operate.ExplainFilteredUploads(rc, params.Game, rc.HostEnumerator())
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
+	// like Install.GetUploads, uploads already installed or being
+	// installed aren't offered again
+	var available []*itchio.Upload
+	rc.WithConn(func(conn *sqlite.Conn) {
+		available = excludeInstalledUploads(conn, uploads.Uploads)
+	})
+
 	res := &butlerd.GameFindUploadsResult{
-		Uploads: uploads.Uploads,
+		Uploads:      available,
+		Explanations: explainUploads(decisions, available),
+		MatchedRules: uploads.MatchedRules,
 	}
 	return res, nil
 }
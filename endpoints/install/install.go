@@ -11,6 +11,8 @@ func Register(router *butlerd.Router) {
 	messages.InstallGetUploads.Register(router, InstallGetUploads)
 	messages.InstallPlanUpload.Register(router, InstallPlanUpload)
 	messages.InstallQueue.Register(router, InstallQueue)
+	messages.InstallQueueMany.Register(router, InstallQueueMany)
+	messages.InstallSync.Register(router, InstallSync)
 	messages.InstallPerform.Register(router, InstallPerform)
 	messages.InstallCancel.Register(router, InstallCancel)
 	messages.UninstallPerform.Register(router, UninstallPerform)
@@ -19,10 +21,17 @@ func Register(router *butlerd.Router) {
 	messages.InstallLocationsList.Register(router, InstallLocationsList)
 	messages.InstallLocationsAdd.Register(router, InstallLocationsAdd)
 	messages.InstallLocationsRemove.Register(router, InstallLocationsRemove)
+	messages.InstallLocationsSetSpaceLimits.Register(router, InstallLocationsSetSpaceLimits)
 	messages.InstallLocationsScan.Register(router, InstallLocationsScan)
+	messages.InstallLocationsMoveCaves.Register(router, InstallLocationsMoveCaves)
+	messages.InstallLocationsAudit.Register(router, InstallLocationsAudit)
+	messages.InstallLocationsApplyAudit.Register(router, InstallLocationsApplyAudit)
 	messages.InstallCreateShortcut.Register(router, InstallCreateShortcut)
 
 	messages.CavesGetSettings.Register(router, CavesGetSettings)
 	messages.CavesSetSettings.Register(router, CavesSetSettings)
 	messages.CavesSetPinned.Register(router, CavesSetPinned)
+	messages.CavesResetCompatPrefix.Register(router, CavesResetCompatPrefix)
+	messages.CavesGetLockStatus.Register(router, CavesGetLockStatus)
+	messages.CavesForceUnlock.Register(router, CavesForceUnlock)
 }
@@ -1,12 +1,17 @@
 package install
 
 import (
-	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
 
 	"crawshaw.io/sqlite"
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/database/models"
-	"github.com/itchio/butler/endpoints/install/shortcut"
+	"github.com/itchio/butler/shortcut"
+	"github.com/pkg/errors"
 )
 
 func InstallCreateShortcut(rc *butlerd.RequestContext, params butlerd.InstallCreateShortcutParams) (*butlerd.InstallCreateShortcutResult, error) {
@@ -16,11 +21,21 @@ func InstallCreateShortcut(rc *butlerd.RequestContext, params butlerd.InstallCre
 		cave = models.CaveByID(conn, params.CaveID)
 		models.PreloadCaves(conn, cave)
 	})
-	url := fmt.Sprintf("itch://caves/%s/launch", cave.ID)
 
-	err := shortcut.Create(shortcut.CreateParams{
+	iconSource, err := downloadCover(rc, cave)
+	if err != nil {
+		rc.Consumer.Warnf("Could not download cover for shortcut icon: %+v", err)
+	}
+	if iconSource != "" {
+		defer os.Remove(iconSource)
+	}
+
+	err = shortcut.Create(shortcut.CreateParams{
+		ID:          cave.ID,
 		DisplayName: cave.Game.Title,
-		URL:         url,
+		IconSource:  iconSource,
+		URL:         shortcut.CaveURL(cave.ID),
+		Desktop:     params.Desktop,
 		Consumer:    rc.Consumer,
 	})
 	if err != nil {
@@ -30,3 +45,43 @@ func InstallCreateShortcut(rc *butlerd.RequestContext, params butlerd.InstallCre
 	res := &butlerd.InstallCreateShortcutResult{}
 	return res, nil
 }
+
+// downloadCover saves the game's cover to a temporary file, returning
+// an empty path if the game has none.
+func downloadCover(rc *butlerd.RequestContext, cave *models.Cave) (string, error) {
+	coverURL := cave.Game.StillCoverURL
+	if coverURL == "" {
+		coverURL = cave.Game.CoverURL
+	}
+	if coverURL == "" {
+		return "", nil
+	}
+
+	ext := ".png"
+	if u, err := url.Parse(coverURL); err == nil && path.Ext(u.Path) != "" {
+		ext = path.Ext(u.Path)
+	}
+
+	res, err := rc.HTTPClient.Get(coverURL)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.Errorf("HTTP %d while downloading %s", res.StatusCode, coverURL)
+	}
+
+	f, err := os.CreateTemp("", "butler-cover-*"+ext)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, res.Body)
+	if err != nil {
+		os.Remove(f.Name())
+		return "", errors.WithStack(err)
+	}
+	return f.Name(), nil
+}
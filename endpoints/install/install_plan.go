@@ -14,6 +14,7 @@ import (
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/butlerd/jsonrpc2"
 	"github.com/itchio/butler/cmd/operate"
+	"github.com/itchio/butler/cmd/operate/externalhost"
 	"github.com/itchio/butler/database/models"
 	"github.com/itchio/butler/endpoints/fetch"
 	"github.com/itchio/butler/manager"
@@ -42,29 +43,33 @@ func checkCancelled(ctx context.Context) error {
 // and excludes already-installed or in-progress uploads. Uploads that didn't
 // survive narrowing are returned separately (same installed/in-progress
 // exclusion applied), so callers can offer them behind a warning.
-func getGameUploads(rc *butlerd.RequestContext, conn *sqlite.Conn, gameID int64) (*itchio.Game, []*itchio.Upload, []*itchio.Upload, error) {
+// Explanations cover all of the game's uploads. Upload rules are those of
+// the profile the game would be installed with.
+func getGameUploads(rc *butlerd.RequestContext, conn *sqlite.Conn, gameID int64, profileID int64) (*itchio.Game, []*itchio.Upload, []*itchio.Upload, []*butlerd.UploadExplanation, error) {
 	consumer := rc.Consumer
 
 	game := fetch.LazyFetchGame(rc, gameID)
 	if err := checkCancelled(rc.Ctx); err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 	consumer.Opf("Planning install for %s", operate.GameToString(game))
 
 	baseUploads := fetch.LazyFetchGameUploads(rc, gameID)
 	if err := checkCancelled(rc.Ctx); err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
-	narrowRes, err := manager.NarrowDownUploads(consumer, game, baseUploads, rc.HostEnumerator())
+	rules := rc.UploadRules(operate.AccessForGameIDForProfile(conn, gameID, profileID).ProfileID)
+	narrowRes, decisions, err := manager.ExplainUploads(consumer, game, baseUploads, rc.HostEnumerator(), rules)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	uploads := excludeInstalledUploads(conn, narrowRes.Uploads)
 	incompatibleUploads := excludeInstalledUploads(conn, narrowRes.IncompatibleUploads)
+	explanations := explainUploads(decisions, uploads)
 
-	return game, uploads, incompatibleUploads, nil
+	return game, uploads, incompatibleUploads, explanations, nil
 }
 
 // excludeInstalledUploads filters out already-installed and
@@ -102,7 +107,7 @@ func excludeInstalledUploads(conn *sqlite.Conn, baseUploads []*itchio.Upload) []
 // and assesses disk usage for a single upload. Errors are stored in the returned
 // InstallPlanInfo rather than returned, matching the original behavior where
 // planning errors are soft failures.
-func getPlanInfo(rc *butlerd.RequestContext, conn *sqlite.Conn, upload *itchio.Upload, gameID int64, downloadSessionID string) (*butlerd.InstallPlanInfo, error) {
+func getPlanInfo(rc *butlerd.RequestContext, conn *sqlite.Conn, upload *itchio.Upload, gameID int64, downloadSessionID string, installLocationID string, caveID string) (*butlerd.InstallPlanInfo, error) {
 	consumer := rc.Consumer
 
 	info := &butlerd.InstallPlanInfo{}
@@ -137,11 +142,6 @@ func getPlanInfo(rc *butlerd.RequestContext, conn *sqlite.Conn, upload *itchio.U
 	info.Build = upload.Build
 	operate.LogUpload(consumer, upload, upload.Build)
 
-	if upload.Storage == itchio.UploadStorageExternal && operate.IsBadExternalHost(upload.Host) {
-		setInfoError(errors.WithStack(butlerd.CodeUnsupportedHost))
-		return info, nil
-	}
-
 	sessionID := downloadSessionID
 	if sessionID == "" {
 		sessionID = uuid.New().String()
@@ -155,12 +155,27 @@ func getPlanInfo(rc *butlerd.RequestContext, conn *sqlite.Conn, upload *itchio.U
 	}
 	sourceURL := operate.MakeSourceURL(client, consumer, sessionID, installParams, "")
 
+	var err error
+	var resolved *externalhost.Resolved
+	if upload.Storage == itchio.UploadStorageExternal {
+		resolved, err = operate.ResolveExternalUpload(rc.Ctx, rc.HTTPClient, consumer, sourceURL, upload)
+		if err != nil {
+			setInfoError(err)
+			return info, nil
+		}
+	}
+
 	if err := checkCancelled(rc.Ctx); err != nil {
 		return nil, err
 	}
 
 	beforeOpen := time.Now()
-	file, err := eos.Open(sourceURL, option.WithConsumer(consumer))
+	var file eos.File
+	if resolved != nil {
+		file, err = operate.OpenExternalUpload(resolved, option.WithConsumer(consumer))
+	} else {
+		file, err = eos.Open(sourceURL, option.WithConsumer(consumer))
+	}
 	consumer.Infof("(opening file took %s)", time.Since(beforeOpen))
 	if err != nil {
 		setInfoError(errors.WithStack(err))
@@ -200,6 +215,14 @@ func getPlanInfo(rc *butlerd.RequestContext, conn *sqlite.Conn, upload *itchio.U
 		Accuracy:        dui.Accuracy.String(),
 	}
 
+	if installLocationID != "" {
+		err = operate.CheckDiskSpace(conn, consumer, installLocationID, caveID, dui)
+		if err != nil {
+			setInfoError(err)
+			return info, nil
+		}
+	}
+
 	return info, nil
 }
 
@@ -213,14 +236,15 @@ func InstallPlan(rc *butlerd.RequestContext, params butlerd.InstallPlanParams) (
 		return nil, errors.WithStack(err)
 	}
 
-	game, uploads, _, err := getGameUploads(rc, conn, params.GameID)
+	game, uploads, _, explanations, err := getGameUploads(rc, conn, params.GameID, params.ProfileID)
 	if err != nil {
 		return nil, err
 	}
 
 	res := &butlerd.InstallPlanResult{
-		Game:    game,
-		Uploads: uploads,
+		Game:         game,
+		Uploads:      uploads,
+		Explanations: explanations,
 	}
 
 	// Select the upload to plan: explicit UploadID or first available
@@ -237,7 +261,7 @@ func InstallPlan(rc *butlerd.RequestContext, params butlerd.InstallPlanParams) (
 	}
 
 	if selectedUpload != nil {
-		info, err := getPlanInfo(rc, conn, selectedUpload, params.GameID, params.DownloadSessionID)
+		info, err := getPlanInfo(rc, conn, selectedUpload, params.GameID, params.DownloadSessionID, params.InstallLocationID, params.CaveID)
 		if err != nil {
 			return nil, err
 		}
@@ -259,7 +283,7 @@ func InstallGetUploads(rc *butlerd.RequestContext, params butlerd.InstallGetUplo
 		return nil, errors.WithStack(err)
 	}
 
-	game, uploads, incompatibleUploads, err := getGameUploads(rc, conn, params.GameID)
+	game, uploads, incompatibleUploads, explanations, err := getGameUploads(rc, conn, params.GameID, params.ProfileID)
 	if err != nil {
 		return nil, err
 	}
@@ -268,5 +292,6 @@ func InstallGetUploads(rc *butlerd.RequestContext, params butlerd.InstallGetUplo
 		Game:                game,
 		Uploads:             uploads,
 		IncompatibleUploads: incompatibleUploads,
+		Explanations:        explanations,
 	}, nil
 }
@@ -32,7 +32,7 @@ func InstallPlanUpload(rc *butlerd.RequestContext, params butlerd.InstallPlanUpl
 		return nil, errors.Errorf("game upload mapping not found for upload %d", params.UploadID)
 	}
 
-	info, err := getPlanInfo(rc, conn, &upload, gameUpload.GameID, params.DownloadSessionID)
+	info, err := getPlanInfo(rc, conn, &upload, gameUpload.GameID, params.DownloadSessionID, params.InstallLocationID, params.CaveID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,219 @@
+package install
+
+import (
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/horror"
+	"github.com/itchio/butler/cmd/operate"
+	"github.com/itchio/butler/cmd/wipe"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/endpoints/downloads"
+	"github.com/itchio/butler/endpoints/fetch"
+	"github.com/itchio/butler/manager"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/pkg/errors"
+	"xorm.io/builder"
+)
+
+func InstallQueueMany(rc *butlerd.RequestContext, params butlerd.InstallQueueManyParams) (*butlerd.InstallQueueManyResult, error) {
+	consumer := rc.Consumer
+
+	var installLocation *models.InstallLocation
+	rc.WithConn(func(conn *sqlite.Conn) {
+		installLocation = models.InstallLocationByID(conn, params.InstallLocationID)
+	})
+	if installLocation == nil {
+		return nil, errors.Errorf("Install location not found (%s)", params.InstallLocationID)
+	}
+
+	games, err := listGamesToQueue(rc, params)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	consumer.Infof("Queuing installs for %d games", len(games))
+
+	res := &butlerd.InstallQueueManyResult{
+		Outcomes: []*butlerd.InstallQueueManyOutcome{},
+	}
+	var items []*butlerd.InstallQueueResult
+	for _, game := range games {
+		if err := checkCancelled(rc.Ctx); err != nil {
+			return nil, err
+		}
+
+		outcome := queueOne(rc, params, game)
+		if outcome.Item != nil {
+			items = append(items, outcome.Item)
+		}
+		res.Outcomes = append(res.Outcomes, outcome)
+	}
+
+	if params.QueueDownload && len(items) > 0 {
+		err := downloads.QueueAll(rc, items)
+		if err != nil {
+			// nothing was queued: the staged installs would never be performed
+			consumer.Errorf("Could not queue downloads: %+v", err)
+			for _, outcome := range res.Outcomes {
+				if outcome.Item == nil {
+					continue
+				}
+				wipeErr := wipe.Do(consumer, outcome.Item.StagingFolder)
+				if wipeErr != nil {
+					consumer.Warnf("Could not wipe staging folder: %+v", wipeErr)
+				}
+				setOutcomeError(outcome, err)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func queueOne(rc *butlerd.RequestContext, params butlerd.InstallQueueManyParams, game *itchio.Game) *butlerd.InstallQueueManyOutcome {
+	outcome := &butlerd.InstallQueueManyOutcome{
+		Game: game,
+	}
+
+	upload := pickUploadToQueue(rc, params, game, outcome)
+	if upload == nil {
+		return outcome
+	}
+
+	item, err := InstallQueue(rc, butlerd.InstallQueueParams{
+		InstallLocationID: params.InstallLocationID,
+		Game:              game,
+		Upload:            upload,
+		Build:             upload.Build,
+		IgnoreInstallers:  params.IgnoreInstallers,
+		FastQueue:         params.FastQueue,
+		ProfileID:         params.ProfileID,
+	})
+	if err != nil {
+		setOutcomeError(outcome, err)
+		return outcome
+	}
+
+	outcome.Status = butlerd.InstallQueueManyStatusQueued
+	outcome.Item = item
+	return outcome
+}
+
+// pickUploadToQueue picks an upload for game according to the upload
+// policy. When it returns nil, outcome says why the game was skipped
+// or failed.
+func pickUploadToQueue(rc *butlerd.RequestContext, params butlerd.InstallQueueManyParams, game *itchio.Game, outcome *butlerd.InstallQueueManyOutcome) *itchio.Upload {
+	consumer := rc.Consumer
+
+	var installed bool
+	var err error
+	rc.WithConn(func(conn *sqlite.Conn) {
+		installed = models.MustSelectOne(conn, &models.Cave{}, builder.Eq{"game_id": game.ID})
+		if !installed {
+			err = maybeMaterializeBundleAccess(rc, conn, game.ID, params.ProfileID)
+		}
+	})
+	if installed {
+		outcome.Status = butlerd.InstallQueueManyStatusSkipped
+		outcome.Reason = "already installed"
+		return nil
+	}
+	if err != nil {
+		setOutcomeError(outcome, err)
+		return nil
+	}
+
+	// with the platform policy, uploads are narrowed down for that
+	// platform, which may not be the one we're running on.
+	hostEnum := rc.HostEnumerator()
+	var platform string
+	if params.UploadPolicy == butlerd.InstallUploadPolicyPlatform {
+		platform = params.Platform
+		hostEnum = manager.PlatformHostEnumerator(platform)
+	}
+
+	uploadsFilterResult, err := operate.GetFilteredUploadsFor(rc, game, hostEnum)
+	if err != nil {
+		setOutcomeError(outcome, err)
+		return nil
+	}
+
+	strict := params.UploadPolicy == butlerd.InstallUploadPolicySkipIfAmbiguous
+	upload, reason := manager.PickUploadUnattended(uploadsFilterResult.Uploads, platform, strict)
+	if upload == nil {
+		consumer.Infof("Skipping %s: %s", operate.GameToString(game), reason)
+		outcome.Status = butlerd.InstallQueueManyStatusSkipped
+		outcome.Reason = reason
+		return nil
+	}
+	return upload
+}
+
+func setOutcomeError(outcome *butlerd.InstallQueueManyOutcome, err error) {
+	outcome.Status = butlerd.InstallQueueManyStatusFailed
+	outcome.Item = nil
+	if be, ok := butlerd.AsButlerdError(err); ok {
+		outcome.ErrorCode = be.RpcErrorCode()
+		outcome.Reason = be.RpcErrorMessage()
+	} else {
+		outcome.Reason = err.Error()
+	}
+}
+
+// listGamesToQueue returns the games passed, or lists those of the
+// collection or bundle. The first page is fetched fresh, which fetches
+// all of them: a cached listing may be outdated or not be there at all.
+func listGamesToQueue(rc *butlerd.RequestContext, params butlerd.InstallQueueManyParams) (games []*itchio.Game, err error) {
+	// fetch errors are panics
+	defer horror.RecoverInto(&err)
+
+	switch {
+	case params.CollectionID != 0:
+		var cursor butlerd.Cursor
+		for {
+			res, err := fetch.FetchCollectionGames(rc, butlerd.FetchCollectionGamesParams{
+				ProfileID:    params.ProfileID,
+				CollectionID: params.CollectionID,
+				Cursor:       cursor,
+				Fresh:        cursor == "",
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, cg := range res.Items {
+				if cg.Game != nil {
+					games = append(games, cg.Game)
+				}
+			}
+			if res.NextCursor == "" {
+				break
+			}
+			cursor = res.NextCursor
+		}
+	case params.BundleID != 0:
+		var cursor butlerd.Cursor
+		for {
+			res, err := fetch.FetchBundleGames(rc, butlerd.FetchBundleGamesParams{
+				ProfileID: params.ProfileID,
+				BundleID:  params.BundleID,
+				Cursor:    cursor,
+				Fresh:     cursor == "",
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, bg := range res.Items {
+				if bg.Game != nil {
+					games = append(games, bg.Game)
+				}
+			}
+			if res.NextCursor == "" {
+				break
+			}
+			cursor = res.NextCursor
+		}
+	default:
+		games = params.Games
+	}
+
+	return games, nil
+}
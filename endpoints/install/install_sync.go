@@ -0,0 +1,252 @@
+package install
+
+import (
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/cmd/operate"
+	"github.com/itchio/butler/cmd/wipe"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/endpoints/downloads"
+	"github.com/itchio/butler/endpoints/update"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/hades"
+	"github.com/pkg/errors"
+	"xorm.io/builder"
+)
+
+func InstallSync(rc *butlerd.RequestContext, params butlerd.InstallSyncParams) (*butlerd.InstallSyncResult, error) {
+	consumer := rc.Consumer
+
+	var installLocation *models.InstallLocation
+	var caves []*models.Cave
+	rc.WithConn(func(conn *sqlite.Conn) {
+		installLocation = models.InstallLocationByID(conn, params.InstallLocationID)
+		models.MustSelect(conn, &caves, builder.Eq{"install_location_id": params.InstallLocationID}, hades.Search{})
+		models.PreloadCaves(conn, caves)
+	})
+	if installLocation == nil {
+		return nil, errors.Errorf("Install location not found (%s)", params.InstallLocationID)
+	}
+
+	queueParams := butlerd.InstallQueueManyParams{
+		CollectionID:      params.CollectionID,
+		BundleID:          params.BundleID,
+		ProfileID:         params.ProfileID,
+		InstallLocationID: params.InstallLocationID,
+		UploadPolicy:      params.UploadPolicy,
+		Platform:          params.Platform,
+		IgnoreInstallers:  params.IgnoreInstallers,
+	}
+	// if listing fails, nothing is uninstalled
+	games, err := listGamesToQueue(rc, queueParams)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	plan, err := planSync(caves, games, params.AllowEmpty)
+	if err != nil {
+		return nil, err
+	}
+	present := plan.present
+	keptCaveIDs := plan.keptCaveIDs
+	uninstalls := plan.uninstalls
+	consumer.Infof("Syncing %d games to (%s): %d installed, %d to uninstall", len(games), installLocation.Path, len(keptCaveIDs), len(uninstalls))
+
+	res := &butlerd.InstallSyncResult{
+		Actions: []*butlerd.InstallSyncAction{},
+	}
+
+	// uninstall first, to make room
+	for _, action := range uninstalls {
+		res.Actions = append(res.Actions, action)
+		if params.DryRun {
+			continue
+		}
+		if err := checkCancelled(rc.Ctx); err != nil {
+			return nil, err
+		}
+
+		consumer.Infof("Uninstalling %s", operate.GameToString(action.Game))
+		_, err := UninstallPerform(rc, butlerd.UninstallPerformParams{
+			CaveID: action.CaveID,
+		})
+		if err != nil {
+			setSyncActionError(action, err)
+			continue
+		}
+		action.Status = butlerd.InstallSyncActionStatusDone
+	}
+
+	if len(keptCaveIDs) > 0 {
+		updateRes, err := update.CheckUpdate(rc, butlerd.CheckUpdateParams{
+			CaveIDs: keptCaveIDs,
+		})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		for _, gu := range updateRes.Updates {
+			action := &butlerd.InstallSyncAction{
+				Type:   butlerd.InstallSyncActionUpdate,
+				Game:   gu.Game,
+				CaveID: gu.CaveID,
+				Status: butlerd.InstallSyncActionStatusPlanned,
+			}
+			res.Actions = append(res.Actions, action)
+
+			if !gu.Direct || len(gu.Choices) == 0 {
+				action.Status = butlerd.InstallSyncActionStatusSkipped
+				action.Reason = "not a direct update, needs to be picked manually"
+				continue
+			}
+			choice := gu.Choices[0]
+			action.Upload = choice.Upload
+			action.Build = choice.Build
+			if params.DryRun {
+				continue
+			}
+			if err := checkCancelled(rc.Ctx); err != nil {
+				return nil, err
+			}
+
+			item, err := InstallQueue(rc, butlerd.InstallQueueParams{
+				CaveID: gu.CaveID,
+				Reason: butlerd.DownloadReasonUpdate,
+				Upload: choice.Upload,
+				Build:  choice.Build,
+			})
+			if err != nil {
+				setSyncActionError(action, err)
+				continue
+			}
+			action.Status = butlerd.InstallSyncActionStatusQueued
+			action.Item = item
+		}
+	}
+
+	for _, game := range games {
+		if present[game.ID] {
+			continue
+		}
+		if err := checkCancelled(rc.Ctx); err != nil {
+			return nil, err
+		}
+
+		var outcome *butlerd.InstallQueueManyOutcome
+		if params.DryRun {
+			outcome = &butlerd.InstallQueueManyOutcome{Game: game}
+			if upload := pickUploadToQueue(rc, queueParams, game, outcome); upload != nil {
+				outcome.Item = &butlerd.InstallQueueResult{Game: game, Upload: upload, Build: upload.Build}
+			}
+		} else {
+			outcome = queueOne(rc, queueParams, game)
+		}
+		res.Actions = append(res.Actions, syncActionFromOutcome(outcome, params.DryRun))
+	}
+
+	if params.QueueDownload && !params.DryRun {
+		var items []*butlerd.InstallQueueResult
+		for _, action := range res.Actions {
+			if action.Item != nil {
+				items = append(items, action.Item)
+			}
+		}
+
+		if len(items) > 0 {
+			err := downloads.QueueAll(rc, items)
+			if err != nil {
+				// nothing was queued: the staged installs would never be performed
+				consumer.Errorf("Could not queue downloads: %+v", err)
+				for _, action := range res.Actions {
+					if action.Item == nil {
+						continue
+					}
+					wipeErr := wipe.Do(consumer, action.Item.StagingFolder)
+					if wipeErr != nil {
+						consumer.Warnf("Could not wipe staging folder: %+v", wipeErr)
+					}
+					setSyncActionError(action, err)
+				}
+			}
+		}
+	}
+
+	return res, nil
+}
+
+type syncPlan struct {
+	// games that already have a cave
+	present     map[int64]bool
+	keptCaveIDs []string
+	uninstalls  []*butlerd.InstallSyncAction
+}
+
+// planSync sorts caves into those to keep and those to uninstall, since
+// their game isn't listed anymore. An empty listing is more likely
+// a problem than an emptied collection, so it only uninstalls
+// everything if allowEmpty is set.
+func planSync(caves []*models.Cave, games []*itchio.Game, allowEmpty bool) (*syncPlan, error) {
+	wanted := make(map[int64]bool)
+	for _, game := range games {
+		wanted[game.ID] = true
+	}
+
+	plan := &syncPlan{
+		present: make(map[int64]bool),
+	}
+	for _, cave := range caves {
+		if wanted[cave.GameID] {
+			plan.present[cave.GameID] = true
+			plan.keptCaveIDs = append(plan.keptCaveIDs, cave.ID)
+			continue
+		}
+		plan.uninstalls = append(plan.uninstalls, &butlerd.InstallSyncAction{
+			Type:   butlerd.InstallSyncActionUninstall,
+			Game:   cave.Game,
+			CaveID: cave.ID,
+			Upload: cave.Upload,
+			Build:  cave.Build,
+			Status: butlerd.InstallSyncActionStatusPlanned,
+		})
+	}
+
+	if len(games) == 0 && len(plan.uninstalls) > 0 && !allowEmpty {
+		return nil, errors.Errorf("No games listed, refusing to uninstall %d games (set allowEmpty to do it anyway)", len(plan.uninstalls))
+	}
+	return plan, nil
+}
+
+func syncActionFromOutcome(outcome *butlerd.InstallQueueManyOutcome, dryRun bool) *butlerd.InstallSyncAction {
+	action := &butlerd.InstallSyncAction{
+		Type:      butlerd.InstallSyncActionInstall,
+		Game:      outcome.Game,
+		Reason:    outcome.Reason,
+		ErrorCode: outcome.ErrorCode,
+	}
+
+	switch outcome.Status {
+	case butlerd.InstallQueueManyStatusSkipped:
+		action.Status = butlerd.InstallSyncActionStatusSkipped
+	case butlerd.InstallQueueManyStatusFailed:
+		action.Status = butlerd.InstallSyncActionStatusFailed
+	default:
+		action.Upload = outcome.Item.Upload
+		action.Build = outcome.Item.Build
+		if dryRun {
+			action.Status = butlerd.InstallSyncActionStatusPlanned
+		} else {
+			action.Status = butlerd.InstallSyncActionStatusQueued
+			action.Item = outcome.Item
+		}
+	}
+	return action
+}
+
+func setSyncActionError(action *butlerd.InstallSyncAction, err error) {
+	outcome := &butlerd.InstallQueueManyOutcome{}
+	setOutcomeError(outcome, err)
+	action.Status = butlerd.InstallSyncActionStatusFailed
+	action.Item = nil
+	action.Reason = outcome.Reason
+	action.ErrorCode = outcome.ErrorCode
+}
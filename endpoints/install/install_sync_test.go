@@ -0,0 +1,37 @@
+package install
+
+import (
+	"testing"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/database/models"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanSync(t *testing.T) {
+	kept := &models.Cave{ID: "kept", GameID: 1}
+	removed := &models.Cave{ID: "removed", GameID: 2, Game: &itchio.Game{ID: 2}}
+	caves := []*models.Cave{kept, removed}
+
+	plan, err := planSync(caves, []*itchio.Game{{ID: 1}, {ID: 3}}, false)
+	require.NoError(t, err)
+	require.EqualValues(t, map[int64]bool{1: true}, plan.present, "game 3 is new")
+	require.EqualValues(t, []string{"kept"}, plan.keptCaveIDs)
+	require.Len(t, plan.uninstalls, 1)
+	require.EqualValues(t, "removed", plan.uninstalls[0].CaveID)
+	require.EqualValues(t, butlerd.InstallSyncActionUninstall, plan.uninstalls[0].Type)
+	require.EqualValues(t, butlerd.InstallSyncActionStatusPlanned, plan.uninstalls[0].Status)
+	require.EqualValues(t, removed.Game, plan.uninstalls[0].Game)
+
+	_, err = planSync(caves, nil, false)
+	require.Error(t, err, "an empty listing shouldn't uninstall everything")
+
+	plan, err = planSync(caves, nil, true)
+	require.NoError(t, err)
+	require.Len(t, plan.uninstalls, 2)
+
+	plan, err = planSync(nil, nil, false)
+	require.NoError(t, err, "nothing to uninstall, nothing to refuse")
+	require.Empty(t, plan.uninstalls)
+}
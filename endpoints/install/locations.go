@@ -148,3 +148,22 @@ func InstallLocationsRemove(rc *butlerd.RequestContext, params butlerd.InstallLo
 	res := &butlerd.InstallLocationsRemoveResult{}
 	return res, nil
 }
+
+func InstallLocationsSetSpaceLimits(rc *butlerd.RequestContext, params butlerd.InstallLocationsSetSpaceLimitsParams) (*butlerd.InstallLocationsSetSpaceLimitsResult, error) {
+	conn := rc.GetConn()
+	defer rc.PutConn(conn)
+
+	il := models.InstallLocationByID(conn, params.ID)
+	if il == nil {
+		return nil, errors.Errorf("install location (%s) not found", params.ID)
+	}
+
+	il.QuotaSize = params.QuotaSize
+	il.ReservedSize = params.ReservedSize
+	models.MustSave(conn, il)
+
+	res := &butlerd.InstallLocationsSetSpaceLimitsResult{
+		InstallLocation: fetch.FormatInstallLocation(conn, rc.Consumer, il),
+	}
+	return res, nil
+}
@@ -0,0 +1,72 @@
+package install
+
+import (
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/cmd/auditinstalls"
+	"github.com/pkg/errors"
+)
+
+func InstallLocationsAudit(rc *butlerd.RequestContext, params butlerd.InstallLocationsAuditParams) (*butlerd.InstallLocationsAuditResult, error) {
+	r, err := auditinstalls.Audit(rc.WithConn, rc.Consumer, params.InstallLocationIDs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := &butlerd.InstallLocationsAuditResult{
+		Entries:    []*butlerd.InstallAuditEntry{},
+		WastedSize: r.WastedSize,
+	}
+	for _, e := range r.Entries {
+		fe := &butlerd.InstallAuditEntry{
+			Kind:               butlerd.InstallAuditKind(e.Kind),
+			Status:             butlerd.InstallAuditStatus(e.Status),
+			InstallLocationID:  e.InstallLocationID,
+			Path:               e.Path,
+			CaveID:             e.CaveID,
+			GameID:             e.GameID,
+			Size:               e.Size,
+			HasReceipt:         e.HasReceipt,
+			VerifiedSourceHash: e.VerifiedSourceHash,
+			NumAddedFiles:      e.NumAddedFiles,
+			AddedSize:          e.AddedSize,
+			AddedFiles:         e.AddedFiles,
+			NumRemovedFiles:    e.NumRemovedFiles,
+			RemovedFiles:       e.RemovedFiles,
+		}
+		for _, a := range e.Actions {
+			fe.Actions = append(fe.Actions, butlerd.InstallAuditAction(a))
+		}
+		res.Entries = append(res.Entries, fe)
+	}
+	return res, nil
+}
+
+func InstallLocationsApplyAudit(rc *butlerd.RequestContext, params butlerd.InstallLocationsApplyAuditParams) (*butlerd.InstallLocationsApplyAuditResult, error) {
+	var fixes []*auditinstalls.Fix
+	for _, f := range params.Fixes {
+		fixes = append(fixes, &auditinstalls.Fix{
+			Action: auditinstalls.Action(f.Action),
+			Path:   f.Path,
+		})
+	}
+
+	outcomes, err := auditinstalls.Apply(rc.WithConn, rc.Consumer, fixes)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := &butlerd.InstallLocationsApplyAuditResult{
+		Outcomes: []*butlerd.InstallAuditFixOutcome{},
+	}
+	for _, o := range outcomes {
+		res.Outcomes = append(res.Outcomes, &butlerd.InstallAuditFixOutcome{
+			Fix: &butlerd.InstallAuditFix{
+				Action: butlerd.InstallAuditAction(o.Fix.Action),
+				Path:   o.Fix.Path,
+			},
+			Error:  o.Error,
+			CaveID: o.CaveID,
+		})
+	}
+	return res, nil
+}
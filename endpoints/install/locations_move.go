@@ -0,0 +1,36 @@
+package install
+
+import (
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/messages"
+	"github.com/itchio/butler/cmd/movecaves"
+	"github.com/pkg/errors"
+)
+
+func InstallLocationsMoveCaves(rc *butlerd.RequestContext, params butlerd.InstallLocationsMoveCavesParams) (*butlerd.InstallLocationsMoveCavesResult, error) {
+	ctx, cleanup := rc.MakeCancelable(params.ID)
+	defer cleanup()
+
+	rc.StartProgress()
+	defer rc.EndProgress()
+
+	res, err := movecaves.Do(movecaves.Params{
+		Ctx:               ctx,
+		Consumer:          rc.Consumer,
+		WithConn:          rc.WithConn,
+		CaveIDs:           params.CaveIDs,
+		InstallLocationID: params.InstallLocationID,
+		OnCaveMoved: func(caveID string) {
+			messages.InstallLocationsMoveCavesYield.Notify(rc, butlerd.InstallLocationsMoveCavesYieldNotification{
+				CaveID: caveID,
+			})
+		},
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &butlerd.InstallLocationsMoveCavesResult{
+		MovedCaveIDs: res.MovedCaveIDs,
+	}, nil
+}
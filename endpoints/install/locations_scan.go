@@ -16,6 +16,7 @@ import (
 	"github.com/itchio/butler/cmd/operate"
 	"github.com/itchio/butler/database/models"
 	"github.com/itchio/butler/manager"
+	"github.com/itchio/butler/manager/installscan"
 
 	itchio "github.com/itchio/go-itchio"
 
@@ -206,7 +207,7 @@ func (sc *scanContext) DoMarket() error {
 			return errors.WithStack(err)
 		}
 
-		legCave := &legacyCave{}
+		legCave := &installscan.LegacyCave{}
 		err = json.Unmarshal(legCaveBytes, legCave)
 		if err != nil {
 			return errors.WithStack(err)
@@ -261,52 +262,37 @@ func (sc *scanContext) DoInstallLocation(il *models.InstallLocation) error {
 	rc := sc.rc
 	consumer := rc.Consumer
 
-	entries, err := ioutil.ReadDir(il.Path)
+	folderNames, err := installscan.FolderNames(il.Path)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	handleEntryPanics := func(entry os.FileInfo) error {
-		InstallFolderName := entry.Name()
+	handleEntryPanics := func(InstallFolderName string) error {
 		InstallFolder := filepath.Join(il.Path, InstallFolderName)
 
-		if InstallFolderName == "downloads" {
-			// definitely not a cave folder, skip
-			return nil
-		}
-
 		if sc.hasCaveAtLoc(il.ID, InstallFolderName) {
 			// already have cave, skip
 			return nil
 		}
 
-		dotItchPath := filepath.Join(InstallFolder, ".itch")
-		_, err := os.Stat(dotItchPath)
+		contents, err := installscan.Read(consumer, InstallFolder)
 		if err != nil {
-			// no .itch folder, skip
+			consumer.Errorf("%s", err.Error())
+			consumer.Infof("Skipping...")
 			return nil
 		}
-
-		receipt, err := bfs.ReadReceipt(InstallFolder)
-		if err != nil {
-			consumer.Warnf("While reading receipt in (%s): %s", InstallFolder, err.Error())
+		if contents == nil {
+			// no .itch folder, skip
+			return nil
 		}
 
-		legacyReceiptPath := filepath.Join(dotItchPath, "receipt.json")
-		legacyReceiptBytes, _ := ioutil.ReadFile(legacyReceiptPath)
-
-		if receipt != nil {
+		if receipt := contents.Receipt; receipt != nil {
 			var buildID int64
 			if receipt.Build != nil {
 				buildID = receipt.Build.ID
 			}
 
-			receiptStats, err := os.Stat(bfs.ReceiptPath(InstallFolder))
-			if err != nil {
-				return errors.WithStack(err)
-			}
-
-			legCave := &legacyCave{
+			legCave := &installscan.LegacyCave{
 				ID:       uuid.New().String(),
 				GameID:   receipt.Game.ID,
 				UploadID: receipt.Upload.ID,
@@ -316,24 +302,14 @@ func (sc *scanContext) DoInstallLocation(il *models.InstallLocation) error {
 				LastTouched: 0,
 				SecondsRun:  0,
 
-				InstalledAt: receiptStats.ModTime().UTC().Format(time.RFC3339),
+				InstalledAt: contents.ReceiptModTime.UTC().Format(time.RFC3339),
 
 				PathScheme:      2,
 				InstallLocation: il.ID,
 				InstallFolder:   InstallFolderName,
 			}
 			sc.queue(&task{legCave, receipt.Files})
-		} else {
-			consumer.Infof("Reading legacy itch recept from %s", legacyReceiptPath)
-
-			lr := &legacyReceipt{}
-			err := json.Unmarshal(legacyReceiptBytes, lr)
-			if err != nil {
-				consumer.Errorf("Could not unmarshal legacy itch receipt: %s", err.Error())
-				consumer.Infof("Skipping...")
-				return nil
-			}
-
+		} else if lr := contents.LegacyReceipt; lr != nil {
 			if lr.Cave != nil && sc.hasCave(lr.Cave.ID) {
 				return nil
 			}
@@ -350,7 +326,7 @@ func (sc *scanContext) DoInstallLocation(il *models.InstallLocation) error {
 		return nil
 	}
 
-	handleEntry := func(entry os.FileInfo) (err error) {
+	handleEntry := func(folderName string) (err error) {
 		defer func() {
 			if r := recover(); r != nil {
 				if rErr, ok := r.(error); ok {
@@ -360,20 +336,20 @@ func (sc *scanContext) DoInstallLocation(il *models.InstallLocation) error {
 				}
 			}
 		}()
-		err = handleEntryPanics(entry)
+		err = handleEntryPanics(folderName)
 		return
 	}
 
-	for _, entry := range entries {
-		err := handleEntry(entry)
+	for _, folderName := range folderNames {
+		err := handleEntry(folderName)
 		if err != nil {
-			consumer.Errorf("While handling entry %s: %s", entry.Name(), err.Error())
+			consumer.Errorf("While handling entry %s: %s", folderName, err.Error())
 		}
 	}
 	return nil
 }
 
-func (sc *scanContext) importLegacyCave(legacyCave *legacyCave, files []string) (err error) {
+func (sc *scanContext) importLegacyCave(legacyCave *installscan.LegacyCave, files []string) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			if rErr, ok := r.(error); ok {
@@ -387,7 +363,7 @@ func (sc *scanContext) importLegacyCave(legacyCave *legacyCave, files []string)
 	return
 }
 
-func (sc *scanContext) importLegacyCavePanics(legacyCave *legacyCave, files []string) error {
+func (sc *scanContext) importLegacyCavePanics(legacyCave *installscan.LegacyCave, files []string) error {
 	sc.numScanned++
 	rc := sc.rc
 	consumer := rc.Consumer
@@ -602,45 +578,6 @@ func (sc *scanContext) queue(task *task) {
 	sc.tasks = append(sc.tasks, task)
 }
 
-type legacyReceipt struct {
-	Cave *legacyCave `json:"cave"`
-
-	// Introduced in v0.12.0 (January 2016!!)
-	// See https://github.com/itchio/itch/commit/1a550b52cb250b2b60b9c32b7f09fb8fc0bdc647#diff-2c5c1d50b675bd97c2b17e7fba3e5eeaR190
-	Files []string `json:"files"`
-}
-
-type legacyCave struct {
-	// These have been here since v0.14.0 at least,
-	// see https://github.com/itchio/itch/blob/v0.14.0/appsrc/tasks/install.js#L42
-	ID       string `json:"id"`
-	GameID   int64  `json:"gameId"`
-	UploadID int64  `json:"uploadId"`
-	// see https://github.com/itchio/itch/blob/v0.14.0/appsrc/tasks/install.js#L103
-	// and https://github.com/itchio/itch/commit/7f76cecbbae0a22bb574f8557b642acd24ee91d4#diff-0fcbef7d4100ec13581b447ef7050e7fL99
-	BuildID int64 `json:"buildId"`
-
-	// Introduced in v0.14.0
-	// See https://github.com/itchio/itch/commit/62ac56a107ffe0a5d64e9574248a97761db0af8f#diff-edc50cd9cd6f10a0854f25aa2e3ea52dR35
-	LastTouched float64 `json:"lastTouched"`
-	SecondsRun  float64 `json:"secondsRun"`
-
-	// Introduced in v18.0.0 as a `new Date()` (coerced to an RFC3339 string when
-	// passed through JSON.stringify).
-	// See https://github.com/itchio/itch/commit/eb046948d528053628a8b5dcd2860446223a8541#diff-0fcbef7d4100ec13581b447ef7050e7fR104
-	// Changed to a number in v18.3.0,
-	// See https://github.com/itchio/itch/commit/d3ad339a58c3763cef216015cce6c2b8084a89c2#diff-0fcbef7d4100ec13581b447ef7050e7fR114
-	InstalledAt interface{} `json:"installedAt"`
-
-	// Introduced in v18.5.0 (1 year 7 months ago at the time of this writing)
-	// Prior to that, it was a mess, so let's just not import their caves.
-	// See https://github.com/itchio/itch/commit/9cfc5c14184c506afff282ab50f19a7a774197a6#diff-b57d301bc67d78b1004baedc3472f13b
-	PathScheme int `json:"pathScheme"`
-
-	InstallLocation string `json:"installLocation"`
-	InstallFolder   string `json:"installFolder"`
-}
-
 func fromJSTimestamp(timestamp float64) *time.Time {
 	// javascript's Date.now() returns milliseconds since epoch
 	// this assumes UTC timestamp
@@ -660,6 +597,6 @@ func fromJSDate(date string) *time.Time {
 }
 
 type task struct {
-	legacyCave *legacyCave
+	legacyCave *installscan.LegacyCave
 	files      []string
 }
@@ -0,0 +1,108 @@
+package launch
+
+import (
+	"time"
+
+	"crawshaw.io/sqlite"
+	"github.com/google/uuid"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/manager"
+	"github.com/pkg/errors"
+)
+
+func CompatHostsList(rc *butlerd.RequestContext, params butlerd.CompatHostsListParams) (*butlerd.CompatHostsListResult, error) {
+	res := &butlerd.CompatHostsListResult{
+		CompatHosts: []*butlerd.CompatHost{},
+	}
+
+	var mchs []*models.CompatHost
+	rc.WithConn(func(conn *sqlite.Conn) {
+		mchs = models.AllCompatHosts(conn)
+	})
+
+	for _, mch := range mchs {
+		ch, err := butlerd.FormatCompatHost(mch)
+		if err != nil {
+			return nil, err
+		}
+		res.CompatHosts = append(res.CompatHosts, ch)
+	}
+	return res, nil
+}
+
+func CompatHostsSave(rc *butlerd.RequestContext, params butlerd.CompatHostsSaveParams) (*butlerd.CompatHostsSaveResult, error) {
+	ch := params.CompatHost
+
+	var err error
+	rc.WithConn(func(conn *sqlite.Conn) {
+		mch := &models.CompatHost{}
+		if ch.ID != "" {
+			mch = models.CompatHostByID(conn, ch.ID)
+			if mch == nil {
+				err = errors.Errorf("compat host not found: %s", ch.ID)
+				return
+			}
+		} else {
+			createdAt := time.Now().UTC()
+			mch.ID = uuid.New().String()
+			mch.CreatedAt = &createdAt
+			ch.ID = mch.ID
+		}
+
+		mch.Name = ch.Name
+		mch.Platform = ch.Platform
+		mch.Is64 = ch.Is64
+		mch.Position = ch.Position
+		err = models.MarshalJSON(ch.Wrapper, &mch.Wrapper, "compat host wrapper")
+		if err != nil {
+			return
+		}
+		mch.Save(conn)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &butlerd.CompatHostsSaveResult{
+		CompatHost: ch,
+	}, nil
+}
+
+func CompatHostsRemove(rc *butlerd.RequestContext, params butlerd.CompatHostsRemoveParams) (*butlerd.CompatHostsRemoveResult, error) {
+	found := rc.WithConnBool(func(conn *sqlite.Conn) bool {
+		mch := models.CompatHostByID(conn, params.ID)
+		if mch == nil {
+			return false
+		}
+		mch.Delete(conn)
+		return true
+	})
+	if !found {
+		return nil, errors.Errorf("compat host not found: %s", params.ID)
+	}
+	return &butlerd.CompatHostsRemoveResult{}, nil
+}
+
+// selectCaveHost narrows hosts down to the native ones and the cave's
+// compat host, if it has one and it's available.
+func selectCaveHost(rc *butlerd.RequestContext, settings *butlerd.CaveSettings, hosts manager.Hosts) manager.Hosts {
+	if settings.HostID == "" {
+		return hosts
+	}
+
+	selected, ok := hosts.ByID(settings.HostID)
+	if !ok {
+		rc.Consumer.Warnf("Compat host (%s) is missing or unavailable, considering all hosts", settings.HostID)
+		return hosts
+	}
+
+	var res manager.Hosts
+	for _, host := range hosts {
+		if host.Wrapper == nil && host.RemoteLaunchName == "" {
+			res = append(res, host)
+		}
+	}
+	rc.Consumer.Infof("Using compat host %s", selected)
+	return append(res, selected)
+}
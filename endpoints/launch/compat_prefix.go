@@ -0,0 +1,36 @@
+package launch
+
+import (
+	"os"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/manager"
+	"github.com/pkg/errors"
+)
+
+// prepareCompatPrefix gives wine hosts a prefix of their own for each
+// cave, so that DLL overrides and prereqs don't leak between games.
+// The prefix is created on first launch, wine populates it.
+func prepareCompatPrefix(rc *butlerd.RequestContext, cave *models.Cave, host manager.Host) (manager.Host, error) {
+	if !host.NeedsCompatPrefix() {
+		return host, nil
+	}
+
+	var prefix string
+	rc.WithConn(func(conn *sqlite.Conn) {
+		prefix = cave.GetCompatPrefixFolder(conn)
+	})
+	if prefix == "" {
+		rc.Consumer.Warnf("Cave has no install location, using the default wine prefix")
+		return host, nil
+	}
+
+	err := os.MkdirAll(prefix, 0o755)
+	if err != nil {
+		return host, errors.WithMessage(err, "creating wine prefix")
+	}
+	rc.Consumer.Infof("Using wine prefix (%s)", prefix)
+	return host.WithCompatPrefix(prefix), nil
+}
@@ -0,0 +1,166 @@
+package launch
+
+import (
+	"time"
+
+	"crawshaw.io/sqlite"
+	"github.com/google/uuid"
+	"github.com/itchio/butler/buildinfo"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/horror"
+	"github.com/itchio/butler/butlerd/messages"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/ox"
+	"github.com/pkg/errors"
+	"xorm.io/builder"
+)
+
+type crashContext struct {
+	rc            *butlerd.RequestContext
+	cave          *models.Cave
+	profileID     int64
+	runtime       ox.Runtime
+	strategy      butlerd.LaunchStrategy
+	actionName    string
+	launchProfile string
+	sandbox       bool
+	playSessionID string
+}
+
+// record persists a crash reported by a launcher, and lets the client
+// know about it. Failing to record a crash never fails the launch.
+func (cc *crashContext) record(report *CrashReport) {
+	rc := cc.rc
+	consumer := rc.Consumer
+	defer horror.RecoverAndLog(consumer)
+
+	crashedAt := time.Now().UTC()
+	c := &models.Crash{
+		ID:            uuid.New().String(),
+		ProfileID:     cc.profileID,
+		CaveID:        cc.cave.ID,
+		GameID:        cc.cave.GameID,
+		UploadID:      cc.cave.UploadID,
+		BuildID:       cc.cave.BuildID,
+		PlaySessionID: cc.playSessionID,
+
+		CrashedAt:  &crashedAt,
+		SecondsRun: int64(report.RunDuration.Seconds()),
+
+		ExitCode: report.ExitCode,
+		Signal:   report.Signal,
+
+		Stdout: report.Stdout,
+		Stderr: report.Stderr,
+	}
+
+	err := models.MarshalJSON(&butlerd.CrashLaunchInfo{
+		Strategy:         cc.strategy,
+		ActionName:       cc.actionName,
+		LaunchProfile:    cc.launchProfile,
+		TargetPath:       report.TargetPath,
+		Args:             report.Args,
+		WorkingDirectory: report.WorkingDirectory,
+		Sandbox:          cc.sandbox,
+		SandboxType:      report.SandboxType,
+	}, &c.LaunchParams, "crash launch params")
+	if err != nil {
+		consumer.Warnf("Could not record crash: %+v", err)
+		return
+	}
+
+	err = models.MarshalJSON(&butlerd.CrashEnvironment{
+		OS:            cc.runtime.OS(),
+		Arch:          cc.runtime.Arch(),
+		ButlerVersion: buildinfo.VersionString,
+		EnvKeys:       report.EnvKeys,
+	}, &c.Environment, "crash environment")
+	if err != nil {
+		consumer.Warnf("Could not record crash: %+v", err)
+		return
+	}
+
+	rc.WithConn(func(conn *sqlite.Conn) {
+		c.Save(conn)
+		models.PruneCrashes(conn, c.CaveID)
+	})
+	consumer.Infof("Recorded crash (%s)", c.ID)
+
+	summary := formatCrashSummary(c)
+	summary.Game = cc.cave.Game
+	messages.LaunchCrashed.Notify(rc, butlerd.LaunchCrashedNotification{
+		Crash: summary,
+	})
+}
+
+func ListCrashes(rc *butlerd.RequestContext, params butlerd.LaunchListCrashesParams) (*butlerd.LaunchListCrashesResult, error) {
+	cond := builder.NewCond()
+	if params.CaveID != "" {
+		cond = cond.And(builder.Eq{"cave_id": params.CaveID})
+	}
+	if params.GameID != 0 {
+		cond = cond.And(builder.Eq{"game_id": params.GameID})
+	}
+
+	res := &butlerd.LaunchListCrashesResult{
+		Crashes: []*butlerd.CrashSummary{},
+	}
+	rc.WithConn(func(conn *sqlite.Conn) {
+		for _, c := range models.CrashesMatching(conn, cond) {
+			summary := formatCrashSummary(c)
+			summary.Game = models.GameByID(conn, c.GameID)
+			res.Crashes = append(res.Crashes, summary)
+		}
+	})
+	return res, nil
+}
+
+func GetCrash(rc *butlerd.RequestContext, params butlerd.LaunchGetCrashParams) (*butlerd.LaunchGetCrashResult, error) {
+	var c *models.Crash
+	var summary *butlerd.CrashSummary
+	rc.WithConn(func(conn *sqlite.Conn) {
+		c = models.CrashByID(conn, params.CrashID)
+		if c != nil {
+			summary = formatCrashSummary(c)
+			summary.Game = models.GameByID(conn, c.GameID)
+		}
+	})
+	if c == nil {
+		return nil, errors.Errorf("crash not found: %s", params.CrashID)
+	}
+
+	report := &butlerd.CrashReport{
+		Summary:     summary,
+		Stdout:      c.Stdout,
+		Stderr:      c.Stderr,
+		LaunchInfo:  &butlerd.CrashLaunchInfo{},
+		Environment: &butlerd.CrashEnvironment{},
+	}
+
+	err := models.UnmarshalJSON(c.LaunchParams, report.LaunchInfo, "crash launch info")
+	if err != nil {
+		return nil, err
+	}
+	err = models.UnmarshalJSON(c.Environment, report.Environment, "crash environment")
+	if err != nil {
+		return nil, err
+	}
+
+	return &butlerd.LaunchGetCrashResult{
+		Crash: report,
+	}, nil
+}
+
+func formatCrashSummary(c *models.Crash) *butlerd.CrashSummary {
+	return &butlerd.CrashSummary{
+		ID:         c.ID,
+		CaveID:     c.CaveID,
+		GameID:     c.GameID,
+		UploadID:   c.UploadID,
+		BuildID:    c.BuildID,
+		CrashedAt:  c.CrashedAt,
+		SecondsRun: c.SecondsRun,
+		ExitCode:   c.ExitCode,
+		Signal:     c.Signal,
+	}
+}
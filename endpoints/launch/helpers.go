@@ -106,6 +106,7 @@ func getTargets(rc *butlerd.RequestContext, params getTargetsParams) (*getTarget
 	info := params.info
 
 	installFolder := info.installFolder
+	hosts := selectCaveHost(rc, caveSettings(rc, info.cave), params.hosts)
 
 	upload, _, err := getUploadAndBuild(rc, info)
 	if err != nil {
@@ -138,7 +139,7 @@ func getTargets(rc *butlerd.RequestContext, params getTargetsParams) (*getTarget
 	}
 
 	if !shouldBrowse {
-		for _, host := range params.hosts {
+		for _, host := range hosts {
 			hostTargets, err := getTargetsForHost(rc, upload, appManifest, verdict, info, host)
 			if err != nil {
 				return nil, err
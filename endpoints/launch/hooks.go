@@ -0,0 +1,129 @@
+package launch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/shell/loggerwriter"
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+)
+
+const (
+	hookStagePreLaunch = "pre-launch"
+	hookStagePostExit  = "post-exit"
+)
+
+// hookRunner runs pre-launch and post-exit hook commands for a launch.
+type hookRunner struct {
+	consumer *state.Consumer
+	dir      string
+	env      []string
+
+	preLaunch []*butlerd.LaunchHook
+	postExit  []*butlerd.LaunchHook
+}
+
+// newHookRunner collects global hooks first, then the cave's own.
+func newHookRunner(consumer *state.Consumer, cave *models.Cave, installFolder string, launchProfile *butlerd.LaunchProfile, global *butlerd.LaunchHooks, caveHooks *butlerd.LaunchHooks) *hookRunner {
+	hr := &hookRunner{
+		consumer: consumer,
+		dir:      installFolder,
+		env: []string{
+			fmt.Sprintf("ITCHIO_CAVE_ID=%s", cave.ID),
+			fmt.Sprintf("ITCHIO_GAME_ID=%d", cave.GameID),
+			fmt.Sprintf("ITCHIO_UPLOAD_ID=%d", cave.UploadID),
+			fmt.Sprintf("ITCHIO_BUILD_ID=%d", cave.BuildID),
+			fmt.Sprintf("ITCHIO_INSTALL_FOLDER=%s", installFolder),
+		},
+	}
+	if launchProfile != nil {
+		hr.env = append(hr.env, fmt.Sprintf("ITCHIO_LAUNCH_PROFILE=%s", launchProfile.Name))
+	}
+
+	for _, hooks := range []*butlerd.LaunchHooks{global, caveHooks} {
+		if hooks == nil {
+			continue
+		}
+		hr.preLaunch = append(hr.preLaunch, hooks.PreLaunch...)
+		hr.postExit = append(hr.postExit, hooks.PostExit...)
+	}
+	return hr
+}
+
+// runPreLaunch runs all pre-launch hooks, and returns an error if one
+// that's set to abort the launch failed.
+func (hr *hookRunner) runPreLaunch(ctx context.Context) error {
+	return hr.runAll(ctx, hookStagePreLaunch, hr.preLaunch, nil)
+}
+
+// runPostExit runs all post-exit hooks. They're not tied to the launch's
+// context, so that cleanup (unmounting, backing up saves) still happens
+// if the launch was cancelled.
+func (hr *hookRunner) runPostExit(launchErr error) error {
+	result := "ok"
+	if launchErr != nil {
+		result = "error"
+	}
+	extraEnv := []string{fmt.Sprintf("ITCHIO_LAUNCH_RESULT=%s", result)}
+	return hr.runAll(context.Background(), hookStagePostExit, hr.postExit, extraEnv)
+}
+
+func (hr *hookRunner) runAll(ctx context.Context, stage string, hooks []*butlerd.LaunchHook, extraEnv []string) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	consumer := hr.consumer
+	consumer.Infof("Running (%d) %s hooks", len(hooks), stage)
+	for i, hook := range hooks {
+		err := hr.run(ctx, stage, hook, extraEnv)
+		if err == nil {
+			continue
+		}
+
+		if hook.Aborts() {
+			consumer.Errorf("%s hook %d failed: %v", stage, i+1, err)
+			return errors.WithMessage(err, fmt.Sprintf("%s hook (%s)", stage, hook.Command))
+		}
+		consumer.Warnf("%s hook %d failed, continuing: %v", stage, i+1, err)
+	}
+	return nil
+}
+
+func (hr *hookRunner) run(parentCtx context.Context, stage string, hook *butlerd.LaunchHook, extraEnv []string) error {
+	consumer := hr.consumer
+
+	tokens, err := hook.CommandTokens()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(parentCtx, hook.Timeout())
+	defer cancel()
+
+	consumer.Infof("→ Running %s hook:", stage)
+	consumer.Infof("  %s", strings.Join(tokens, " ::: "))
+
+	cmd := exec.CommandContext(ctx, tokens[0], tokens[1:]...)
+	cmd.Dir = hr.dir
+	cmd.Env = append(os.Environ(), hr.env...)
+	cmd.Env = append(cmd.Env, fmt.Sprintf("ITCHIO_HOOK=%s", stage))
+	cmd.Env = append(cmd.Env, extraEnv...)
+	cmd.Stdout = loggerwriter.New(consumer, "out")
+	cmd.Stderr = loggerwriter.New(consumer, "err")
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.Errorf("timed out after %s", hook.Timeout())
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package launch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/headway/state"
+)
+
+func TestHookRunnerOrderAndEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.txt")
+	appendEnv := func(name string) *butlerd.LaunchHook {
+		return &butlerd.LaunchHook{Command: `sh -c 'echo "` + name + ` $ITCHIO_HOOK $ITCHIO_GAME_ID $ITCHIO_LAUNCH_RESULT" >> ` + out + `'`}
+	}
+
+	cave := &models.Cave{ID: "cave-1", GameID: 42}
+	hr := newHookRunner(&state.Consumer{}, cave, dir, nil,
+		&butlerd.LaunchHooks{
+			PreLaunch: []*butlerd.LaunchHook{appendEnv("global")},
+			PostExit:  []*butlerd.LaunchHook{appendEnv("global")},
+		},
+		&butlerd.LaunchHooks{
+			PreLaunch: []*butlerd.LaunchHook{appendEnv("cave")},
+		},
+	)
+
+	if err := hr.runPreLaunch(context.Background()); err != nil {
+		t.Fatalf("pre-launch hooks failed: %+v", err)
+	}
+	if err := hr.runPostExit(nil); err != nil {
+		t.Fatalf("post-exit hooks failed: %+v", err)
+	}
+
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	expected := []string{
+		"global pre-launch 42 ",
+		"cave pre-launch 42 ",
+		"global post-exit 42 ok",
+	}
+	if strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected hooks to run as %q, got %q", expected, lines)
+	}
+}
+
+func TestHookRunnerFailurePolicy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	cave := &models.Cave{ID: "cave-1"}
+	hr := newHookRunner(&state.Consumer{}, cave, t.TempDir(), nil, &butlerd.LaunchHooks{
+		PreLaunch: []*butlerd.LaunchHook{{Command: "sh -c 'exit 1'"}},
+	}, nil)
+	if err := hr.runPreLaunch(context.Background()); err != nil {
+		t.Fatalf("hook set to warn should not fail the launch, got %+v", err)
+	}
+
+	hr.preLaunch[0].OnFailure = butlerd.LaunchHookFailurePolicyAbort
+	if err := hr.runPreLaunch(context.Background()); err == nil {
+		t.Fatalf("hook set to abort should fail the launch")
+	}
+
+	hr.preLaunch[0] = &butlerd.LaunchHook{
+		Command:        "sleep 5",
+		TimeoutSeconds: 1,
+		OnFailure:      butlerd.LaunchHookFailurePolicyAbort,
+	}
+	err := hr.runPreLaunch(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/itchio/butler/butlerd/messages"
 	"github.com/itchio/butler/cmd/operate"
 	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/endpoints/tasks"
 	"github.com/itchio/hush/manifest"
 
 	"github.com/itchio/httpkit/neterr"
@@ -31,6 +32,11 @@ var ErrCandidateDisappeared = goerrors.New("candidate disappeared from disk!")
 func Register(router *butlerd.Router) {
 	messages.Launch.Register(router, Launch)
 	messages.LaunchGetTargets.Register(router, GetTargets)
+	messages.LaunchListCrashes.Register(router, ListCrashes)
+	messages.LaunchGetCrash.Register(router, GetCrash)
+	messages.CompatHostsList.Register(router, CompatHostsList)
+	messages.CompatHostsSave.Register(router, CompatHostsSave)
+	messages.CompatHostsRemove.Register(router, CompatHostsRemove)
 }
 
 func Launch(rc *butlerd.RequestContext, params butlerd.LaunchParams) (*butlerd.LaunchResult, error) {
@@ -77,17 +83,34 @@ func Launch(rc *butlerd.RequestContext, params butlerd.LaunchParams) (*butlerd.L
 			return errors.WithStack(butlerd.CodeNoLaunchCandidates)
 		}
 
-		if params.Target != "" {
+		settings := caveSettings(rc, cave)
+
+		var launchProfile *butlerd.LaunchProfile
+		if params.Profile != "" {
+			launchProfile = findLaunchProfile(settings, params.Profile)
+			if launchProfile == nil {
+				consumer.Errorf("Requested launch profile (%s) matched none of the (%d) profiles", params.Profile, len(settings.LaunchProfiles))
+				return errors.WithStack(butlerd.CodeLaunchProfileNotFound)
+			}
+			consumer.Infof("Using launch profile (%s)", launchProfile.Name)
+		}
+
+		requestedTarget := params.Target
+		if requestedTarget == "" && launchProfile != nil {
+			requestedTarget = launchProfile.Target
+		}
+
+		if requestedTarget != "" {
 			// an explicit per-launch target is an API contract: fail rather
 			// than surprising a non-interactive caller with a picker callback
-			target = findTarget(targets, params.Target)
+			target = findTarget(targets, requestedTarget)
 			if target == nil {
-				consumer.Errorf("Requested target (%s) matched none of the (%d) targets", params.Target, len(targets))
+				consumer.Errorf("Requested target (%s) matched none of the (%d) targets", requestedTarget, len(targets))
 				return errors.WithStack(butlerd.CodeLaunchTargetNotFound)
 			}
-			consumer.Infof("Using requested target (%s):", params.Target)
+			consumer.Infof("Using requested target (%s):", requestedTarget)
 			consumer.Logf("%s", target.Strategy.String())
-		} else if preferred := settingsLaunchTarget(rc, cave); preferred != "" {
+		} else if preferred := settings.LaunchTarget; preferred != "" {
 			// a persisted preference is best-effort: it may go stale when the
 			// game updates, so fall back to normal selection instead of failing
 			target = findTarget(targets, preferred)
@@ -140,6 +163,11 @@ func Launch(rc *butlerd.RequestContext, params butlerd.LaunchParams) (*butlerd.L
 			return errors.WithStack(err)
 		}
 
+		host, err := prepareCompatPrefix(rc, cave, target.Host)
+		if err != nil {
+			return err
+		}
+
 		var workingDirectory = ""
 		var args = []string{}
 		var env = make(map[string]string)
@@ -150,13 +178,29 @@ func Launch(rc *butlerd.RequestContext, params butlerd.LaunchParams) (*butlerd.L
 		if params.CommandTemplate != "" && target.Strategy.Strategy != butlerd.LaunchStrategyNative {
 			consumer.Warnf("Custom command template does not apply to %s launches", target.Strategy.Strategy)
 		}
+		if launchProfile != nil && target.Strategy.Strategy != butlerd.LaunchStrategyNative {
+			if len(launchProfile.Env) > 0 || len(launchProfile.Args) > 0 || launchProfile.WorkingDirectory != "" {
+				consumer.Warnf("Launch profile environment, arguments and working directory do not apply to %s launches", target.Strategy.Strategy)
+			}
+		}
 
 		err = requestAPIKeyIfNecessary(rc, target.Action, game, access, env)
 		if err != nil {
 			return errors.WithMessage(err, "While requesting API key")
 		}
 
-		sandbox := resolveSandbox(params.Sandbox, target.Action.Sandbox)
+		sandboxPref := params.Sandbox
+		sandboxOptions := params.SandboxOptions
+		if launchProfile != nil {
+			if launchProfile.Sandbox != nil {
+				sandboxPref = launchProfile.Sandbox
+			}
+			if launchProfile.SandboxOptions != nil {
+				sandboxOptions = launchProfile.SandboxOptions
+			}
+		}
+
+		sandbox := resolveSandbox(sandboxPref, target.Action.Sandbox)
 		if target.Action.Sandbox {
 			if sandbox {
 				consumer.Infof("Enabling sandbox because of manifest opt-in")
@@ -165,8 +209,28 @@ func Launch(rc *butlerd.RequestContext, params butlerd.LaunchParams) (*butlerd.L
 			}
 		}
 
+		hooks := newHookRunner(consumer, cave, installFolder, launchProfile, params.Hooks, settings.Hooks)
+		err = hooks.runPreLaunch(rc.Ctx)
+		if err != nil {
+			return err
+		}
+
 		tracksSession := launcherTracksSession(launcher)
 
+		crashes := &crashContext{
+			rc:         rc,
+			cave:       cave,
+			profileID:  access.ProfileID,
+			runtime:    runtime,
+			strategy:   target.Strategy.Strategy,
+			actionName: target.Action.Name,
+			sandbox:    sandbox,
+		}
+		if launchProfile != nil {
+			crashes.launchProfile = launchProfile.Name
+		}
+		crashed := false
+
 		var (
 			sessionWatcherDone chan struct{}
 			sessionStartedChan chan time.Time
@@ -186,6 +250,18 @@ func Launch(rc *butlerd.RequestContext, params butlerd.LaunchParams) (*butlerd.L
 			sessionCtx, sessionCancel = context.WithCancel(rc.Ctx)
 			defer sessionCancel()
 
+			platform := interactionPlatform(runtime)
+			architecture := interactionArchitecture(runtime)
+			playSession := &models.PlaySession{
+				ProfileID:    access.ProfileID,
+				CaveID:       cave.ID,
+				GameID:       cave.GameID,
+				UploadID:     cave.UploadID,
+				BuildID:      cave.BuildID,
+				Platform:     string(platform),
+				Architecture: string(architecture),
+			}
+
 			tracker := &sessionTracker{
 				consumer:     consumer,
 				client:       rc.Client(access.APIKey),
@@ -193,8 +269,9 @@ func Launch(rc *butlerd.RequestContext, params butlerd.LaunchParams) (*butlerd.L
 				uploadID:     cave.UploadID,
 				buildID:      cave.BuildID,
 				credentials:  access.Credentials,
-				platform:     interactionPlatform(runtime),
-				architecture: interactionArchitecture(runtime),
+				platform:     platform,
+				architecture: architecture,
+				journal:      newDBSessionJournal(rc, playSession),
 				persistSummary: func(summary *itchio.UserGameInteractionsSummary) {
 					rc.WithConn(func(conn *sqlite.Conn) {
 						if err := models.SaveUserGameInteractionSummary(conn, access.ProfileID, cave.GameID, summary); err != nil {
@@ -204,6 +281,8 @@ func Launch(rc *butlerd.RequestContext, params butlerd.LaunchParams) (*butlerd.L
 				},
 			}
 
+			crashes.playSessionID = playSession.ID
+
 			go func() {
 				defer close(sessionWatcherDone)
 				defer horror.RecoverAndLog(consumer)
@@ -228,26 +307,32 @@ func Launch(rc *butlerd.RequestContext, params butlerd.LaunchParams) (*butlerd.L
 			AppManifest:      targetRes.appManifest,
 			Action:           target.Action,
 			Sandbox:          sandbox,
-			SandboxOptions:   params.SandboxOptions,
+			SandboxOptions:   sandboxOptions,
 			WorkingDirectory: workingDirectory,
 			Args:             args,
 			Env:              env,
 			CommandTemplate:  params.CommandTemplate,
+			LaunchProfile:    launchProfile,
+			HTMLServer:       params.HTMLServer,
 
 			PrereqsDir:    params.PrereqsDir,
 			ForcePrereqs:  params.ForcePrereqs,
 			Access:        access.OnlyAPIKey(),
 			InstallFolder: installFolder,
-			Host:          target.Host,
+			Host:          host,
 
 			SessionStarted: sessionStarted,
+			RecordCrash: func(report *CrashReport) {
+				crashed = true
+				crashes.record(report)
+			},
 		}
 
 		err = launcher.Do(launcherParams)
 		launchEndedAt := time.Now()
 
 		if tracksSession {
-			sessionEndedChan <- sessionEnd{at: launchEndedAt, crashed: err != nil}
+			sessionEndedChan <- sessionEnd{at: launchEndedAt, crashed: err != nil || crashed}
 
 			// go-itchio's retry backoffs are not context-aware, so this is a soft bound.
 			consumer.Debugf("Waiting on session watcher...")
@@ -267,12 +352,20 @@ func Launch(rc *butlerd.RequestContext, params butlerd.LaunchParams) (*butlerd.L
 						fresh.Save(conn)
 					}
 				})
+
+				// picks up this session if it couldn't be fully synced
+				// above, along with any earlier ones.
+				rc.QueueBackgroundTask(tasks.SyncPlaySessions())
 			}
 		}
 
+		hookErr := hooks.runPostExit(err)
 		if err != nil {
 			return err
 		}
+		if hookErr != nil {
+			return hookErr
+		}
 
 		res = &butlerd.LaunchResult{}
 		return nil
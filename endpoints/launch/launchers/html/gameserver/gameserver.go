@@ -0,0 +1,292 @@
+// Package gameserver serves an HTML5 game's install folder on a loopback
+// port, so clients don't each need their own file server.
+package gameserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/itchio/go-brotli/dec"
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+)
+
+type Params struct {
+	// Absolute path on disk to serve
+	RootFolder string
+
+	// If true, send COOP/COEP headers so the game runs cross-origin
+	// isolated and can use SharedArrayBuffer
+	CrossOriginIsolated bool
+
+	// For logging
+	Consumer *state.Consumer
+}
+
+// Server serves a single folder on 127.0.0.1, under a random token
+// prefix so that other local pages can't guess its URLs.
+type Server struct {
+	params   Params
+	token    string
+	listener net.Listener
+	server   *http.Server
+}
+
+// MIME types that Go's mime package (or the OS registry) gets wrong or
+// doesn't know about, but that game engines care about.
+var mimeTypes = map[string]string{
+	".wasm":     "application/wasm",
+	".js":       "text/javascript",
+	".mjs":      "text/javascript",
+	".json":     "application/json",
+	".data":     "application/octet-stream",
+	".mem":      "application/octet-stream",
+	".unityweb": "application/octet-stream",
+	".pck":      "application/octet-stream",
+	".html":     "text/html; charset=utf-8",
+	".css":      "text/css; charset=utf-8",
+	".svg":      "image/svg+xml",
+}
+
+// Start listens on a random loopback port and starts serving.
+func Start(params Params) (*Server, error) {
+	if params.Consumer == nil {
+		params.Consumer = &state.Consumer{}
+	}
+
+	tokenBytes := make([]byte, 16)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	s := &Server{
+		params:   params,
+		token:    hex.EncodeToString(tokenBytes),
+		listener: listener,
+	}
+	s.server = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		err := s.server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			params.Consumer.Warnf("HTML game server stopped: %v", err)
+		}
+	}()
+
+	return s, nil
+}
+
+// URL returns the address of a file, given its path relative to
+// the root folder.
+func (s *Server) URL(relativePath string) string {
+	u := url.URL{
+		Scheme: "http",
+		Host:   s.listener.Addr().String(),
+		Path:   "/" + s.token + "/" + filepath.ToSlash(relativePath),
+	}
+	return u.String()
+}
+
+// Close stops the server. In-flight requests are cut off.
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	prefix := "/" + s.token + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	name := path.Clean("/" + strings.TrimPrefix(r.URL.Path, prefix))
+
+	h := w.Header()
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Content-Type-Options", "nosniff")
+	if s.params.CrossOriginIsolated {
+		h.Set("Cross-Origin-Opener-Policy", "same-origin")
+		h.Set("Cross-Origin-Embedder-Policy", "require-corp")
+		h.Set("Cross-Origin-Resource-Policy", "same-origin")
+	}
+
+	s.serveFile(w, r, name)
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	fullPath := filepath.Join(s.params.RootFolder, filepath.FromSlash(name))
+
+	f, stats, err := openFile(fullPath)
+	if err == nil && stats.IsDir() {
+		f.Close()
+		fullPath = filepath.Join(fullPath, "index.html")
+		name = path.Join(name, "index.html")
+		f, stats, err = openFile(fullPath)
+	}
+
+	if err != nil {
+		// e.g. Emscripten asks for game.wasm, but only game.wasm.br
+		// or game.wasm.gz was shipped. Prefer brotli if advertised,
+		// either is decompressed for clients that don't accept it.
+		candidates := []string{".gz", ".br"}
+		if acceptsEncoding(r, "br") {
+			candidates = []string{".br", ".gz"}
+		}
+		for _, ext := range candidates {
+			f, stats, err = openFile(fullPath + ext)
+			if err == nil {
+				name += ext
+				break
+			}
+		}
+	}
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	contentName, encoding := splitEncoding(name)
+	if encoding == "" && path.Ext(name) == ".unityweb" {
+		encoding = sniffUnityWebEncoding(f)
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", contentType(contentName))
+
+	if encoding != "" {
+		h.Add("Vary", "Accept-Encoding")
+		if !acceptsEncoding(r, encoding) {
+			// browsers don't advertise brotli over plain http, so
+			// that's not rare, but decompressing is cheap enough
+			s.serveDecompressed(w, r, f, encoding)
+			return
+		}
+		h.Set("Content-Encoding", encoding)
+	}
+
+	http.ServeContent(w, r, "", stats.ModTime(), f)
+}
+
+func (s *Server) serveDecompressed(w http.ResponseWriter, r *http.Request, f *os.File, encoding string) {
+	var dr io.ReadCloser
+	switch encoding {
+	case "gzip":
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			http.Error(w, "corrupt gzip file", http.StatusInternalServerError)
+			return
+		}
+		dr = gr
+	case "br":
+		dr = dec.NewBrotliReader(f)
+	default:
+		http.Error(w, "unsupported encoding", http.StatusInternalServerError)
+		return
+	}
+	defer dr.Close()
+
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, err := io.Copy(w, dr)
+	if err != nil {
+		s.params.Consumer.Debugf("While serving (%s): %v", r.URL.Path, err)
+	}
+}
+
+func openFile(fullPath string) (*os.File, os.FileInfo, error) {
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	stats, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, stats, nil
+}
+
+func encodingForExt(ext string) string {
+	switch ext {
+	case ".gz":
+		return "gzip"
+	case ".br":
+		return "br"
+	}
+	return ""
+}
+
+// splitEncoding turns "Build/game.wasm.br" into ("Build/game.wasm", "br")
+func splitEncoding(name string) (string, string) {
+	ext := path.Ext(name)
+	if encoding := encodingForExt(ext); encoding != "" {
+		return strings.TrimSuffix(name, ext), encoding
+	}
+	return name, ""
+}
+
+// Unity's .unityweb files may be gzip or brotli compressed, without
+// that showing in their name.
+func sniffUnityWebEncoding(f *os.File) string {
+	header := make([]byte, 64)
+	n, _ := f.ReadAt(header, 0)
+	header = header[:n]
+
+	if len(header) >= 2 && header[0] == 0x1f && header[1] == 0x8b {
+		return "gzip"
+	}
+	if bytes.Contains(header, []byte("UnityWeb Compressed Content (brotli)")) {
+		return "br"
+	}
+	return ""
+}
+
+func contentType(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if t, ok := mimeTypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(name) != encoding {
+			continue
+		}
+		return strings.ReplaceAll(params, " ", "") != "q=0"
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package gameserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/itchio/go-brotli/enc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startTestServer(t *testing.T, files map[string][]byte, crossOriginIsolated bool) *Server {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, contents := range files {
+		fullPath := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0o755))
+		require.NoError(t, os.WriteFile(fullPath, contents, 0o644))
+	}
+
+	s, err := Start(Params{
+		RootFolder:          root,
+		CrossOriginIsolated: crossOriginIsolated,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func get(t *testing.T, u string, headers map[string]string) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	require.NoError(t, err)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	// don't let the transport add or decode gzip on its own
+	transport := &http.Transport{DisableCompression: true}
+	res, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res, body
+}
+
+func gzipped(t *testing.T, contents []byte) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write(contents)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func TestServesWithTokenOnly(t *testing.T) {
+	s := startTestServer(t, map[string][]byte{
+		"index.html": []byte("<html></html>"),
+	}, false)
+
+	res, body := get(t, s.URL("index.html"), nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Equal(t, "<html></html>", string(body))
+	assert.Empty(t, res.Header.Get("Cross-Origin-Opener-Policy"))
+
+	withoutToken := strings.Replace(s.URL("index.html"), "/"+s.token+"/", "/", 1)
+	res, _ = get(t, withoutToken, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, _ = get(t, s.URL("../../etc/passwd"), nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res, body = get(t, s.URL(""), nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "<html></html>", string(body))
+}
+
+func TestMimeTypesAndHeaders(t *testing.T) {
+	s := startTestServer(t, map[string][]byte{
+		"game.wasm": []byte("\x00asm"),
+		"game.data": []byte("data"),
+	}, true)
+
+	res, _ := get(t, s.URL("game.wasm"), nil)
+	assert.Equal(t, "application/wasm", res.Header.Get("Content-Type"))
+	assert.Equal(t, "same-origin", res.Header.Get("Cross-Origin-Opener-Policy"))
+	assert.Equal(t, "require-corp", res.Header.Get("Cross-Origin-Embedder-Policy"))
+
+	res, _ = get(t, s.URL("game.data"), nil)
+	assert.Equal(t, "application/octet-stream", res.Header.Get("Content-Type"))
+}
+
+func brotlied(t *testing.T, contents []byte) []byte {
+	var buf bytes.Buffer
+	bw := enc.NewBrotliWriter(&buf, nil)
+	_, err := bw.Write(contents)
+	require.NoError(t, err)
+	require.NoError(t, bw.Close())
+	return buf.Bytes()
+}
+
+func TestPrecompressed(t *testing.T) {
+	wasm := []byte("\x00asm pretend this is a big module")
+	js := []byte("console.log('hi')")
+	s := startTestServer(t, map[string][]byte{
+		"Build/game.wasm.gz":       gzipped(t, wasm),
+		"Build/game.js.br":         brotlied(t, js),
+		"Build/game.data.unityweb": gzipped(t, []byte("unity data")),
+	}, false)
+
+	res, body := get(t, s.URL("Build/game.wasm.gz"), map[string]string{"Accept-Encoding": "gzip, deflate, br"})
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/wasm", res.Header.Get("Content-Type"))
+	assert.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+	assert.Equal(t, gzipped(t, wasm), body)
+
+	// asking for the uncompressed name finds the precompressed file
+	res, _ = get(t, s.URL("Build/game.wasm"), map[string]string{"Accept-Encoding": "gzip"})
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+
+	// clients that don't accept gzip get it decompressed
+	res, body = get(t, s.URL("Build/game.wasm"), nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Empty(t, res.Header.Get("Content-Encoding"))
+	assert.Equal(t, wasm, body)
+
+	res, body = get(t, s.URL("Build/game.js.br"), map[string]string{"Accept-Encoding": "gzip, br"})
+	assert.Equal(t, "text/javascript", res.Header.Get("Content-Type"))
+	assert.Equal(t, "br", res.Header.Get("Content-Encoding"))
+	assert.Equal(t, brotlied(t, js), body)
+
+	// browsers don't advertise brotli over plain http
+	res, body = get(t, s.URL("Build/game.js"), map[string]string{"Accept-Encoding": "gzip, deflate"})
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/javascript", res.Header.Get("Content-Type"))
+	assert.Empty(t, res.Header.Get("Content-Encoding"))
+	assert.Equal(t, js, body)
+
+	res, _ = get(t, s.URL("Build/game.data.unityweb"), map[string]string{"Accept-Encoding": "gzip"})
+	assert.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+}
+
+func TestRangeRequests(t *testing.T) {
+	s := startTestServer(t, map[string][]byte{
+		"game.pck": []byte("0123456789"),
+	}, false)
+
+	res, body := get(t, s.URL("game.pck"), map[string]string{"Range": "bytes=2-5"})
+	assert.Equal(t, http.StatusPartialContent, res.StatusCode)
+	assert.Equal(t, "2345", string(body))
+	assert.Equal(t, "bytes 2-5/10", res.Header.Get("Content-Range"))
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	req := func(accept string) *http.Request {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", accept)
+		return r
+	}
+
+	assert.True(t, acceptsEncoding(req("gzip, deflate, br"), "br"))
+	assert.True(t, acceptsEncoding(req("gzip;q=0.5"), "gzip"))
+	assert.False(t, acceptsEncoding(req("gzip;q=0"), "gzip"))
+	assert.False(t, acceptsEncoding(req("gzip"), "br"))
+	assert.False(t, acceptsEncoding(req(""), "gzip"))
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/endpoints/launch"
+	"github.com/itchio/butler/endpoints/launch/launchers/html/gameserver"
 	"github.com/pkg/errors"
 )
 
@@ -19,21 +20,39 @@ type Launcher struct{}
 var _ launch.Launcher = (*Launcher)(nil)
 
 func (l *Launcher) Do(params launch.LauncherParams) error {
+	consumer := params.RequestContext.Consumer
 	rootFolder := params.InstallFolder
 	indexPath, err := filepath.Rel(rootFolder, params.FullTargetPath)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	messages.LaunchRunning.Notify(params.RequestContext, butlerd.LaunchRunningNotification{})
-	params.SessionStarted()
-
-	_, err = messages.HTMLLaunch.Call(params.RequestContext, butlerd.HTMLLaunchParams{
+	htmlParams := butlerd.HTMLLaunchParams{
 		RootFolder: rootFolder,
 		IndexPath:  indexPath,
 		Args:       params.Args,
 		Env:        params.Env,
-	})
+	}
+
+	if params.HTMLServer != nil {
+		server, err := gameserver.Start(gameserver.Params{
+			RootFolder:          rootFolder,
+			CrossOriginIsolated: params.HTMLServer.CrossOriginIsolated,
+			Consumer:            consumer,
+		})
+		if err != nil {
+			return errors.WithMessage(err, "starting HTML game server")
+		}
+		defer server.Close()
+
+		htmlParams.URL = server.URL(indexPath)
+		consumer.Infof("Serving (%s) at (%s)", rootFolder, htmlParams.URL)
+	}
+
+	messages.LaunchRunning.Notify(params.RequestContext, butlerd.LaunchRunningNotification{})
+	params.SessionStarted()
+
+	_, err = messages.HTMLLaunch.Call(params.RequestContext, htmlParams)
 	messages.LaunchExited.Notify(params.RequestContext, butlerd.LaunchExitedNotification{})
 	if err != nil {
 		return errors.WithStack(err)
@@ -2,8 +2,11 @@ package native
 
 import (
 	"fmt"
+	"path/filepath"
 	"runtime"
 	"strings"
+
+	"github.com/itchio/butler/butlerd"
 )
 
 // buildEnvBlock merges explicit launch environment values into the host
@@ -32,6 +35,44 @@ func buildEnvBlock(hostEnv []string, overrides map[string]string) []string {
 	return result
 }
 
+// mergeProfileEnv applies a launch profile's environment variables on top
+// of envMap. A profile variable replaces any existing one with the same
+// name, as compared by the host OS.
+func mergeProfileEnv(envMap map[string]string, profile *butlerd.LaunchProfile) {
+	if profile == nil {
+		return
+	}
+	mergeEnv(envMap, profile.Env)
+}
+
+// mergeEnv sets vars on top of envMap, replacing existing variables
+// with the same name, as compared by the host OS.
+func mergeEnv(envMap map[string]string, vars map[string]string) {
+	for name, value := range vars {
+		for existing := range envMap {
+			if existing != name && normalizeEnvName(existing) == normalizeEnvName(name) {
+				delete(envMap, existing)
+			}
+		}
+		envMap[name] = value
+	}
+}
+
+// profileWorkingDirectory returns the working directory requested by a
+// launch profile, resolved against the install folder, or empty if it
+// doesn't request one.
+func profileWorkingDirectory(profile *butlerd.LaunchProfile, installFolder string) string {
+	if profile == nil || profile.WorkingDirectory == "" {
+		return ""
+	}
+
+	wd := profile.WorkingDirectory
+	if !filepath.IsAbs(wd) {
+		wd = filepath.Join(installFolder, wd)
+	}
+	return wd
+}
+
 func normalizeEnvName(name string) string {
 	if runtime.GOOS == "windows" {
 		return strings.ToUpper(name)
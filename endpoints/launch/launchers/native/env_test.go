@@ -1,9 +1,11 @@
 package native
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/itchio/butler/butlerd"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,3 +38,43 @@ func envValues(env []string, name string) []string {
 	}
 	return values
 }
+
+func TestMergeProfileEnv(t *testing.T) {
+	envMap := map[string]string{
+		"ITCHIO_APP": "1",
+		"TEMP":       "/tmp/game",
+	}
+
+	mergeProfileEnv(envMap, &butlerd.LaunchProfile{
+		Name: "Vulkan",
+		Env: map[string]string{
+			"TEMP":       "/tmp/vulkan",
+			"DXVK_HUD":   "fps",
+			"ITCHIO_APP": "1",
+		},
+	})
+
+	assert.Equal(t, map[string]string{
+		"ITCHIO_APP": "1",
+		"TEMP":       "/tmp/vulkan",
+		"DXVK_HUD":   "fps",
+	}, envMap)
+
+	mergeProfileEnv(envMap, nil)
+	assert.Len(t, envMap, 3)
+}
+
+func TestProfileWorkingDirectory(t *testing.T) {
+	installFolder := filepath.Join("games", "thing")
+
+	assert.Equal(t, "", profileWorkingDirectory(nil, installFolder))
+	assert.Equal(t, "", profileWorkingDirectory(&butlerd.LaunchProfile{Name: "default"}, installFolder))
+	assert.Equal(t,
+		filepath.Join(installFolder, "bin"),
+		profileWorkingDirectory(&butlerd.LaunchProfile{WorkingDirectory: "bin"}, installFolder),
+	)
+
+	abs, err := filepath.Abs("elsewhere")
+	assert.NoError(t, err)
+	assert.Equal(t, abs, profileWorkingDirectory(&butlerd.LaunchProfile{WorkingDirectory: abs}, installFolder))
+}
@@ -0,0 +1,88 @@
+package native
+
+import (
+	"runtime"
+
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/messages"
+	"github.com/itchio/butler/endpoints/launch"
+	"github.com/itchio/butler/manager/linuxdeps"
+	"github.com/itchio/dash"
+	"github.com/itchio/hush/bfs"
+	"github.com/pkg/errors"
+)
+
+// assessLinuxDependencies lets the user know which system libraries
+// a native Linux game is missing, instead of having it crash on start.
+func assessLinuxDependencies(params launch.LauncherParams) error {
+	if runtime.GOOS != "linux" || params.Host.Wrapper != nil {
+		return nil
+	}
+	if params.Candidate == nil || params.Candidate.Flavor != dash.FlavorNativeLinux {
+		return nil
+	}
+
+	consumer := params.RequestContext.Consumer
+
+	// the receipt saves walking the install folder on every launch
+	var installedFiles []string
+	receipt, err := bfs.ReadReceipt(params.InstallFolder)
+	if err != nil {
+		consumer.Debugf("Could not read receipt: %v", err)
+	}
+	if receipt != nil && len(receipt.Files) > 0 {
+		installedFiles = receipt.Files
+	}
+
+	report, err := linuxdeps.Assess(linuxdeps.Params{
+		InstallFolder:  params.InstallFolder,
+		InstalledFiles: installedFiles,
+		Target:         params.FullTargetPath,
+		Consumer:       consumer,
+	})
+	if err != nil {
+		consumer.Warnf("Could not assess shared library dependencies: %v", err)
+		return nil
+	}
+
+	if len(report.Missing) == 0 {
+		consumer.Infof("✓ All shared libraries found")
+		return nil
+	}
+
+	formatted := &butlerd.LinuxDependencyReport{
+		Arch:           report.Arch,
+		DistroID:       report.Distro.ID,
+		DistroName:     report.Distro.Name,
+		Missing:        []*butlerd.LinuxMissingLibrary{},
+		InstallCommand: report.InstallCommand,
+	}
+	consumer.Warnf("(%d) shared libraries are missing:", len(report.Missing))
+	for _, ml := range report.Missing {
+		consumer.Warnf("  %s (package %q), needed by %v", ml.Name, ml.Package, ml.NeededBy)
+		formatted.Missing = append(formatted.Missing, &butlerd.LinuxMissingLibrary{
+			Name:     ml.Name,
+			NeededBy: ml.NeededBy,
+			Package:  ml.Package,
+		})
+	}
+	if report.InstallCommand != "" {
+		consumer.Infof("They can be installed with: %s", report.InstallCommand)
+	}
+
+	r, err := messages.LinuxDependenciesMissing.Call(params.RequestContext, butlerd.LinuxDependenciesMissingParams{
+		Report: formatted,
+	})
+	if err != nil {
+		// older clients don't know about this, the game may still run
+		consumer.Warnf("Could not report missing libraries, launching anyway: %v", err)
+		return nil
+	}
+
+	if !r.Continue {
+		consumer.Warnf("Giving up because of missing libraries, as the user asked")
+		return errors.WithStack(butlerd.CodeOperationAborted)
+	}
+	consumer.Warnf("Continuing in spite of missing libraries, as the user asked")
+	return nil
+}
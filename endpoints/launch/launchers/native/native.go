@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"slices"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -54,6 +55,10 @@ func (l *Launcher) Do(params launch.LauncherParams) error {
 			cwd = params.InstallFolder
 		}
 	}
+	if wd := profileWorkingDirectory(params.LaunchProfile, installFolder); wd != "" {
+		consumer.Infof("Using working directory (%s) from launch profile", wd)
+		cwd = wd
+	}
 
 	_, err := os.Stat(params.FullTargetPath)
 	if err != nil {
@@ -103,6 +108,11 @@ func (l *Launcher) Do(params launch.LauncherParams) error {
 		}
 	}
 
+	err = assessLinuxDependencies(params)
+	if err != nil {
+		return err
+	}
+
 	envMap := make(map[string]string)
 	for k, v := range params.Env {
 		envMap[k] = v
@@ -124,12 +134,22 @@ func (l *Launcher) Do(params launch.LauncherParams) error {
 		envMap["ITCHIO_SANDBOX"] = "1"
 	}
 
+	if params.Host.Wrapper != nil {
+		// e.g. WINEPREFIX for compat hosts
+		mergeEnv(envMap, params.Host.Wrapper.Env)
+	}
+	mergeProfileEnv(envMap, params.LaunchProfile)
+
 	const maxLines = 40
-	stdout := newOutputCollector(maxLines)
-	stderr := newOutputCollector(maxLines)
+	const maxCrashOutputBytes = 16 * 1024
+	stdout := newOutputCollector(maxLines, maxCrashOutputBytes)
+	stderr := newOutputCollector(maxLines, maxCrashOutputBytes)
 
 	fullTargetPath := params.FullTargetPath
 	args := params.Args
+	if params.LaunchProfile != nil {
+		args = append(append([]string{}, args...), params.LaunchProfile.Args...)
+	}
 
 	if params.Host.Wrapper != nil {
 		wr := params.Host.Wrapper
@@ -188,6 +208,7 @@ func (l *Launcher) Do(params launch.LauncherParams) error {
 	for k := range envMap {
 		envKeys = append(envKeys, k)
 	}
+	sort.Strings(envKeys)
 	consumer.Infof("Environment variables passed: %s", strings.Join(envKeys, ", "))
 
 	// TODO: sanitize environment somewhat?
@@ -276,7 +297,7 @@ func (l *Launcher) Do(params launch.LauncherParams) error {
 		params.SessionStarted()
 
 		messages.LaunchRunning.Notify(params.RequestContext, butlerd.LaunchRunningNotification{})
-		exitCode, err := interpretRunError(run.Run())
+		exitCode, signal, err := interpretRunError(run.Run())
 		messages.LaunchExited.Notify(params.RequestContext, butlerd.LaunchExitedNotification{})
 		if err != nil {
 			return err
@@ -284,17 +305,37 @@ func (l *Launcher) Do(params launch.LauncherParams) error {
 
 		runDuration := time.Since(startTime)
 
-		if exitCode != 0 {
-			var signedExitCode = int64(exitCode)
-			if runtime.GOOS == "windows" {
-				// Windows uses 32-bit unsigned integers as exit codes, although the
-				// command interpreter treats them as signed. If a process fails
-				// initialization, a Windows system error code may be returned.
-				signedExitCode = int64(int32(signedExitCode))
-
-				// The line above turns `4294967295` into -1
+		var signedExitCode = int64(exitCode)
+		if runtime.GOOS == "windows" {
+			// Windows uses 32-bit unsigned integers as exit codes, although the
+			// command interpreter treats them as signed. If a process fails
+			// initialization, a Windows system error code may be returned.
+			signedExitCode = int64(int32(signedExitCode))
+
+			// The line above turns `4294967295` into -1
+		}
+
+		if exitCode != 0 && params.Ctx.Err() == nil && params.RecordCrash != nil {
+			report := &launch.CrashReport{
+				ExitCode:    signedExitCode,
+				Signal:      signal,
+				RunDuration: runDuration,
+
+				Stdout: stdout.Tail(),
+				Stderr: stderr.Tail(),
+
+				TargetPath:       fullTargetPath,
+				Args:             args,
+				WorkingDirectory: cwd,
+				EnvKeys:          envKeys,
 			}
+			if params.Sandbox {
+				report.SandboxType = string(sandboxConfig.Type)
+			}
+			params.RecordCrash(report)
+		}
 
+		if exitCode != 0 {
 			exeName := filepath.Base(params.FullTargetPath)
 			msg := fmt.Sprintf("Exit code 0x%x (%d) for (%s)", uint32(exitCode), signedExitCode, exeName)
 			consumer.Warnf("%s", msg)
@@ -478,18 +519,24 @@ func fillPeInfoIfNeeded(params launch.LauncherParams) error {
 	return nil
 }
 
-func interpretRunError(err error) (int, error) {
+// interpretRunError returns the exit code of the game and, if it was
+// killed by a signal, the signal's name.
+func interpretRunError(err error) (int, string, error) {
 	if err != nil {
 		if exitError, ok := AsExitError(err); ok {
 			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				return status.ExitStatus(), nil
+				var signal string
+				if status.Signaled() {
+					signal = status.Signal().String()
+				}
+				return status.ExitStatus(), signal, nil
 			}
 		}
 
-		return 127, err
+		return 127, "", err
 	}
 
-	return 0, nil
+	return 0, "", nil
 }
 
 type causer interface {
@@ -3,41 +3,83 @@ package native
 import (
 	"bufio"
 	"io"
+	"strings"
+	"sync"
 )
 
 type outputCollector struct {
-	lines  []string
+	mu       sync.Mutex
+	lines    []string
+	maxLines int
+
+	// raw tail of the output, for crash reports
+	tail      []byte
+	maxBytes  int
+	truncated bool
+
 	writer io.Writer
 }
 
 var _ io.Writer = (*outputCollector)(nil)
 
-func newOutputCollector(maxLines int) *outputCollector {
+func newOutputCollector(maxLines int, maxBytes int) *outputCollector {
 	pipeR, pipeW := io.Pipe()
 
 	oc := &outputCollector{
-		writer: pipeW,
+		maxLines: maxLines,
+		maxBytes: maxBytes,
+		writer:   pipeW,
 	}
 
 	go func() {
 		s := bufio.NewScanner(pipeR)
 		for s.Scan() {
-			line := s.Text()
-			oc.lines = append(oc.lines, line)
-
-			if len(oc.lines) > maxLines {
-				oc.lines = oc.lines[1:]
-			}
+			oc.addLine(s.Text())
 		}
 	}()
 
 	return oc
 }
 
+func (oc *outputCollector) addLine(line string) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	oc.lines = append(oc.lines, line)
+	if len(oc.lines) > oc.maxLines {
+		oc.lines = oc.lines[1:]
+	}
+}
+
 func (oc *outputCollector) Lines() []string {
-	return oc.lines
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	return append([]string(nil), oc.lines...)
+}
+
+// Tail returns the last maxBytes of output, starting at a line
+// boundary when possible.
+func (oc *outputCollector) Tail() string {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	tail := string(oc.tail)
+	if oc.truncated {
+		if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+			tail = tail[i+1:]
+		}
+	}
+	return tail
 }
 
 func (oc *outputCollector) Write(p []byte) (int, error) {
+	oc.mu.Lock()
+	oc.tail = append(oc.tail, p...)
+	if len(oc.tail) > oc.maxBytes {
+		oc.tail = append(oc.tail[:0], oc.tail[len(oc.tail)-oc.maxBytes:]...)
+		oc.truncated = true
+	}
+	oc.mu.Unlock()
+
 	return oc.writer.Write(p)
 }
@@ -0,0 +1,27 @@
+package native
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutputCollectorTail(t *testing.T) {
+	oc := newOutputCollector(40, 16)
+
+	oc.Write([]byte("short\n"))
+	if tail := oc.Tail(); tail != "short\n" {
+		t.Fatalf("expected untruncated tail, got %q", tail)
+	}
+
+	oc.Write([]byte("first line\nsecond line\n"))
+	tail := oc.Tail()
+	if tail != "second line\n" {
+		t.Fatalf("expected tail to start at a line boundary, got %q", tail)
+	}
+
+	oc.Write([]byte(strings.Repeat("x", 40)))
+	tail = oc.Tail()
+	if tail != strings.Repeat("x", 16) {
+		t.Fatalf("expected last 16 bytes without line boundary, got %q", tail)
+	}
+}
@@ -22,6 +22,7 @@ func handlePrereqs(params launch.LauncherParams) error {
 		Consumer:       params.RequestContext.Consumer,
 		PrereqsDir:     params.PrereqsDir,
 		Force:          params.ForcePrereqs,
+		Mirror:         params.RequestContext.Config.PrereqsMirror,
 	})
 	if err != nil {
 		return err
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/itchio/butler/butlerd/horror"
+	"github.com/itchio/butler/database/models"
 	itchio "github.com/itchio/go-itchio"
 	"github.com/itchio/headway/state"
 	"github.com/pkg/errors"
@@ -15,7 +16,7 @@ type sessionClient interface {
 	UpdateUserGameSession(ctx context.Context, p itchio.UpdateUserGameSessionParams) (*itchio.UpdateUserGameSessionResponse, error)
 }
 
-const defaultSessionUpdateInterval = 1 * time.Minute
+const defaultSessionUpdateInterval = models.PlaySessionHeartbeatInterval
 
 // Leave enough time for the API client's retry backoff (1+2+4+8+16s).
 const finalSessionUpdateTimeout = 35 * time.Second
@@ -35,6 +36,10 @@ type sessionTracker struct {
 
 	persistSummary func(summary *itchio.UserGameInteractionsSummary)
 
+	// Records the session locally so that it can be synced later
+	// if itch.io can't be reached while playing. May be nil.
+	journal sessionJournal
+
 	updateInterval     time.Duration
 	finalUpdateTimeout time.Duration
 }
@@ -61,6 +66,10 @@ type sessionEnd struct {
 }
 
 func (st *sessionTracker) run(ctx context.Context, started <-chan time.Time, ended <-chan sessionEnd) {
+	if st.journal == nil {
+		st.journal = nopSessionJournal{}
+	}
+
 	var startedAt time.Time
 	var end sessionEnd
 	gameRunning := false
@@ -81,10 +90,12 @@ func (st *sessionTracker) run(ctx context.Context, started <-chan time.Time, end
 		gameRunning = true
 	}
 
+	st.journal.started(startedAt)
+
 	sessionID, err := st.createSession(ctx, startedAt)
 	if err != nil {
 		st.consumer.Warnf("Initial session creation: %+v", err)
-		return
+		st.consumer.Infof("Session will be recorded locally and synced later")
 	}
 
 	if gameRunning {
@@ -98,7 +109,12 @@ func (st *sessionTracker) run(ctx context.Context, started <-chan time.Time, end
 				st.consumer.Debugf("Launch cancelled while running, skipping final update")
 				return
 			case <-ticker.C:
-				if err := st.updateSession(ctx, sessionID, startedAt, time.Now(), false); err != nil {
+				now := time.Now()
+				st.journal.heartbeat(now, secondsBetween(startedAt, now))
+				if sessionID == 0 {
+					continue
+				}
+				if err := st.updateSession(ctx, sessionID, startedAt, now, false); err != nil {
 					st.consumer.Warnf("Regular session update: %+v", err)
 				}
 			case end = <-ended:
@@ -108,6 +124,11 @@ func (st *sessionTracker) run(ctx context.Context, started <-chan time.Time, end
 		}
 	}
 
+	st.journal.ended(end.at, secondsBetween(startedAt, end.at), end.crashed)
+	if sessionID == 0 {
+		return
+	}
+
 	// Request teardown should not cancel the final update.
 	finalCtx, cancel := context.WithTimeout(context.Background(), st.finalTimeout())
 	defer cancel()
@@ -115,6 +136,7 @@ func (st *sessionTracker) run(ctx context.Context, started <-chan time.Time, end
 		st.consumer.Warnf("Final session update: %+v", err)
 		return
 	}
+	st.journal.synced()
 
 	st.consumer.Debugf("Entire session committed successfully!")
 }
@@ -142,13 +164,14 @@ func (st *sessionTracker) createSession(ctx context.Context, startedAt time.Time
 	}
 
 	st.persist(res.Summary)
+	st.journal.remoteCreated(res.UserGameSession.ID)
 	return res.UserGameSession.ID, nil
 }
 
 func (st *sessionTracker) updateSession(ctx context.Context, sessionID int64, startedAt, at time.Time, crashed bool) (retErr error) {
 	defer horror.RecoverInto(&retErr)
 
-	secondsRun := int64(at.Sub(startedAt).Seconds())
+	secondsRun := secondsBetween(startedAt, at)
 	lastRunAt := at.UTC()
 
 	res, err := st.client.UpdateUserGameSession(ctx, itchio.UpdateUserGameSessionParams{
@@ -163,9 +186,14 @@ func (st *sessionTracker) updateSession(ctx context.Context, sessionID int64, st
 	}
 
 	st.persist(res.Summary)
+	st.journal.acknowledged(secondsRun)
 	return nil
 }
 
+func secondsBetween(startedAt, at time.Time) int64 {
+	return int64(at.Sub(startedAt).Seconds())
+}
+
 func (st *sessionTracker) persist(summary *itchio.UserGameInteractionsSummary) {
 	if summary == nil || st.persistSummary == nil {
 		return
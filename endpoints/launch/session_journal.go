@@ -0,0 +1,107 @@
+package launch
+
+import (
+	"time"
+
+	"crawshaw.io/sqlite"
+	"github.com/google/uuid"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/headway/state"
+)
+
+// sessionJournal durably records what happens during a session, so
+// that playtime isn't lost when itch.io can't be reached.
+type sessionJournal interface {
+	started(at time.Time)
+	heartbeat(at time.Time, secondsRun int64)
+	ended(at time.Time, secondsRun int64, crashed bool)
+
+	// the session was created on itch.io
+	remoteCreated(sessionID int64)
+	// itch.io acknowledged that many seconds run
+	acknowledged(secondsRun int64)
+	// itch.io acknowledged the end of the session
+	synced()
+}
+
+type nopSessionJournal struct{}
+
+var _ sessionJournal = nopSessionJournal{}
+
+func (nopSessionJournal) started(at time.Time)                               {}
+func (nopSessionJournal) heartbeat(at time.Time, secondsRun int64)           {}
+func (nopSessionJournal) ended(at time.Time, secondsRun int64, crashed bool) {}
+func (nopSessionJournal) remoteCreated(sessionID int64)                      {}
+func (nopSessionJournal) acknowledged(secondsRun int64)                      {}
+func (nopSessionJournal) synced()                                            {}
+
+// dbSessionJournal keeps a models.PlaySession up to date. Its methods are
+// only ever called from the session tracker's goroutine.
+type dbSessionJournal struct {
+	consumer *state.Consumer
+	withConn func(f func(conn *sqlite.Conn))
+	session  *models.PlaySession
+}
+
+var _ sessionJournal = (*dbSessionJournal)(nil)
+
+func newDBSessionJournal(rc *butlerd.RequestContext, session *models.PlaySession) *dbSessionJournal {
+	session.ID = uuid.New().String()
+	return &dbSessionJournal{
+		consumer: rc.Consumer,
+		withConn: rc.WithConn,
+		session:  session,
+	}
+}
+
+func (j *dbSessionJournal) started(at time.Time) {
+	at = at.UTC()
+	j.session.StartedAt = &at
+	j.session.LastHeartbeatAt = &at
+	j.save()
+}
+
+func (j *dbSessionJournal) heartbeat(at time.Time, secondsRun int64) {
+	at = at.UTC()
+	j.session.LastHeartbeatAt = &at
+	j.session.SecondsRun = secondsRun
+	j.save()
+}
+
+func (j *dbSessionJournal) ended(at time.Time, secondsRun int64, crashed bool) {
+	at = at.UTC()
+	j.session.LastHeartbeatAt = &at
+	j.session.EndedAt = &at
+	j.session.SecondsRun = secondsRun
+	j.session.Crashed = crashed
+	j.save()
+}
+
+func (j *dbSessionJournal) remoteCreated(sessionID int64) {
+	j.session.RemoteSessionID = sessionID
+	j.save()
+}
+
+func (j *dbSessionJournal) acknowledged(secondsRun int64) {
+	j.session.SyncedSecondsRun = secondsRun
+	j.save()
+}
+
+func (j *dbSessionJournal) synced() {
+	now := time.Now().UTC()
+	j.session.SyncedAt = &now
+	j.save()
+}
+
+func (j *dbSessionJournal) save() {
+	// the database may be busy, but that shouldn't take the
+	// session tracker down with it.
+	defer func() {
+		if r := recover(); r != nil {
+			j.consumer.Warnf("Could not write session journal: %v", r)
+		}
+	}()
+
+	j.withConn(j.session.Save)
+}
@@ -298,3 +298,101 @@ func TestSessionPeriodicUpdate(t *testing.T) {
 		t.Fatalf("final update should report crashed=false")
 	}
 }
+
+type fakeSessionJournal struct {
+	mu sync.Mutex
+
+	startedCalls int
+	endedCalls   int
+	remoteID     int64
+	secondsRun   int64
+	crashed      bool
+	wasSynced    bool
+}
+
+func (j *fakeSessionJournal) started(at time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.startedCalls++
+}
+
+func (j *fakeSessionJournal) heartbeat(at time.Time, secondsRun int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.secondsRun = secondsRun
+}
+
+func (j *fakeSessionJournal) ended(at time.Time, secondsRun int64, crashed bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.endedCalls++
+	j.secondsRun = secondsRun
+	j.crashed = crashed
+}
+
+func (j *fakeSessionJournal) remoteCreated(sessionID int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.remoteID = sessionID
+}
+
+func (j *fakeSessionJournal) acknowledged(secondsRun int64) {}
+
+func (j *fakeSessionJournal) synced() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.wasSynced = true
+}
+
+func TestSessionJournalOnCreateFailure(t *testing.T) {
+	client := &fakeSessionClient{createErr: errors.New("offline")}
+	journal := &fakeSessionJournal{}
+	st := newTestTracker(client)
+	st.journal = journal
+
+	started, ended := newChans()
+	done := runTracker(st, context.Background(), started, ended)
+
+	startedAt := time.Now()
+	started <- startedAt
+	ended <- sessionEnd{at: startedAt.Add(90 * time.Second), crashed: true}
+	waitDone(t, done)
+
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	if journal.startedCalls != 1 || journal.endedCalls != 1 {
+		t.Fatalf("expected session start and end to be journaled, got %d starts and %d ends", journal.startedCalls, journal.endedCalls)
+	}
+	if journal.secondsRun != 90 {
+		t.Fatalf("expected 90 seconds run to be journaled, got %d", journal.secondsRun)
+	}
+	if !journal.crashed {
+		t.Fatalf("expected crash to be journaled")
+	}
+	if journal.remoteID != 0 || journal.wasSynced {
+		t.Fatalf("session should be left for the sync task")
+	}
+}
+
+func TestSessionJournalOnCleanExit(t *testing.T) {
+	client := &fakeSessionClient{}
+	journal := &fakeSessionJournal{}
+	st := newTestTracker(client)
+	st.journal = journal
+
+	started, ended := newChans()
+	done := runTracker(st, context.Background(), started, ended)
+
+	started <- time.Now()
+	ended <- sessionEnd{at: time.Now(), crashed: false}
+	waitDone(t, done)
+
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	if journal.remoteID != 1 {
+		t.Fatalf("expected remote session to be journaled, got %d", journal.remoteID)
+	}
+	if !journal.wasSynced {
+		t.Fatalf("expected session to be marked as synced")
+	}
+}
@@ -37,16 +37,27 @@ func GetTargets(rc *butlerd.RequestContext, params butlerd.LaunchGetTargetsParam
 	}, nil
 }
 
-// settingsLaunchTarget returns the launch target persisted in the cave's
-// settings, or empty if unset or unreadable.
-func settingsLaunchTarget(rc *butlerd.RequestContext, cave *models.Cave) string {
+// caveSettings returns the cave's persisted settings, or empty settings
+// if unset or unreadable.
+func caveSettings(rc *butlerd.RequestContext, cave *models.Cave) *butlerd.CaveSettings {
 	var settings butlerd.CaveSettings
 	err := models.UnmarshalJSONAllowEmpty(cave.Settings, &settings, "cave settings")
 	if err != nil {
 		rc.Consumer.Warnf("Could not parse cave settings: %v", err)
-		return ""
+		return &butlerd.CaveSettings{}
 	}
-	return settings.LaunchTarget
+	return &settings
+}
+
+// findLaunchProfile returns the launch profile with the given name,
+// or nil if there's none.
+func findLaunchProfile(settings *butlerd.CaveSettings, name string) *butlerd.LaunchProfile {
+	for _, profile := range settings.LaunchProfiles {
+		if profile != nil && profile.Name == name {
+			return profile
+		}
+	}
+	return nil
 }
 
 // findTarget matches a preferred target against action names first,
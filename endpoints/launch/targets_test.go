@@ -5,8 +5,10 @@ import (
 
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/manager"
 	"github.com/itchio/headway/state"
 	"github.com/itchio/hush/manifest"
+	"github.com/itchio/ox"
 )
 
 func makeTarget(name string, path string) *butlerd.LaunchTarget {
@@ -62,16 +64,66 @@ func TestSettingsLaunchTarget(t *testing.T) {
 	cave := &models.Cave{
 		Settings: models.JSON(`{"launchTarget":"index.html"}`),
 	}
-	if got := settingsLaunchTarget(rc, cave); got != "index.html" {
+	if got := caveSettings(rc, cave).LaunchTarget; got != "index.html" {
 		t.Errorf("expected cave settings launch target, got %q", got)
 	}
 
-	if got := settingsLaunchTarget(rc, &models.Cave{}); got != "" {
+	if got := caveSettings(rc, &models.Cave{}).LaunchTarget; got != "" {
 		t.Errorf("expected no preference for empty settings, got %q", got)
 	}
 
 	broken := &models.Cave{Settings: models.JSON(`{nope`)}
-	if got := settingsLaunchTarget(rc, broken); got != "" {
+	if got := caveSettings(rc, broken).LaunchTarget; got != "" {
 		t.Errorf("expected no preference for broken settings, got %q", got)
 	}
 }
+
+func TestFindLaunchProfile(t *testing.T) {
+	t.Parallel()
+
+	rc := &butlerd.RequestContext{Consumer: &state.Consumer{}}
+
+	cave := &models.Cave{
+		Settings: models.JSON(`{"launchProfiles":[{"name":"Vulkan","args":["-vulkan"]},{"name":"benchmark","target":"bench.exe"}]}`),
+	}
+	settings := caveSettings(rc, cave)
+
+	profile := findLaunchProfile(settings, "benchmark")
+	if profile == nil || profile.Target != "bench.exe" {
+		t.Errorf("expected benchmark profile, got %v", profile)
+	}
+
+	if got := findLaunchProfile(settings, "vulkan"); got != nil {
+		t.Errorf("expected profile names to be case-sensitive, got %v", got)
+	}
+
+	if got := findLaunchProfile(caveSettings(rc, &models.Cave{}), "Vulkan"); got != nil {
+		t.Errorf("expected no profile for empty settings, got %v", got)
+	}
+}
+
+func TestSelectCaveHost(t *testing.T) {
+	rc := &butlerd.RequestContext{Consumer: &state.Consumer{}}
+
+	native := manager.Host{Runtime: ox.Runtime{Platform: ox.PlatformLinux, Is64: true}}
+	windows := ox.Runtime{Platform: ox.PlatformWindows}
+	proton := manager.Host{Runtime: windows, ID: "proton", Wrapper: &manager.Wrapper{WrapperBinary: "/bin/proton"}}
+	prefix := manager.Host{Runtime: windows, ID: "prefix", Wrapper: &manager.Wrapper{WrapperBinary: "/bin/wine"}}
+	wine := manager.Host{Runtime: windows, Wrapper: &manager.Wrapper{WrapperBinary: "/bin/wine"}}
+	hosts := manager.Hosts{native, proton, prefix, wine}
+
+	got := selectCaveHost(rc, &butlerd.CaveSettings{}, hosts)
+	if len(got) != 4 {
+		t.Fatalf("expected all hosts without a host setting, got %v", got)
+	}
+
+	got = selectCaveHost(rc, &butlerd.CaveSettings{HostID: "prefix"}, hosts)
+	if len(got) != 2 || got[0].ID != "" || got[1].ID != "prefix" {
+		t.Fatalf("expected native and selected host, got %v", got)
+	}
+
+	got = selectCaveHost(rc, &butlerd.CaveSettings{HostID: "removed"}, hosts)
+	if len(got) != 4 {
+		t.Fatalf("expected all hosts for a missing host, got %v", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/cmd/operate"
@@ -56,6 +57,14 @@ type LauncherParams struct {
 	// User-provided command template applied after runtime and host wrappers.
 	CommandTemplate string
 
+	// Launch profile selected for this launch, may be nil. Its target and
+	// sandbox overrides are already applied, launchers that support it
+	// merge its environment, arguments and working directory.
+	LaunchProfile *butlerd.LaunchProfile
+
+	// If non-nil, the HTML launcher serves the install folder itself
+	HTMLServer *butlerd.HTMLServerOptions
+
 	PrereqsDir    string
 	ForcePrereqs  bool
 	Access        *operate.GameAccess
@@ -63,6 +72,28 @@ type LauncherParams struct {
 	Host          manager.Host
 
 	SessionStarted func()
+
+	// Called by launchers that can tell when the game exited abnormally
+	RecordCrash func(report *CrashReport)
+}
+
+// CrashReport is what a launcher knows about an abnormal exit. The
+// launch endpoint completes it with cave and environment information.
+type CrashReport struct {
+	ExitCode int64
+	// Name of the signal that terminated the game, if any
+	Signal      string
+	RunDuration time.Duration
+
+	Stdout string
+	Stderr string
+
+	TargetPath       string
+	Args             []string
+	WorkingDirectory string
+	SandboxType      string
+	// Names of the environment variables passed to the game
+	EnvKeys []string
 }
 
 // cf. https://github.com/itchio/itch/issues/1751
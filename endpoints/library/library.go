@@ -0,0 +1,75 @@
+package library
+
+import (
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/messages"
+	"github.com/itchio/butler/cmd/library"
+	"github.com/pkg/errors"
+)
+
+func Register(router *butlerd.Router) {
+	messages.LibraryExport.Register(router, LibraryExport)
+	messages.LibraryImport.Register(router, LibraryImport)
+}
+
+func LibraryExport(rc *butlerd.RequestContext, params butlerd.LibraryExportParams) (*butlerd.LibraryExportResult, error) {
+	conn := rc.GetConn()
+	defer rc.PutConn(conn)
+
+	a, err := library.Export(conn, library.ExportParams{
+		Consumer:           rc.Consumer,
+		File:               params.Path,
+		IncludeCredentials: params.IncludeCredentials,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := &butlerd.LibraryExportResult{
+		NumCaves:            int64(len(a.Caves)),
+		NumInstallLocations: int64(len(a.InstallLocations)),
+		NumDownloads:        int64(len(a.Downloads)),
+	}
+	return res, nil
+}
+
+func LibraryImport(rc *butlerd.RequestContext, params butlerd.LibraryImportParams) (*butlerd.LibraryImportResult, error) {
+	a, err := library.ReadArchive(params.Path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var remaps []library.PathRemap
+	for _, r := range params.PathRemaps {
+		remaps = append(remaps, library.PathRemap{From: r.From, To: r.To})
+	}
+
+	conn := rc.GetConn()
+	defer rc.PutConn(conn)
+
+	ir, err := library.Import(conn, library.ImportParams{
+		Consumer: rc.Consumer,
+		Archive:  a,
+		Remaps:   remaps,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := &butlerd.LibraryImportResult{
+		ImportedInstallLocations: ir.ImportedInstallLocations,
+		ImportedProfiles:         ir.ImportedProfiles,
+		ImportedCaves:            ir.ImportedCaves,
+		MergedCaves:              ir.MergedCaves,
+		ImportedDownloads:        ir.ImportedDownloads,
+		Skipped:                  []*butlerd.LibraryImportSkip{},
+	}
+	for _, skip := range ir.Skipped {
+		res.Skipped = append(res.Skipped, &butlerd.LibraryImportSkip{
+			Kind:   skip.Kind,
+			ID:     skip.ID,
+			Reason: skip.Reason,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,65 @@
+package profile
+
+import (
+	"sort"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/database/models"
+)
+
+func GetPlaytimeStats(rc *butlerd.RequestContext, params butlerd.ProfileGetPlaytimeStatsParams) (*butlerd.ProfileGetPlaytimeStatsResult, error) {
+	// will panic if invalid profile or missing param
+	rc.ProfileClient(params.ProfileID)
+
+	res := &butlerd.ProfileGetPlaytimeStatsResult{
+		Games: []*butlerd.PlaytimeStats{},
+	}
+
+	rc.WithConn(func(conn *sqlite.Conn) {
+		byGame := make(map[int64]*butlerd.PlaytimeStats)
+		statsFor := func(gameID int64) *butlerd.PlaytimeStats {
+			stats, ok := byGame[gameID]
+			if !ok {
+				stats = &butlerd.PlaytimeStats{GameID: gameID}
+				byGame[gameID] = stats
+				res.Games = append(res.Games, stats)
+			}
+			return stats
+		}
+
+		// what itch.io last told us
+		for _, ugi := range models.UserGameInteractionsByUser(conn, params.ProfileID) {
+			stats := statsFor(ugi.GameID)
+			stats.SecondsRun = ugi.SecondsRun
+			stats.LastRunAt = ugi.LastRunAt
+		}
+
+		// what it doesn't know about yet
+		for _, lp := range models.LocalPlaytimeByGame(conn, params.ProfileID) {
+			stats := statsFor(lp.GameID)
+			stats.SecondsRun += lp.UnsyncedSecondsRun
+			stats.LocalSecondsRun = lp.SecondsRun
+			stats.UnsyncedSecondsRun = lp.UnsyncedSecondsRun
+			stats.NumSessions = lp.NumSessions
+			stats.NumCrashes = lp.NumCrashes
+			if lp.LastRunAt != nil && (stats.LastRunAt == nil || lp.LastRunAt.After(*stats.LastRunAt)) {
+				stats.LastRunAt = lp.LastRunAt
+			}
+			if stats.SecondsRun < stats.LocalSecondsRun {
+				// local sessions predate the profile, or itch.io
+				// hasn't been reachable yet
+				stats.SecondsRun = stats.LocalSecondsRun
+			}
+		}
+
+		for _, stats := range res.Games {
+			stats.Game = models.GameByID(conn, stats.GameID)
+		}
+	})
+
+	sort.SliceStable(res.Games, func(i, j int) bool {
+		return res.Games[i].SecondsRun > res.Games[j].SecondsRun
+	})
+	return res, nil
+}
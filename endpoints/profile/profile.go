@@ -5,6 +5,7 @@ import (
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/butlerd/messages"
 	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/endpoints/tasks"
 	"github.com/itchio/go-itchio"
 	"github.com/itchio/hades"
 	"github.com/itchio/httpkit/neterr"
@@ -21,6 +22,9 @@ func Register(router *butlerd.Router) {
 	messages.ProfileForget.Register(router, Forget)
 	messages.ProfileDataPut.Register(router, DataPut)
 	messages.ProfileDataGet.Register(router, DataGet)
+	messages.ProfileUploadRulesGet.Register(router, UploadRulesGet)
+	messages.ProfileUploadRulesSet.Register(router, UploadRulesSet)
+	messages.ProfileGetPlaytimeStats.Register(router, GetPlaytimeStats)
 }
 
 func List(rc *butlerd.RequestContext, params butlerd.ProfileListParams) (*butlerd.ProfileListResult, error) {
@@ -244,6 +248,8 @@ func UseSavedLogin(rc *butlerd.RequestContext, params butlerd.ProfileUseSavedLog
 		}
 	} else {
 		consumer.Opf("Logged in! (online)")
+		// sessions played while offline can be sent now
+		rc.QueueBackgroundTask(tasks.SyncPlaySessions())
 	}
 
 	res := &butlerd.ProfileUseSavedLoginResult{
@@ -0,0 +1,59 @@
+package profile
+
+import (
+	"encoding/json"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/database/models"
+	"github.com/itchio/butler/manager"
+	"github.com/pkg/errors"
+	"xorm.io/builder"
+)
+
+func UploadRulesGet(rc *butlerd.RequestContext, params butlerd.ProfileUploadRulesGetParams) (*butlerd.ProfileUploadRulesGetResult, error) {
+	// will panic if invalid profile or missing param
+	rc.ProfileClient(params.ProfileID)
+
+	rules := rc.UploadRules(params.ProfileID)
+	if rules == nil {
+		rules = []*manager.UploadRule{}
+	}
+
+	res := &butlerd.ProfileUploadRulesGetResult{
+		Rules: rules,
+	}
+	return res, nil
+}
+
+func UploadRulesSet(rc *butlerd.RequestContext, params butlerd.ProfileUploadRulesSetParams) (*butlerd.ProfileUploadRulesSetResult, error) {
+	// will panic if invalid profile or missing param
+	rc.ProfileClient(params.ProfileID)
+
+	if len(params.Rules) == 0 {
+		rc.WithConn(func(conn *sqlite.Conn) {
+			models.MustDelete(conn, &models.ProfileData{}, builder.Eq{
+				"profile_id": params.ProfileID,
+				"key":        butlerd.UploadRulesKey,
+			})
+		})
+		return &butlerd.ProfileUploadRulesSetResult{}, nil
+	}
+
+	value, err := json.Marshal(params.Rules)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	pd := &models.ProfileData{
+		ProfileID: params.ProfileID,
+		Key:       butlerd.UploadRulesKey,
+		Value:     string(value),
+	}
+	rc.WithConn(func(conn *sqlite.Conn) {
+		models.MustSave(conn, pd)
+	})
+
+	res := &butlerd.ProfileUploadRulesSetResult{}
+	return res, nil
+}
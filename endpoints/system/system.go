@@ -3,6 +3,7 @@ package system
 import (
 	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/butler/butlerd/messages"
+	"github.com/itchio/butler/statfs"
 	"github.com/itchio/headway/united"
 	"github.com/pkg/errors"
 )
@@ -16,10 +17,14 @@ func StatFSHandler(rc *butlerd.RequestContext, params butlerd.SystemStatFSParams
 		return nil, errors.Errorf("path must be set")
 	}
 
-	res, err := StatFS(params.Path)
+	stats, err := statfs.Do(params.Path)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
+	res := &butlerd.SystemStatFSResult{
+		FreeSize:  stats.FreeSize,
+		TotalSize: stats.TotalSize,
+	}
 
 	consumer := rc.Consumer
 	consumer.Statf("(%s): %s free out of %s total",
@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/itchio/butler/butlerd"
@@ -9,9 +10,26 @@ import (
 	itchio "github.com/itchio/go-itchio"
 )
 
+const fetchUserGameSessionsKind = "fetch-user-game-sessions"
+
+type fetchUserGameSessionsPayload struct {
+	GameID int64 `json:"gameId"`
+}
+
+func rebuildFetchUserGameSessions(payload []byte) (butlerd.BackgroundTask, error) {
+	var p fetchUserGameSessionsPayload
+	err := json.Unmarshal(payload, &p)
+	if err != nil {
+		return butlerd.BackgroundTask{}, err
+	}
+	return FetchUserGameSessions(p.GameID), nil
+}
+
 func FetchUserGameSessions(gameID int64) butlerd.BackgroundTask {
 	return butlerd.BackgroundTask{
-		Desc: fmt.Sprintf("fetch user game sessions for game %d", gameID),
+		Desc:    fmt.Sprintf("fetch user game sessions for game %d", gameID),
+		Kind:    fetchUserGameSessionsKind,
+		Payload: fetchUserGameSessionsPayload{GameID: gameID},
 		Do: func(rc *butlerd.RequestContext) error {
 			consumer := rc.Consumer
 			conn := rc.GetConn()
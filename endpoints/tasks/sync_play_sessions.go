@@ -0,0 +1,166 @@
+package tasks
+
+import (
+	"time"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/horror"
+	"github.com/itchio/butler/cmd/operate"
+	"github.com/itchio/butler/database/models"
+	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/httpkit/neterr"
+	"github.com/pkg/errors"
+)
+
+const syncPlaySessionsKind = "sync-play-sessions"
+
+// Sessions that haven't had a heartbeat in that long are assumed to
+// have been left open by a butler instance that went away. A few missed
+// heartbeats are tolerated, as journal writes are skipped when the
+// database is busy, and timers fire late after the machine wakes up.
+const stalePlaySessionThreshold = 5 * models.PlaySessionHeartbeatInterval
+
+type syncPlaySessionsPayload struct{}
+
+func rebuildSyncPlaySessions(payload []byte) (butlerd.BackgroundTask, error) {
+	return SyncPlaySessions(), nil
+}
+
+// SyncPlaySessions reconciles the local play session journal with
+// itch.io, creating or updating remote sessions as needed.
+func SyncPlaySessions() butlerd.BackgroundTask {
+	return butlerd.BackgroundTask{
+		Desc:    "sync play sessions",
+		Kind:    syncPlaySessionsKind,
+		Payload: syncPlaySessionsPayload{},
+		Do: func(rc *butlerd.RequestContext) error {
+			consumer := rc.Consumer
+			conn := rc.GetConn()
+			defer rc.PutConn(conn)
+
+			closed := models.CloseStalePlaySessions(conn, time.Now().UTC().Add(-stalePlaySessionThreshold))
+			if closed > 0 {
+				consumer.Infof("Closed %d play sessions left open", closed)
+			}
+
+			var pending []*models.PlaySession
+			for _, ps := range models.UnsyncedPlaySessions(conn) {
+				if ps.EndedAt != nil {
+					pending = append(pending, ps)
+				}
+			}
+			if len(pending) == 0 {
+				return nil
+			}
+			consumer.Infof("%d play sessions pending sync", len(pending))
+
+			var numFailed int
+			for _, ps := range pending {
+				err := syncPlaySession(rc, conn, ps)
+				if err != nil {
+					if neterr.IsNetworkError(err) {
+						return err
+					}
+					consumer.Warnf("Could not sync play session (%s): %+v", ps.ID, err)
+					numFailed++
+				}
+			}
+
+			if numFailed > 0 {
+				return errors.Errorf("%d play sessions could not be synced", numFailed)
+			}
+			return nil
+		},
+	}
+}
+
+func syncPlaySession(rc *butlerd.RequestContext, conn *sqlite.Conn, ps *models.PlaySession) (retErr error) {
+	defer horror.RecoverInto(&retErr)
+
+	access, err := operate.StrictAccessForGameID(conn, ps.GameID, ps.ProfileID)
+	if err != nil {
+		return err
+	}
+	client := rc.Client(access.APIKey)
+	lastRunAt := ps.LastRunAt()
+
+	if ps.RemoteSessionID == 0 {
+		// itch.io can't deduplicate session creation, so remember what it
+		// had before asking: if the response is lost, the next sync can
+		// tell whether the request made it.
+		before, err := client.GetGameSessionsSummary(rc.Ctx, ps.GameID)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if ps.CreateLanded(before.Summary) {
+			rc.Consumer.Infof("Play session (%s) was created on itch.io by a previous sync", ps.ID)
+			if ps.Crashed {
+				rc.Consumer.Warnf("Its crash can't be reported without its ID")
+			}
+			err = models.SaveUserGameInteractionSummary(conn, ps.ProfileID, ps.GameID, before.Summary)
+			if err != nil {
+				return err
+			}
+			ps.MarkSynced(conn)
+			return nil
+		}
+
+		now := time.Now().UTC()
+		ps.CreateSentAt = &now
+		ps.RemoteSecondsRunBefore = 0
+		if before.Summary != nil {
+			ps.RemoteSecondsRunBefore = before.Summary.SecondsRun
+		}
+		ps.Save(conn)
+
+		res, err := client.CreateUserGameSession(rc.Ctx, itchio.CreateUserGameSessionParams{
+			GameID:       ps.GameID,
+			UploadID:     ps.UploadID,
+			BuildID:      ps.BuildID,
+			Credentials:  access.Credentials,
+			Platform:     itchio.SessionPlatform(ps.Platform),
+			Architecture: itchio.SessionArchitecture(ps.Architecture),
+
+			SecondsRun: ps.SecondsRun,
+			LastRunAt:  lastRunAt,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if res.UserGameSession == nil {
+			return errors.New("session creation returned no session")
+		}
+
+		ps.RemoteSessionID = res.UserGameSession.ID
+		ps.SyncedSecondsRun = ps.SecondsRun
+		ps.CreateSentAt = nil
+		ps.Save(conn)
+
+		err = models.SaveUserGameInteractionSummary(conn, ps.ProfileID, ps.GameID, res.Summary)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ps.Crashed || ps.SyncedSecondsRun < ps.SecondsRun {
+		res, err := client.UpdateUserGameSession(rc.Ctx, itchio.UpdateUserGameSessionParams{
+			SessionID: ps.RemoteSessionID,
+
+			SecondsRun: ps.SecondsRun,
+			LastRunAt:  lastRunAt,
+			Crashed:    ps.Crashed,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		err = models.SaveUserGameInteractionSummary(conn, ps.ProfileID, ps.GameID, res.Summary)
+		if err != nil {
+			return err
+		}
+	}
+
+	ps.MarkSynced(conn)
+	return nil
+}
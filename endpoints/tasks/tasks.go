@@ -0,0 +1,81 @@
+package tasks
+
+import (
+	"time"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/butlerd"
+	"github.com/itchio/butler/butlerd/messages"
+	"github.com/itchio/butler/database/models"
+	"github.com/pkg/errors"
+)
+
+func Register(router *butlerd.Router) {
+	router.RegisterBackgroundTaskKind(fetchUserGameSessionsKind, rebuildFetchUserGameSessions)
+	router.RegisterBackgroundTaskKind(syncPlaySessionsKind, rebuildSyncPlaySessions)
+
+	messages.BackgroundTasksList.Register(router, List)
+	messages.BackgroundTasksRetry.Register(router, Retry)
+}
+
+func List(rc *butlerd.RequestContext, params butlerd.BackgroundTasksListParams) (*butlerd.BackgroundTasksListResult, error) {
+	var records []*models.BackgroundTask
+	rc.WithConn(func(conn *sqlite.Conn) {
+		records = models.AllBackgroundTasks(conn)
+	})
+
+	res := &butlerd.BackgroundTasksListResult{
+		Tasks: []*butlerd.BackgroundTaskInfo{},
+	}
+	for _, record := range records {
+		res.Tasks = append(res.Tasks, formatBackgroundTask(record))
+	}
+	return res, nil
+}
+
+func Retry(rc *butlerd.RequestContext, params butlerd.BackgroundTasksRetryParams) (*butlerd.BackgroundTasksRetryResult, error) {
+	var err error
+	rc.WithConn(func(conn *sqlite.Conn) {
+		err = retryWithConn(conn, params.TaskID, time.Now().UTC())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rc.WakeTaskQueue()
+	return &butlerd.BackgroundTasksRetryResult{}, nil
+}
+
+// retryWithConn makes a task due at now, with all its attempts back.
+func retryWithConn(conn *sqlite.Conn, taskID string, now time.Time) error {
+	record := models.BackgroundTaskByID(conn, taskID)
+	if record == nil {
+		return errors.Errorf("background task not found: %s", taskID)
+	}
+	if record.StartedAt != nil {
+		return errors.Errorf("background task is running: %s", taskID)
+	}
+
+	record.Attempts = 0
+	record.FailedAt = nil
+	record.NextRunAt = &now
+	record.Save(conn)
+	return nil
+}
+
+func formatBackgroundTask(record *models.BackgroundTask) *butlerd.BackgroundTaskInfo {
+	bt := &butlerd.BackgroundTaskInfo{
+		ID:        record.ID,
+		Kind:      record.Kind,
+		Desc:      record.Desc,
+		Attempts:  record.Attempts,
+		Running:   record.StartedAt != nil,
+		CreatedAt: record.CreatedAt,
+		LastError: record.LastError,
+		FailedAt:  record.FailedAt,
+	}
+	if record.FailedAt == nil {
+		bt.NextRunAt = record.NextRunAt
+	}
+	return bt
+}
@@ -0,0 +1,64 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"crawshaw.io/sqlite"
+	"github.com/itchio/butler/database/models"
+	"github.com/stretchr/testify/require"
+)
+
+func tasksTestConn(t *testing.T) *sqlite.Conn {
+	conn, err := sqlite.OpenConn("file::memory:?mode=memory", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, models.HadesContext().AutoMigrate(conn))
+	return conn
+}
+
+func TestRetryResetsFailedTask(t *testing.T) {
+	conn := tasksTestConn(t)
+
+	then := time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)
+	lastError := "offline"
+	models.MustSave(conn, &models.BackgroundTask{
+		ID:        "failed",
+		Kind:      "k",
+		Attempts:  10,
+		NextRunAt: &then,
+		FailedAt:  &then,
+		LastError: &lastError,
+	})
+
+	now := then.Add(time.Hour)
+	require.NoError(t, retryWithConn(conn, "failed", now))
+
+	record := models.BackgroundTaskByID(conn, "failed")
+	require.NotNil(t, record)
+	require.EqualValues(t, 0, record.Attempts)
+	require.Nil(t, record.FailedAt)
+	require.True(t, record.IsDue(now))
+}
+
+func TestRetryRefusesRunningTask(t *testing.T) {
+	conn := tasksTestConn(t)
+
+	now := time.Now().UTC()
+	models.MustSave(conn, &models.BackgroundTask{ID: "running", Kind: "k", Attempts: 2, StartedAt: &now})
+
+	err := retryWithConn(conn, "running", now)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is running")
+
+	record := models.BackgroundTaskByID(conn, "running")
+	require.EqualValues(t, 2, record.Attempts, "left untouched")
+}
+
+func TestRetryUnknownTask(t *testing.T) {
+	conn := tasksTestConn(t)
+
+	err := retryWithConn(conn, "nope", time.Now().UTC())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not found")
+}
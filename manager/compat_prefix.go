@@ -0,0 +1,28 @@
+package manager
+
+import (
+	"github.com/itchio/ox"
+)
+
+// NeedsCompatPrefix returns true if the host runs Windows games through
+// a wrapper that doesn't already have its own WINEPREFIX.
+func (h Host) NeedsCompatPrefix() bool {
+	if h.Wrapper == nil || h.Runtime.Platform != ox.PlatformWindows {
+		return false
+	}
+	_, ok := h.Wrapper.Env["WINEPREFIX"]
+	return !ok
+}
+
+// WithCompatPrefix returns a copy of the host that runs games
+// inside the given wine prefix.
+func (h Host) WithCompatPrefix(prefix string) Host {
+	wrapper := *h.Wrapper
+	wrapper.Env = make(map[string]string)
+	for k, v := range h.Wrapper.Env {
+		wrapper.Env[k] = v
+	}
+	wrapper.Env["WINEPREFIX"] = prefix
+	h.Wrapper = &wrapper
+	return h
+}
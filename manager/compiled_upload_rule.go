@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"regexp"
+	"strings"
+
+	itchio "github.com/itchio/go-itchio"
+	"github.com/pkg/errors"
+)
+
+// compiled rules are kept out of upload_rules.go, which generous
+// assimilates, and whose structs must only have json fields.
+
+// compiledUploadRule is a rule that passed validation, with its name
+// pattern compiled once
+type compiledUploadRule struct {
+	*UploadRule
+	nameRegexp *regexp.Regexp
+}
+
+func (r *UploadRule) compile() (*compiledUploadRule, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	cr := &compiledUploadRule{UploadRule: r}
+	if r.NamePattern != "" {
+		re, err := regexp.Compile("(?i)" + r.NamePattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid namePattern")
+		}
+		cr.nameRegexp = re
+	}
+	return cr, nil
+}
+
+// matches returns true if all the criteria set match u
+func (r *compiledUploadRule) matches(u *itchio.Upload) bool {
+	filename := strings.ToLower(u.Filename)
+
+	if r.Type != "" && string(u.Type) != r.Type {
+		return false
+	}
+	if r.nameRegexp != nil && !r.nameRegexp.MatchString(u.Filename) && !r.nameRegexp.MatchString(u.DisplayName) {
+		return false
+	}
+	if r.Platform != "" && !taggedFor(u.Platforms, r.Platform) {
+		return false
+	}
+	if r.Arch != "" && !builtFor(u.Platforms, r.Platform, itchio.Architectures(r.Arch)) {
+		return false
+	}
+	if r.Format != "" && !strings.HasSuffix(filename, "."+strings.ToLower(strings.TrimPrefix(r.Format, "."))) {
+		return false
+	}
+	if r.InstallerType != "" && GuessInstallerType(u.Filename) != r.InstallerType {
+		return false
+	}
+	if r.Demo && !u.Demo {
+		return false
+	}
+	return true
+}
@@ -18,7 +18,9 @@ type HostEnumerator interface {
 	Enumerate(consumer *state.Consumer) (Hosts, error)
 }
 
-type defaultHostEnumerator struct{}
+type defaultHostEnumerator struct {
+	compatHosts Hosts
+}
 
 var _ HostEnumerator = (*defaultHostEnumerator)(nil)
 
@@ -26,6 +28,14 @@ func DefaultHostEnumerator() HostEnumerator {
 	return &defaultHostEnumerator{}
 }
 
+// HostEnumeratorWithCompatHosts enumerates user-defined compat hosts
+// after the native host, and before automatically detected ones.
+func HostEnumeratorWithCompatHosts(compatHosts Hosts) HostEnumerator {
+	return &defaultHostEnumerator{
+		compatHosts: compatHosts,
+	}
+}
+
 func (h Host) Validate() error {
 	if h.Runtime.Platform == "" {
 		return errors.Errorf("invalid host (empty platform)")
@@ -47,6 +57,25 @@ func (dre *defaultHostEnumerator) Enumerate(consumer *state.Consumer) (Hosts, er
 		native,
 	}
 
+	for _, h := range dre.compatHosts {
+		if h.Wrapper == nil || h.Wrapper.WrapperBinary == "" {
+			consumer.Warnf("Skipping compat host (%s): no wrapper binary", h.Name)
+			continue
+		}
+
+		binaryPath, err := exec.LookPath(h.Wrapper.WrapperBinary)
+		if err != nil {
+			consumer.Warnf("Skipping compat host (%s): %v", h.Name, err)
+			continue
+		}
+
+		wrapper := *h.Wrapper
+		wrapper.WrapperBinary = binaryPath
+		h.Wrapper = &wrapper
+		consumer.Debugf("Compat host: %v", h)
+		rts = append(rts, h)
+	}
+
 	if native.Runtime.Platform != ox.PlatformWindows {
 		consumer.Debugf("Looking for wine...")
 
@@ -74,6 +103,9 @@ func (dre *defaultHostEnumerator) Enumerate(consumer *state.Consumer) (Hosts, er
 
 func (h Host) String() string {
 	res := h.Runtime.String()
+	if h.Name != "" {
+		res += fmt.Sprintf(" (%s)", h.Name)
+	}
 	if h.RemoteLaunchName != "" {
 		res += fmt.Sprintf(" (remoteLaunchName=%s)", h.RemoteLaunchName)
 	} else if h.Wrapper != nil {
@@ -127,3 +159,23 @@ func (sre *singleHostEnumerator) Enumerate(consumer *state.Consumer) (Hosts, err
 	}
 	return res, nil
 }
+
+// PlatformHostEnumerator enumerates a 64-bit host for platform ("windows",
+// "linux" or "osx"), to narrow down uploads for a machine other than
+// this one.
+func PlatformHostEnumerator(platform string) HostEnumerator {
+	return SingleHostEnumerator(ox.Runtime{
+		Platform: ox.Platform(platform),
+		Is64:     true,
+	})
+}
+
+// ByID returns the host with the given compat host ID, if any.
+func (h Hosts) ByID(id string) (Host, bool) {
+	for _, host := range h {
+		if host.ID != "" && host.ID == id {
+			return host, true
+		}
+	}
+	return Host{}, false
+}
@@ -0,0 +1,91 @@
+package manager_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/itchio/butler/manager"
+	"github.com/itchio/ox"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HostEnumeratorWithCompatHosts(t *testing.T) {
+	if ox.CurrentRuntime().Platform == ox.PlatformWindows {
+		t.Skip("uses a shell script as wrapper binary")
+	}
+	consumer := makeTestConsumer(t)
+
+	binDir := t.TempDir()
+	protonPath := filepath.Join(binDir, "proton")
+	assert.NoError(t, os.WriteFile(protonPath, []byte("#!/bin/sh\n"), 0o755))
+	t.Setenv("PATH", binDir)
+
+	windows64 := ox.Runtime{Platform: ox.PlatformWindows, Is64: true}
+	hosts, err := manager.HostEnumeratorWithCompatHosts(manager.Hosts{
+		{
+			Runtime: windows64,
+			ID:      "missing",
+			Name:    "Missing",
+			Wrapper: &manager.Wrapper{WrapperBinary: "not-installed"},
+		},
+		{
+			Runtime: windows64,
+			ID:      "proton",
+			Name:    "Proton",
+			Wrapper: &manager.Wrapper{
+				WrapperBinary: "proton",
+				BeforeTarget:  []string{"run"},
+				Env:           map[string]string{"WINEPREFIX": "/tmp/prefix"},
+			},
+		},
+	}).Enumerate(consumer)
+	assert.NoError(t, err)
+
+	// the fake PATH has no wine, so no automatically detected host
+	assert.Len(t, hosts, 2)
+	assert.EqualValues(t, manager.NativeHost(), hosts[0])
+
+	proton, ok := hosts.ByID("proton")
+	assert.True(t, ok)
+	assert.EqualValues(t, protonPath, proton.Wrapper.WrapperBinary)
+	assert.EqualValues(t, []string{"run"}, proton.Wrapper.BeforeTarget)
+	assert.EqualValues(t, "/tmp/prefix", proton.Wrapper.Env["WINEPREFIX"])
+
+	_, ok = hosts.ByID("missing")
+	assert.False(t, ok)
+	_, ok = hosts.ByID("")
+	assert.False(t, ok)
+}
+
+func Test_CompatPrefix(t *testing.T) {
+	windows := ox.Runtime{Platform: ox.PlatformWindows}
+
+	assert.False(t, manager.NativeHost().NeedsCompatPrefix())
+	assert.False(t, manager.Host{Runtime: windows}.NeedsCompatPrefix())
+
+	shared := manager.Host{
+		Runtime: windows,
+		Wrapper: &manager.Wrapper{
+			WrapperBinary: "/usr/bin/wine",
+			Env:           map[string]string{"WINEPREFIX": "/home/user/.wine-games"},
+		},
+	}
+	assert.False(t, shared.NeedsCompatPrefix())
+
+	wine := manager.Host{
+		Runtime: windows,
+		Wrapper: &manager.Wrapper{
+			WrapperBinary: "/usr/bin/wine",
+			Env:           map[string]string{"WINEDEBUG": "-all"},
+		},
+	}
+	assert.True(t, wine.NeedsCompatPrefix())
+
+	prefixed := wine.WithCompatPrefix("/games/compat-prefixes/cave")
+	assert.False(t, prefixed.NeedsCompatPrefix())
+	assert.EqualValues(t, "/games/compat-prefixes/cave", prefixed.Wrapper.Env["WINEPREFIX"])
+	assert.EqualValues(t, "-all", prefixed.Wrapper.Env["WINEDEBUG"])
+	// the original host is left untouched
+	assert.True(t, wine.NeedsCompatPrefix())
+}
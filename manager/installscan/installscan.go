@@ -0,0 +1,159 @@
+// Package installscan reads what install folders hold from the receipts
+// left in them. It's shared by butlerd's InstallLocations.Scan, which
+// imports caves it finds, and library imports, which check that caves
+// are where they say they are.
+package installscan
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/itchio/headway/state"
+	"github.com/itchio/hush/bfs"
+	"github.com/pkg/errors"
+)
+
+// Folders of install locations that never hold games
+var reservedFolderNames = map[string]bool{
+	"downloads":       true,
+	"compat-prefixes": true,
+}
+
+// FolderNames lists the folders of an install location that may
+// hold games.
+func FolderNames(installLocationPath string) ([]string, error) {
+	entries, err := ioutil.ReadDir(installLocationPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		// symlinks to folders are fine, Read checks for a .itch folder
+		if reservedFolderNames[entry.Name()] {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+// Contents is what an install folder says it holds.
+type Contents struct {
+	// Written by butler
+	Receipt *bfs.Receipt
+	// When the receipt was last written
+	ReceiptModTime time.Time
+
+	// Written by itch v18 and older, only read when there's no receipt
+	LegacyReceipt *LegacyReceipt
+}
+
+// Read returns what an install folder holds, or nil if it doesn't
+// have a .itch folder.
+func Read(consumer *state.Consumer, installFolder string) (*Contents, error) {
+	dotItchPath := filepath.Join(installFolder, ".itch")
+	if _, err := os.Stat(dotItchPath); err != nil {
+		return nil, nil
+	}
+
+	c := &Contents{}
+	receipt, err := bfs.ReadReceipt(installFolder)
+	if err != nil {
+		consumer.Warnf("While reading receipt in (%s): %s", installFolder, err.Error())
+	}
+	if receipt != nil {
+		stats, err := os.Stat(bfs.ReceiptPath(installFolder))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		c.Receipt = receipt
+		c.ReceiptModTime = stats.ModTime()
+		return c, nil
+	}
+
+	legacyReceiptPath := filepath.Join(dotItchPath, "receipt.json")
+	legacyReceiptBytes, err := ioutil.ReadFile(legacyReceiptPath)
+	if err != nil {
+		return c, nil
+	}
+	consumer.Infof("Reading legacy itch receipt from %s", legacyReceiptPath)
+
+	lr := &LegacyReceipt{}
+	err = json.Unmarshal(legacyReceiptBytes, lr)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling legacy itch receipt")
+	}
+	c.LegacyReceipt = lr
+	return c, nil
+}
+
+// Verify checks that an install folder's receipt is for the given upload
+// and build, and that all the files it lists are there. A buildID of 0
+// matches any build.
+func Verify(consumer *state.Consumer, installFolder string, uploadID int64, buildID int64) error {
+	c, err := Read(consumer, installFolder)
+	if err != nil {
+		return errors.WithMessage(err, "reading receipt")
+	}
+	if c == nil || c.Receipt == nil {
+		return errors.Errorf("no receipt in %s", installFolder)
+	}
+
+	receipt := c.Receipt
+	if receipt.Upload == nil || receipt.Upload.ID != uploadID {
+		return errors.Errorf("%s holds a different upload", installFolder)
+	}
+	if buildID != 0 && (receipt.Build == nil || receipt.Build.ID != buildID) {
+		return errors.Errorf("%s holds a different build", installFolder)
+	}
+	for _, file := range receipt.Files {
+		_, err := os.Lstat(filepath.Join(installFolder, filepath.FromSlash(file)))
+		if err != nil {
+			return errors.Errorf("%s is missing %s", installFolder, file)
+		}
+	}
+	return nil
+}
+
+type LegacyReceipt struct {
+	Cave *LegacyCave `json:"cave"`
+
+	// Introduced in v0.12.0 (January 2016!!)
+	// See https://github.com/itchio/itch/commit/1a550b52cb250b2b60b9c32b7f09fb8fc0bdc647#diff-2c5c1d50b675bd97c2b17e7fba3e5eeaR190
+	Files []string `json:"files"`
+}
+
+type LegacyCave struct {
+	// These have been here since v0.14.0 at least,
+	// see https://github.com/itchio/itch/blob/v0.14.0/appsrc/tasks/install.js#L42
+	ID       string `json:"id"`
+	GameID   int64  `json:"gameId"`
+	UploadID int64  `json:"uploadId"`
+	// see https://github.com/itchio/itch/blob/v0.14.0/appsrc/tasks/install.js#L103
+	// and https://github.com/itchio/itch/commit/7f76cecbbae0a22bb574f8557b642acd24ee91d4#diff-0fcbef7d4100ec13581b447ef7050e7fL99
+	BuildID int64 `json:"buildId"`
+
+	// Introduced in v0.14.0
+	// See https://github.com/itchio/itch/commit/62ac56a107ffe0a5d64e9574248a97761db0af8f#diff-edc50cd9cd6f10a0854f25aa2e3ea52dR35
+	LastTouched float64 `json:"lastTouched"`
+	SecondsRun  float64 `json:"secondsRun"`
+
+	// Introduced in v18.0.0 as a `new Date()` (coerced to an RFC3339 string when
+	// passed through JSON.stringify).
+	// See https://github.com/itchio/itch/commit/eb046948d528053628a8b5dcd2860446223a8541#diff-0fcbef7d4100ec13581b447ef7050e7fR104
+	// Changed to a number in v18.3.0,
+	// See https://github.com/itchio/itch/commit/d3ad339a58c3763cef216015cce6c2b8084a89c2#diff-0fcbef7d4100ec13581b447ef7050e7fR114
+	InstalledAt interface{} `json:"installedAt"`
+
+	// Introduced in v18.5.0 (1 year 7 months ago at the time of this writing)
+	// Prior to that, it was a mess, so let's just not import their caves.
+	// See https://github.com/itchio/itch/commit/9cfc5c14184c506afff282ab50f19a7a774197a6#diff-b57d301bc67d78b1004baedc3472f13b
+	PathScheme int `json:"pathScheme"`
+
+	InstallLocation string `json:"installLocation"`
+	InstallFolder   string `json:"installFolder"`
+}
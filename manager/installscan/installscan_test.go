@@ -0,0 +1,99 @@
+package installscan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/hush/bfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path string, contents string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+}
+
+func writeReceipt(t *testing.T, installFolder string, uploadID int64, buildID int64, files ...string) {
+	for _, file := range files {
+		writeFile(t, filepath.Join(installFolder, file), file)
+	}
+	receipt := &bfs.Receipt{
+		Game:   &itchio.Game{ID: 1},
+		Upload: &itchio.Upload{ID: uploadID},
+		Files:  files,
+	}
+	if buildID != 0 {
+		receipt.Build = &itchio.Build{ID: buildID}
+	}
+	require.NoError(t, receipt.WriteReceipt(installFolder))
+}
+
+func TestFolderNames(t *testing.T) {
+	il := t.TempDir()
+	for _, name := range []string{"alpha", "beta", "downloads", "compat-prefixes"} {
+		require.NoError(t, os.Mkdir(filepath.Join(il, name), 0o755))
+	}
+
+	names, err := FolderNames(il)
+	require.NoError(t, err)
+	assert.EqualValues(t, []string{"alpha", "beta"}, names)
+}
+
+func TestRead(t *testing.T) {
+	consumer := &state.Consumer{}
+	il := t.TempDir()
+
+	plain := filepath.Join(il, "plain")
+	writeFile(t, filepath.Join(plain, "readme.txt"), "hi")
+	c, err := Read(consumer, plain)
+	assert.NoError(t, err)
+	assert.Nil(t, c, "folders without .itch don't hold games")
+
+	installed := filepath.Join(il, "installed")
+	writeReceipt(t, installed, 10, 100, "game.exe")
+	c, err = Read(consumer, installed)
+	require.NoError(t, err)
+	require.NotNil(t, c.Receipt)
+	assert.EqualValues(t, 10, c.Receipt.Upload.ID)
+	assert.False(t, c.ReceiptModTime.IsZero())
+	assert.Nil(t, c.LegacyReceipt)
+
+	legacy := filepath.Join(il, "legacy")
+	writeFile(t, filepath.Join(legacy, ".itch", "receipt.json"), `{"cave": {"id": "cave-1", "uploadId": 20}, "files": ["game.exe"]}`)
+	c, err = Read(consumer, legacy)
+	require.NoError(t, err)
+	assert.Nil(t, c.Receipt)
+	require.NotNil(t, c.LegacyReceipt)
+	assert.EqualValues(t, "cave-1", c.LegacyReceipt.Cave.ID)
+	assert.EqualValues(t, []string{"game.exe"}, c.LegacyReceipt.Files)
+}
+
+func TestVerify(t *testing.T) {
+	consumer := &state.Consumer{}
+	installFolder := filepath.Join(t.TempDir(), "game")
+	writeReceipt(t, installFolder, 10, 100, "game.exe", "data/level1.dat")
+
+	assert.NoError(t, Verify(consumer, installFolder, 10, 100))
+	assert.NoError(t, Verify(consumer, installFolder, 10, 0), "any build should do")
+
+	assertFails := func(uploadID int64, buildID int64, message string) {
+		err := Verify(consumer, installFolder, uploadID, buildID)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), message)
+		}
+	}
+	assertFails(11, 100, "different upload")
+	assertFails(10, 101, "different build")
+
+	require.NoError(t, os.Remove(filepath.Join(installFolder, "data", "level1.dat")))
+	assertFails(10, 100, "is missing data/level1.dat")
+
+	err := Verify(consumer, t.TempDir(), 10, 100)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no receipt")
+	}
+}
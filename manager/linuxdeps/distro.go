@@ -0,0 +1,147 @@
+package linuxdeps
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Family groups distros that share package names and a package manager.
+type Family string
+
+const (
+	FamilyUnknown Family = ""
+	FamilyDebian  Family = "debian"
+	FamilyFedora  Family = "fedora"
+	FamilyArch    Family = "arch"
+	FamilySUSE    Family = "suse"
+)
+
+// Distro is what os-release says about the running system.
+type Distro struct {
+	// Like "ubuntu", "fedora", "steamos"
+	ID string
+	// Like "Ubuntu 24.04 LTS"
+	Name   string
+	Family Family
+	// Release codename of ubuntu or the ubuntu derivative, like "noble",
+	// used to look up packages
+	UbuntuCodename string
+}
+
+// DetectDistro reads os-release. On failure, it returns a distro
+// of unknown family.
+func DetectDistro(osReleasePath string) *Distro {
+	if osReleasePath == "" {
+		osReleasePath = "/etc/os-release"
+		if _, err := os.Stat(osReleasePath); err != nil {
+			osReleasePath = "/usr/lib/os-release"
+		}
+	}
+
+	d := &Distro{}
+	f, err := os.Open(osReleasePath)
+	if err != nil {
+		return d
+	}
+	defer f.Close()
+
+	var idLike []string
+	var versionCodename string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(s.Text()), "=")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, "'")
+		}
+
+		switch key {
+		case "ID":
+			d.ID = value
+		case "PRETTY_NAME":
+			d.Name = value
+		case "ID_LIKE":
+			idLike = strings.Fields(value)
+		case "UBUNTU_CODENAME":
+			d.UbuntuCodename = value
+		case "VERSION_CODENAME":
+			versionCodename = value
+		}
+	}
+	if d.UbuntuCodename == "" && d.ID == "ubuntu" {
+		d.UbuntuCodename = versionCodename
+	}
+
+	for _, id := range append([]string{d.ID}, idLike...) {
+		if family := familyForID(id); family != FamilyUnknown {
+			d.Family = family
+			break
+		}
+	}
+	return d
+}
+
+func familyForID(id string) Family {
+	switch {
+	case id == "debian" || id == "ubuntu":
+		return FamilyDebian
+	case id == "fedora" || id == "rhel" || id == "centos":
+		return FamilyFedora
+	case id == "arch":
+		return FamilyArch
+	case id == "suse" || strings.HasPrefix(id, "opensuse") || id == "sles":
+		return FamilySUSE
+	}
+	return FamilyUnknown
+}
+
+// InstallCommand returns a shell command that installs the packages
+// providing the missing libraries. It's empty if any of them isn't known.
+func (d *Distro) InstallCommand(missing []*MissingLibrary) string {
+	var prefix string
+	switch d.Family {
+	case FamilyDebian:
+		prefix = "sudo apt install"
+	case FamilyFedora:
+		prefix = "sudo dnf install"
+	case FamilyArch:
+		prefix = "sudo pacman -S"
+	case FamilySUSE:
+		prefix = "sudo zypper install"
+	default:
+		return ""
+	}
+
+	var packages []string
+	seen := make(map[string]bool)
+	for _, ml := range missing {
+		if ml.Package == "" {
+			return ""
+		}
+		if seen[ml.Package] {
+			continue
+		}
+		seen[ml.Package] = true
+		packages = append(packages, shellQuote(ml.Package))
+	}
+	if len(packages) == 0 {
+		return ""
+	}
+	return prefix + " " + strings.Join(packages, " ")
+}
+
+// shellQuote quotes rpm capabilities like "libGL.so.1()(64bit)"
+func shellQuote(s string) string {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("._+:-", r)) {
+			return "'" + s + "'"
+		}
+	}
+	return s
+}
@@ -0,0 +1,332 @@
+// Package linuxdeps finds shared libraries a Linux game needs but that
+// aren't installed, and suggests distro packages that provide them.
+package linuxdeps
+
+import (
+	"debug/elf"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itchio/elefant"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/httpkit/eos"
+	"github.com/pkg/errors"
+)
+
+type Params struct {
+	// Absolute path of the game's install folder. Libraries bundled
+	// anywhere in it count as found.
+	InstallFolder string
+
+	// Files of the install folder as listed in its receipt, slash-separated
+	// and relative to it. If nil, the install folder is walked when
+	// looking for bundled libraries.
+	InstalledFiles []string
+
+	// Absolute path of the ELF executable that's about to be launched
+	Target string
+
+	// Path of the os-release file, defaults to /etc/os-release
+	OSReleasePath string
+
+	// Used to look up Debian packages, defaults to a client
+	// with a short timeout
+	HTTPClient *http.Client
+
+	Consumer *state.Consumer
+}
+
+// Report lists libraries that are missing for a target to run.
+type Report struct {
+	// Architecture of the target: "386", "amd64" or "arm64"
+	Arch string
+
+	Distro *Distro
+
+	// Missing libraries, sorted by name
+	Missing []*MissingLibrary
+
+	// Shell command that installs the packages providing missing
+	// libraries, if they're all known for this distro
+	InstallCommand string
+}
+
+type MissingLibrary struct {
+	// Shared object name, like "libSDL2-2.0.so.0"
+	Name string
+
+	// Files that need it, relative to the install folder when
+	// they're in it
+	NeededBy []string
+
+	// Name of the package that provides it on this distro,
+	// empty if unknown
+	Package string
+}
+
+// libraries provided by the kernel or the dynamic linker itself
+var virtualLibraries = map[string]bool{
+	"linux-vdso.so.1":   true,
+	"linux-gate.so.1":   true,
+	"linux-vdso64.so.1": true,
+}
+
+// tracer completes elefant.Trace, which only looks into the folders
+// listed in ld.so.conf: imports it can't resolve are looked up in the
+// RUNPATH of the file that needs them, among libraries bundled with the
+// game, and in folders the dynamic linker always looks into, and what's
+// found there is traced in turn.
+type tracer struct {
+	params Params
+	arch   elefant.Arch
+
+	fallbackPaths []string
+	// shared objects bundled with the game, by file name, built
+	// the first time an import can't be resolved
+	bundled map[string][]string
+	// whether a file is an ELF for the target architecture
+	archCache map[string]bool
+
+	visited map[string]bool
+	// missing library name => files that need it
+	missing map[string]map[string]bool
+}
+
+// Assess walks the target's dependencies, including those of bundled
+// and system libraries, and reports the ones that can't be found.
+func Assess(params Params) (*Report, error) {
+	if params.Consumer == nil {
+		params.Consumer = &state.Consumer{}
+	}
+	if params.HTTPClient == nil {
+		params.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	info, err := probe(params.Target, params.Consumer)
+	if err != nil {
+		return nil, errors.WithMessage(err, "reading target")
+	}
+
+	t := &tracer{
+		params:        params,
+		arch:          info.Arch,
+		fallbackPaths: fallbackSearchPaths(),
+		archCache:     make(map[string]bool),
+		visited:       make(map[string]bool),
+		missing:       make(map[string]map[string]bool),
+	}
+	err = t.trace(params.Target, info)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Arch:   string(info.Arch),
+		Distro: DetectDistro(params.OSReleasePath),
+	}
+	lookup := newPackageLookup(report.Distro, params.HTTPClient, params.Consumer)
+	for name, neededBy := range t.missing {
+		ml := &MissingLibrary{
+			Name:    name,
+			Package: lookup.packageFor(name, report.Arch),
+		}
+		for path := range neededBy {
+			ml.NeededBy = append(ml.NeededBy, path)
+		}
+		sort.Strings(ml.NeededBy)
+		report.Missing = append(report.Missing, ml)
+	}
+	sort.Slice(report.Missing, func(i, j int) bool {
+		return report.Missing[i].Name < report.Missing[j].Name
+	})
+	report.InstallCommand = report.Distro.InstallCommand(report.Missing)
+	return report, nil
+}
+
+func probe(path string, consumer *state.Consumer) (*elefant.ElfInfo, error) {
+	f, err := eos.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	return elefant.Probe(f, elefant.ProbeParams{Consumer: consumer})
+}
+
+func (t *tracer) trace(path string, info *elefant.ElfInfo) error {
+	root, err := elefant.Trace(info, path, elefant.TraceParams{Consumer: t.params.Consumer})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	t.walk(root)
+	return nil
+}
+
+func (t *tracer) walk(node *elefant.TraceNode) {
+	if t.visited[node.FullPath] {
+		return
+	}
+	t.visited[node.FullPath] = true
+
+	for _, name := range node.UnresolvedImports {
+		if virtualLibraries[name] {
+			continue
+		}
+
+		found := t.lookup(name, node.FullPath)
+		if found == "" {
+			if t.missing[name] == nil {
+				t.missing[name] = make(map[string]bool)
+			}
+			t.missing[name][t.displayPath(node.FullPath)] = true
+			continue
+		}
+		if t.visited[found] {
+			continue
+		}
+
+		info, err := probe(found, t.params.Consumer)
+		if err == nil {
+			err = t.trace(found, info)
+		}
+		if err != nil {
+			t.params.Consumer.Debugf("Skipping (%s): %v", found, err)
+			t.visited[found] = true
+		}
+	}
+
+	for _, child := range node.Children {
+		t.walk(child)
+	}
+}
+
+func (t *tracer) lookup(name string, neededBy string) string {
+	if strings.Contains(name, "/") {
+		if t.matchesArch(name) {
+			return name
+		}
+		return ""
+	}
+
+	for _, dir := range runPaths(neededBy) {
+		if candidate := filepath.Join(dir, name); t.matchesArch(candidate) {
+			return candidate
+		}
+	}
+	// games often set LD_LIBRARY_PATH in a launch script, so anything
+	// bundled counts, wherever it is
+	for _, candidate := range t.bundledLibraries()[name] {
+		if t.matchesArch(candidate) {
+			return candidate
+		}
+	}
+	for _, dir := range t.fallbackPaths {
+		if candidate := filepath.Join(dir, name); t.matchesArch(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// runPaths returns the folders an ELF file's RPATH and RUNPATH
+// tell the dynamic linker to look into, which elefant doesn't read.
+func runPaths(path string) []string {
+	ef, err := elf.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer ef.Close()
+
+	var dirs []string
+	origin := filepath.Dir(path)
+	for _, tag := range []elf.DynTag{elf.DT_RUNPATH, elf.DT_RPATH} {
+		values, _ := ef.DynString(tag)
+		for _, value := range values {
+			for _, dir := range strings.Split(value, ":") {
+				if dir == "" {
+					continue
+				}
+				dir = strings.ReplaceAll(dir, "${ORIGIN}", origin)
+				dir = strings.ReplaceAll(dir, "$ORIGIN", origin)
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+func (t *tracer) matchesArch(path string) bool {
+	if matches, ok := t.archCache[path]; ok {
+		return matches
+	}
+
+	matches := false
+	f, err := os.Open(path)
+	if err == nil {
+		info, err := elefant.Probe(f, elefant.ProbeParams{Consumer: &state.Consumer{}})
+		matches = err == nil && info.Arch == t.arch
+		f.Close()
+	}
+	t.archCache[path] = matches
+	return matches
+}
+
+func (t *tracer) displayPath(path string) string {
+	if rel, err := filepath.Rel(t.params.InstallFolder, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+func (t *tracer) bundledLibraries() map[string][]string {
+	if t.bundled == nil {
+		t.bundled = indexBundledLibraries(t.params.InstallFolder, t.params.InstalledFiles, t.params.Consumer)
+	}
+	return t.bundled
+}
+
+func isSharedObject(name string) bool {
+	return strings.Contains(name, ".so")
+}
+
+// indexBundledLibraries lists shared objects in the install folder, from
+// the receipt's file list if there's one.
+func indexBundledLibraries(installFolder string, installedFiles []string, consumer *state.Consumer) map[string][]string {
+	bundled := make(map[string][]string)
+	add := func(path string) {
+		name := filepath.Base(path)
+		if isSharedObject(name) {
+			bundled[name] = append(bundled[name], path)
+		}
+	}
+
+	if installedFiles != nil {
+		for _, file := range installedFiles {
+			add(filepath.Join(installFolder, filepath.FromSlash(file)))
+		}
+		return bundled
+	}
+
+	consumer.Debugf("No receipt, looking for bundled libraries in (%s)", installFolder)
+	err := filepath.Walk(installFolder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == ".itch" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		add(path)
+		return nil
+	})
+	if err != nil {
+		consumer.Warnf("Could not look for bundled libraries: %v", err)
+	}
+	return bundled
+}
@@ -0,0 +1,198 @@
+package linuxdeps_test
+
+import (
+	"debug/elf"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/itchio/butler/manager/linuxdeps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// copies a dynamically-linked system binary into a fake install folder
+func prepareGame(t *testing.T) (string, string) {
+	if runtime.GOOS != "linux" {
+		t.Skip("needs a Linux system binary")
+	}
+	input, err := os.ReadFile("/bin/ls")
+	if err != nil {
+		t.Skipf("no system binary to test with: %v", err)
+	}
+
+	installFolder := t.TempDir()
+	target := filepath.Join(installFolder, "game.x86")
+	assert.NoError(t, os.WriteFile(target, input, 0o755))
+	return installFolder, target
+}
+
+// renames an import of an ELF file in place, names must
+// be of the same length
+func renameImport(t *testing.T, path string, from string, to string) {
+	require.Len(t, to, len(from))
+
+	ef, err := elf.Open(path)
+	require.NoError(t, err)
+	dynstr := ef.Section(".dynstr")
+	require.NotNil(t, dynstr)
+	strtab, err := dynstr.Data()
+	require.NoError(t, err)
+	offset := dynstr.Offset
+	ef.Close()
+
+	index := strings.Index(string(strtab), "\x00"+from+"\x00")
+	require.NotEqual(t, -1, index, "%s should import %s", path, from)
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteAt([]byte(to), int64(offset)+int64(index)+1)
+	require.NoError(t, err)
+}
+
+func findSystemLibrary(t *testing.T, name string) string {
+	for _, dir := range []string{"/lib/x86_64-linux-gnu", "/lib/aarch64-linux-gnu", "/lib/i386-linux-gnu", "/lib64", "/usr/lib64", "/lib", "/usr/lib"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	t.Skipf("could not find %s", name)
+	return ""
+}
+
+func writeOSRelease(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "os-release")
+	assert.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	return path
+}
+
+func Test_AssessInstalled(t *testing.T) {
+	installFolder, target := prepareGame(t)
+
+	report, err := linuxdeps.Assess(linuxdeps.Params{
+		InstallFolder: installFolder,
+		Target:        target,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Missing)
+	assert.Empty(t, report.InstallCommand)
+}
+
+func Test_AssessMissing(t *testing.T) {
+	installFolder, target := prepareGame(t)
+	renameImport(t, target, "libc.so.6", "libq.so.6")
+
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Path+" "+r.URL.Query().Get("file"))
+		if !strings.HasPrefix(r.URL.Query().Get("file"), "/usr/lib/") {
+			w.WriteHeader(404)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"packages": []string{"libq6-dbg", "libq6"},
+		})
+	}))
+	defer server.Close()
+	defer func(url string) { linuxdeps.DebSearchURL = url }(linuxdeps.DebSearchURL)
+	linuxdeps.DebSearchURL = server.URL
+
+	report, err := linuxdeps.Assess(linuxdeps.Params{
+		InstallFolder: installFolder,
+		Target:        target,
+		OSReleasePath: writeOSRelease(t, "ID=ubuntu\nID_LIKE=debian\nPRETTY_NAME=\"Ubuntu 24.04 LTS\"\nVERSION_CODENAME=noble\n"),
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, linuxdeps.FamilyDebian, report.Distro.Family)
+	assert.EqualValues(t, "Ubuntu 24.04 LTS", report.Distro.Name)
+	assert.EqualValues(t, "noble", report.Distro.UbuntuCodename)
+
+	if assert.Len(t, report.Missing, 1) {
+		ml := report.Missing[0]
+		assert.EqualValues(t, "libq.so.6", ml.Name)
+		assert.EqualValues(t, []string{"game.x86"}, ml.NeededBy)
+		if report.Arch == "386" {
+			assert.EqualValues(t, "libq6:i386", ml.Package)
+		} else {
+			assert.EqualValues(t, "libq6", ml.Package)
+		}
+		assert.EqualValues(t, "sudo apt install "+ml.Package, report.InstallCommand)
+	}
+	if assert.Len(t, queries, 1) {
+		assert.True(t, strings.HasPrefix(queries[0], "/noble/"+linuxdeps.DebianArch(report.Arch)+" /usr/lib/"), queries[0])
+	}
+
+	// bundling libraries anywhere in the install folder is enough
+	libc, err := os.ReadFile(findSystemLibrary(t, "libc.so.6"))
+	require.NoError(t, err)
+	libs := filepath.Join(installFolder, "lib")
+	require.NoError(t, os.MkdirAll(libs, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(libs, "libq.so.6"), libc, 0o644))
+
+	report, err = linuxdeps.Assess(linuxdeps.Params{
+		InstallFolder: installFolder,
+		Target:        target,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Missing)
+
+	// when given, only files from the receipt are considered
+	report, err = linuxdeps.Assess(linuxdeps.Params{
+		InstallFolder:  installFolder,
+		InstalledFiles: []string{"game.x86"},
+		Target:         target,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, report.Missing, 1)
+
+	report, err = linuxdeps.Assess(linuxdeps.Params{
+		InstallFolder:  installFolder,
+		InstalledFiles: []string{"game.x86", "lib/libq.so.6"},
+		Target:         target,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Missing)
+}
+
+func Test_DistroPackages(t *testing.T) {
+	cases := []struct {
+		osRelease      string
+		family         linuxdeps.Family
+		ubuntuCodename string
+	}{
+		{"ID=debian\nVERSION_CODENAME=bookworm\n", linuxdeps.FamilyDebian, ""},
+		{"ID=ubuntu\nVERSION_CODENAME=noble\n", linuxdeps.FamilyDebian, "noble"},
+		{"ID=linuxmint\nID_LIKE=\"ubuntu debian\"\nVERSION_CODENAME=wilma\nUBUNTU_CODENAME=noble\n", linuxdeps.FamilyDebian, "noble"},
+		{"ID=fedora\n", linuxdeps.FamilyFedora, ""},
+		{"ID=steamos\nID_LIKE=arch\n", linuxdeps.FamilyArch, ""},
+		{"ID=\"opensuse-tumbleweed\"\nID_LIKE=\"opensuse suse\"\n", linuxdeps.FamilySUSE, ""},
+		{"ID=nixos\n", linuxdeps.FamilyUnknown, ""},
+	}
+
+	for _, c := range cases {
+		d := linuxdeps.DetectDistro(writeOSRelease(t, c.osRelease))
+		assert.EqualValues(t, c.family, d.Family, c.osRelease)
+		assert.EqualValues(t, c.ubuntuCodename, d.UbuntuCodename, c.osRelease)
+	}
+
+	missing := []*linuxdeps.MissingLibrary{
+		{Name: "libSDL2-2.0.so.0", Package: "libSDL2-2.0.so.0()(64bit)"},
+		{Name: "libGL.so.1", Package: "libGL.so.1()(64bit)"},
+	}
+	d := linuxdeps.DetectDistro(writeOSRelease(t, "ID=fedora\n"))
+	assert.EqualValues(t, "sudo dnf install 'libSDL2-2.0.so.0()(64bit)' 'libGL.so.1()(64bit)'", d.InstallCommand(missing))
+
+	// a single unknown library means no install command
+	d = linuxdeps.DetectDistro(writeOSRelease(t, "ID=debian\n"))
+	assert.EqualValues(t, "", d.InstallCommand([]*linuxdeps.MissingLibrary{
+		{Name: "libSDL2-2.0.so.0", Package: "libsdl2-2.0-0"},
+		{Name: "libfancy.so.3"},
+	}))
+}
@@ -0,0 +1,142 @@
+package linuxdeps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+)
+
+// DebSearchURL is where broth answers which ubuntu packages ship a file
+var DebSearchURL = "https://broth.itch.zone/debsearch/by-file-path/ubuntu"
+
+type debSearchResponse struct {
+	Packages []string `json:"packages"`
+}
+
+// DebianPackageForFile asks broth which package ships a file in the given
+// ubuntu release, like "noble", and debian architecture, like "amd64".
+// It returns an empty string if none does.
+func DebianPackageForFile(client *http.Client, codename string, debarch string, filePath string) (string, error) {
+	values := make(url.Values)
+	values.Add("file", filePath)
+	searchURL := fmt.Sprintf("%s/%s/%s?%s", DebSearchURL, codename, debarch, values.Encode())
+
+	res, err := client.Get(searchURL)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return "", nil
+	}
+
+	resBytes, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var sr debSearchResponse
+	err = json.Unmarshal(resBytes, &sr)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	for _, p := range sr.Packages {
+		if strings.HasSuffix(p, "-dbg") {
+			continue
+		}
+		if strings.HasSuffix(p, "-cross") {
+			continue
+		}
+		return p, nil
+	}
+	return "", nil
+}
+
+// DebianArch returns the debian name of an elefant architecture,
+// or an empty string if there's none.
+func DebianArch(arch string) string {
+	switch arch {
+	case "386":
+		return "i386"
+	case "amd64":
+		return "amd64"
+	case "arm64":
+		return "arm64"
+	}
+	return ""
+}
+
+var multiarchTriplets = map[string]string{
+	"386":   "i386-linux-gnu",
+	"amd64": "x86_64-linux-gnu",
+	"arm64": "aarch64-linux-gnu",
+}
+
+type packageLookup struct {
+	distro   *Distro
+	client   *http.Client
+	consumer *state.Consumer
+
+	// set after a failed request, so an offline machine
+	// doesn't wait for every missing library
+	offline bool
+}
+
+func newPackageLookup(distro *Distro, client *http.Client, consumer *state.Consumer) *packageLookup {
+	return &packageLookup{
+		distro:   distro,
+		client:   client,
+		consumer: consumer,
+	}
+}
+
+// packageFor returns what to pass to the distro's package manager to
+// install a library for the given architecture, or an empty string
+// if it's not known.
+func (pl *packageLookup) packageFor(soname string, arch string) string {
+	switch pl.distro.Family {
+	case FamilyDebian:
+		return pl.debianPackageFor(soname, arch)
+	case FamilyFedora, FamilySUSE:
+		// both dnf and zypper install packages by the sonames they provide
+		if arch == "386" {
+			return soname
+		}
+		return soname + "()(64bit)"
+	}
+	return ""
+}
+
+func (pl *packageLookup) debianPackageFor(soname string, arch string) string {
+	codename := pl.distro.UbuntuCodename
+	debarch := DebianArch(arch)
+	triplet := multiarchTriplets[arch]
+	if codename == "" || debarch == "" || pl.offline {
+		return ""
+	}
+
+	for _, dir := range []string{"/usr/lib/", "/lib/"} {
+		pkg, err := DebianPackageForFile(pl.client, codename, debarch, dir+triplet+"/"+soname)
+		if err != nil {
+			pl.consumer.Warnf("Could not look up package for (%s): %v", soname, err)
+			pl.offline = true
+			return ""
+		}
+		if pkg != "" {
+			if arch == "386" {
+				// 32-bit games on 64-bit systems need multiarch packages
+				pkg += ":i386"
+			}
+			return pkg
+		}
+	}
+	return ""
+}
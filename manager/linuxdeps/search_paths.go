@@ -0,0 +1,49 @@
+package linuxdeps
+
+import (
+	"os"
+	"strings"
+)
+
+// Folders the dynamic linker looks into even when ld.so.conf, which
+// elefant reads, doesn't list them.
+var wellKnownSearchPaths = []string{
+	"/lib",
+	"/usr/lib",
+	"/lib64",
+	"/usr/lib64",
+	"/lib32",
+	"/usr/lib32",
+	"/lib/x86_64-linux-gnu",
+	"/usr/lib/x86_64-linux-gnu",
+	"/lib/i386-linux-gnu",
+	"/usr/lib/i386-linux-gnu",
+	"/lib/aarch64-linux-gnu",
+	"/usr/lib/aarch64-linux-gnu",
+}
+
+// fallbackSearchPaths returns the folders to look into for libraries
+// elefant couldn't find: LD_LIBRARY_PATH, then well-known ones.
+func fallbackSearchPaths() []string {
+	var paths []string
+	for _, dir := range strings.Split(os.Getenv("LD_LIBRARY_PATH"), ":") {
+		if dir != "" {
+			paths = append(paths, dir)
+		}
+	}
+	paths = append(paths, wellKnownSearchPaths...)
+	return dedupPaths(paths)
+}
+
+func dedupPaths(paths []string) []string {
+	seen := make(map[string]bool)
+	var res []string
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		res = append(res, path)
+	}
+	return res
+}
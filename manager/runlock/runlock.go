@@ -12,6 +12,7 @@ import (
 
 	"github.com/itchio/headway/state"
 	"github.com/itchio/wharf/werrors"
+	"github.com/pkg/errors"
 )
 
 type Lock interface {
@@ -28,6 +29,24 @@ type runlockPayload struct {
 	Task      string `json:"task"`
 	LockedAt  string `json:"lockedAt"`
 	ButlerPID int64  `json:"butlerPID"`
+	// Set so that locks taken from another machine, when the
+	// install folder is on a network share, aren't considered stale
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// Status describes who holds the lock of an install folder.
+type Status struct {
+	Task      string
+	LockedAt  time.Time
+	ButlerPID int64
+	Hostname  string
+
+	// True if the lock was taken from another machine, in which case
+	// there's no way to tell whether its owner is still running.
+	Remote bool
+
+	// True if the process that took the lock isn't running anymore.
+	Stale bool
 }
 
 func New(consumer *state.Consumer, installFolder string) Lock {
@@ -39,53 +58,27 @@ func New(consumer *state.Consumer, installFolder string) Lock {
 }
 
 func (rl *lock) Lock(ctx context.Context, task string) error {
-	printed := false
+	waiting := false
 
 	isLocked := func() bool {
-		debugf := func(f string, a ...interface{}) {
-			rl.consumer.Debugf(f, a...)
-			printed = true
-		}
-		if printed {
-			debugf = func(f string, a ...interface{}) {}
-		}
-
-		rp, _ := rl.read()
-		if rp == nil {
+		status, _ := Inspect(rl.installFolder)
+		if status == nil {
 			return false
 		}
-		debugf("Has runlock file at (%s), PID (%d)", rl.file(), rp.ButlerPID)
-		proc, _ := os.FindProcess(int(rp.ButlerPID))
-		if proc != nil {
-			debugf("Got a process handle, %#v", proc)
-
-			if runtime.GOOS == "windows" {
-				debugf("...on Windows, that means the process is still running")
-			} else {
-				debugf("...trying to poke it with a 0 signal")
-				err := proc.Signal(syscall.Signal(0))
-				if err != nil {
-					debugf("Got error while signalling PID (%d), assuming dead: %#v", rp.ButlerPID, err)
-
-					// not running anymore
-					rl.Unlock()
-					return false
-				}
-			}
-
-			debugf("PID (%d) still running!", rp.ButlerPID)
-			proc.Release()
-		} else {
-			debugf("Didn't get a process handle, assuming dead")
 
-			// not running anymore
+		if status.Stale {
+			rl.consumer.Warnf("Breaking stale lock (%s) for %s: PID (%d) isn't running anymore", rl.file(), status.Task, status.ButlerPID)
 			rl.Unlock()
 			return false
 		}
 
-		if !printed {
-			printed = true
-			rl.consumer.Debugf("Waiting (%s) for %s", rl.file(), task)
+		if !waiting {
+			waiting = true
+			if status.Remote {
+				rl.consumer.Debugf("Waiting (%s) for %s, locked by %s on (%s) since %s", rl.file(), task, status.Task, status.Hostname, status.LockedAt)
+			} else {
+				rl.consumer.Debugf("Waiting (%s) for %s, locked by %s with PID (%d) since %s", rl.file(), task, status.Task, status.ButlerPID, status.LockedAt)
+			}
 		}
 		return true
 	}
@@ -105,10 +98,12 @@ func (rl *lock) Lock(ctx context.Context, task string) error {
 	}
 
 	rl.consumer.Debugf("Locking (%s) for %s", rl.file(), task)
+	hostname, _ := os.Hostname()
 	return rl.write(&runlockPayload{
 		Task:      task,
 		LockedAt:  time.Now().Format(time.RFC3339Nano),
 		ButlerPID: int64(os.Getpid()),
+		Hostname:  hostname,
 	})
 }
 
@@ -117,7 +112,59 @@ func (rl *lock) Unlock() error {
 }
 
 func (rl *lock) file() string {
-	return filepath.Join(rl.installFolder, ".itch", "runlock.json")
+	return lockFile(rl.installFolder)
+}
+
+func lockFile(installFolder string) string {
+	return filepath.Join(installFolder, ".itch", "runlock.json")
+}
+
+// Inspect returns the status of an install folder's lock, or nil
+// if it's not locked.
+func Inspect(installFolder string) (*Status, error) {
+	rp, err := readPayload(lockFile(installFolder))
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	status := &Status{
+		Task:      rp.Task,
+		ButlerPID: rp.ButlerPID,
+		Hostname:  rp.Hostname,
+	}
+	status.LockedAt, _ = time.Parse(time.RFC3339Nano, rp.LockedAt)
+
+	// locks written by older versions don't have a hostname, they're
+	// assumed to be from this machine
+	hostname, _ := os.Hostname()
+	if rp.Hostname != "" && hostname != "" && rp.Hostname != hostname {
+		status.Remote = true
+	} else {
+		status.Stale = !processAlive(rp.ButlerPID)
+	}
+	return status, nil
+}
+
+// ForceUnlock removes an install folder's lock, whoever holds it.
+func ForceUnlock(installFolder string) error {
+	return os.RemoveAll(lockFile(installFolder))
+}
+
+func processAlive(pid int64) bool {
+	proc, _ := os.FindProcess(int(pid))
+	if proc == nil {
+		return false
+	}
+	defer proc.Release()
+
+	if runtime.GOOS == "windows" {
+		// on Windows, getting a process handle means it's still running
+		return true
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
 }
 
 func (rl *lock) write(rp *runlockPayload) error {
@@ -134,15 +181,15 @@ func (rl *lock) write(rp *runlockPayload) error {
 	return ioutil.WriteFile(file, contents, 0o644)
 }
 
-func (rl *lock) read() (*runlockPayload, error) {
+func readPayload(file string) (*runlockPayload, error) {
 	var rp runlockPayload
-	contents, err := ioutil.ReadFile(rl.file())
+	contents, err := ioutil.ReadFile(file)
 	if err != nil {
-		return nil, err
+		return nil, errors.WithStack(err)
 	}
 	err = json.Unmarshal(contents, &rp)
 	if err != nil {
-		return nil, err
+		return nil, errors.WithMessage(err, "parsing runlock")
 	}
 	return &rp, nil
 }
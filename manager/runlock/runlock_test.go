@@ -2,7 +2,11 @@ package runlock_test
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -64,3 +68,83 @@ func Test_Runlock(t *testing.T) {
 		"r2-lock",
 	}, steps)
 }
+
+func writeRunlock(t *testing.T, installFolder string, contents string) {
+	wtest.Must(t, os.MkdirAll(filepath.Join(installFolder, ".itch"), 0o755))
+	wtest.Must(t, ioutil.WriteFile(filepath.Join(installFolder, ".itch", "runlock.json"), []byte(contents), 0o644))
+}
+
+func deadPID(t *testing.T) int {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	wtest.Must(t, cmd.Run())
+	return cmd.Process.Pid
+}
+
+func Test_RunlockStale(t *testing.T) {
+	assert := assert.New(t)
+
+	installFolder, err := ioutil.TempDir("", "runlock-test-stale")
+	wtest.Must(t, err)
+	defer os.RemoveAll(installFolder)
+
+	status, err := runlock.Inspect(installFolder)
+	wtest.Must(t, err)
+	assert.Nil(status)
+
+	hostname, _ := os.Hostname()
+	writeRunlock(t, installFolder, fmt.Sprintf(`{"task":"install","lockedAt":"2024-01-02T03:04:05Z","butlerPID":%d,"hostname":%q}`, deadPID(t), hostname))
+
+	status, err = runlock.Inspect(installFolder)
+	wtest.Must(t, err)
+	assert.EqualValues("install", status.Task)
+	assert.EqualValues(2024, status.LockedAt.Year())
+	assert.False(status.Remote)
+	assert.True(status.Stale)
+
+	var warnings []string
+	consumer := &state.Consumer{
+		OnMessage: func(lvl string, msg string) {
+			if lvl == "warning" {
+				warnings = append(warnings, msg)
+			}
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	rl := runlock.New(consumer, installFolder)
+	wtest.Must(t, rl.Lock(ctx, "launch"))
+	assert.Len(warnings, 1)
+
+	status, err = runlock.Inspect(installFolder)
+	wtest.Must(t, err)
+	assert.EqualValues("launch", status.Task)
+	assert.EqualValues(os.Getpid(), status.ButlerPID)
+	assert.False(status.Stale)
+	wtest.Must(t, rl.Unlock())
+}
+
+func Test_RunlockRemote(t *testing.T) {
+	assert := assert.New(t)
+
+	installFolder, err := ioutil.TempDir("", "runlock-test-remote")
+	wtest.Must(t, err)
+	defer os.RemoveAll(installFolder)
+
+	writeRunlock(t, installFolder, fmt.Sprintf(`{"task":"install","lockedAt":"2024-01-02T03:04:05Z","butlerPID":%d,"hostname":"some-other-machine"}`, deadPID(t)))
+
+	status, err := runlock.Inspect(installFolder)
+	wtest.Must(t, err)
+	assert.True(status.Remote)
+	assert.False(status.Stale)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	rl := runlock.New(&state.Consumer{}, installFolder)
+	assert.Error(rl.Lock(ctx, "launch"))
+
+	wtest.Must(t, runlock.ForceUnlock(installFolder))
+	status, err = runlock.Inspect(installFolder)
+	wtest.Must(t, err)
+	assert.Nil(status)
+}
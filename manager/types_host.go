@@ -10,6 +10,13 @@ type Host struct {
 	// os + arch, e.g. windows-i386, linux-amd64
 	Runtime ox.Runtime `json:"runtime"`
 
+	// ID of the user-defined compat host, empty for native
+	// and automatically detected hosts
+	ID string `json:"id,omitempty"`
+
+	// Human-readable name of the user-defined compat host
+	Name string `json:"name,omitempty"`
+
 	// wrapper tool (wine, etc.) that butler can launch itself
 	Wrapper *Wrapper `json:"wrapper,omitempty"`
 
@@ -0,0 +1,144 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+
+	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/headway/state"
+)
+
+// UploadDecision records what narrowing down did with an upload: why it
+// was filtered out, or how it ranks among the ones that were kept.
+type UploadDecision struct {
+	Upload *itchio.Upload
+
+	// Position among kept uploads, 1 being the best match.
+	// 0 if the upload was filtered out.
+	Rank int64
+
+	// Why the upload was filtered out, empty if it was kept
+	ExcludedBecause UploadExclusion
+
+	// Human-readable details on why the upload was filtered out
+	ExcludedDetail string
+
+	// Sum of the score components, only set for kept uploads
+	Score           int64
+	ScoreComponents []*UploadScoreComponent
+
+	// Hosts that can run the upload, natively or through a wrapper
+	CompatibleHosts []string
+}
+
+type UploadScoreComponent struct {
+	Name   string
+	Points int64
+}
+
+type UploadExclusion string
+
+const (
+	UploadExclusionUntagged      UploadExclusion = "untagged"
+	UploadExclusionWrongPlatform UploadExclusion = "wrong-platform"
+	UploadExclusionWrongFormat   UploadExclusion = "wrong-format"
+	UploadExclusionWrongArch     UploadExclusion = "wrong-arch"
+)
+
+// ExplainUploads narrows down uploads like NarrowDownUploads, and also
+// returns what it decided for each upload, in their original order.
+func ExplainUploads(consumer *state.Consumer, game *itchio.Game, uploads []*itchio.Upload, runtimeEnum HostEnumerator, rules []*UploadRule) (*NarrowDownUploadsResult, []*UploadDecision, error) {
+	uf, err := newUploadFilter(consumer, game, runtimeEnum, rules)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	uf.decisions = make(map[*itchio.Upload]*UploadDecision, len(uploads))
+	var decisions []*UploadDecision
+	for _, u := range uploads {
+		d := &UploadDecision{
+			Upload: u,
+		}
+		for _, h := range uf.runtimes {
+			if IsCompatible(u.Platforms, h.Runtime) {
+				d.CompatibleHosts = append(d.CompatibleHosts, h.String())
+			}
+		}
+		uf.decisions[u] = d
+		decisions = append(decisions, d)
+	}
+
+	res := uf.narrowDownUploads(uploads)
+	return res, decisions, nil
+}
+
+// recordExclusions calls f with the decision of every upload of before
+// that isn't in after, when decisions are being recorded.
+func (uf *uploadFilter) recordExclusions(before []*itchio.Upload, after []*itchio.Upload, f func(u *itchio.Upload, d *UploadDecision)) {
+	if uf.decisions == nil {
+		return
+	}
+
+	kept := make(map[*itchio.Upload]bool, len(after))
+	for _, u := range after {
+		kept[u] = true
+	}
+	for _, u := range before {
+		if !kept[u] {
+			f(u, uf.decisions[u])
+		}
+	}
+}
+
+func (uf *uploadFilter) recordRanks(scored []*scoredUpload) {
+	if uf.decisions == nil {
+		return
+	}
+
+	for i, su := range scored {
+		d := uf.decisions[su.upload]
+		d.Rank = int64(i + 1)
+		d.Score = su.score
+		d.ScoreComponents = su.components
+	}
+}
+
+func (uf *uploadFilter) explainWrongPlatform(u *itchio.Upload, d *UploadDecision) {
+	tagged := taggedPlatforms(u.Platforms)
+	if len(tagged) == 0 {
+		d.ExcludedBecause = UploadExclusionUntagged
+		d.ExcludedDetail = "not tagged for any platform"
+		return
+	}
+
+	var hostNames []string
+	for _, h := range uf.runtimes {
+		hostNames = append(hostNames, h.String())
+	}
+	d.ExcludedBecause = UploadExclusionWrongPlatform
+	d.ExcludedDetail = fmt.Sprintf("tagged for %s, this machine runs %s", strings.Join(tagged, ", "), strings.Join(hostNames, ", "))
+}
+
+func explainWrongFormat(u *itchio.Upload, d *UploadDecision) {
+	d.ExcludedBecause = UploadExclusionWrongFormat
+	d.ExcludedDetail = "package manager formats (.rpm, .deb, .pkg) can't be installed"
+}
+
+func explainWrongArch(u *itchio.Upload, d *UploadDecision) {
+	d.ExcludedBecause = UploadExclusionWrongArch
+	d.ExcludedDetail = "there's an upload for an architecture that better matches this machine"
+}
+
+func taggedPlatforms(p itchio.Platforms) []string {
+	var res []string
+	if p.Windows != "" {
+		res = append(res, "windows")
+	}
+	if p.Linux != "" {
+		res = append(res, "linux")
+	}
+	if p.OSX != "" {
+		res = append(res, "osx")
+	}
+	return res
+}
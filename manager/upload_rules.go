@@ -0,0 +1,262 @@
+package manager
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	itchio "github.com/itchio/go-itchio"
+	"github.com/itchio/headway/state"
+	"github.com/itchio/ox"
+	"github.com/pkg/errors"
+)
+
+type UploadRuleAction string
+
+const (
+	UploadRuleActionPrefer UploadRuleAction = "prefer"
+	UploadRuleActionAvoid  UploadRuleAction = "avoid"
+)
+
+// Points a rule adds or removes when it doesn't specify a weight. It's
+// enough to override format and exclusivity preferences, but not to
+// pick a soundtrack over something that can be launched.
+const defaultUploadRuleWeight = 300
+
+// UploadRule is a user preference that moves the uploads it matches up
+// (or down) when picking among compatible ones. Every criterion that is
+// set must match.
+type UploadRule struct {
+	Action UploadRuleAction `json:"action"`
+
+	// Upload type, like "default", "soundtrack" or "book"
+	Type string `json:"type,omitempty"`
+
+	// Regular expression matched against the file name and the display
+	// name, case-insensitively
+	NamePattern string `json:"namePattern,omitempty"`
+
+	// "windows", "linux" or "osx"
+	Platform string `json:"platform,omitempty"`
+
+	// "386" or "amd64". A preferred architecture keeps uploads for it
+	// even when there are uploads that better match this machine.
+	Arch string `json:"arch,omitempty"`
+
+	// File extension, like "zip" or "tar.gz"
+	Format string `json:"format,omitempty"`
+
+	// Kind of file, guessed from its name: "archive", "msi", "exe"
+	// or "dmg"
+	InstallerType string `json:"installerType,omitempty"`
+
+	// Only match demos
+	Demo bool `json:"demo,omitempty"`
+
+	// Points added or removed, defaults to 300
+	Weight int64 `json:"weight,omitempty"`
+}
+
+func (r *UploadRule) Validate() error {
+	_, err := r.compile()
+	return err
+}
+
+func (r *UploadRule) validate() error {
+	switch r.Action {
+	case UploadRuleActionPrefer, UploadRuleActionAvoid:
+	default:
+		return errors.Errorf("action must be prefer or avoid, got (%s)", r.Action)
+	}
+
+	switch r.Platform {
+	case "", "windows", "linux", "osx":
+	default:
+		return errors.Errorf("platform must be windows, linux or osx, got (%s)", r.Platform)
+	}
+
+	switch r.Arch {
+	case "", string(itchio.Architectures386), string(itchio.ArchitecturesAmd64):
+	default:
+		return errors.Errorf("arch must be 386 or amd64, got (%s)", r.Arch)
+	}
+
+	switch r.InstallerType {
+	case "", "archive", "msi", "exe", "dmg":
+	default:
+		return errors.Errorf("installerType must be archive, msi, exe or dmg, got (%s)", r.InstallerType)
+	}
+
+	if r.Weight < 0 {
+		return errors.Errorf("weight can't be negative, use the avoid action instead")
+	}
+
+	if r.Type == "" && r.NamePattern == "" && r.Platform == "" && r.Arch == "" && r.Format == "" && r.InstallerType == "" && !r.Demo {
+		return errors.New("rule matches every upload, set at least one criterion")
+	}
+	return nil
+}
+
+// Points returns what the rule adds to the score of uploads it matches
+func (r *UploadRule) Points() int64 {
+	weight := r.Weight
+	if weight == 0 {
+		weight = defaultUploadRuleWeight
+	}
+	if r.Action == UploadRuleActionAvoid {
+		return -weight
+	}
+	return weight
+}
+
+// String describes the rule, like "prefer arch=386 format=zip"
+func (r *UploadRule) String() string {
+	parts := []string{string(r.Action)}
+	add := func(name string, value string) {
+		if value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%s", name, value))
+		}
+	}
+	add("type", r.Type)
+	add("name", r.NamePattern)
+	add("platform", r.Platform)
+	add("arch", r.Arch)
+	add("format", r.Format)
+	add("installerType", r.InstallerType)
+	if r.Demo {
+		parts = append(parts, "demo")
+	}
+	return strings.Join(parts, " ")
+}
+
+// UploadRuleMatch records that a rule applied to an upload
+type UploadRuleMatch struct {
+	UploadID int64       `json:"uploadId"`
+	Rule     *UploadRule `json:"rule"`
+}
+
+var archiveFormatRegexp = regexp.MustCompile(`\.(zip|7z|rar|tar|tgz|tar\.(gz|bz2|xz))$`)
+
+// GuessInstallerType guesses what kind of file an upload is from its
+// name: "archive", "msi", "exe", "dmg", or an empty string.
+func GuessInstallerType(filename string) string {
+	filename = strings.ToLower(filename)
+	switch {
+	case archiveFormatRegexp.MatchString(filename):
+		return "archive"
+	case strings.HasSuffix(filename, ".msi"):
+		return "msi"
+	case strings.HasSuffix(filename, ".exe"):
+		return "exe"
+	case strings.HasSuffix(filename, ".dmg"):
+		return "dmg"
+	}
+	return ""
+}
+
+func taggedFor(p itchio.Platforms, platform string) bool {
+	switch platform {
+	case "windows":
+		return p.Windows != ""
+	case "linux":
+		return p.Linux != ""
+	case "osx":
+		return p.OSX != ""
+	}
+	return false
+}
+
+// builtFor returns true if the upload is built for arch on platform,
+// or on any platform if it's empty
+func builtFor(p itchio.Platforms, platform string, arch itchio.Architectures) bool {
+	switch platform {
+	case "windows":
+		return p.Windows == arch
+	case "linux":
+		return p.Linux == arch
+	case "osx":
+		return p.OSX == arch
+	}
+	return p.Windows == arch || p.Linux == arch || p.OSX == arch
+}
+
+// validRules compiles the rules that pass validation, and warns about
+// the others
+func validRules(consumer *state.Consumer, rules []*UploadRule) []*compiledUploadRule {
+	var res []*compiledUploadRule
+	for _, r := range rules {
+		cr, err := r.compile()
+		if err != nil {
+			consumer.Warnf("Ignoring upload rule (%s): %v", r, err)
+			continue
+		}
+		res = append(res, cr)
+	}
+	return res
+}
+
+// matchingRules returns the rules that match u
+func (uf *uploadFilter) matchingRules(u *itchio.Upload) []*compiledUploadRule {
+	var res []*compiledUploadRule
+	for _, r := range uf.rules {
+		if r.matches(u) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// keepPreferredArch adds back uploads that were filtered out of kept but
+// match a prefer rule for their architecture, as long as one of the hosts
+// can run them, preserving the order of all.
+func (uf *uploadFilter) keepPreferredArch(all []*itchio.Upload, kept []*itchio.Upload) []*itchio.Upload {
+	if len(uf.rules) == 0 || len(kept) == len(all) {
+		return kept
+	}
+
+	keep := make(map[*itchio.Upload]bool, len(all))
+	for _, u := range kept {
+		keep[u] = true
+	}
+	for _, u := range all {
+		if keep[u] || !uf.canRunArch(u) {
+			continue
+		}
+		for _, r := range uf.matchingRules(u) {
+			if r.Action == UploadRuleActionPrefer && r.Arch != "" {
+				uf.consumer.Debugf("Keeping upload %d, preferred by rule (%s)", u.ID, r)
+				keep[u] = true
+				break
+			}
+		}
+	}
+
+	var res []*itchio.Upload
+	for _, u := range all {
+		if keep[u] {
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+// canRunArch returns true if one of the hosts can run u's build for its
+// platform: 64-bit hosts run 386 builds, 32-bit hosts can't run amd64 ones.
+func (uf *uploadFilter) canRunArch(u *itchio.Upload) bool {
+	for _, h := range uf.runtimes {
+		var arch itchio.Architectures
+		switch h.Runtime.Platform {
+		case ox.PlatformWindows:
+			arch = u.Platforms.Windows
+		case ox.PlatformLinux:
+			arch = u.Platforms.Linux
+		case ox.PlatformOSX:
+			arch = u.Platforms.OSX
+		}
+		if arch == "" || (arch == itchio.ArchitecturesAmd64 && !h.Runtime.Is64) {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"fmt"
 	"regexp"
 	"sort"
 	"strings"
@@ -14,6 +15,10 @@ type uploadFilter struct {
 	consumer *state.Consumer
 	runtimes Hosts
 	game     *itchio.Game
+	rules    []*compiledUploadRule
+
+	// when set, narrowDownUploads records what it does with each upload
+	decisions map[*itchio.Upload]*UploadDecision
 }
 
 type NarrowDownUploadsResult struct {
@@ -24,9 +29,24 @@ type NarrowDownUploadsResult struct {
 	IncompatibleUploads []*itchio.Upload
 	HadWrongFormat      bool
 	HadWrongArch        bool
+	// User preference rules that applied to uploads, nil if none did
+	MatchedRules []*UploadRuleMatch
+}
+
+// NarrowDownUploads filters out uploads that can't be installed on the
+// given hosts, and sorts the rest best match first. User preference
+// rules move uploads up or down, invalid ones are ignored.
+func NarrowDownUploads(consumer *state.Consumer, game *itchio.Game, uploads []*itchio.Upload, runtimeEnum HostEnumerator, rules []*UploadRule) (*NarrowDownUploadsResult, error) {
+	uf, err := newUploadFilter(consumer, game, runtimeEnum, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	res := uf.narrowDownUploads(uploads)
+	return res, nil
 }
 
-func NarrowDownUploads(consumer *state.Consumer, game *itchio.Game, uploads []*itchio.Upload, runtimeEnum HostEnumerator) (*NarrowDownUploadsResult, error) {
+func newUploadFilter(consumer *state.Consumer, game *itchio.Game, runtimeEnum HostEnumerator, rules []*UploadRule) (*uploadFilter, error) {
 	runtimes, err := runtimeEnum.Enumerate(consumer)
 	if err != nil {
 		return nil, err
@@ -40,21 +60,36 @@ func NarrowDownUploads(consumer *state.Consumer, game *itchio.Game, uploads []*i
 		consumer: consumer,
 		runtimes: runtimes,
 		game:     game,
+		rules:    validRules(consumer, rules),
 	}
-
-	res := uf.narrowDownUploads(uploads)
-	return res, nil
+	return uf, nil
 }
 
 func (uf *uploadFilter) narrowDownUploads(uploads []*itchio.Upload) *NarrowDownUploadsResult {
 	platformUploads := uf.excludeWrongPlatform(uploads)
+	uf.recordExclusions(uploads, platformUploads, uf.explainWrongPlatform)
+
 	formatUploads := uf.excludeWrongFormat(platformUploads)
+	uf.recordExclusions(platformUploads, formatUploads, explainWrongFormat)
 	hadWrongFormat := len(formatUploads) < len(platformUploads)
 
-	archUploads := uf.excludeWrongArch(formatUploads)
+	archUploads := uf.keepPreferredArch(formatUploads, uf.excludeWrongArch(formatUploads))
+	uf.recordExclusions(formatUploads, archUploads, explainWrongArch)
 	hadWrongArch := len(archUploads) < len(formatUploads)
 
-	sortedUploads := uf.sortUploads(archUploads)
+	scoredUploads := uf.scoreUploads(archUploads)
+	uf.recordRanks(scoredUploads)
+	var sortedUploads []*itchio.Upload
+	for _, su := range scoredUploads {
+		sortedUploads = append(sortedUploads, su.upload)
+	}
+
+	var matchedRules []*UploadRuleMatch
+	for _, u := range sortedUploads {
+		for _, r := range uf.matchingRules(u) {
+			matchedRules = append(matchedRules, &UploadRuleMatch{UploadID: u.ID, Rule: r.UploadRule})
+		}
+	}
 
 	// everything that didn't survive narrowing, in original order
 	kept := make(map[*itchio.Upload]bool, len(sortedUploads))
@@ -74,6 +109,7 @@ func (uf *uploadFilter) narrowDownUploads(uploads []*itchio.Upload) *NarrowDownU
 		IncompatibleUploads: incompatibleUploads,
 		HadWrongFormat:      hadWrongFormat,
 		HadWrongArch:        hadWrongArch,
+		MatchedRules:        matchedRules,
 	}
 }
 
@@ -131,8 +167,9 @@ func (uf *uploadFilter) excludeWrongFormat(uploads []*itchio.Upload) []*itchio.U
 }
 
 type scoredUpload struct {
-	score  int64
-	upload *itchio.Upload
+	score      int64
+	components []*UploadScoreComponent
+	upload     *itchio.Upload
 }
 
 var (
@@ -142,32 +179,42 @@ var (
 
 func (uf *uploadFilter) scoreUpload(upload *itchio.Upload, index int) *scoredUpload {
 	filename := strings.ToLower(upload.Filename)
-	var score int64 = 500 - int64(index)
+	su := &scoredUpload{
+		upload: upload,
+	}
+	add := func(name string, points int64) {
+		su.score += points
+		su.components = append(su.components, &UploadScoreComponent{Name: name, Points: points})
+	}
+
+	// Earlier uploads on the game page first
+	add("order", 500-int64(index))
 
 	if preferredFormatRegexp.MatchString(filename) {
 		// Preferred formats
-		score += 100
+		add("preferred-format", 100)
 	} else if usuallySourceFormatRegexp.MatchString(filename) {
 		// Usually not what you want (usually set of sources on Linux)
-		score -= 100
+		add("source-format", -100)
 	}
 
 	// We prefer things we can launch
 	if upload.Type == "default" {
-		score += 400
+		add("launchable", 400)
 	}
 
 	// Demos are penalized (if we have access to non-demo files)
 	if upload.Demo {
-		score -= 500
+		add("demo", -500)
 	}
 
-	score += ExclusivityScore(upload.Platforms)
+	add("exclusivity", ExclusivityScore(upload.Platforms))
 
-	return &scoredUpload{
-		score:  score,
-		upload: upload,
+	for _, r := range uf.matchingRules(upload) {
+		add("rule: "+r.String(), r.Points())
 	}
+
+	return su
 }
 
 type highestScoreFirst struct {
@@ -188,7 +235,8 @@ func (hsf *highestScoreFirst) Swap(i, j int) {
 	hsf.els[i], hsf.els[j] = hsf.els[j], hsf.els[i]
 }
 
-func (uf *uploadFilter) sortUploads(uploads []*itchio.Upload) []*itchio.Upload {
+// scoreUploads returns uploads scored, highest score first
+func (uf *uploadFilter) scoreUploads(uploads []*itchio.Upload) []*scoredUpload {
 	var scoredUploads []*scoredUpload
 
 	for index, u := range uploads {
@@ -196,13 +244,7 @@ func (uf *uploadFilter) sortUploads(uploads []*itchio.Upload) []*itchio.Upload {
 	}
 
 	sort.Stable(&highestScoreFirst{scoredUploads})
-
-	var res []*itchio.Upload
-	for _, su := range scoredUploads {
-		res = append(res, su.upload)
-	}
-
-	return res
+	return scoredUploads
 }
 
 func (uf *uploadFilter) excludeWrongArch(uploads []*itchio.Upload) []*itchio.Upload {
@@ -275,3 +317,34 @@ func hasUploadsMatching(uploads []*itchio.Upload, f func(u *itchio.Upload) bool)
 
 	return false
 }
+
+// PickUploadUnattended picks among narrowed-down uploads (best match
+// first) without asking anyone, for batch installs. If platform is set
+// ("windows", "linux" or "osx"), only uploads tagged for it are
+// considered. If strict is set, nothing is picked when more than one
+// upload is left. When nothing is picked, the second value says why.
+func PickUploadUnattended(uploads []*itchio.Upload, platform string, strict bool) (*itchio.Upload, string) {
+	if platform != "" {
+		uploads = excludeUploads(uploads, func(u *itchio.Upload) bool {
+			switch platform {
+			case "windows":
+				return u.Platforms.Windows == ""
+			case "linux":
+				return u.Platforms.Linux == ""
+			case "osx":
+				return u.Platforms.OSX == ""
+			}
+			return true
+		})
+	}
+
+	switch {
+	case len(uploads) == 0 && platform != "":
+		return nil, fmt.Sprintf("no compatible upload for %s", platform)
+	case len(uploads) == 0:
+		return nil, "no compatible upload"
+	case len(uploads) > 1 && strict:
+		return nil, fmt.Sprintf("%d compatible uploads, can't pick one", len(uploads))
+	}
+	return uploads[0], ""
+}
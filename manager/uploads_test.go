@@ -29,7 +29,7 @@ func Test_NarrowDownUploads_FormatBlacklist(t *testing.T) {
 	}
 
 	ndu := func(uploads []*itchio.Upload, runtime ox.Runtime) *manager.NarrowDownUploadsResult {
-		res, err := manager.NarrowDownUploads(consumer, game, uploads, manager.SingleHostEnumerator(runtime))
+		res, err := manager.NarrowDownUploads(consumer, game, uploads, manager.SingleHostEnumerator(runtime), nil)
 		wtest.Must(t, err)
 		return res
 	}
@@ -69,7 +69,7 @@ func Test_NarrowDownUploads(t *testing.T) {
 	}
 
 	ndu := func(uploads []*itchio.Upload, runtime ox.Runtime) *manager.NarrowDownUploadsResult {
-		res, err := manager.NarrowDownUploads(consumer, game, uploads, manager.SingleHostEnumerator(runtime))
+		res, err := manager.NarrowDownUploads(consumer, game, uploads, manager.SingleHostEnumerator(runtime), nil)
 		wtest.Must(t, err)
 		return res
 	}
@@ -361,3 +361,227 @@ func Test_NarrowDownUploads(t *testing.T) {
 		}, ndu(bothWindowsUploads, windows32), "do exclude 64-bit on 32-bit windows, if we have both")
 	}
 }
+
+func Test_PickUploadUnattended(t *testing.T) {
+	windows := &itchio.Upload{ID: 1, Platforms: itchio.Platforms{Windows: "all"}}
+	linux := &itchio.Upload{ID: 2, Platforms: itchio.Platforms{Linux: "all"}}
+	both := &itchio.Upload{ID: 3, Platforms: itchio.Platforms{Windows: "all", Linux: "all"}}
+
+	u, reason := manager.PickUploadUnattended([]*itchio.Upload{windows, linux}, "", false)
+	assert.EqualValues(t, windows, u, "best match goes first")
+	assert.EqualValues(t, "", reason)
+
+	u, _ = manager.PickUploadUnattended([]*itchio.Upload{windows, linux, both}, "linux", false)
+	assert.EqualValues(t, linux, u, "only uploads for platform")
+
+	u, reason = manager.PickUploadUnattended([]*itchio.Upload{windows}, "osx", false)
+	assert.Nil(t, u)
+	assert.EqualValues(t, "no compatible upload for osx", reason)
+
+	u, reason = manager.PickUploadUnattended([]*itchio.Upload{windows, both}, "", true)
+	assert.Nil(t, u, "strict skips ambiguous picks")
+	assert.EqualValues(t, "2 compatible uploads, can't pick one", reason)
+
+	u, _ = manager.PickUploadUnattended([]*itchio.Upload{windows, linux}, "linux", true)
+	assert.EqualValues(t, linux, u, "strict is fine with one upload for platform")
+
+	u, reason = manager.PickUploadUnattended(nil, "", false)
+	assert.Nil(t, u)
+	assert.EqualValues(t, "no compatible upload", reason)
+}
+
+func Test_PlatformHostEnumerator(t *testing.T) {
+	consumer := makeTestConsumer(t)
+
+	game := &itchio.Game{
+		Classification: itchio.GameClassificationGame,
+	}
+	windows := &itchio.Upload{ID: 1, Type: "default", Platforms: itchio.Platforms{Windows: "all"}}
+	linux := &itchio.Upload{ID: 2, Type: "default", Platforms: itchio.Platforms{Linux: "all"}}
+	uploads := []*itchio.Upload{windows, linux}
+
+	linuxHost := manager.SingleHostEnumerator(ox.Runtime{Platform: ox.PlatformLinux, Is64: true})
+	res, err := manager.NarrowDownUploads(consumer, game, uploads, linuxHost, nil)
+	wtest.Must(t, err)
+	assert.EqualValues(t, []*itchio.Upload{linux}, res.Uploads)
+
+	res, err = manager.NarrowDownUploads(consumer, game, uploads, manager.PlatformHostEnumerator("windows"), nil)
+	wtest.Must(t, err)
+	assert.EqualValues(t, []*itchio.Upload{windows}, res.Uploads, "uploads for another platform than ours")
+
+	u, _ := manager.PickUploadUnattended(res.Uploads, "windows", true)
+	assert.EqualValues(t, windows, u)
+}
+
+func Test_ExplainUploads(t *testing.T) {
+	consumer := makeTestConsumer(t)
+
+	game := &itchio.Game{
+		Classification: itchio.GameClassificationGame,
+	}
+	linux64 := manager.SingleHostEnumerator(ox.Runtime{
+		Platform: ox.PlatformLinux,
+		Is64:     true,
+	})
+
+	uploads := []*itchio.Upload{
+		{ID: 1, Filename: "game-windows.zip", Type: "default", Platforms: itchio.Platforms{Windows: "all"}},
+		{ID: 2, Filename: "game-linux32.tar.gz", Type: "default", Platforms: itchio.Platforms{Linux: itchio.Architectures386}},
+		{ID: 3, Filename: "game-linux64.zip", Type: "default", Platforms: itchio.Platforms{Linux: itchio.ArchitecturesAmd64}},
+		{ID: 4, Filename: "game.deb", Type: "default", Platforms: itchio.Platforms{Linux: "all"}},
+		{ID: 5, Filename: "untagged.zip", Type: "default"},
+		{ID: 6, Filename: "soundtrack.zip", Type: "soundtrack"},
+	}
+
+	narrowed, err := manager.NarrowDownUploads(consumer, game, uploads, linux64, nil)
+	wtest.Must(t, err)
+	explained, decisions, err := manager.ExplainUploads(consumer, game, uploads, linux64, nil)
+	wtest.Must(t, err)
+	assert.EqualValues(t, narrowed, explained, "explaining doesn't change narrowing")
+	assert.Len(t, decisions, len(uploads))
+
+	byID := make(map[int64]*manager.UploadDecision)
+	for i, d := range decisions {
+		assert.EqualValues(t, uploads[i], d.Upload, "original order")
+		byID[d.Upload.ID] = d
+	}
+
+	assert.EqualValues(t, manager.UploadExclusionWrongPlatform, byID[1].ExcludedBecause)
+	assert.Empty(t, byID[1].CompatibleHosts)
+	assert.EqualValues(t, manager.UploadExclusionWrongArch, byID[2].ExcludedBecause)
+	assert.EqualValues(t, manager.UploadExclusionWrongFormat, byID[4].ExcludedBecause)
+	assert.EqualValues(t, manager.UploadExclusionUntagged, byID[5].ExcludedBecause)
+	assert.NotEmpty(t, byID[3].CompatibleHosts)
+
+	// ranks match the narrowed down order, and scores add up
+	for i, u := range narrowed.Uploads {
+		e := byID[u.ID]
+		assert.EqualValues(t, i+1, e.Rank)
+		assert.EqualValues(t, "", e.ExcludedBecause)
+
+		var sum int64
+		for _, c := range e.ScoreComponents {
+			sum += c.Points
+		}
+		assert.EqualValues(t, e.Score, sum)
+	}
+	assert.EqualValues(t, 0, byID[1].Rank)
+}
+
+func Test_UploadRules(t *testing.T) {
+	consumer := makeTestConsumer(t)
+
+	game := &itchio.Game{
+		Classification: itchio.GameClassificationGame,
+	}
+	linux64 := manager.SingleHostEnumerator(ox.Runtime{
+		Platform: ox.PlatformLinux,
+		Is64:     true,
+	})
+
+	uploads := []*itchio.Upload{
+		{ID: 1, Filename: "game-linux64.zip", Type: "default", Platforms: itchio.Platforms{Linux: itchio.ArchitecturesAmd64}},
+		{ID: 2, Filename: "game-linux32.tar.gz", Type: "default", Platforms: itchio.Platforms{Linux: itchio.Architectures386}},
+		{ID: 3, Filename: "game-demo.zip", Type: "default", Demo: true, Platforms: itchio.Platforms{Linux: itchio.ArchitecturesAmd64}},
+	}
+
+	{
+		res, err := manager.NarrowDownUploads(consumer, game, uploads, linux64, nil)
+		wtest.Must(t, err)
+		assert.EqualValues(t, []int64{1, 3}, uploadIDs(res.Uploads))
+		assert.Nil(t, res.MatchedRules)
+	}
+
+	{
+		rules := []*manager.UploadRule{
+			{Action: manager.UploadRuleActionPrefer, Arch: "386"},
+		}
+		res, err := manager.NarrowDownUploads(consumer, game, uploads, linux64, rules)
+		wtest.Must(t, err)
+		assert.EqualValues(t, []int64{2, 1, 3}, uploadIDs(res.Uploads), "preferred arch is kept, and ranked first")
+		assert.Len(t, res.MatchedRules, 1)
+		assert.EqualValues(t, 2, res.MatchedRules[0].UploadID)
+
+		_, decisions, err := manager.ExplainUploads(consumer, game, uploads, linux64, rules)
+		wtest.Must(t, err)
+		assert.EqualValues(t, 1, decisions[1].Rank)
+		last := decisions[1].ScoreComponents[len(decisions[1].ScoreComponents)-1]
+		assert.EqualValues(t, "rule: prefer arch=386", last.Name)
+		assert.EqualValues(t, 300, last.Points)
+	}
+
+	{
+		rules := []*manager.UploadRule{
+			{Action: manager.UploadRuleActionAvoid, NamePattern: "LINUX64"},
+			{Action: manager.UploadRuleActionPrefer, Demo: true, Weight: 2000},
+		}
+		res, err := manager.NarrowDownUploads(consumer, game, uploads, linux64, rules)
+		wtest.Must(t, err)
+		assert.EqualValues(t, []int64{3, 1}, uploadIDs(res.Uploads))
+		assert.Len(t, res.MatchedRules, 2)
+	}
+
+	{
+		// invalid rules are ignored
+		rules := []*manager.UploadRule{
+			{Action: manager.UploadRuleActionPrefer, NamePattern: "("},
+			{Action: "love", Demo: true},
+		}
+		res, err := manager.NarrowDownUploads(consumer, game, uploads, linux64, rules)
+		wtest.Must(t, err)
+		assert.EqualValues(t, []int64{1, 3}, uploadIDs(res.Uploads))
+	}
+
+	{
+		// a 32-bit host can't run amd64 builds, preferred or not
+		linux32 := manager.SingleHostEnumerator(ox.Runtime{
+			Platform: ox.PlatformLinux,
+			Is64:     false,
+		})
+		rules := []*manager.UploadRule{
+			{Action: manager.UploadRuleActionPrefer, Arch: "amd64"},
+		}
+		res, err := manager.NarrowDownUploads(consumer, game, uploads, linux32, rules)
+		wtest.Must(t, err)
+		assert.EqualValues(t, []int64{2}, uploadIDs(res.Uploads))
+		assert.True(t, res.HadWrongArch)
+	}
+
+	{
+		// name patterns match the file name or the display name
+		named := []*itchio.Upload{
+			{ID: 1, Filename: "a.zip", DisplayName: "Windowed edition", Type: "default", Platforms: itchio.Platforms{Linux: "all"}},
+			{ID: 2, Filename: "windowed.zip", Type: "default", Platforms: itchio.Platforms{Linux: "all"}},
+			{ID: 3, Filename: "b.zip", Type: "default", Platforms: itchio.Platforms{Linux: "all"}},
+		}
+		rules := []*manager.UploadRule{
+			{Action: manager.UploadRuleActionAvoid, NamePattern: "^window"},
+		}
+		res, err := manager.NarrowDownUploads(consumer, game, named, linux64, rules)
+		wtest.Must(t, err)
+		assert.EqualValues(t, []int64{3, 1, 2}, uploadIDs(res.Uploads))
+		assert.Len(t, res.MatchedRules, 2)
+	}
+}
+
+func Test_UploadRuleValidate(t *testing.T) {
+	assert.NoError(t, (&manager.UploadRule{Action: manager.UploadRuleActionAvoid, InstallerType: "msi"}).Validate())
+	assert.Error(t, (&manager.UploadRule{Action: manager.UploadRuleActionPrefer}).Validate(), "matches everything")
+	assert.Error(t, (&manager.UploadRule{Action: "love", Format: "zip"}).Validate())
+	assert.Error(t, (&manager.UploadRule{Action: manager.UploadRuleActionPrefer, Platform: "amiga"}).Validate())
+	assert.Error(t, (&manager.UploadRule{Action: manager.UploadRuleActionPrefer, Arch: "arm64"}).Validate())
+	assert.Error(t, (&manager.UploadRule{Action: manager.UploadRuleActionPrefer, NamePattern: "["}).Validate())
+	assert.Error(t, (&manager.UploadRule{Action: manager.UploadRuleActionPrefer, Format: "zip", Weight: -1}).Validate())
+
+	assert.EqualValues(t, "archive", manager.GuessInstallerType("Game-1.2.TAR.GZ"))
+	assert.EqualValues(t, "msi", manager.GuessInstallerType("setup.msi"))
+	assert.EqualValues(t, "", manager.GuessInstallerType("game.deb"))
+}
+
+func uploadIDs(uploads []*itchio.Upload) []int64 {
+	var ids []int64
+	for _, u := range uploads {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
@@ -0,0 +1,173 @@
+package peercache
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+)
+
+var hashRegexps = map[string]*regexp.Regexp{
+	"md5":    regexp.MustCompile(`^[0-9a-f]{32}$`),
+	"sha1":   regexp.MustCompile(`^[0-9a-f]{40}$`),
+	"sha256": regexp.MustCompile(`^[0-9a-f]{64}$`),
+}
+
+// ValidKey returns true if algo is a supported hash algorithm and hash
+// a lowercase hex digest of the right length for it. Only valid keys
+// ever become paths.
+func ValidKey(algo string, hash string) bool {
+	re, ok := hashRegexps[algo]
+	return ok && re.MatchString(hash)
+}
+
+// Cache stores files in a folder, by content hash.
+type Cache struct {
+	Dir string
+
+	// Total size above which the least recently used files are
+	// removed, 0 for no limit
+	MaxSize int64
+}
+
+func (c *Cache) path(algo string, hash string) string {
+	return filepath.Join(c.Dir, algo, hash)
+}
+
+// Get returns the path of the file with the given hash, if it's cached.
+// It also counts as using it, for eviction.
+func (c *Cache) Get(algo string, hash string) (string, bool) {
+	if !ValidKey(algo, hash) {
+		return "", false
+	}
+
+	p := c.path(algo, hash)
+	stats, err := os.Stat(p)
+	if err != nil || !stats.Mode().IsRegular() {
+		return "", false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(p, now, now)
+	return p, true
+}
+
+// Add stores a copy of the file at src, which must already have been
+// verified to have the given hash. It's hard-linked when possible.
+func (c *Cache) Add(consumer *state.Consumer, src string, algo string, hash string) error {
+	if !ValidKey(algo, hash) {
+		return errors.Errorf("invalid cache key (%s:%s)", algo, hash)
+	}
+
+	p := c.path(algo, hash)
+	if _, err := os.Stat(p); err == nil {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(p), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	tmp := p + ".tmp"
+	_ = os.Remove(tmp)
+	err = os.Link(src, tmp)
+	if err != nil {
+		consumer.Debugf("Could not hard-link into peer cache, copying: %v", err)
+		err = copyFile(src, tmp)
+		if err != nil {
+			_ = os.Remove(tmp)
+			return err
+		}
+	}
+
+	err = os.Rename(tmp, p)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	now := time.Now()
+	_ = os.Chtimes(p, now, now)
+
+	return c.evict(consumer, p)
+}
+
+// evict removes the least recently used files until the cache fits
+// in MaxSize, never removing keep.
+func (c *Cache) evict(consumer *state.Consumer, keep string) error {
+	if c.MaxSize <= 0 {
+		return nil
+	}
+
+	type entry struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var entries []entry
+	var total int64
+	for algo := range hashRegexps {
+		infos, err := os.ReadDir(filepath.Join(c.Dir, algo))
+		if err != nil {
+			continue
+		}
+		for _, info := range infos {
+			if !ValidKey(algo, info.Name()) {
+				continue
+			}
+			stats, err := info.Info()
+			if err != nil {
+				continue
+			}
+			entries = append(entries, entry{
+				path:    filepath.Join(c.Dir, algo, info.Name()),
+				size:    stats.Size(),
+				modTime: stats.ModTime(),
+			})
+			total += stats.Size()
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+	for _, e := range entries {
+		if total <= c.MaxSize {
+			break
+		}
+		if e.path == keep {
+			continue
+		}
+		consumer.Debugf("Evicting (%s) from peer cache", e.path)
+		err := os.Remove(e.path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		total -= e.size
+	}
+	return nil
+}
+
+func copyFile(src string, dst string) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer r.Close()
+
+	w, err := os.Create(dst)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = io.Copy(w, r)
+	if err != nil {
+		w.Close()
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(w.Close())
+}
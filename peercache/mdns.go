@@ -0,0 +1,293 @@
+package peercache
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Just enough mDNS (RFC 6762) for butlerd instances to find each other:
+// announcers answer PTR queries for mdnsService with their SRV and A
+// records, and discovery sends one query and collects answers for a
+// little while.
+
+const mdnsService = "_butler-peercache._tcp.local."
+
+// Questions with this bit set in their class ask for a unicast reply
+const mdnsUnicastResponse = dnsmessage.Class(1 << 15)
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// How long discovery waits for answers
+const mdnsDiscoveryTimeout = time.Second
+
+type mdnsPeer struct {
+	Instance string
+	Address  string
+}
+
+func buildMDNSQuery() ([]byte, error) {
+	name, err := dnsmessage.NewName(mdnsService)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{})
+	b.EnableCompression()
+	err = b.StartQuestions()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	err = b.Question(dnsmessage.Question{
+		Name:  name,
+		Type:  dnsmessage.TypePTR,
+		Class: dnsmessage.ClassINET | mdnsUnicastResponse,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	msg, err := b.Finish()
+	return msg, errors.WithStack(err)
+}
+
+// parseMDNSQuery returns whether msg asks for our service, and whether
+// it wants a unicast reply.
+func parseMDNSQuery(msg []byte) (bool, bool) {
+	var m dnsmessage.Message
+	if err := m.Unpack(msg); err != nil || m.Response {
+		return false, false
+	}
+
+	for _, q := range m.Questions {
+		if (q.Type == dnsmessage.TypePTR || q.Type == dnsmessage.TypeALL) && strings.EqualFold(q.Name.String(), mdnsService) {
+			return true, q.Class&mdnsUnicastResponse != 0
+		}
+	}
+	return false, false
+}
+
+// buildMDNSAnswer announces instance, serving on port at ips
+func buildMDNSAnswer(instance string, port int, ips []net.IP) ([]byte, error) {
+	serviceName, err := dnsmessage.NewName(mdnsService)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	instanceName, err := dnsmessage.NewName(instance + "." + mdnsService)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	hostName, err := dnsmessage.NewName(instance + ".local.")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	header := func(name dnsmessage.Name) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{
+			Name:  name,
+			Class: dnsmessage.ClassINET,
+			TTL:   120,
+		}
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true, Authoritative: true})
+	b.EnableCompression()
+	err = b.StartAnswers()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	err = b.PTRResource(header(serviceName), dnsmessage.PTRResource{PTR: instanceName})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	err = b.SRVResource(header(instanceName), dnsmessage.SRVResource{Port: uint16(port), Target: hostName})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, ip := range ips {
+		ip4 := ip.To4()
+		if ip4 == nil {
+			continue
+		}
+		var a dnsmessage.AResource
+		copy(a.A[:], ip4)
+		err = b.AResource(header(hostName), a)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	msg, err := b.Finish()
+	return msg, errors.WithStack(err)
+}
+
+// parseMDNSAnswer returns the peers announced in msg
+func parseMDNSAnswer(msg []byte) []*mdnsPeer {
+	var m dnsmessage.Message
+	if err := m.Unpack(msg); err != nil || !m.Response {
+		return nil
+	}
+
+	type srv struct {
+		instance string
+		target   string
+		port     uint16
+	}
+	var srvs []srv
+	addrs := make(map[string][]net.IP)
+
+	// records may be in any section
+	var resources []dnsmessage.Resource
+	resources = append(resources, m.Answers...)
+	resources = append(resources, m.Authorities...)
+	resources = append(resources, m.Additionals...)
+	for _, r := range resources {
+		name := strings.ToLower(r.Header.Name.String())
+		switch body := r.Body.(type) {
+		case *dnsmessage.SRVResource:
+			if strings.HasSuffix(name, "."+mdnsService) {
+				srvs = append(srvs, srv{
+					instance: strings.TrimSuffix(name, "."+mdnsService),
+					target:   strings.ToLower(body.Target.String()),
+					port:     body.Port,
+				})
+			}
+		case *dnsmessage.AResource:
+			addrs[name] = append(addrs[name], net.IP(body.A[:]))
+		}
+	}
+
+	var peers []*mdnsPeer
+	for _, s := range srvs {
+		for _, ip := range addrs[s.target] {
+			peers = append(peers, &mdnsPeer{
+				Instance: s.instance,
+				Address:  net.JoinHostPort(ip.String(), strconv.Itoa(int(s.port))),
+			})
+		}
+	}
+	return peers
+}
+
+// mdnsAnnouncer answers queries for our service until closed
+type mdnsAnnouncer struct {
+	conn *net.UDPConn
+}
+
+func startMDNSAnnouncer(consumer *state.Consumer, instance string, port int) (*mdnsAnnouncer, error) {
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	go func() {
+		buf := make([]byte, 9000)
+		for {
+			n, src, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					consumer.Warnf("mDNS announcer stopped: %v", err)
+				}
+				return
+			}
+
+			wanted, unicast := parseMDNSQuery(buf[:n])
+			if !wanted {
+				continue
+			}
+
+			answer, err := buildMDNSAnswer(instance, port, localIPs())
+			if err != nil {
+				consumer.Warnf("Could not build mDNS answer: %v", err)
+				continue
+			}
+
+			dest := mdnsGroup
+			if unicast {
+				dest = src
+			}
+			_, err = conn.WriteToUDP(answer, dest)
+			if err != nil {
+				consumer.Debugf("Could not send mDNS answer to (%s): %v", dest, err)
+			}
+		}
+	}()
+
+	return &mdnsAnnouncer{conn: conn}, nil
+}
+
+func (a *mdnsAnnouncer) Close() error {
+	return a.conn.Close()
+}
+
+// discoverMDNS asks the network for peers, and returns those that
+// answered in time, except ownInstance.
+func discoverMDNS(ctx context.Context, ownInstance string) ([]*mdnsPeer, error) {
+	query, err := buildMDNSQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer conn.Close()
+
+	_, err = conn.WriteToUDP(query, mdnsGroup)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	deadline := time.Now().Add(mdnsDiscoveryTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	err = conn.SetReadDeadline(deadline)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var peers []*mdnsPeer
+	seen := make(map[string]bool)
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			// deadline reached
+			break
+		}
+
+		for _, peer := range parseMDNSAnswer(buf[:n]) {
+			if peer.Instance == ownInstance || seen[peer.Address] {
+				continue
+			}
+			seen[peer.Address] = true
+			peers = append(peers, peer)
+		}
+	}
+	return peers, nil
+}
+
+// localIPs returns the non-loopback IPv4 addresses of this machine
+func localIPs() []net.IP {
+	var ips []net.IP
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || ipnet.IP.To4() == nil {
+			continue
+		}
+		ips = append(ips, ipnet.IP)
+	}
+	return ips
+}
@@ -0,0 +1,280 @@
+// Package peercache lets butlerd instances on the same network get install
+// sources from each other instead of from itch.io. Files are kept and
+// served by content hash, so peers can only be asked for files whose hash
+// itch.io gave out, and whatever they send is verified against it.
+package peercache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/itchio/headway/counter"
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+)
+
+// How long peers found with mDNS are remembered before asking again
+const discoveryTTL = time.Minute
+
+// ErrNotFound is returned by Fetch when neither the local cache
+// nor any peer has a file.
+var ErrNotFound = errors.New("no peer has this file")
+
+type Params struct {
+	// Folder files are kept in, required
+	Dir string
+
+	// Total size above which the least recently used files are
+	// removed, 0 for no limit
+	MaxSize int64
+
+	// Address to serve files to peers on, like ":7676". When empty,
+	// peers are asked for files but nothing is served. Requires Secret.
+	ListenAddress string
+
+	// Peers to ask for files, as "host:port"
+	Peers []string
+
+	// Find peers with mDNS, and announce ourselves if serving
+	MDNS bool
+
+	// Shared with peers, which must prove they know it to be served.
+	// It's never sent: peers sign a challenge with it instead.
+	Secret string
+}
+
+// PeerCache keeps verified install sources, serves them to peers and
+// gets them from peers.
+type PeerCache struct {
+	params     Params
+	cache      *Cache
+	instance   string
+	httpClient *http.Client
+
+	server    *Server
+	announcer *mdnsAnnouncer
+
+	mu           sync.Mutex
+	discovered   []string
+	discoveredAt time.Time
+}
+
+func New(params Params) (*PeerCache, error) {
+	if params.Dir == "" {
+		return nil, errors.New("peer cache folder must be set")
+	}
+	if params.ListenAddress != "" && params.Secret == "" {
+		return nil, errors.New("peer cache secret must be set to serve files to peers")
+	}
+	err := os.MkdirAll(params.Dir, 0o755)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	instanceBytes := make([]byte, 8)
+	_, err = rand.Read(instanceBytes)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &PeerCache{
+		params: params,
+		cache: &Cache{
+			Dir:     params.Dir,
+			MaxSize: params.MaxSize,
+		},
+		instance: "butler-" + hex.EncodeToString(instanceBytes),
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: (&net.Dialer{
+					Timeout: 3 * time.Second,
+				}).DialContext,
+				ResponseHeaderTimeout: 10 * time.Second,
+			},
+		},
+	}, nil
+}
+
+// Start serves files to peers and announces them over mDNS, if
+// configured to. Failing to announce isn't fatal.
+func (pc *PeerCache) Start(consumer *state.Consumer) error {
+	if pc.params.ListenAddress == "" {
+		return nil
+	}
+
+	server, err := StartServer(consumer, pc.cache, pc.params.ListenAddress, pc.params.Secret)
+	if err != nil {
+		return errors.WithMessage(err, "starting peer cache server")
+	}
+	pc.server = server
+	consumer.Infof("Serving peer cache (%s) on port %d", pc.params.Dir, server.Port())
+
+	if pc.params.MDNS {
+		announcer, err := startMDNSAnnouncer(consumer, pc.instance, server.Port())
+		if err != nil {
+			consumer.Warnf("Could not announce peer cache over mDNS: %v", err)
+		} else {
+			pc.announcer = announcer
+		}
+	}
+	return nil
+}
+
+// Close stops serving and announcing
+func (pc *PeerCache) Close() error {
+	if pc.announcer != nil {
+		pc.announcer.Close()
+	}
+	if pc.server != nil {
+		return pc.server.Close()
+	}
+	return nil
+}
+
+// Add keeps a copy of a verified file for peers, see Cache.Add
+func (pc *PeerCache) Add(consumer *state.Consumer, path string, algo string, hash string) error {
+	return pc.cache.Add(consumer, path, algo, hash)
+}
+
+// Fetch writes the file with the given hash to destPath, from the local
+// cache or from the first peer that has it, and returns where it came from.
+// The file must still be verified. Returns ErrNotFound if nobody has it.
+func (pc *PeerCache) Fetch(ctx context.Context, consumer *state.Consumer, algo string, hash string, size int64, destPath string) (string, error) {
+	if !ValidKey(algo, hash) {
+		return "", errors.Errorf("invalid hash (%s:%s)", algo, hash)
+	}
+
+	if p, ok := pc.cache.Get(algo, hash); ok {
+		err := copyFile(p, destPath)
+		if err != nil {
+			return "", err
+		}
+		return "local peer cache", nil
+	}
+
+	for _, peer := range pc.peers(ctx, consumer) {
+		err := pc.fetchFromPeer(ctx, consumer, peer, algo, hash, size, destPath)
+		if err != nil {
+			if errors.Cause(err) == context.Canceled {
+				return "", err
+			}
+			consumer.Infof("Peer (%s) didn't send %s:%s: %v", peer, algo, hash, err)
+			continue
+		}
+		return peer, nil
+	}
+	return "", ErrNotFound
+}
+
+func (pc *PeerCache) fetchFromPeer(ctx context.Context, consumer *state.Consumer, peer string, algo string, hash string, size int64, destPath string) error {
+	url := fmt.Sprintf("http://%s%s%s/%s", peer, routePrefix, algo, hash)
+	res, err := pc.get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("HTTP %s", res.Status)
+	}
+	if size > 0 && res.ContentLength != size {
+		return errors.Errorf("has %d bytes, expected %d", res.ContentLength, size)
+	}
+
+	f, err := os.Create(destPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	w := counter.NewWriterCallback(func(written int64) {
+		if size > 0 {
+			consumer.Progress(float64(written) / float64(size))
+		}
+	}, f)
+	written, err := io.Copy(w, res.Body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if size > 0 && written != size {
+		return errors.Errorf("sent %d bytes, expected %d", written, size)
+	}
+	return nil
+}
+
+// get requests url from a peer, answering its challenge if it sends one
+func (pc *PeerCache) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res, err := pc.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	nonce := res.Header.Get(challengeHeader)
+	if res.StatusCode != http.StatusUnauthorized || nonce == "" {
+		return res, nil
+	}
+	res.Body.Close()
+	if pc.params.Secret == "" {
+		return nil, errors.New("asks for a secret, and none is set")
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set(authHeader, nonce+":"+signRequest(pc.params.Secret, nonce, req.Method, req.URL.Path))
+
+	res, err = pc.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return res, nil
+}
+
+// peers returns the configured peers, then those found with mDNS
+func (pc *PeerCache) peers(ctx context.Context, consumer *state.Consumer) []string {
+	peers := append([]string{}, pc.params.Peers...)
+	if !pc.params.MDNS {
+		return peers
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if time.Since(pc.discoveredAt) > discoveryTTL {
+		found, err := discoverMDNS(ctx, pc.instance)
+		if err != nil {
+			consumer.Warnf("Could not look for peers over mDNS: %v", err)
+		}
+		pc.discovered = nil
+		for _, peer := range found {
+			pc.discovered = append(pc.discovered, peer.Address)
+		}
+		pc.discoveredAt = time.Now()
+		consumer.Infof("Found %d peers over mDNS", len(pc.discovered))
+	}
+
+	seen := make(map[string]bool)
+	for _, peer := range peers {
+		seen[peer] = true
+	}
+	for _, peer := range pc.discovered {
+		if !seen[peer] {
+			peers = append(peers, peer)
+		}
+	}
+	return peers
+}
@@ -0,0 +1,239 @@
+package peercache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itchio/headway/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSource(t *testing.T, contents []byte) (string, string) {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), "source.zip")
+	require.NoError(t, os.WriteFile(p, contents, 0o644))
+	sum := sha256.Sum256(contents)
+	return p, hex.EncodeToString(sum[:])
+}
+
+func TestValidKey(t *testing.T) {
+	_, hash := writeSource(t, []byte("hi"))
+	assert.True(t, ValidKey("sha256", hash))
+	assert.False(t, ValidKey("sha1", hash), "wrong length")
+	assert.False(t, ValidKey("crc32", "00000000"))
+	assert.False(t, ValidKey("md5", "../../../../etc/passwd"))
+	assert.False(t, ValidKey("md5", "D41D8CD98F00B204E9800998ECF8427E"), "uppercase")
+}
+
+func TestCacheEviction(t *testing.T) {
+	consumer := &state.Consumer{}
+	c := &Cache{Dir: t.TempDir(), MaxSize: 25}
+
+	var hashes []string
+	for i := 0; i < 3; i++ {
+		p, hash := writeSource(t, []byte(fmt.Sprintf("file number %d", i)))
+		require.NoError(t, c.Add(consumer, p, "sha256", hash))
+		hashes = append(hashes, hash)
+
+		// mtimes need to be apart for LRU order
+		old := time.Now().Add(time.Duration(i-10) * time.Minute)
+		require.NoError(t, os.Chtimes(c.path("sha256", hash), old, old))
+	}
+
+	// each is 13 bytes, only the last one fits in 25
+	_, ok := c.Get("sha256", hashes[0])
+	assert.False(t, ok)
+	_, ok = c.Get("sha256", hashes[1])
+	assert.False(t, ok)
+	p, ok := c.Get("sha256", hashes[2])
+	require.True(t, ok)
+	contents, err := os.ReadFile(p)
+	require.NoError(t, err)
+	assert.Equal(t, "file number 2", string(contents))
+}
+
+func TestFetchFromPeer(t *testing.T) {
+	consumer := &state.Consumer{}
+	contents := []byte("twenty gigabytes, give or take")
+	src, hash := writeSource(t, contents)
+
+	server, err := New(Params{
+		Dir:           t.TempDir(),
+		ListenAddress: "127.0.0.1:0",
+		Secret:        "hunter2",
+	})
+	require.NoError(t, err)
+	require.NoError(t, server.Start(consumer))
+	t.Cleanup(func() { server.Close() })
+	require.NoError(t, server.Add(consumer, src, "sha256", hash))
+	peer := net.JoinHostPort("127.0.0.1", fmt.Sprintf("%d", server.server.Port()))
+
+	newClient := func(secret string) *PeerCache {
+		client, err := New(Params{
+			Dir:    t.TempDir(),
+			Peers:  []string{"127.0.0.1:1", peer},
+			Secret: secret,
+		})
+		require.NoError(t, err)
+		return client
+	}
+	ctx := context.Background()
+	dest := filepath.Join(t.TempDir(), "dest.zip")
+
+	{
+		// unreachable peers are skipped
+		source, err := newClient("hunter2").Fetch(ctx, consumer, "sha256", hash, int64(len(contents)), dest)
+		require.NoError(t, err)
+		assert.Equal(t, peer, source)
+		fetched, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, contents, fetched)
+	}
+
+	{
+		_, err := newClient("wrong").Fetch(ctx, consumer, "sha256", hash, int64(len(contents)), dest)
+		assert.Equal(t, ErrNotFound, err)
+		_, err = newClient("").Fetch(ctx, consumer, "sha256", hash, int64(len(contents)), dest)
+		assert.Equal(t, ErrNotFound, err)
+	}
+
+	{
+		_, other := writeSource(t, []byte("not cached anywhere"))
+		_, err := newClient("hunter2").Fetch(ctx, consumer, "sha256", other, 19, dest)
+		assert.Equal(t, ErrNotFound, err)
+	}
+
+	url := fmt.Sprintf("http://%s%ssha256/%s", peer, routePrefix, hash)
+	challenge := func() string {
+		res, err := http.Get(url)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		nonce := res.Header.Get(challengeHeader)
+		require.NotEmpty(t, nonce)
+		return nonce
+	}
+	get := func(auth string, headers ...string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		req.Header.Set(authHeader, auth)
+		for i := 0; i < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res
+	}
+
+	{
+		// ranges are supported, for resuming
+		nonce := challenge()
+		auth := nonce + ":" + signRequest("hunter2", nonce, http.MethodGet, "/peer-cache/v1/sha256/"+hash)
+		res := get(auth, "Range", "bytes=0-5")
+		assert.Equal(t, http.StatusPartialContent, res.StatusCode)
+		assert.EqualValues(t, 6, res.ContentLength)
+
+		// challenges can't be answered twice
+		res = get(auth)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	}
+
+	{
+		// challenges can't be made up, or made to last longer
+		nonce := challenge()
+		issuedAt, rest, _ := strings.Cut(nonce, ".")
+		later, err := strconv.ParseInt(issuedAt, 10, 64)
+		require.NoError(t, err)
+		forged := fmt.Sprintf("%d.%s", later+3600, rest)
+		res := get(forged + ":" + signRequest("hunter2", forged, http.MethodGet, "/peer-cache/v1/sha256/"+hash))
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	}
+
+	{
+		// unanswered challenges don't pile up and lock peers out
+		remembered := func() int {
+			server.server.mu.Lock()
+			defer server.server.mu.Unlock()
+			return len(server.server.answered)
+		}
+		before := remembered()
+		for i := 0; i < 2000; i++ {
+			challenge()
+		}
+		assert.Equal(t, before, remembered())
+		_, err := newClient("hunter2").Fetch(ctx, consumer, "sha256", hash, int64(len(contents)), dest)
+		require.NoError(t, err)
+		assert.Equal(t, before+1, remembered())
+	}
+
+	{
+		// answers are only good for the path they were made for
+		nonce := challenge()
+		_, other := writeSource(t, []byte("some other file"))
+		res := get(nonce + ":" + signRequest("hunter2", nonce, http.MethodGet, "/peer-cache/v1/sha256/"+other))
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	}
+}
+
+func TestServingRequiresSecret(t *testing.T) {
+	_, err := New(Params{
+		Dir:           t.TempDir(),
+		ListenAddress: "127.0.0.1:0",
+	})
+	assert.Error(t, err)
+
+	_, err = StartServer(&state.Consumer{}, &Cache{Dir: t.TempDir()}, "127.0.0.1:0", "")
+	assert.Error(t, err)
+}
+
+func TestFetchFromLocalCache(t *testing.T) {
+	consumer := &state.Consumer{}
+	src, hash := writeSource(t, []byte("already here"))
+
+	pc, err := New(Params{Dir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, pc.Add(consumer, src, "sha256", hash))
+
+	dest := filepath.Join(t.TempDir(), "dest.zip")
+	source, err := pc.Fetch(context.Background(), consumer, "sha256", hash, 12, dest)
+	require.NoError(t, err)
+	assert.Equal(t, "local peer cache", source)
+}
+
+func TestMDNSMessages(t *testing.T) {
+	query, err := buildMDNSQuery()
+	require.NoError(t, err)
+	wanted, unicast := parseMDNSQuery(query)
+	assert.True(t, wanted)
+	assert.True(t, unicast)
+
+	answer, err := buildMDNSAnswer("butler-abcd", 7676, []net.IP{
+		net.ParseIP("192.168.1.20"),
+		net.ParseIP("fe80::1"),
+		net.ParseIP("10.0.0.3"),
+	})
+	require.NoError(t, err)
+	wanted, _ = parseMDNSQuery(answer)
+	assert.False(t, wanted, "answers aren't queries")
+
+	peers := parseMDNSAnswer(answer)
+	require.Len(t, peers, 2)
+	assert.Equal(t, "butler-abcd", peers[0].Instance)
+	assert.Equal(t, "192.168.1.20:7676", peers[0].Address)
+	assert.Equal(t, "10.0.0.3:7676", peers[1].Address)
+
+	assert.Empty(t, parseMDNSAnswer(query))
+}
@@ -0,0 +1,232 @@
+package peercache
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itchio/headway/state"
+	"github.com/pkg/errors"
+)
+
+// Files are served at /peer-cache/v1/{algo}/{hash}
+const routePrefix = "/peer-cache/v1/"
+
+// Requests without authHeader are answered with a 401 and a nonce in
+// challengeHeader. Peers then send "{nonce}:{signature}" in authHeader,
+// see signRequest. The secret itself is never sent.
+const (
+	challengeHeader = "X-Butler-Peer-Challenge"
+	authHeader      = "X-Butler-Peer-Auth"
+)
+
+// How long a challenge can be answered for
+const challengeTTL = 30 * time.Second
+
+// Server serves the files of a cache to peers that know its secret,
+// with range support.
+type Server struct {
+	cache    *Cache
+	secret   string
+	consumer *state.Consumer
+	listener net.Listener
+	server   *http.Server
+
+	// Challenges aren't stored: they carry when they were issued,
+	// authenticated with this key. Only answered ones are remembered,
+	// so they can't be answered twice.
+	challengeKey []byte
+	mu           sync.Mutex
+	answered     map[string]time.Time
+}
+
+// StartServer listens on address and starts serving cache to peers
+// that know secret, which is required.
+func StartServer(consumer *state.Consumer, cache *Cache, address string, secret string) (*Server, error) {
+	if secret == "" {
+		return nil, errors.New("a secret is required to serve the peer cache")
+	}
+
+	challengeKey := make([]byte, 32)
+	_, err := rand.Read(challengeKey)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	s := &Server{
+		cache:        cache,
+		secret:       secret,
+		consumer:     consumer,
+		listener:     listener,
+		challengeKey: challengeKey,
+		answered:     make(map[string]time.Time),
+	}
+	s.server = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		err := s.server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			consumer.Warnf("Peer cache server stopped: %v", err)
+		}
+	}()
+
+	return s, nil
+}
+
+// Port returns the port the server listens on
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Close stops the server. In-flight requests are cut off.
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get(authHeader) == "" {
+		nonce, err := s.newChallenge()
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(challengeHeader, nonce)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !s.authorized(r) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, routePrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	algo, hash, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, routePrefix), "/")
+	if !ok || !ValidKey(algo, hash) {
+		http.NotFound(w, r)
+		return
+	}
+
+	p, ok := s.cache.Get(algo, hash)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	stats, err := f.Stat()
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodGet && r.Header.Get("Range") == "" {
+		s.consumer.Infof("Serving %s:%s to peer %s", algo, hash, r.RemoteAddr)
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, "", stats.ModTime(), f)
+}
+
+// newChallenge returns a nonce peers can sign once to be served, as
+// "{issued at}.{random}.{mac}". Nothing is stored until it's answered.
+func (s *Server) newChallenge() (string, error) {
+	randomBytes := make([]byte, 16)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	payload := fmt.Sprintf("%d.%s", time.Now().Unix(), hex.EncodeToString(randomBytes))
+	return payload + "." + s.challengeMAC(payload), nil
+}
+
+func (s *Server) challengeMAC(payload string) string {
+	mac := hmac.New(sha256.New, s.challengeKey)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// authorized checks the answer to a challenge this server issued.
+// Challenges can only be answered once.
+func (s *Server) authorized(r *http.Request) bool {
+	nonce, signature, ok := strings.Cut(r.Header.Get(authHeader), ":")
+	if !ok {
+		return false
+	}
+
+	dot := strings.LastIndexByte(nonce, '.')
+	if dot == -1 {
+		return false
+	}
+	payload, nonceMAC := nonce[:dot], nonce[dot+1:]
+	if !hmac.Equal([]byte(nonceMAC), []byte(s.challengeMAC(payload))) {
+		return false
+	}
+
+	issuedAtString, _, _ := strings.Cut(payload, ".")
+	issuedAtUnix, err := strconv.ParseInt(issuedAtString, 10, 64)
+	if err != nil {
+		return false
+	}
+	issuedAt := time.Unix(issuedAtUnix, 0)
+	if time.Since(issuedAt) > challengeTTL {
+		return false
+	}
+
+	expected := signRequest(s.secret, nonce, r.Method, r.URL.Path)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return false
+	}
+
+	// only peers that know the secret get here, so this stays small
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for answered, at := range s.answered {
+		if time.Since(at) > challengeTTL {
+			delete(s.answered, answered)
+		}
+	}
+	if _, ok := s.answered[nonce]; ok {
+		return false
+	}
+	s.answered[nonce] = issuedAt
+	return true
+}
+
+// signRequest answers a challenge for one request: it's an HMAC of the
+// nonce, method and path, keyed with the secret.
+func signRequest(secret string, nonce string, method string, path string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s", nonce, method, path)
+	return hex.EncodeToString(mac.Sum(nil))
+}
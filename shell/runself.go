@@ -126,6 +126,13 @@ func RunSelf(params RunSelfParams) (*RunSelfResult, error) {
 	cmd := exec.Command(info.SelfPath, args...)
 	parser := newParserWriter(consumer, res, params.OnResult)
 	cmd.Dir = info.WorkingDir
+	if params.Host.Wrapper != nil && len(params.Host.Wrapper.Env) > 0 {
+		// e.g. WINEPREFIX, so prereqs end up where the game runs
+		cmd.Env = os.Environ()
+		for k, v := range params.Host.Wrapper.Env {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
 	cmd.Stdout = parser
 	cmd.Stderr = loggerwriter.New(consumer, "err")
 
@@ -0,0 +1,61 @@
+package shortcut
+
+import (
+	"fmt"
+
+	"github.com/itchio/headway/state"
+)
+
+type CreateParams struct {
+	// Identifies the shortcut so it can be refreshed or removed later,
+	// caves use their ID
+	ID string
+
+	// What the user should see
+	DisplayName string
+
+	// Path to icon file
+	IconSource string
+
+	// What the shortcut should open
+	URL string
+
+	// Also place the shortcut on the user's desktop. Only supported on Linux.
+	Desktop bool
+
+	// For logging
+	Consumer *state.Consumer
+}
+
+type RemoveParams struct {
+	// Same as the one passed to Create
+	ID string
+
+	// Same as the one passed to Create
+	DisplayName string
+
+	// For logging
+	Consumer *state.Consumer
+}
+
+// CaveURL returns what shortcuts to a cave open. The app launches it
+// like it would from its library, with the cave's preferred target.
+func CaveURL(caveID string) string {
+	return fmt.Sprintf("itch://caves/%s/launch", caveID)
+}
+
+// RefreshCave re-creates a cave's shortcut if it has one, for when the
+// game, where it's installed or what it launches changes.
+func RefreshCave(consumer *state.Consumer, caveID string, displayName string) {
+	refreshed, err := Refresh(CreateParams{
+		ID:          caveID,
+		DisplayName: displayName,
+		URL:         CaveURL(caveID),
+		Consumer:    consumer,
+	})
+	if err != nil {
+		consumer.Warnf("While refreshing shortcut: %+v", err)
+	} else if refreshed {
+		consumer.Infof("Refreshed shortcut")
+	}
+}
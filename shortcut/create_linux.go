@@ -0,0 +1,263 @@
+//go:build linux
+// +build linux
+
+package shortcut
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/pkg/errors"
+)
+
+// Create writes a freedesktop desktop entry in the applications folder,
+// so the game shows up in menus, and optionally on the desktop. The icon
+// is copied next to other user icons.
+func Create(params CreateParams) error {
+	err := validation.ValidateStruct(&params,
+		validation.Field(&params.ID, validation.Required),
+		validation.Field(&params.DisplayName, validation.Required),
+		validation.Field(&params.URL, validation.Required),
+		validation.Field(&params.Consumer, validation.Required),
+	)
+	if err != nil {
+		return err
+	}
+
+	consumer := params.Consumer
+
+	iconPath, err := installIcon(params.ID, params.IconSource)
+	if err != nil {
+		consumer.Warnf("Could not install shortcut icon: %+v", err)
+	}
+
+	contents := desktopEntry(params.DisplayName, params.URL, iconPath)
+
+	entryPath := filepath.Join(dataHome(), "applications", entryName(params.ID))
+	err = writeEntry(entryPath, contents)
+	if err != nil {
+		return err
+	}
+	consumer.Infof("Created shortcut (%s)", entryPath)
+
+	if params.Desktop {
+		desktopPath := filepath.Join(desktopDir(), entryName(params.ID))
+		err = writeEntry(desktopPath, contents)
+		if err != nil {
+			return err
+		}
+		consumer.Infof("Created desktop shortcut (%s)", desktopPath)
+	}
+
+	return nil
+}
+
+// Refresh re-creates a shortcut if there is one, keeping its icon
+// unless a new one is given.
+func Refresh(params CreateParams) (bool, error) {
+	if _, err := os.Stat(filepath.Join(dataHome(), "applications", entryName(params.ID))); err != nil {
+		return false, nil
+	}
+	if _, err := os.Stat(filepath.Join(desktopDir(), entryName(params.ID))); err == nil {
+		params.Desktop = true
+	}
+	return true, Create(params)
+}
+
+func Remove(params RemoveParams) error {
+	if params.ID == "" {
+		return errors.New("shortcut ID must be set")
+	}
+
+	paths := []string{
+		filepath.Join(dataHome(), "applications", entryName(params.ID)),
+		filepath.Join(desktopDir(), entryName(params.ID)),
+	}
+	if iconPath := findIcon(params.ID); iconPath != "" {
+		paths = append(paths, iconPath)
+	}
+
+	for _, path := range paths {
+		err := os.Remove(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return errors.WithStack(err)
+		}
+		params.Consumer.Infof("Removed (%s)", path)
+	}
+	return nil
+}
+
+func entryName(id string) string {
+	return fmt.Sprintf("io.itch.%s.desktop", id)
+}
+
+func iconBaseName(id string) string {
+	return fmt.Sprintf("io.itch.%s", id)
+}
+
+func desktopEntry(displayName string, url string, iconPath string) string {
+	var sb strings.Builder
+	sb.WriteString("[Desktop Entry]\n")
+	sb.WriteString("Type=Application\n")
+	sb.WriteString("Version=1.0\n")
+	fmt.Fprintf(&sb, "Name=%s\n", escapeValue(displayName))
+	fmt.Fprintf(&sb, "Exec=%s\n", escapeValue("xdg-open "+quoteExecArg(url)))
+	if iconPath != "" {
+		fmt.Fprintf(&sb, "Icon=%s\n", escapeValue(iconPath))
+	}
+	sb.WriteString("Terminal=false\n")
+	sb.WriteString("Categories=Game;\n")
+	return sb.String()
+}
+
+// escapeValue escapes a string as the desktop entry spec wants
+// for values of type string.
+func escapeValue(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		"\n", `\n`,
+		"\t", `\t`,
+		"\r", `\r`,
+	).Replace(s)
+}
+
+// quoteExecArg quotes an argument of the Exec key. Field codes
+// start with '%', so literal ones are doubled.
+func quoteExecArg(s string) string {
+	s = strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"`", "\\`",
+		`$`, `\$`,
+		`%`, `%%`,
+	).Replace(s)
+	return `"` + s + `"`
+}
+
+// writeEntry writes a desktop entry atomically. It's marked executable,
+// which some desktops require before launching entries from the desktop.
+func writeEntry(path string, contents string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	tmpPath := path + ".tmp"
+	err = os.WriteFile(tmpPath, []byte(contents), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		os.Remove(tmpPath)
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// installIcon copies an icon in the user's icon folder, where it's found
+// by its name as an unthemed icon. If source is empty, the icon installed
+// earlier is kept.
+func installIcon(id string, source string) (string, error) {
+	if source == "" {
+		return findIcon(id), nil
+	}
+
+	ext := strings.ToLower(filepath.Ext(source))
+	if ext == "" {
+		ext = ".png"
+	}
+
+	if previous := findIcon(id); previous != "" {
+		os.Remove(previous)
+	}
+
+	iconPath := filepath.Join(dataHome(), "icons", iconBaseName(id)+ext)
+	err := os.MkdirAll(filepath.Dir(iconPath), 0o755)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	src, err := os.Open(source)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(iconPath)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return iconPath, nil
+}
+
+func findIcon(id string) string {
+	matches, _ := filepath.Glob(filepath.Join(dataHome(), "icons", iconBaseName(id)+".*"))
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
+}
+
+func dataHome() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(homeDir(), ".local", "share")
+}
+
+// desktopDir follows XDG_DESKTOP_DIR from the environment, then from
+// user-dirs.dirs, like xdg-user-dir does.
+func desktopDir() string {
+	if dir := os.Getenv("XDG_DESKTOP_DIR"); filepath.IsAbs(dir) {
+		return dir
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if !filepath.IsAbs(configHome) {
+		configHome = filepath.Join(homeDir(), ".config")
+	}
+
+	if f, err := os.Open(filepath.Join(configHome, "user-dirs.dirs")); err == nil {
+		defer f.Close()
+
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			key, value, ok := strings.Cut(strings.TrimSpace(s.Text()), "=")
+			if !ok || key != "XDG_DESKTOP_DIR" {
+				continue
+			}
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			value = strings.Replace(value, "$HOME", homeDir(), 1)
+			if filepath.IsAbs(value) {
+				return value
+			}
+		}
+	}
+	return filepath.Join(homeDir(), "Desktop")
+}
+
+func homeDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "/"
+	}
+	return home
+}
@@ -0,0 +1,76 @@
+//go:build linux
+// +build linux
+
+package shortcut
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/itchio/headway/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_QuoteExecArg(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.EqualValues(`"itch://caves/abc/launch"`, quoteExecArg("itch://caves/abc/launch"))
+	assert.EqualValues(`"a \"b\" \$c \`+"`"+`d\`+"`"+` 100%%"`, quoteExecArg("a \"b\" $c `d` 100%"))
+	assert.EqualValues(`Exec=xdg-open "a\\\\b"`, "Exec="+escapeValue("xdg-open "+quoteExecArg(`a\b`)))
+}
+
+func Test_CreateRefreshRemove(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("XDG_DESKTOP_DIR", filepath.Join(dir, "Desktop"))
+
+	iconSource := filepath.Join(dir, "cover.png")
+	assert.NoError(os.WriteFile(iconSource, []byte("png"), 0o644))
+
+	consumer := &state.Consumer{}
+	entryPath := filepath.Join(dir, "data", "applications", "io.itch.cave1.desktop")
+	desktopPath := filepath.Join(dir, "Desktop", "io.itch.cave1.desktop")
+	iconPath := filepath.Join(dir, "data", "icons", "io.itch.cave1.png")
+
+	refreshed, err := Refresh(CreateParams{ID: "cave1", DisplayName: "Game", URL: "itch://caves/cave1/launch", Consumer: consumer})
+	assert.NoError(err)
+	assert.False(refreshed)
+
+	assert.NoError(Create(CreateParams{
+		ID:          "cave1",
+		DisplayName: "Super Game",
+		IconSource:  iconSource,
+		URL:         "itch://caves/cave1/launch",
+		Desktop:     true,
+		Consumer:    consumer,
+	}))
+
+	contents, err := os.ReadFile(entryPath)
+	assert.NoError(err)
+	assert.Contains(string(contents), "Name=Super Game\n")
+	assert.Contains(string(contents), "Exec=xdg-open \"itch://caves/cave1/launch\"\n")
+	assert.Contains(string(contents), "Icon="+iconPath+"\n")
+	assert.FileExists(desktopPath)
+	assert.FileExists(iconPath)
+
+	// refreshing keeps the icon and the desktop shortcut
+	refreshed, err = Refresh(CreateParams{ID: "cave1", DisplayName: "Super Game 2", URL: "itch://caves/cave1/launch", Consumer: consumer})
+	assert.NoError(err)
+	assert.True(refreshed)
+
+	contents, err = os.ReadFile(desktopPath)
+	assert.NoError(err)
+	assert.Contains(string(contents), "Name=Super Game 2\n")
+	assert.Contains(string(contents), "Icon="+iconPath+"\n")
+
+	assert.NoError(Remove(RemoveParams{ID: "cave1", Consumer: consumer}))
+	assert.NoFileExists(entryPath)
+	assert.NoFileExists(desktopPath)
+	assert.NoFileExists(iconPath)
+
+	// removing again is fine
+	assert.NoError(Remove(RemoveParams{ID: "cave1", Consumer: consumer}))
+}
@@ -0,0 +1,18 @@
+//go:build !windows && !linux
+// +build !windows,!linux
+
+package shortcut
+
+import "github.com/pkg/errors"
+
+func Create(params CreateParams) error {
+	return errors.Errorf("stub")
+}
+
+func Refresh(params CreateParams) (bool, error) {
+	return false, nil
+}
+
+func Remove(params RemoveParams) error {
+	return nil
+}
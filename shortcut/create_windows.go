@@ -29,20 +29,15 @@ func Create(params CreateParams) error {
 
 	consumer := params.Consumer
 
-	startMenuPath, err := winox.GetFolderPath(winox.FolderTypePrograms)
+	shortcutPath, err := getShortcutPath(params.DisplayName)
 	if err != nil {
 		return err
 	}
-
-	itchCorpPath := filepath.Join(startMenuPath, "Itch Corp")
-	err = os.MkdirAll(itchCorpPath, 0o755)
+	err = os.MkdirAll(filepath.Dir(shortcutPath), 0o755)
 	if err != nil {
 		return err
 	}
 
-	shortcutName := fmt.Sprintf("%s.url", sanitizeFileName(params.DisplayName))
-	shortcutPath := filepath.Join(itchCorpPath, shortcutName)
-
 	comshim.Add(1)
 	defer comshim.Done()
 
@@ -73,6 +68,50 @@ func Create(params CreateParams) error {
 	return nil
 }
 
+// Refresh re-creates a shortcut if there is one. Shortcuts are found
+// by display name, so renamed games aren't refreshed.
+func Refresh(params CreateParams) (bool, error) {
+	shortcutPath, err := getShortcutPath(params.DisplayName)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(shortcutPath); err != nil {
+		return false, nil
+	}
+	return true, Create(params)
+}
+
+func Remove(params RemoveParams) error {
+	if params.DisplayName == "" {
+		// shortcuts are found by display name
+		return nil
+	}
+
+	shortcutPath, err := getShortcutPath(params.DisplayName)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(shortcutPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	params.Consumer.Infof("Removed shortcut (%s)", shortcutPath)
+	return nil
+}
+
+func getShortcutPath(displayName string) (string, error) {
+	startMenuPath, err := winox.GetFolderPath(winox.FolderTypePrograms)
+	if err != nil {
+		return "", err
+	}
+
+	shortcutName := fmt.Sprintf("%s.url", sanitizeFileName(displayName))
+	return filepath.Join(startMenuPath, "Itch Corp", shortcutName), nil
+}
+
 var anyAmountOfSpaces = regexp.MustCompile(`\s+`)
 
 func sanitizeFileName(s string) string {
@@ -0,0 +1,9 @@
+// Package statfs tells how much space is left on the volume holding a path.
+package statfs
+
+type Stats struct {
+	// Bytes available to unprivileged users
+	FreeSize int64
+	// Size of the volume, in bytes
+	TotalSize int64
+}
@@ -1,16 +1,15 @@
 //go:build !windows
 // +build !windows
 
-package system
+package statfs
 
 import (
 	"syscall"
 
-	"github.com/itchio/butler/butlerd"
 	"github.com/pkg/errors"
 )
 
-func StatFS(path string) (*butlerd.SystemStatFSResult, error) {
+func Do(path string) (*Stats, error) {
 	var stats syscall.Statfs_t
 	err := syscall.Statfs(path, &stats)
 	if err != nil {
@@ -22,7 +21,7 @@ func StatFS(path string) (*butlerd.SystemStatFSResult, error) {
 	var freeSize int64 = int64(stats.Bavail) * int64(stats.Bsize)
 	var totalSize int64 = int64(stats.Blocks) * int64(stats.Bsize)
 
-	res := &butlerd.SystemStatFSResult{
+	res := &Stats{
 		FreeSize:  freeSize,
 		TotalSize: totalSize,
 	}
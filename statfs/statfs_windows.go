@@ -1,23 +1,22 @@
 //go:build windows
 // +build windows
 
-package system
+package statfs
 
 import (
 	"syscall"
 
-	"github.com/itchio/butler/butlerd"
 	"github.com/itchio/ox/syscallex"
 	"github.com/pkg/errors"
 )
 
-func StatFS(path string) (*butlerd.SystemStatFSResult, error) {
+func Do(path string) (*Stats, error) {
 	dfs, err := syscallex.GetDiskFreeSpaceEx(syscall.StringToUTF16Ptr(path))
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	res := &butlerd.SystemStatFSResult{
+	res := &Stats{
 		// XXX: cast hygiene
 		FreeSize:  int64(dfs.FreeBytesAvailable),
 		TotalSize: int64(dfs.TotalNumberOfBytes),
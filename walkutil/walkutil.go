@@ -49,3 +49,24 @@ func ResolveSingleZipDir(path string, filter tlc.FilterFunc) string {
 	comm.Opf("(%s) contains a single .zip file, treating %s as the container", path, only.Name())
 	return inner
 }
+
+// FolderSize returns the total size of regular files in a folder.
+// Entries it can't read are skipped: the first error is returned along
+// with the size of everything else.
+func FolderSize(folder string) (int64, error) {
+	var size int64
+	var firstErr error
+	filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, firstErr
+}
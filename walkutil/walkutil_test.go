@@ -118,3 +118,24 @@ func TestResolveSingleZipDir_NonExistent(t *testing.T) {
 	got := walkutil.ResolveSingleZipDir("/this/path/does/not/exist", filtering.FilterPaths)
 	assert.Equal(t, "/this/path/does/not/exist", got)
 }
+
+func TestFolderSize(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "hello")
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "sub", "b.txt"), "world!")
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink("a.txt", filepath.Join(dir, "link.txt")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	size, err := walkutil.FolderSize(dir)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, size, "only regular files should count")
+
+	_, err = walkutil.FolderSize(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}